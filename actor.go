@@ -0,0 +1,71 @@
+// Copyright (c) Bas van Beek 2024.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package run
+
+import "strconv"
+
+// interrupter is implemented by Service Units that want to receive the error
+// which initiated their stop request instead of having GracefulStop called.
+type interrupter interface {
+	Service
+	interrupt(err error)
+}
+
+// NewActor takes a name and an oklog/run style execute and interrupt function
+// pair and turns them into a Group compatible Service, ready for registration.
+// The execute function is called in the Serve phase and must block until
+// interrupted. The interrupt function receives the error that initiated the
+// shutdown, or ErrRequestedShutdown if only this actor is stopped (e.g. by
+// Deregister or StopUnit).
+func NewActor(name string, execute func() error, interrupt func(error)) Service {
+	return &actor{name: name, execute: execute, interruptFn: interrupt}
+}
+
+type actor struct {
+	name        string
+	execute     func() error
+	interruptFn func(error)
+}
+
+func (a *actor) Name() string {
+	return a.name
+}
+
+func (a *actor) Serve() error {
+	return a.execute()
+}
+
+func (a *actor) GracefulStop() {
+	a.interrupt(ErrRequestedShutdown)
+}
+
+func (a *actor) interrupt(err error) {
+	if a.interruptFn != nil {
+		a.interruptFn(err)
+	}
+}
+
+// Add registers an oklog/run style actor with the Group, easing migration of
+// code bases using oklog/run.Group. Each actor is named "actor-<n>" where n is
+// the order in which it was added. Use NewActor and Register for actors that
+// need a more descriptive name.
+func (g *Group) Add(execute func() error, interrupt func(error)) {
+	g.mu.Lock()
+	g.actors++
+	name := "actor-" + strconv.Itoa(g.actors)
+	g.mu.Unlock()
+
+	g.Register(NewActor(name, execute, interrupt))
+}
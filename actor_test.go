@@ -0,0 +1,74 @@
+// Copyright (c) Bas van Beek 2024.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package run_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/basvanbeek/telemetry"
+
+	"github.com/basvanbeek/run"
+)
+
+func TestActor(t *testing.T) {
+	var (
+		g       = run.Group{Name: "Actor", Logger: telemetry.NoopLogger()}
+		quit    = make(chan struct{})
+		causes  = make(chan error, 1)
+		irq     = make(chan error)
+		started = make(chan struct{})
+	)
+
+	g.Register(run.NewActor("blocking",
+		func() error {
+			close(started)
+			<-quit
+			return nil
+		},
+		func(err error) {
+			causes <- err
+			close(quit)
+		},
+	))
+	// oklog/run style registration
+	g.Add(
+		func() error {
+			<-started
+			return errIRQ
+		},
+		func(error) {},
+	)
+
+	if units := g.ListUnits(); !strings.Contains(units, "actor-1") {
+		t.Errorf("Expected actor-1 to be listed, got %s", units)
+	}
+
+	go func() { irq <- g.Run("./myService") }()
+
+	select {
+	case err := <-irq:
+		if !errors.Is(err, errIRQ) {
+			t.Errorf("Expected proper close, got %v", err)
+		}
+		if cause := <-causes; !errors.Is(cause, errIRQ) {
+			t.Errorf("Expected interrupt with %v, got %v", errIRQ, cause)
+		}
+	case <-time.After(100 * time.Millisecond):
+		t.Errorf("timeout")
+	}
+}
@@ -0,0 +1,92 @@
+// Copyright (c) Bas van Beek 2024.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package run
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Kinds of Anomaly.
+const (
+	// AnomalyDroppedError is detected when a Service or ServiceContext Unit
+	// returns an error while the Group is already shutting down for another
+	// reason, in which case the error does not affect the outcome of Run.
+	AnomalyDroppedError = "dropped-error"
+	// AnomalyLateExit is detected when a Service or ServiceContext Unit
+	// returns after Run has returned, e.g. after the shutdown timeout.
+	AnomalyLateExit = "late-exit"
+	// AnomalyMisuse is detected when the Group API is used in a way that
+	// can't have the intended effect, e.g. registering a Config Unit after
+	// RunConfig or calling Run concurrently. Its Err wraps ErrMisuse.
+	AnomalyMisuse = "misuse"
+)
+
+// ErrMisuse is wrapped by the error of an AnomalyMisuse.
+const ErrMisuse Error = "api misuse"
+
+// Anomaly describes misbehavior of a Unit detected while collecting the exits
+// of the Service and ServiceContext Units, or misuse of the Group API.
+type Anomaly struct {
+	Time time.Time
+	Unit string
+	Kind string
+	// Err holds the error returned by the Unit, if any.
+	Err error
+}
+
+// String implements fmt.Stringer.
+func (a Anomaly) String() string {
+	if a.Err == nil {
+		return fmt.Sprintf("%s: unit %s", a.Kind, a.Unit)
+	}
+	return fmt.Sprintf("%s: unit %s: %v", a.Kind, a.Unit, a.Err)
+}
+
+// Anomalies returns the anomalies detected since the Group was created or
+// last Reset, in order of detection.
+func (g *Group) Anomalies() []Anomaly {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return append([]Anomaly(nil), g.anomalies...)
+}
+
+// anomaly logs and records the provided Anomaly. It panics if the Group was
+// created with WithPanicOnAnomaly.
+func (g *Group) anomaly(a Anomaly) {
+	a.Time = g.Clock().Now()
+	g.mu.Lock()
+	g.anomalies = append(g.anomalies, a)
+	panicking := g.panicOnAnomaly
+	g.mu.Unlock()
+	if g.Logger != nil {
+		g.Logger.Info("unit-anomaly", "unit", a.Unit, "kind", a.Kind, "error", a.Err)
+	}
+	if panicking {
+		panic("run: " + a.String())
+	}
+}
+
+// droppedError records an AnomalyDroppedError if the provided error returned
+// by the Unit during shutdown signals a failure.
+func (g *Group) droppedError(u Unit, err error) {
+	if err == nil || errors.Is(err, ErrRequestedShutdown) ||
+		errors.Is(err, context.Canceled) {
+		return
+	}
+	g.anomaly(Anomaly{Unit: g.nameOf(u), Kind: AnomalyDroppedError, Err: err})
+}
@@ -0,0 +1,120 @@
+// Copyright (c) Bas van Beek 2024.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package run_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/basvanbeek/telemetry"
+
+	"github.com/basvanbeek/run"
+)
+
+func TestAnomalyDroppedError(t *testing.T) {
+	var (
+		g       = run.Group{Name: "anomaly", Logger: telemetry.NoopLogger()}
+		started = make(chan struct{})
+		stop    = make(chan struct{})
+		e       = errors.New("close failed")
+	)
+	g.Register(
+		run.NewActor("trigger", func() error {
+			<-started
+			return run.ErrRequestedShutdown
+		}, func(error) {}),
+		run.NewActor("closer", func() error {
+			close(started)
+			<-stop
+			return e
+		}, func(error) { close(stop) }),
+	)
+
+	if err := g.Run("./myService"); err != nil {
+		t.Fatalf("Expected proper close, got %v", err)
+	}
+	a := g.Anomalies()
+	if len(a) != 1 || a[0].Kind != run.AnomalyDroppedError || a[0].Unit != "closer" ||
+		!errors.Is(a[0].Err, e) {
+		t.Errorf("Expected dropped error of unit closer, got %v", a)
+	}
+}
+
+func TestAnomalyLateExit(t *testing.T) {
+	var (
+		g        = run.NewGroup("anomaly", run.WithShutdownTimeout(10*time.Millisecond))
+		started  = make(chan struct{})
+		returned = make(chan struct{})
+	)
+	g.Logger = telemetry.NoopLogger()
+	g.Register(
+		run.NewActor("trigger", func() error {
+			<-started
+			return run.ErrRequestedShutdown
+		}, func(error) {}),
+		run.NewActor("slow", func() error {
+			defer close(returned)
+			close(started)
+			time.Sleep(50 * time.Millisecond)
+			return nil
+		}, func(error) {}),
+	)
+
+	if err := g.Run("./myService"); !errors.Is(err, run.ErrShutdownTimeout) {
+		t.Fatalf("Expected %v, got %v", run.ErrShutdownTimeout, err)
+	}
+	<-returned
+	for start := time.Now(); len(g.Anomalies()) == 0 && time.Since(start) < time.Second; {
+		time.Sleep(time.Millisecond)
+	}
+	if a := g.Anomalies(); len(a) != 1 || a[0].Kind != run.AnomalyLateExit || a[0].Unit != "slow" {
+		t.Errorf("Expected late exit of unit slow, got %v", a)
+	}
+}
+
+func TestAnomalyMisuse(t *testing.T) {
+	var (
+		g     = run.Group{Name: "misuse", Logger: telemetry.NoopLogger()}
+		entry = make(chan struct{})
+		leave = make(chan struct{})
+	)
+	g.Register(run.NewPreRunner("blocker", func() error {
+		close(entry)
+		<-leave
+		return nil
+	}))
+
+	irq := make(chan error)
+	go func() { irq <- g.Run("./myService") }()
+	<-entry
+
+	// registering a Config Unit after RunConfig has no effect
+	g.Register(configUnit{fs: run.NewFlagSet("late")})
+	// Run must not be called concurrently
+	if err := g.Run("./myService"); !errors.Is(err, run.ErrRunning) || !errors.Is(err, run.ErrMisuse) {
+		t.Errorf("Expected %v, got %v", run.ErrRunning, err)
+	}
+	close(leave)
+	if err := <-irq; err != nil {
+		t.Fatalf("Expected proper close, got %v", err)
+	}
+
+	a := g.Anomalies()
+	if len(a) != 2 || a[0].Kind != run.AnomalyMisuse || a[0].Unit != "config-unit" ||
+		a[1].Kind != run.AnomalyMisuse || !errors.Is(a[1].Err, run.ErrMisuse) {
+		t.Errorf("Expected 2 misuse anomalies, got %v", a)
+	}
+}
@@ -0,0 +1,180 @@
+// Copyright (c) Bas van Beek 2024.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package run
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/spf13/pflag"
+
+	"github.com/basvanbeek/multierror"
+)
+
+// ArgSpec describes the positional arguments expected by an ArgsReceiver.
+type ArgSpec struct {
+	// Usage describes the positional arguments in help output,
+	// e.g. "<source> <destination>".
+	Usage string
+	// Min holds the minimum amount of positional arguments.
+	Min int
+	// Max holds the maximum amount of positional arguments. A negative value
+	// allows for an unlimited amount.
+	Max int
+	// Validate optionally checks the provided positional arguments.
+	Validate func(args []string) error
+}
+
+// ArgsReceiver is an extension interface that Units can implement if they
+// consume the positional arguments remaining after flag parsing, up to the
+// "--" terminator (see Group.PassThroughArgs).
+// Group checks the positional arguments against the ArgSpec returned by Args
+// and passes them to ReceiveArgs before the Config Units are validated.
+// If the arguments don't satisfy the ArgSpec, RunConfig will fail.
+type ArgsReceiver interface {
+	// Unit is embedded for Group registration and identification
+	Unit
+	// Args returns the specification of expected positional arguments.
+	Args() ArgSpec
+	// ReceiveArgs receives the positional arguments.
+	ReceiveArgs(args []string)
+}
+
+// Args returns the command line arguments parsed by RunConfig and the
+// positional arguments remaining after flag parsing, up to the "--"
+// terminator, allowing Units such as command dispatchers to inspect them
+// without re-parsing os.Args. Both are nil until RunConfig has parsed the
+// command line.
+func (g *Group) Args() (parsed, remaining []string) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.args, g.remaining
+}
+
+// PassThroughArgs returns the arguments following the "--" terminator on the
+// command line. These are neither parsed as flags nor handed to ArgsReceiver
+// Units, allowing Units to pass them on as is, e.g. to a child process. It
+// returns nil if the command line holds no terminator.
+func (g *Group) PassThroughArgs() []string {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.passThrough
+}
+
+// UnknownFlags returns the unknown flags, including their values, collected
+// by RunConfig if Group.IgnoreUnknownFlags is set, in order of appearance.
+func (g *Group) UnknownFlags() []string {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.unknown
+}
+
+// splitUnknownFlags splits the provided arguments in the arguments to parse
+// and the unknown flags. As the type of an unknown flag is not known, an
+// argument following an unknown flag without "=" is taken as its value unless
+// it looks like a flag itself.
+func (g *Group) splitUnknownFlags(args []string) (known, unknown []string) {
+	for idx := 0; idx < len(args); idx++ {
+		arg := args[idx]
+		if arg == "--" {
+			// flag parsing terminator
+			known = append(known, args[idx:]...)
+			break
+		}
+		if len(arg) < 2 || arg[0] != '-' {
+			known = append(known, arg)
+			continue
+		}
+		name, _, hasValue := strings.Cut(strings.TrimLeft(arg, "-"), "=")
+		if name == "" {
+			// leave malformed flags to the flag parser
+			known = append(known, arg)
+			continue
+		}
+		var f *pflag.Flag
+		if strings.HasPrefix(arg, "--") {
+			f = g.f.Lookup(name)
+		} else {
+			// shorthand flags can be combined or hold their value
+			f = g.f.ShorthandLookup(name[:1])
+			hasValue = hasValue || len(name) > 1
+		}
+		next := idx+1 < len(args)
+		if f != nil {
+			known = append(known, arg)
+			if !hasValue && f.NoOptDefVal == "" && next {
+				known = append(known, args[idx+1])
+				idx++
+			}
+		} else {
+			unknown = append(unknown, arg)
+			if !hasValue && next && !strings.HasPrefix(args[idx+1], "-") {
+				unknown = append(unknown, args[idx+1])
+				idx++
+			}
+		}
+	}
+	return known, unknown
+}
+
+// receiveArgs checks the provided positional arguments against the ArgSpec of
+// each registered ArgsReceiver and hands them over.
+func (g *Group) receiveArgs(args []string) (err error) {
+	var receivers int
+	for idx := 0; idx < phaseLen(g, &g.a); idx++ {
+		// an ArgsReceiver might have been de-registered
+		a := unitAt(g, &g.a, idx)
+		if a == nil {
+			continue
+		}
+		receivers++
+		spec := a.Args()
+		switch {
+		case len(args) < spec.Min:
+			err = multierror.Append(err, fmt.Errorf("%s: expected at least %d argument(s), got %d",
+				g.nameOf(a), spec.Min, len(args)))
+			continue
+		case spec.Max >= 0 && len(args) > spec.Max:
+			err = multierror.Append(err, fmt.Errorf("%s: expected at most %d argument(s), got %d",
+				g.nameOf(a), spec.Max, len(args)))
+			continue
+		}
+		if spec.Validate != nil {
+			if vErr := spec.Validate(args); vErr != nil {
+				err = multierror.Append(err, fmt.Errorf("%s: %w", g.nameOf(a), vErr))
+				continue
+			}
+		}
+		a.ReceiveArgs(args)
+	}
+	if receivers == 0 && len(args) > 0 {
+		g.Logger.Debug("ignoring positional arguments", "args", strings.Join(args, " "))
+	}
+	return err
+}
+
+// argsUsage returns the positional argument usage lines of the registered
+// ArgsReceiver Units.
+func (g *Group) argsUsage() string {
+	var s string
+	for idx := 0; idx < phaseLen(g, &g.a); idx++ {
+		if a := unitAt(g, &g.a, idx); a != nil {
+			if usage := a.Args().Usage; usage != "" {
+				s += fmt.Sprintf("  %s\t(%s)\n", usage, g.nameOf(a))
+			}
+		}
+	}
+	return s
+}
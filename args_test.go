@@ -0,0 +1,175 @@
+// Copyright (c) Bas van Beek 2024.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package run_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/basvanbeek/telemetry"
+
+	"github.com/basvanbeek/run"
+)
+
+func TestArgsReceiver(t *testing.T) {
+	errNoTxt := errors.New("only .txt files allowed")
+
+	for idx, tt := range []struct {
+		args   []string
+		hasErr bool
+	}{
+		{args: []string{"a.txt"}},
+		{args: []string{"a.txt", "b.txt"}},
+		{args: []string{"--flag", "a.txt"}, hasErr: true},
+		{args: []string{"a.txt", "b.txt", "c.txt"}, hasErr: true},
+		{args: []string{"a.png"}, hasErr: true},
+	} {
+		var (
+			g = run.Group{Name: "Args", Logger: telemetry.NoopLogger()}
+			r = argsReceiver{spec: run.ArgSpec{
+				Usage: "<file>...",
+				Min:   1,
+				Max:   2,
+				Validate: func(args []string) error {
+					for _, arg := range args {
+						if !strings.HasSuffix(arg, ".txt") {
+							return errNoTxt
+						}
+					}
+					return nil
+				},
+			}}
+		)
+		g.Register(&r)
+
+		err := g.Run(tt.args...)
+		if tt.hasErr && err == nil {
+			t.Errorf("[%d] Expected args error, got nil", idx)
+		}
+		if !tt.hasErr {
+			if err != nil {
+				t.Errorf("[%d] Expected proper close, got %v", idx, err)
+			}
+			if want, have := strings.Join(tt.args, " "), strings.Join(r.args, " "); want != have {
+				t.Errorf("[%d] args want: %q, have: %q", idx, want, have)
+			}
+		}
+	}
+}
+
+func TestGroupArgs(t *testing.T) {
+	var (
+		g    = run.Group{Name: "Args", Logger: telemetry.NoopLogger()}
+		fs   = run.NewFlagSet("Args options")
+		addr string
+	)
+	fs.StringVar(&addr, "addr", ":8080", "listen address")
+	g.Register(configUnit{fs: fs})
+
+	if parsed, remaining := g.Args(); parsed != nil || remaining != nil {
+		t.Errorf("Expected no args before RunConfig, got %v %v", parsed, remaining)
+	}
+	if err := g.RunConfig("serve", "--addr", ":9090", "extra-arg"); err != nil {
+		t.Fatalf("Expected nil, got %v", err)
+	}
+	parsed, remaining := g.Args()
+	if want, have := "serve --addr :9090 extra-arg", strings.Join(parsed, " "); want != have {
+		t.Errorf("parsed args want: %q, have: %q", want, have)
+	}
+	if want, have := "serve extra-arg", strings.Join(remaining, " "); want != have {
+		t.Errorf("remaining args want: %q, have: %q", want, have)
+	}
+}
+
+func TestPassThroughArgs(t *testing.T) {
+	var (
+		g    = run.Group{Name: "Args", Logger: telemetry.NoopLogger()}
+		fs   = run.NewFlagSet("Args options")
+		r    = argsReceiver{spec: run.ArgSpec{Max: 1}}
+		addr string
+	)
+	fs.StringVar(&addr, "addr", ":8080", "listen address")
+	g.Register(configUnit{fs: fs}, &r)
+
+	if err := g.RunConfig("--addr", ":9090", "a.txt", "--", "child", "--addr", ":1"); err != nil {
+		t.Fatalf("Expected nil, got %v", err)
+	}
+	if addr != ":9090" {
+		t.Errorf("Expected flags after the terminator not to be parsed, got %q", addr)
+	}
+	if want, have := "a.txt", strings.Join(r.args, " "); want != have {
+		t.Errorf("received args want: %q, have: %q", want, have)
+	}
+	if want, have := "child --addr :1", strings.Join(g.PassThroughArgs(), " "); want != have {
+		t.Errorf("pass-through args want: %q, have: %q", want, have)
+	}
+
+	g = run.Group{Name: "Args", Logger: telemetry.NoopLogger()}
+	if err := g.RunConfig("a.txt"); err != nil {
+		t.Fatalf("Expected nil, got %v", err)
+	}
+	if passThrough := g.PassThroughArgs(); passThrough != nil {
+		t.Errorf("Expected no pass-through args without terminator, got %v", passThrough)
+	}
+}
+
+type argsReceiver struct {
+	spec run.ArgSpec
+	args []string
+}
+
+func (a *argsReceiver) Name() string              { return "args" }
+func (a *argsReceiver) Args() run.ArgSpec         { return a.spec }
+func (a *argsReceiver) ReceiveArgs(args []string) { a.args = args }
+
+func TestIgnoreUnknownFlags(t *testing.T) {
+	var (
+		g = run.NewGroup("Args",
+			run.WithLogger(telemetry.NoopLogger()),
+			run.WithIgnoreUnknownFlags(),
+		)
+		fs       = run.NewFlagSet("Args options")
+		addr     string
+		extended bool
+	)
+	fs.StringVarP(&addr, "addr", "a", ":8080", "listen address")
+	fs.BoolVarP(&extended, "extended", "x", false, "extended output")
+	g.Register(configUnit{fs: fs})
+
+	if err := g.RunConfig("serve", "--sidecar-port", "9000", "-a", ":9090",
+		"--plugin=x", "-x", "-z", "--debug", "--addr", "-1", "extra", "--", "--raw",
+	); err != nil {
+		t.Fatalf("Expected nil, got %v", err)
+	}
+	if addr != "-1" || !extended {
+		t.Errorf("Expected known flags to be parsed, got %q %t", addr, extended)
+	}
+	if want, have := "--sidecar-port 9000 --plugin=x -z --debug",
+		strings.Join(g.UnknownFlags(), " "); want != have {
+		t.Errorf("unknown flags want: %q, have: %q", want, have)
+	}
+	if _, remaining := g.Args(); strings.Join(remaining, " ") != "serve extra" {
+		t.Errorf("Expected remaining args, got %v", remaining)
+	}
+	if passThrough := g.PassThroughArgs(); strings.Join(passThrough, " ") != "--raw" {
+		t.Errorf("Expected pass-through args, got %v", passThrough)
+	}
+
+	g = run.NewGroup("Args", run.WithLogger(telemetry.NoopLogger()))
+	if err := g.RunConfig("--sidecar-port", "9000"); err == nil {
+		t.Error("Expected unknown flag error")
+	}
+}
@@ -0,0 +1,96 @@
+// Copyright (c) Bas van Beek 2024.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package run
+
+import (
+	"fmt"
+
+	"github.com/spf13/pflag"
+
+	"github.com/basvanbeek/run/pkg/flag"
+)
+
+// Sources of flag values as reported by the configuration audit log. Values
+// loaded by a FlagSource Unit report the name of that Unit as their source.
+const (
+	SourceDefault     = "default"
+	SourceCommandLine = "command-line"
+)
+
+// ConfigAuditEntry holds the effective value of a single flag as reported by
+// the configuration audit log enabled with WithConfigAudit.
+type ConfigAuditEntry struct {
+	Unit   string `json:"unit"`
+	Flag   string `json:"flag"`
+	Value  string `json:"value"`
+	Source string `json:"source"`
+}
+
+// String implements fmt.Stringer.
+func (e ConfigAuditEntry) String() string {
+	return fmt.Sprintf("%s --%s=%q (%s)", e.Unit, e.Flag, e.Value, e.Source)
+}
+
+// WithConfigAudit enables logging of a single "effective-config" Info event
+// after a successful Config phase, listing the value and source of every flag
+// of the registered Config Units as an audit trail of the configuration the
+// process runs with. Sensitive flag values are redacted by their flag.Value.
+func WithConfigAudit() Option {
+	return func(g *Group) {
+		g.configAudit = true
+	}
+}
+
+// trackFlagSources records the source of all flags changed since the last
+// call which have no source recorded yet.
+func (g *Group) trackFlagSources(source string) {
+	if g.flagSources == nil {
+		g.flagSources = make(map[string]string)
+	}
+	g.f.Visit(func(f *pflag.Flag) {
+		if _, ok := g.flagSources[f.Name]; !ok {
+			g.flagSources[f.Name] = source
+		}
+	})
+}
+
+// auditConfig returns the effective flag values of the provided FlagSets with
+// their source, in order of Config Unit registration and flag name.
+func (g *Group) auditConfig(fs []*flag.Set) []ConfigAuditEntry {
+	var entries []ConfigAuditEntry
+	for idx, set := range fs {
+		u := unitAt(g, &g.c, idx)
+		if set == nil || u == nil {
+			continue
+		}
+		set.VisitAll(func(f *pflag.Flag) {
+			// duplicate flags are only parsed by the first registered Unit
+			if pf := g.f.Lookup(f.Name); pf != nil {
+				f = pf
+			}
+			source, ok := g.flagSources[f.Name]
+			if !ok {
+				source = SourceDefault
+			}
+			entries = append(entries, ConfigAuditEntry{
+				Unit:   g.nameOf(u),
+				Flag:   f.Name,
+				Value:  f.Value.String(),
+				Source: source,
+			})
+		})
+	}
+	return entries
+}
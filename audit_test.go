@@ -0,0 +1,93 @@
+// Copyright (c) Bas van Beek 2024.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package run_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/basvanbeek/run"
+	"github.com/basvanbeek/run/pkg/log"
+)
+
+type fixedSource struct {
+	values map[string]string
+}
+
+func (f fixedSource) Name() string { return "fixed-source" }
+
+func (f fixedSource) LoadFlags(fs *run.FlagSet) error {
+	for name, value := range f.values {
+		if _, err := fs.SetUnchanged(name, value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func TestConfigAudit(t *testing.T) {
+	var (
+		buf                  bytes.Buffer
+		fs                   = run.NewFlagSet("Audit options")
+		addr, token, timeout string
+		g                    = run.NewGroup("audit",
+			run.WithLogger(log.NewJSON(&buf)),
+			run.WithConfigAudit(),
+		)
+	)
+	fs.StringVar(&addr, "addr", ":8080", "listen address")
+	fs.SensitiveStringVar(&token, "token", "", "api token")
+	fs.StringVar(&timeout, "timeout", "1s", "timeout")
+	g.Register(configUnit{fs: fs}, fixedSource{values: map[string]string{
+		"addr":  ":9090",
+		"token": "secret",
+	}})
+
+	if err := g.RunConfig("./myService", "--addr", ":7070"); err != nil {
+		t.Fatalf("Expected nil, got %v", err)
+	}
+
+	var events int
+	for _, line := range bytes.Split(bytes.TrimSpace(buf.Bytes()), []byte("\n")) {
+		var event struct {
+			Msg   string                 `json:"msg"`
+			Flags []run.ConfigAuditEntry `json:"flags"`
+		}
+		if err := json.Unmarshal(line, &event); err != nil {
+			t.Fatalf("Expected JSON log line, got %q: %v", line, err)
+		}
+		if event.Msg != "effective-config" {
+			continue
+		}
+		events++
+		want := []run.ConfigAuditEntry{
+			{Unit: "config-unit", Flag: "addr", Value: ":7070", Source: run.SourceCommandLine},
+			{Unit: "config-unit", Flag: "timeout", Value: "1s", Source: run.SourceDefault},
+			{Unit: "config-unit", Flag: "token", Value: "********", Source: "fixed-source"},
+		}
+		if len(event.Flags) != len(want) {
+			t.Fatalf("Expected %v, got %v", want, event.Flags)
+		}
+		for idx := range want {
+			if event.Flags[idx] != want[idx] {
+				t.Errorf("Expected %v, got %v", want[idx], event.Flags[idx])
+			}
+		}
+	}
+	if events != 1 {
+		t.Errorf("Expected a single effective-config event, got %d", events)
+	}
+}
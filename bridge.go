@@ -0,0 +1,126 @@
+// Copyright (c) Bas van Beek 2024.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package run
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// ServiceFromContext turns a ServiceContext into a Service. The context
+// provided to ServeContext is canceled when GracefulStop is called.
+// Only the Serve phase is bridged, other phases implemented by the provided
+// Unit are not exposed by the returned Service.
+func ServiceFromContext(sc ServiceContext) Service {
+	return &contextService{sc: sc}
+}
+
+// ContextFromService turns a Service into a ServiceContext. GracefulStop is
+// called once the provided context is canceled, after which the Service is
+// given stopTimeout to return from Serve. A stopTimeout of 0 waits
+// indefinitely.
+// Only the Serve phase is bridged, other phases implemented by the provided
+// Unit are not exposed by the returned ServiceContext.
+func ContextFromService(s Service, stopTimeout time.Duration) ServiceContext {
+	return &serviceContext{s: s, stopTimeout: stopTimeout}
+}
+
+type contextService struct {
+	sc      ServiceContext
+	mu      sync.Mutex
+	cancel  context.CancelFunc
+	stopped bool
+}
+
+func (c *contextService) Name() string {
+	return c.sc.Name()
+}
+
+func (c *contextService) Serve() error {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	c.mu.Lock()
+	if c.stopped {
+		// GracefulStop was called before Serve
+		cancel()
+	}
+	c.cancel = cancel
+	c.mu.Unlock()
+	defer func() {
+		// allow the Service to be served again in a next run
+		c.mu.Lock()
+		c.cancel, c.stopped = nil, false
+		c.mu.Unlock()
+	}()
+
+	return c.sc.ServeContext(ctx)
+}
+
+func (c *contextService) GracefulStop() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.stopped = true
+	if c.cancel != nil {
+		c.cancel()
+	}
+}
+
+type serviceContext struct {
+	s           Service
+	stopTimeout time.Duration
+	clock       Clock
+}
+
+func (s *serviceContext) Name() string {
+	return s.s.Name()
+}
+
+// useClock implements clockAware.
+func (s *serviceContext) useClock(c Clock) {
+	s.clock = c
+}
+
+func (s *serviceContext) ServeContext(ctx context.Context) error {
+	errs := make(chan error, 1)
+	go func() { errs <- s.s.Serve() }()
+
+	select {
+	case err := <-errs:
+		return err
+	case <-ctx.Done():
+	}
+
+	go s.s.GracefulStop()
+
+	var timeout <-chan time.Time
+	if s.stopTimeout > 0 {
+		clock := s.clock
+		if clock == nil {
+			clock = SystemClock
+		}
+		timer := clock.NewTimer(s.stopTimeout)
+		defer timer.Stop()
+		timeout = timer.C()
+	}
+	select {
+	case err := <-errs:
+		return err
+	case <-timeout:
+		return fmt.Errorf("%s did not stop within %s", s.s.Name(), s.stopTimeout)
+	}
+}
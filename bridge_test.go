@@ -0,0 +1,151 @@
+// Copyright (c) Bas van Beek 2024.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package run_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/basvanbeek/telemetry"
+
+	"github.com/basvanbeek/run"
+	"github.com/basvanbeek/run/pkg/test"
+)
+
+// ctxService blocks in ServeContext until its context is canceled.
+type ctxService struct {
+	started chan struct{}
+}
+
+func (c ctxService) Name() string { return "ctx-service" }
+
+func (c ctxService) ServeContext(ctx context.Context) error {
+	c.started <- struct{}{}
+	<-ctx.Done()
+	return nil
+}
+
+func TestServiceFromContext(t *testing.T) {
+	var (
+		sc  = ctxService{started: make(chan struct{}, 1)}
+		svc = run.ServiceFromContext(sc)
+		res = make(chan error)
+	)
+
+	// a stop request arriving before Serve must not be lost
+	svc.GracefulStop()
+	go func() { res <- svc.Serve() }()
+	select {
+	case err := <-res:
+		if err != nil {
+			t.Errorf("Expected nil, got %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Expected Serve to return when stopped before serving")
+	}
+	<-sc.started
+
+	// the Service can be served again
+	go func() { res <- svc.Serve() }()
+	<-sc.started
+	select {
+	case err := <-res:
+		t.Fatalf("Expected Serve to block until stopped, got %v", err)
+	default:
+	}
+	svc.GracefulStop()
+	select {
+	case err := <-res:
+		if err != nil {
+			t.Errorf("Expected nil, got %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Expected Serve to return once stopped")
+	}
+}
+
+func TestContextFromService(t *testing.T) {
+	var (
+		stopped = make(chan struct{})
+		sc      = run.ContextFromService(run.NewActor("actor",
+			func() error {
+				<-stopped
+				return nil
+			},
+			func(error) { close(stopped) },
+		), time.Second)
+		ctx, cancel = context.WithCancel(context.Background())
+		res         = make(chan error)
+	)
+	go func() { res <- sc.ServeContext(ctx) }()
+	cancel()
+	select {
+	case err := <-res:
+		if err != nil {
+			t.Errorf("Expected nil, got %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Expected ServeContext to return once the context was canceled")
+	}
+}
+
+func TestContextFromServiceStopTimeout(t *testing.T) {
+	var (
+		clock = test.NewClock(time.Now())
+		g     = run.NewGroup("bridge", run.WithClock(clock),
+			run.WithLogger(telemetry.NoopLogger()),
+			run.WithShutdownProgressInterval(-1))
+		block   = make(chan struct{})
+		started = make(chan struct{})
+		res     = make(chan error)
+	)
+	defer close(block)
+	g.Register(run.ContextFromService(&test.Svc{
+		SvcName: "stuck",
+		Execute: func() error {
+			close(started)
+			<-block
+			return nil
+		},
+	}, time.Minute), &test.Svc{
+		SvcName: "irqsvc",
+		Execute: func() error {
+			<-started
+			return run.ErrRequestedShutdown
+		},
+	})
+
+	go func() { res <- g.Run("./myService") }()
+	clock.BlockUntil(1)
+	clock.Advance(time.Minute)
+	select {
+	case err := <-res:
+		if err != nil {
+			t.Errorf("Expected nil, got %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Expected the stop timeout to expire")
+	}
+	var found bool
+	for _, a := range g.Anomalies() {
+		found = found || (a.Kind == run.AnomalyDroppedError &&
+			strings.Contains(a.Err.Error(), "did not stop within 1m0s"))
+	}
+	if !found {
+		t.Errorf("Expected dropped stop timeout error, got %v", g.Anomalies())
+	}
+}
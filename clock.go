@@ -0,0 +1,83 @@
+// Copyright (c) Bas van Beek 2024.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package run
+
+import "time"
+
+// Clock abstracts the passing of time for the time based behavior of the
+// Group such as timeouts, staggered starts, restart backoff and timings, and
+// of Units using Group.Clock such as the heartbeat unit. Tests can inject a
+// fake Clock using WithClock instead of sleeping.
+type Clock interface {
+	// Now returns the current time.
+	Now() time.Time
+	// After waits for the duration to elapse and then sends the current time
+	// on the returned channel.
+	After(d time.Duration) <-chan time.Time
+	// NewTimer creates a Timer sending the current time on its channel after
+	// at least the provided duration.
+	NewTimer(d time.Duration) Timer
+}
+
+// Timer abstracts time.Timer for use with Clock.
+type Timer interface {
+	// C returns the channel on which the time is delivered.
+	C() <-chan time.Time
+	// Stop prevents the Timer from firing. It returns false if the Timer
+	// already expired or has been stopped.
+	Stop() bool
+}
+
+// SystemClock is the Clock backed by the time package, used by default.
+var SystemClock Clock = systemClock{}
+
+type systemClock struct{}
+
+func (systemClock) Now() time.Time                         { return time.Now() }
+func (systemClock) After(d time.Duration) <-chan time.Time { return time.After(d) }
+func (systemClock) NewTimer(d time.Duration) Timer         { return systemTimer{time.NewTimer(d)} }
+
+type systemTimer struct {
+	t *time.Timer
+}
+
+func (t systemTimer) C() <-chan time.Time { return t.t.C }
+func (t systemTimer) Stop() bool          { return t.t.Stop() }
+
+// clockAware is implemented by Units provided by this package which use the
+// Clock of the Group they are pre-run or served by.
+type clockAware interface {
+	useClock(c Clock)
+}
+
+// WithClock sets the Clock used by the Group. Defaults to SystemClock.
+func WithClock(c Clock) Option {
+	return func(g *Group) {
+		g.clock = c
+	}
+}
+
+// Clock returns the Clock used by the Group, see WithClock.
+func (g *Group) Clock() Clock {
+	if g.clock == nil {
+		return SystemClock
+	}
+	return g.clock
+}
+
+// since returns the time elapsed since t according to the Group's Clock.
+func (g *Group) since(t time.Time) time.Duration {
+	return g.Clock().Now().Sub(t)
+}
@@ -0,0 +1,78 @@
+// Copyright (c) Bas van Beek 2024.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package run_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/basvanbeek/telemetry"
+
+	"github.com/basvanbeek/run"
+	"github.com/basvanbeek/run/pkg/test"
+)
+
+func TestWithClock(t *testing.T) {
+	var (
+		clock = test.NewClock(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+		g     = run.NewGroup("clock", run.WithClock(clock),
+			run.WithLogger(telemetry.NoopLogger()),
+			run.WithShutdownTimeout(time.Hour),
+			run.WithShutdownProgressInterval(-1))
+		block   = make(chan struct{})
+		started = make(chan struct{})
+		res     = make(chan error)
+	)
+	defer close(block)
+
+	g.Register(&test.Svc{
+		SvcName: "stuck",
+		Execute: func() error {
+			close(started)
+			<-block
+			return nil
+		},
+	}, &test.Svc{
+		SvcName: "irqsvc",
+		Execute: func() error {
+			<-started
+			return run.ErrRequestedShutdown
+		},
+	})
+
+	go func() { res <- g.Run("./myService") }()
+
+	// the shutdown timeout only expires once the clock is advanced
+	clock.BlockUntil(1)
+	select {
+	case err := <-res:
+		t.Fatalf("Expected Run to wait for the shutdown timeout, got %v", err)
+	default:
+	}
+	clock.Advance(time.Hour)
+
+	select {
+	case err := <-res:
+		if !errors.Is(err, run.ErrShutdownTimeout) {
+			t.Errorf("Expected %v, got %v", run.ErrShutdownTimeout, err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("timeout")
+	}
+	if s := g.Status(); !s.Started.Equal(clock.Now().Add(-time.Hour)) || s.UptimeSeconds != 3600 {
+		t.Errorf("Expected status to follow the clock, got %+v", s)
+	}
+}
@@ -0,0 +1,79 @@
+// Copyright (c) Bas van Beek 2024.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package run
+
+import (
+	"io"
+	"os"
+
+	color "github.com/logrusorgru/aurora/v4"
+)
+
+// Palette holds the styling functions used for the --help output. A nil
+// function leaves the text unstyled.
+type Palette struct {
+	// Heading styles the "Usage", "Flags" and "Arguments" headings.
+	Heading func(s string) string
+	// Section styles the flag set names.
+	Section func(s string) string
+	// Tier styles the flag tier headings shown by --help-all.
+	Tier func(s string) string
+}
+
+// DefaultPalette holds the default styling of the --help output.
+var DefaultPalette = Palette{
+	Heading: func(s string) string { return color.Cyan(color.Bold(s)).String() },
+	Section: func(s string) string { return color.Cyan(s).String() },
+	Tier:    func(s string) string { return color.Bold(s).String() },
+}
+
+// WithPalette overrides the styling of the --help output.
+func WithPalette(p Palette) Option {
+	return func(g *Group) {
+		g.palette = &p
+	}
+}
+
+// colors returns the Palette to use for the --help output. Output is plain if
+// requested by the --no-color flag or the NO_COLOR environment variable, or if
+// the Group's stdout is not a terminal.
+func (g *Group) colors(noColor bool) Palette {
+	if noColor || os.Getenv("NO_COLOR") != "" || !isTerminal(g.stdout()) {
+		return Palette{}
+	}
+	if g.palette != nil {
+		return *g.palette
+	}
+	return DefaultPalette
+}
+
+// style applies the provided styling function if set.
+func style(fn func(string) string, s string) string {
+	if fn == nil {
+		return s
+	}
+	return fn(s)
+}
+
+// isTerminal returns true if the provided io.Writer is a file representing a
+// character device.
+func isTerminal(w io.Writer) bool {
+	f, ok := w.(*os.File)
+	if !ok {
+		return false
+	}
+	fi, err := f.Stat()
+	return err == nil && fi.Mode()&os.ModeCharDevice != 0
+}
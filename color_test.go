@@ -0,0 +1,74 @@
+// Copyright (c) Bas van Beek 2024.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package run_test
+
+import (
+	"io"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/basvanbeek/telemetry"
+
+	"github.com/basvanbeek/run"
+)
+
+// captureStdout returns what is written to stdout while running fn.
+func captureStdout(t *testing.T, fn func()) string {
+	t.Helper()
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	stdout := os.Stdout
+	os.Stdout = w
+	defer func() { os.Stdout = stdout }()
+
+	out := make(chan string)
+	go func() {
+		b, _ := io.ReadAll(r)
+		out <- string(b)
+	}()
+	fn()
+	_ = w.Close()
+	return <-out
+}
+
+func TestHelpColors(t *testing.T) {
+	styled := func(s string) string { return "\x1b[1m" + s + "\x1b[0m" }
+	for _, args := range [][]string{
+		{"./myService", "--help"},
+		{"./myService", "--help", "--no-color"},
+	} {
+		g := run.NewGroup("colors",
+			run.WithLogger(telemetry.NoopLogger()),
+			run.WithPalette(run.Palette{Heading: styled, Section: styled}),
+		)
+		g.Register(configUnit{fs: run.NewFlagSet("colors")})
+
+		out := captureStdout(t, func() {
+			if err := g.Run(args...); err != nil {
+				t.Errorf("Expected bail early, got %v", err)
+			}
+		})
+		// output to a pipe is never colored
+		if !strings.Contains(out, "Usage of colors:") || strings.Contains(out, "\x1b[") {
+			t.Errorf("Expected plain help output, got %q", out)
+		}
+		if !strings.Contains(out, "--no-color") {
+			t.Errorf("Expected --no-color flag in help output, got %q", out)
+		}
+	}
+}
@@ -0,0 +1,81 @@
+// Copyright (c) Bas van Beek 2024.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package run
+
+import "fmt"
+
+// ConfigCommand is an extension interface that Units can implement to add a
+// custom bail-early flag to the common service options, analogous to the
+// --version and --help flags. Examples are --print-schema, --migrate-only or
+// --check-license.
+// If the flag is set, Execute is called once all Config Units have been
+// validated after which RunConfig returns ErrBailEarlyRequest.
+type ConfigCommand interface {
+	// Unit is embedded for Group registration and identification
+	Unit
+	// Flag returns the name and usage of the command's boolean flag.
+	Flag() (name, usage string)
+	// Execute runs the command.
+	Execute() error
+}
+
+// registerCommands adds the flags of the registered ConfigCommand Units to the
+// provided common service options. The returned slice holds for each
+// ConfigCommand if its flag has been set.
+func (g *Group) registerCommands(gFS *FlagSet) ([]bool, error) {
+	commands := make([]bool, phaseLen(g, &g.k))
+	for idx := range commands {
+		// a ConfigCommand might have been de-registered
+		cmd := unitAt(g, &g.k, idx)
+		if cmd == nil {
+			continue
+		}
+		name, usage := cmd.Flag()
+		if gFS.Lookup(name) != nil {
+			return nil, fmt.Errorf("%s: command flag --%s clashes with common "+
+				"service options", g.nameOf(cmd), name)
+		}
+		gFS.BoolVar(&commands[idx], name, false, usage)
+	}
+	return commands, nil
+}
+
+// runCommands executes the ConfigCommand Units whose flag has been set in
+// order of registration. It returns ErrBailEarlyRequest if at least one
+// ConfigCommand was executed successfully.
+func (g *Group) runCommands(commands []bool) error {
+	var executed bool
+	for idx, set := range commands {
+		if !set {
+			continue
+		}
+		cmd := unitAt(g, &g.k, idx)
+		if cmd == nil {
+			continue
+		}
+		l := g.phaseLogger("config-command", g.nameOf(cmd),
+			g.itemNr(idx+1, len(commands)))
+		err := cmd.Execute()
+		l.exit(err)
+		if err != nil {
+			return fmt.Errorf("config command %s: %w", g.nameOf(cmd), err)
+		}
+		executed = true
+	}
+	if executed {
+		return ErrBailEarlyRequest
+	}
+	return nil
+}
@@ -0,0 +1,86 @@
+// Copyright (c) Bas van Beek 2024.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package run_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/basvanbeek/telemetry"
+
+	"github.com/basvanbeek/run"
+)
+
+type configCommand struct {
+	flag     string
+	err      error
+	executed bool
+}
+
+func (c *configCommand) Name() string { return "command-" + c.flag }
+
+func (c *configCommand) Flag() (string, string) {
+	return c.flag, "run the " + c.flag + " command and exit."
+}
+
+func (c *configCommand) Execute() error {
+	c.executed = true
+	return c.err
+}
+
+func TestConfigCommand(t *testing.T) {
+	cfg := configUnit{fs: run.NewFlagSet("config")}
+
+	tests := []struct {
+		name     string
+		args     []string
+		cmdErr   error
+		executed bool
+		err      error
+	}{
+		{name: "not set", args: []string{"./myService"}},
+		{name: "set", args: []string{"./myService", "--print-schema"}, executed: true, err: run.ErrBailEarlyRequest},
+		{name: "failed", args: []string{"./myService", "--print-schema"}, cmdErr: errIRQ, executed: true, err: errIRQ},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var (
+				g   = run.Group{Logger: telemetry.NoopLogger()}
+				cmd = &configCommand{flag: "print-schema", err: tt.cmdErr}
+			)
+			g.Register(cfg, cmd)
+
+			err := g.RunConfig(tt.args...)
+			if !errors.Is(err, tt.err) {
+				t.Errorf("Expected %v, got %v", tt.err, err)
+			}
+			if tt.err == nil && err != nil {
+				t.Errorf("Expected no error, got %v", err)
+			}
+			if cmd.executed != tt.executed {
+				t.Errorf("Expected executed %t, got %t", tt.executed, cmd.executed)
+			}
+		})
+	}
+}
+
+func TestConfigCommandClash(t *testing.T) {
+	g := run.Group{Logger: telemetry.NoopLogger()}
+	g.Register(&configCommand{flag: "version"})
+
+	if err := g.RunConfig("./myService"); err == nil {
+		t.Error("Expected clashing command flag error")
+	}
+}
@@ -0,0 +1,175 @@
+// Copyright (c) Bas van Beek 2024.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package run
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"runtime/pprof"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/basvanbeek/run/pkg/version"
+)
+
+// bundleFile holds a file of a diagnostics bundle.
+type bundleFile struct {
+	name string
+	data []byte
+}
+
+// DiagnosticsHandler returns an http.Handler serving a diagnostics bundle of
+// the Group for support cases, as also written by the --diagnostics-bundle
+// flag. Adding the query parameter profiles=true includes heap and goroutine
+// profiles in the bundle. The handler is meant to be mounted on an admin
+// endpoint which is not publicly reachable.
+func (g *Group) DiagnosticsHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/gzip")
+		w.Header().Set("Content-Disposition", fmt.Sprintf(
+			"attachment; filename=%q", g.diagnosticsName()+".tar.gz"))
+		if err := g.writeDiagnostics(w, r.URL.Query().Get("profiles") == "true"); err != nil {
+			g.Logger.Error("diagnostics bundle", err)
+		}
+	})
+}
+
+// diagnosticsBundle writes a diagnostics bundle to the provided path.
+func (g *Group) diagnosticsBundle(path string) (err error) {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0o600)
+	if err != nil {
+		return fmt.Errorf("diagnostics bundle: %w", err)
+	}
+	defer func() {
+		if cErr := f.Close(); err == nil && cErr != nil {
+			err = fmt.Errorf("diagnostics bundle: %w", cErr)
+		}
+	}()
+	if err = g.writeDiagnostics(f, false); err != nil {
+		return fmt.Errorf("diagnostics bundle: %w", err)
+	}
+	return nil
+}
+
+// writeDiagnostics writes a gzipped tar archive holding the version
+// information, effective redacted configuration, Unit list and states,
+// lifecycle events, resource usage, goroutine dump and optionally profiles of
+// the Group.
+func (g *Group) writeDiagnostics(w io.Writer, profiles bool) error {
+	g.mu.Lock()
+	sets := g.sets
+	g.mu.Unlock()
+
+	config, err := json.MarshalIndent(g.effectiveConfig(sets), "", "  ")
+	if err != nil {
+		return err
+	}
+	states := g.unitStates()
+	units, err := json.MarshalIndent(states, "", "  ")
+	if err != nil {
+		return err
+	}
+	resources, err := json.MarshalIndent(g.ResourceUsage(), "", "  ")
+	if err != nil {
+		return err
+	}
+	files := []bundleFile{
+		{"version.txt", []byte(g.Name + " " + version.Parse() + "\n")},
+		{"config.json", config},
+		{"units.txt", []byte(g.ListUnits() + "\n")},
+		{"units.json", units},
+		{"lifecycle.txt", lifecycleEvents(states)},
+		{"resources.json", resources},
+		{"goroutines.txt", stacks()},
+	}
+	if profiles {
+		for _, name := range []string{"heap", "goroutine"} {
+			var buf bytes.Buffer
+			if err = pprof.Lookup(name).WriteTo(&buf, 0); err != nil {
+				return err
+			}
+			files = append(files, bundleFile{name + ".pprof", buf.Bytes()})
+		}
+	}
+
+	var (
+		gz  = gzip.NewWriter(w)
+		tw  = tar.NewWriter(gz)
+		dir = g.diagnosticsName() + "/"
+		now = time.Now()
+	)
+	for _, f := range files {
+		if err = tw.WriteHeader(&tar.Header{
+			Name: dir + f.name, Mode: 0o600, Size: int64(len(f.data)), ModTime: now,
+		}); err != nil {
+			return err
+		}
+		if _, err = tw.Write(f.data); err != nil {
+			return err
+		}
+	}
+	if err = tw.Close(); err != nil {
+		return err
+	}
+	return gz.Close()
+}
+
+// diagnosticsName returns the base name of a diagnostics bundle.
+func (g *Group) diagnosticsName() string {
+	return g.Name + "-diagnostics-" + time.Now().UTC().Format("20060102T150405")
+}
+
+// lifecycleEvents returns the recorded phase transitions of the provided
+// Units in chronological order.
+func lifecycleEvents(states []UnitState) []byte {
+	type event struct {
+		at   time.Time
+		line string
+	}
+	var events []event
+	for _, s := range states {
+		for _, e := range []struct {
+			at   time.Time
+			name string
+		}{
+			{s.Timing.Configured, "configured"},
+			{s.Timing.PreRunStart, "pre-run"},
+			{s.Timing.PreRunEnd, "pre-run-exit"},
+			{s.Timing.ServeStart, "serve"},
+			{s.Timing.StopStart, "graceful-stop"},
+			{s.Timing.StopEnd, "serve-exit"},
+		} {
+			if !e.at.IsZero() {
+				events = append(events, event{e.at, fmt.Sprintf("%s %s %s\n",
+					e.at.Format(time.RFC3339Nano), s.Name, e.name)})
+			}
+		}
+	}
+	sort.SliceStable(events, func(i, j int) bool {
+		return events[i].at.Before(events[j].at)
+	})
+	var sb strings.Builder
+	for _, e := range events {
+		sb.WriteString(e.line)
+	}
+	return []byte(sb.String())
+}
@@ -0,0 +1,122 @@
+// Copyright (c) Bas van Beek 2024.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package run_test
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/basvanbeek/telemetry"
+
+	"github.com/basvanbeek/run"
+	"github.com/basvanbeek/run/pkg/test"
+)
+
+// readBundle returns the contents of a diagnostics bundle by file name.
+func readBundle(t *testing.T, r io.Reader) map[string]string {
+	t.Helper()
+	gz, err := gzip.NewReader(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var (
+		tr    = tar.NewReader(gz)
+		files = make(map[string]string)
+	)
+	for {
+		hdr, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			return files
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		b, _ := io.ReadAll(tr)
+		files[path.Base(hdr.Name)] = string(b)
+	}
+}
+
+func TestDiagnosticsBundle(t *testing.T) {
+	var (
+		g        = run.Group{Name: "diagnostics", Logger: telemetry.NoopLogger()}
+		fs       = run.NewFlagSet("diagnostics")
+		password string
+		bundle   = filepath.Join(t.TempDir(), "bundle.tar.gz")
+	)
+	fs.SensitiveStringVar(&password, "password", "", "password")
+	g.Register(configUnit{fs: fs})
+
+	if err := g.Run("./myService", "--password", "secret",
+		"--diagnostics-bundle", bundle); err != nil {
+		t.Fatalf("Expected proper close, got %v", err)
+	}
+	f, err := os.Open(bundle)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = f.Close() }()
+
+	files := readBundle(t, f)
+	for _, name := range []string{"version.txt", "config.json", "units.txt",
+		"units.json", "lifecycle.txt", "resources.json", "goroutines.txt"} {
+		if _, ok := files[name]; !ok {
+			t.Errorf("Expected %s in bundle", name)
+		}
+	}
+	if !strings.Contains(files["config.json"], `"password"`) ||
+		strings.Contains(files["config.json"], "secret") {
+		t.Errorf("Expected redacted config, got %s", files["config.json"])
+	}
+}
+
+func TestDiagnosticsHandler(t *testing.T) {
+	var (
+		g       = run.Group{Name: "diagnostics", Logger: telemetry.NoopLogger()}
+		errDone = errors.New("done")
+	)
+	g.Register(&test.Svc{
+		SvcName: "client",
+		Execute: func() error {
+			srv := httptest.NewServer(g.DiagnosticsHandler())
+			defer srv.Close()
+			res, err := http.Get(srv.URL + "?profiles=true") //nolint:noctx // test
+			if err != nil {
+				return err
+			}
+			defer func() { _ = res.Body.Close() }()
+			files := readBundle(t, res.Body)
+			if _, ok := files["heap.pprof"]; !ok {
+				t.Error("Expected heap profile in bundle")
+			}
+			if !strings.Contains(files["lifecycle.txt"], "client serve\n") {
+				t.Errorf("Expected serve event, got %q", files["lifecycle.txt"])
+			}
+			return errDone
+		},
+	})
+
+	if err := g.Run("./myService"); !errors.Is(err, errDone) {
+		t.Errorf("Expected %v, got %v", errDone, err)
+	}
+}
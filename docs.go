@@ -0,0 +1,155 @@
+// Copyright (c) Bas van Beek 2024.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package run
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/pflag"
+
+	"github.com/basvanbeek/run/pkg/flag"
+	"github.com/basvanbeek/run/pkg/version"
+)
+
+// generateDocs writes a man page and a markdown reference document, generated
+// from the Group name, HelpText and provided FlagSets, to the provided
+// directory.
+func (g *Group) generateDocs(dir string, fs []*flag.Set) error {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return fmt.Errorf("generate docs: %w", err)
+	}
+	for name, content := range map[string]string{
+		g.Name + ".1":  g.manPage(fs),
+		g.Name + ".md": g.markdown(fs),
+	} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil { //nolint:gosec // docs are public
+			return fmt.Errorf("generate docs: %w", err)
+		}
+	}
+	return nil
+}
+
+// docFlags returns the flags to document. Hidden flags are omitted unless
+// deprecated, in which case the deprecation notice is documented.
+func docFlags(set *flag.Set) []*pflag.Flag {
+	var flags []*pflag.Flag
+	set.VisitAll(func(f *pflag.Flag) {
+		if f.Hidden && f.Deprecated == "" {
+			return
+		}
+		flags = append(flags, f)
+	})
+	return flags
+}
+
+// docUsage returns the usage text of a flag, including its Tier and
+// deprecation notice.
+func docUsage(set *flag.Set, f *pflag.Flag) string {
+	_, usage := pflag.UnquoteUsage(f)
+	usage = strings.Join(strings.Fields(usage), " ")
+	if tier := set.Tier(f.Name); tier != flag.TierStable {
+		usage = "(" + tier.String() + ") " + usage
+	}
+	if f.Deprecated != "" {
+		usage += " Deprecated: " + f.Deprecated
+	}
+	return usage
+}
+
+func (g *Group) markdown(fs []*flag.Set) string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "# %s\n\n", g.Name)
+	fmt.Fprintf(&sb, "Version: `%s`\n\n", version.Parse())
+	if g.HelpText != "" {
+		fmt.Fprintf(&sb, "%s\n\n", strings.TrimSpace(g.HelpText))
+	}
+	fmt.Fprintf(&sb, "## Usage\n\n```\n%s [flags]\n```\n\n## Flags\n", g.Name)
+	for _, set := range fs {
+		if set == nil {
+			continue
+		}
+		flags := docFlags(set)
+		if len(flags) == 0 {
+			continue
+		}
+		fmt.Fprintf(&sb, "\n### %s\n\n", set.Name)
+		sb.WriteString("| Flag | Type | Default | Description |\n")
+		sb.WriteString("|------|------|---------|-------------|\n")
+		for _, f := range flags {
+			name := "`--" + f.Name + "`"
+			if f.Shorthand != "" {
+				name += ", `-" + f.Shorthand + "`"
+			}
+			def := ""
+			if f.DefValue != "" {
+				def = "`" + f.DefValue + "`"
+			}
+			fmt.Fprintf(&sb, "| %s | %s | %s | %s |\n", name, f.Value.Type(), def,
+				strings.ReplaceAll(docUsage(set, f), "|", `\|`))
+		}
+	}
+	return sb.String()
+}
+
+func (g *Group) manPage(fs []*flag.Set) string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, ".TH %s 1 \"\" \"%s %s\" \"User Commands\"\n",
+		roff(strings.ToUpper(g.Name)), roff(g.Name), roff(version.Parse()))
+	fmt.Fprintf(&sb, ".SH NAME\n%s\n", roff(g.Name))
+	fmt.Fprintf(&sb, ".SH SYNOPSIS\n.B %s\n[flags]\n", roff(g.Name))
+	if g.HelpText != "" {
+		fmt.Fprintf(&sb, ".SH DESCRIPTION\n%s\n", roff(strings.TrimSpace(g.HelpText)))
+	}
+	sb.WriteString(".SH OPTIONS\n")
+	for _, set := range fs {
+		if set == nil {
+			continue
+		}
+		flags := docFlags(set)
+		if len(flags) == 0 {
+			continue
+		}
+		fmt.Fprintf(&sb, ".SS %s\n", roff(set.Name))
+		for _, f := range flags {
+			sb.WriteString(".TP\n")
+			if f.Shorthand != "" {
+				fmt.Fprintf(&sb, "\\fB%s\\fR, ", roff("-"+f.Shorthand))
+			}
+			fmt.Fprintf(&sb, "\\fB%s\\fR \\fI%s\\fR\n", roff("--"+f.Name), roff(f.Value.Type()))
+			usage := docUsage(set, f)
+			if f.DefValue != "" {
+				usage += " (default: " + f.DefValue + ")"
+			}
+			fmt.Fprintf(&sb, "%s\n", roff(usage))
+		}
+	}
+	return sb.String()
+}
+
+// roff escapes text for use in a man page.
+func roff(s string) string {
+	s = strings.ReplaceAll(s, `\`, `\e`)
+	s = strings.ReplaceAll(s, "-", `\-`)
+	lines := strings.Split(s, "\n")
+	for i, l := range lines {
+		if strings.HasPrefix(l, ".") || strings.HasPrefix(l, "'") {
+			lines[i] = `\&` + l
+		}
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,69 @@
+// Copyright (c) Bas van Beek 2024.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package run_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/basvanbeek/telemetry"
+
+	"github.com/basvanbeek/run"
+	"github.com/basvanbeek/run/pkg/flag"
+)
+
+func TestGenerateDocs(t *testing.T) {
+	var (
+		dir        = t.TempDir()
+		g          = run.Group{Name: "docs", HelpText: "Docs service.", Logger: telemetry.NoopLogger()}
+		fs         = run.NewFlagSet("Docs options")
+		addr, mode string
+	)
+	fs.StringVar(&addr, "addr", ":8080", "listen address")
+	fs.StringVar(&mode, "mode", "", "operating mode")
+	_ = fs.MarkDeprecated("mode", "use --addr instead")
+	fs.SetTier(flag.TierAdvanced, "addr")
+	g.Register(configUnit{fs: fs})
+
+	if err := g.Run("./myService", "--generate-docs", dir); err != nil {
+		t.Fatalf("Expected proper close, got %v", err)
+	}
+
+	md, err := os.ReadFile(filepath.Join(dir, "docs.md"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"# docs", "Docs service.", "### Docs options",
+		"| `--addr` | string | `:8080` | (advanced) listen address |",
+		"Deprecated: use --addr instead",
+	} {
+		if !strings.Contains(string(md), want) {
+			t.Errorf("Expected markdown to contain %q, got:\n%s", want, md)
+		}
+	}
+
+	man, err := os.ReadFile(filepath.Join(dir, "docs.1"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{".TH DOCS 1", `\fB\-\-addr\fR \fIstring\fR`, ".SS Docs options"} {
+		if !strings.Contains(string(man), want) {
+			t.Errorf("Expected man page to contain %q, got:\n%s", want, man)
+		}
+	}
+}
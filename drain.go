@@ -0,0 +1,161 @@
+// Copyright (c) Bas van Beek 2024.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package run
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/basvanbeek/telemetry"
+)
+
+// DefaultDrainTimeout is the default maximum amount of time Run waits for
+// in-flight work tracked by the Drainer to complete on shutdown.
+const DefaultDrainTimeout = 30 * time.Second
+
+// Drainer coordinates the draining of in-flight work on shutdown. Units track
+// their in-flight work (e.g. requests or jobs) with a Tracker. Once shutdown
+// is initiated, the Group flips the Drainer into draining state and waits for
+// all Trackers to become idle, bounded by the drain timeout set with
+// WithDrainTimeout, before stopping the Service and ServiceContext Units.
+type Drainer struct {
+	draining atomic.Bool
+	mu       sync.Mutex
+	trackers map[string]*Tracker
+}
+
+// Drainer returns the Drainer of the Group.
+func (g *Group) Drainer() *Drainer {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.drainer == nil {
+		g.drainer = &Drainer{}
+	}
+	return g.drainer
+}
+
+// Draining returns true once the Group is draining in-flight work. Health
+// checks can use it to report the process as not ready, and Units should
+// reject new work while draining.
+func (d *Drainer) Draining() bool {
+	return d.draining.Load()
+}
+
+// Track returns the Tracker of in-flight work registered under the provided
+// name, creating it if needed. Track is safe for concurrent use.
+func (d *Drainer) Track(name string) *Tracker {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.trackers == nil {
+		d.trackers = make(map[string]*Tracker)
+	}
+	t, ok := d.trackers[name]
+	if !ok {
+		t = &Tracker{}
+		d.trackers[name] = t
+	}
+	return t
+}
+
+// drain flips the Drainer into draining state and waits for all Trackers to
+// become idle or the timeout to expire. A nil Drainer returns immediately.
+func (d *Drainer) drain(logger telemetry.Logger, timeout time.Duration) {
+	if d == nil {
+		return
+	}
+	d.draining.Store(true)
+	if timeout <= 0 {
+		timeout = DefaultDrainTimeout
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	d.mu.Lock()
+	names := make([]string, 0, len(d.trackers))
+	for name := range d.trackers {
+		names = append(names, name)
+	}
+	d.mu.Unlock()
+	sort.Strings(names)
+
+	for _, name := range names {
+		t := d.Track(name)
+		if n := t.InFlight(); n > 0 {
+			logger.Info("draining", "tracker", name, "inFlight", n)
+		}
+		if !t.wait(ctx) {
+			logger.Info("drain timeout exceeded", "tracker", name,
+				"inFlight", t.InFlight(), "timeout", timeout.String())
+			return
+		}
+	}
+}
+
+// Tracker counts in-flight work of a Unit, similar to a sync.WaitGroup.
+type Tracker struct {
+	mu       sync.Mutex
+	inFlight int
+	// idle is closed once inFlight drops to zero
+	idle chan struct{}
+}
+
+// Add adds delta, which may be negative, to the in-flight work counter.
+func (t *Tracker) Add(delta int) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.inFlight == 0 && delta > 0 {
+		t.idle = make(chan struct{})
+	}
+	t.inFlight += delta
+	if t.inFlight < 0 {
+		panic("run: negative Tracker counter")
+	}
+	if t.inFlight == 0 && t.idle != nil {
+		close(t.idle)
+		t.idle = nil
+	}
+}
+
+// Done decrements the in-flight work counter by one.
+func (t *Tracker) Done() {
+	t.Add(-1)
+}
+
+// InFlight returns the amount of in-flight work.
+func (t *Tracker) InFlight() int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.inFlight
+}
+
+// wait waits for the Tracker to become idle. It returns false if ctx is done
+// first.
+func (t *Tracker) wait(ctx context.Context) bool {
+	t.mu.Lock()
+	idle := t.idle
+	t.mu.Unlock()
+	if idle == nil {
+		return true
+	}
+	select {
+	case <-idle:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
@@ -0,0 +1,91 @@
+// Copyright (c) Bas van Beek 2024.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package run_test
+
+import (
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/basvanbeek/telemetry"
+
+	"github.com/basvanbeek/run"
+)
+
+func TestDrainer(t *testing.T) {
+	var (
+		g        = run.Group{Name: "drain", Logger: telemetry.NoopLogger()}
+		drainer  = g.Drainer()
+		tracker  = drainer.Track("requests")
+		stop     = make(chan struct{})
+		drained  atomic.Bool
+		draining atomic.Bool
+	)
+	tracker.Add(1)
+	go func() {
+		// in-flight request completing after shutdown was initiated
+		for !drainer.Draining() {
+			time.Sleep(time.Millisecond)
+		}
+		draining.Store(true)
+		time.Sleep(10 * time.Millisecond)
+		drained.Store(true)
+		tracker.Done()
+	}()
+
+	var stoppedDrained bool
+	g.Register(
+		run.NewActor("server", func() error {
+			<-stop
+			return nil
+		}, func(error) {
+			stoppedDrained = drained.Load()
+			close(stop)
+		}),
+		run.NewActor("trigger", func() error {
+			return run.ErrRequestedShutdown
+		}, func(error) {}),
+	)
+
+	if err := g.Run("./myService"); err != nil {
+		t.Fatalf("Expected proper close, got %v", err)
+	}
+	if !draining.Load() || !stoppedDrained {
+		t.Error("Expected in-flight work to be drained before GracefulStop")
+	}
+	if tracker.InFlight() != 0 {
+		t.Errorf("Expected no in-flight work, got %d", tracker.InFlight())
+	}
+}
+
+func TestDrainTimeout(t *testing.T) {
+	var (
+		g       = run.NewGroup("drain", run.WithDrainTimeout(10*time.Millisecond))
+		tracker = g.Drainer().Track("stuck")
+	)
+	g.Logger = telemetry.NoopLogger()
+	tracker.Add(1)
+	g.Register(run.NewActor("trigger", func() error {
+		return run.ErrRequestedShutdown
+	}, func(error) {}))
+
+	start := time.Now()
+	if err := g.Run("./myService"); err != nil {
+		t.Fatalf("Expected proper close, got %v", err)
+	}
+	if d := time.Since(start); d > time.Second {
+		t.Errorf("Expected drain to time out, took %s", d)
+	}
+}
@@ -0,0 +1,106 @@
+// Copyright (c) Bas van Beek 2024.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package run
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/pflag"
+
+	"github.com/basvanbeek/run/pkg/flag"
+)
+
+// dumpConfig writes the effective configuration of all Config Units to the
+// provided path. Sensitive flag values are redacted by their flag.Value.
+func (g *Group) dumpConfig(path string, fs []*flag.Set) error {
+	cfg := g.effectiveConfig(fs)
+
+	var (
+		b   []byte
+		err error
+	)
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		b = marshalYAML(cfg)
+	default:
+		if b, err = json.MarshalIndent(cfg, "", "  "); err != nil {
+			return fmt.Errorf("dump config: %w", err)
+		}
+		b = append(b, '\n')
+	}
+
+	if path == "-" {
+		_, err = g.stdout().Write(b)
+	} else {
+		err = os.WriteFile(path, b, 0o600)
+	}
+	if err != nil {
+		return fmt.Errorf("dump config: %w", err)
+	}
+	return nil
+}
+
+// effectiveConfig returns the flag values of the provided FlagSets keyed by
+// the name of the Config Unit registering them. Sensitive flag values are
+// redacted by their flag.Value.
+func (g *Group) effectiveConfig(fs []*flag.Set) map[string]map[string]string {
+	cfg := make(map[string]map[string]string)
+	for idx, set := range fs {
+		u := unitAt(g, &g.c, idx)
+		if set == nil || u == nil {
+			continue
+		}
+		values := make(map[string]string)
+		set.VisitAll(func(f *pflag.Flag) {
+			// duplicate flags are only parsed by the first registered Unit
+			if pf := g.f.Lookup(f.Name); pf != nil {
+				f = pf
+			}
+			values[f.Name] = f.Value.String()
+		})
+		cfg[g.nameOf(u)] = values
+	}
+	return cfg
+}
+
+// marshalYAML serializes the two level configuration map as YAML.
+func marshalYAML(cfg map[string]map[string]string) []byte {
+	var sb strings.Builder
+	for _, unit := range sortedKeys(cfg) {
+		sb.WriteString(strconv.Quote(unit) + ":\n")
+		if len(cfg[unit]) == 0 {
+			sb.WriteString("  {}\n")
+		}
+		for _, name := range sortedKeys(cfg[unit]) {
+			sb.WriteString("  " + name + ": " + strconv.Quote(cfg[unit][name]) + "\n")
+		}
+	}
+	return []byte(sb.String())
+}
+
+func sortedKeys[T any](m map[string]T) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
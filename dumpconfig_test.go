@@ -0,0 +1,81 @@
+// Copyright (c) Bas van Beek 2024.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package run_test
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/basvanbeek/telemetry"
+
+	"github.com/basvanbeek/run"
+)
+
+func TestDumpConfig(t *testing.T) {
+	var (
+		dir            = t.TempDir()
+		addr, password string
+		preRan         bool
+	)
+
+	newGroup := func() *run.Group {
+		g := &run.Group{Name: "dump", Logger: telemetry.NoopLogger()}
+		fs := run.NewFlagSet("dump")
+		fs.StringVar(&addr, "addr", ":8080", "listen address")
+		fs.SensitiveStringVar(&password, "password", "", "password")
+		g.Register(
+			configUnit{fs: fs},
+			run.NewPreRunner("pre-run", func() error { preRan = true; return nil }),
+		)
+		return g
+	}
+
+	path := filepath.Join(dir, "config.json")
+	if err := newGroup().Run("./myService", "--addr", ":9090",
+		"--password", "secret", "--dump-config", path); err != nil {
+		t.Fatalf("Expected proper close, got %v", err)
+	}
+	if preRan {
+		t.Error("Expected to bail early before the PreRun phase")
+	}
+	b, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var cfg map[string]map[string]string
+	if err = json.Unmarshal(b, &cfg); err != nil {
+		t.Fatal(err)
+	}
+	if want, have := ":9090", cfg["config-unit"]["addr"]; want != have {
+		t.Errorf("Expected addr %q, got %q", want, have)
+	}
+	if strings.Contains(string(b), "secret") {
+		t.Errorf("Expected sensitive values to be redacted, got %s", b)
+	}
+
+	path = filepath.Join(dir, "config.yaml")
+	if err = newGroup().Run("./myService", "--dump-config", path); err != nil {
+		t.Fatalf("Expected proper close, got %v", err)
+	}
+	if b, err = os.ReadFile(path); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(b), `  addr: ":8080"`) {
+		t.Errorf("Expected YAML addr entry, got %s", b)
+	}
+}
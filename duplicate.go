@@ -0,0 +1,124 @@
+// Copyright (c) Bas van Beek 2024.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package run
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// ErrDuplicateUnit is returned by RunConfig if a Unit was registered more than
+// once while DuplicateError is the active DuplicatePolicy.
+const ErrDuplicateUnit Error = "duplicate unit"
+
+// DuplicatePolicy determines how Register handles a Unit which is already
+// registered with Group.
+type DuplicatePolicy int
+
+// Available duplicate registration policies.
+const (
+	// DuplicateError ignores the duplicate registration and has RunConfig
+	// return ErrDuplicateUnit. Duplicates registered after the Config phase
+	// are ignored and logged as error. This is the default policy.
+	DuplicateError DuplicatePolicy = iota
+	// DuplicatePanic panics on duplicate registration.
+	DuplicatePanic
+	// DuplicateIgnore ignores the duplicate registration and logs it.
+	DuplicateIgnore
+	// DuplicateAllow registers the Unit again, running its phases multiple
+	// times.
+	DuplicateAllow
+)
+
+// WithDuplicatePolicy sets how Register handles a Unit which is already
+// registered. Units are matched by identity, which for pointer types is the
+// pointer address. If matchName is set, Units with the same name are
+// considered duplicates as well.
+func WithDuplicatePolicy(p DuplicatePolicy, matchName bool) Option {
+	return func(g *Group) {
+		g.duplicatePolicy = p
+		g.duplicateName = matchName
+	}
+}
+
+// duplicate handles the registration of a Unit which is already registered
+// according to the DuplicatePolicy. It returns true if the Unit must not be
+// registered again. It must be called while holding the Group lock.
+func (g *Group) duplicate(u Unit) bool {
+	if g.duplicatePolicy == DuplicateAllow || !g.isRegistered(u) {
+		return false
+	}
+	switch g.duplicatePolicy {
+	case DuplicatePanic:
+		panic("duplicate unit " + u.Name() + " encountered: " +
+			"a Unit MUST NOT be registered more than once")
+	case DuplicateIgnore:
+		if g.Logger != nil {
+			g.Logger.Info("ignoring duplicate unit", "unit", u.Name())
+		} else {
+			// logged once the Logger is known
+			g.duplicates = append(g.duplicates, u.Name())
+		}
+	default:
+		if g.configured {
+			g.Logger.Error("ignoring duplicate unit", ErrDuplicateUnit, "unit", u.Name())
+		} else {
+			g.duplicates = append(g.duplicates, u.Name())
+		}
+	}
+	return true
+}
+
+// checkDuplicates reports the duplicate registrations found before the Config
+// phase.
+func (g *Group) checkDuplicates() error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	duplicates := g.duplicates
+	g.duplicates = nil
+	if len(duplicates) == 0 {
+		return nil
+	}
+	if g.duplicatePolicy == DuplicateIgnore {
+		for _, name := range duplicates {
+			g.Logger.Info("ignoring duplicate unit", "unit", name)
+		}
+		return nil
+	}
+	return fmt.Errorf("%w: %v", ErrDuplicateUnit, duplicates)
+}
+
+// isRegistered returns true if the provided Unit is registered for at least
+// one of the Group phases. It must be called while holding the Group lock.
+func (g *Group) isRegistered(u Unit) bool {
+	match := func(v Unit) bool {
+		return identical(v, u) || g.duplicateName && v.Name() == u.Name()
+	}
+	return anyUnit(g.i, match) || anyUnit(g.n, match) ||
+		anyUnit(g.c, match) || anyUnit(g.a, match) ||
+		anyUnit(g.v, match) || anyUnit(g.t, match) ||
+		anyUnit(g.k, match) || anyUnit(g.r, match) ||
+		anyUnit(g.q, match) || anyUnit(g.p, match) ||
+		anyUnit(g.j, match) ||
+		anyUnit(g.s, match) || anyUnit(g.x, match) ||
+		anyUnit(g.z, match)
+}
+
+// identical returns true if a and b are the same Unit. Units of a type which
+// is not comparable, e.g. holding function types, are never identical.
+func identical(a, b Unit) bool {
+	ta := reflect.TypeOf(a)
+	return ta == reflect.TypeOf(b) && ta.Comparable() && a == b
+}
@@ -0,0 +1,88 @@
+// Copyright (c) Bas van Beek 2024.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package run_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/basvanbeek/telemetry"
+
+	"github.com/basvanbeek/run"
+	"github.com/basvanbeek/run/pkg/test"
+)
+
+func TestDuplicatePolicy(t *testing.T) {
+	var (
+		runs int
+		pr   = &countingPreRun{runs: &runs}
+	)
+
+	g := run.NewGroup("duplicate", run.WithLogger(telemetry.NoopLogger()))
+	if reg := g.Register(pr, pr); !reg[0] || reg[1] {
+		t.Errorf("Expected duplicate to be ignored, got %v", reg)
+	}
+	if err := g.Run("./myService"); !errors.Is(err, run.ErrDuplicateUnit) {
+		t.Errorf("Expected %v, got %v", run.ErrDuplicateUnit, err)
+	}
+
+	g = run.NewGroup("duplicate",
+		run.WithLogger(telemetry.NoopLogger()),
+		run.WithDuplicatePolicy(run.DuplicateIgnore, false),
+	)
+	g.Register(pr, pr)
+	if err := g.Run("./myService"); err != nil || runs != 1 {
+		t.Errorf("Expected a single PreRun without error, got %d: %v", runs, err)
+	}
+
+	runs = 0
+	g = run.NewGroup("duplicate",
+		run.WithLogger(telemetry.NoopLogger()),
+		run.WithDuplicatePolicy(run.DuplicateAllow, false),
+	)
+	g.Register(pr, pr)
+	if err := g.Run("./myService"); err != nil || runs != 2 {
+		t.Errorf("Expected two PreRuns without error, got %d: %v", runs, err)
+	}
+
+	g = run.NewGroup("duplicate",
+		run.WithLogger(telemetry.NoopLogger()),
+		run.WithDuplicatePolicy(run.DuplicateIgnore, true),
+	)
+	if reg := g.Register(
+		&test.Svc{SvcName: "svc"}, &test.Svc{SvcName: "svc"},
+	); !reg[0] || reg[1] {
+		t.Errorf("Expected same named Unit to be ignored, got %v", reg)
+	}
+
+	g = run.NewGroup("duplicate",
+		run.WithLogger(telemetry.NoopLogger()),
+		run.WithDuplicatePolicy(run.DuplicatePanic, false),
+	)
+	g.Register(pr)
+	defer func() {
+		if recover() == nil {
+			t.Error("Expected panic on duplicate registration")
+		}
+	}()
+	g.Register(pr)
+}
+
+type countingPreRun struct {
+	runs *int
+}
+
+func (c *countingPreRun) Name() string  { return "counting-pre-run" }
+func (c *countingPreRun) PreRun() error { *c.runs++; return nil }
@@ -0,0 +1,40 @@
+// Copyright (c) Bas van Beek 2024.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package run
+
+// Enabler is an extension interface that Units can implement to be
+// conditionally enabled, typically driven by their own flags. Once the Config
+// phase has completed, Units reporting false are deregistered before the
+// Initialize, PreRun and Serve phases, like with a call to Deregister.
+type Enabler interface {
+	Unit
+	// Enabled reports if the Unit should take part in the run.
+	Enabled() bool
+}
+
+// disable deregisters the Enabler Units which report themselves disabled.
+func (g *Group) disable() {
+	g.mu.Lock()
+	units := g.units()
+	g.mu.Unlock()
+
+	for _, u := range units {
+		if e, ok := u.(Enabler); ok && !e.Enabled() {
+			name := g.nameOf(u)
+			g.Deregister(u)
+			g.Logger.Info(g.msg(MsgUnitDisabled), "unit", name)
+		}
+	}
+}
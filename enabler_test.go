@@ -0,0 +1,69 @@
+// Copyright (c) Bas van Beek 2024.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package run_test
+
+import (
+	"testing"
+
+	"github.com/basvanbeek/telemetry"
+
+	"github.com/basvanbeek/run"
+)
+
+type optionalService struct {
+	name    string
+	enabled bool
+	preRan  bool
+	served  bool
+}
+
+func (o *optionalService) Name() string { return o.name }
+func (o *optionalService) FlagSet() *run.FlagSet {
+	fs := run.NewFlagSet(o.name + " options")
+	fs.BoolVar(&o.enabled, o.name, false, "enable "+o.name)
+	return fs
+}
+func (o *optionalService) Validate() error { return nil }
+func (o *optionalService) Enabled() bool   { return o.enabled }
+func (o *optionalService) PreRun() error {
+	o.preRan = true
+	return nil
+}
+func (o *optionalService) Serve() error {
+	o.served = true
+	return run.ErrRequestedShutdown
+}
+func (o *optionalService) GracefulStop() {}
+
+func TestEnabler(t *testing.T) {
+	var (
+		g   = run.Group{Name: "enabler", Logger: telemetry.NoopLogger()}
+		on  = &optionalService{name: "on"}
+		off = &optionalService{name: "off"}
+	)
+	g.Register(on, off)
+
+	if err := g.Run("./myService", "--on"); err != nil {
+		t.Fatalf("Expected proper close, got %v", err)
+	}
+	if !on.preRan || !on.served {
+		t.Errorf("Expected enabled unit to run, got preRun %t and serve %t",
+			on.preRan, on.served)
+	}
+	if off.preRan || off.served {
+		t.Errorf("Expected disabled unit to be skipped, got preRun %t and serve %t",
+			off.preRan, off.served)
+	}
+}
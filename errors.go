@@ -0,0 +1,73 @@
+// Copyright (c) Bas van Beek 2024.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package run
+
+import "fmt"
+
+// ErrStartupTimeout is matched by the error returned by Run if a PreRun stage
+// did not complete within the timeout set by WithPreRunStageTimeout.
+const ErrStartupTimeout Error = "startup timeout exceeded"
+
+// ErrPreRun is matched by the error returned by Run if a PreRunner Unit
+// failed.
+const ErrPreRun Error = "pre-run failed"
+
+// ErrServiceCrashed is matched by the error returned by Run if a Service or
+// ServiceContext Unit initiated the shutdown with an error. Use errors.As with
+// a ServiceCrashedError to find out which Unit crashed.
+const ErrServiceCrashed Error = "service crashed"
+
+// ServiceCrashedError attributes an error returned by a Service or
+// ServiceContext Unit, causing the Group to shut down, to the Unit.
+type ServiceCrashedError struct {
+	// Unit holds the name of the crashed Unit.
+	Unit string
+	// Err holds the error returned by the Unit.
+	Err error
+}
+
+// Error implements error.
+func (s *ServiceCrashedError) Error() string {
+	return fmt.Sprintf("%s: %v", s.Unit, s.Err)
+}
+
+// Unwrap returns the error returned by the Unit.
+func (s *ServiceCrashedError) Unwrap() error {
+	return s.Err
+}
+
+// Is allows errors.Is to match the ErrServiceCrashed category.
+func (s *ServiceCrashedError) Is(target error) bool {
+	return target == ErrServiceCrashed //nolint:errorlint // sentinel match
+}
+
+// categorized tags err with a failure category sentinel matched by errors.Is,
+// leaving the error message untouched.
+type categorized struct {
+	err      error
+	category Error
+}
+
+func (c *categorized) Error() string   { return c.err.Error() }
+func (c *categorized) Unwrap() []error { return []error{c.err, c.category} }
+
+// categorize tags err with the provided category. A nil error is returned
+// as is.
+func categorize(err error, category Error) error {
+	if err == nil {
+		return nil
+	}
+	return &categorized{err: err, category: category}
+}
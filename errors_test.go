@@ -0,0 +1,84 @@
+// Copyright (c) Bas van Beek 2024.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package run_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/basvanbeek/telemetry"
+
+	"github.com/basvanbeek/run"
+	"github.com/basvanbeek/run/pkg/test"
+)
+
+func TestErrorCategories(t *testing.T) {
+	crash := errors.New("crash")
+	for _, tt := range []struct {
+		name  string
+		units []run.Unit
+		want  error
+	}{
+		{
+			name:  "validation",
+			units: []run.Unit{&service{}}, // requires -f to validate
+			want:  run.ErrValidation,
+		},
+		{
+			name:  "pre-run",
+			units: []run.Unit{run.NewPreRunner("failing", func() error { return crash })},
+			want:  run.ErrPreRun,
+		},
+		{
+			name: "service crashed",
+			units: []run.Unit{&test.Svc{SvcName: "crashing", Execute: func() error {
+				return crash
+			}}},
+			want: run.ErrServiceCrashed,
+		},
+	} {
+		g := run.Group{Name: tt.name, Logger: telemetry.NoopLogger()}
+		g.Register(tt.units...)
+
+		err := g.Run("./myService")
+		if !errors.Is(err, tt.want) {
+			t.Errorf("[%s] Expected %v, got %v", tt.name, tt.want, err)
+		}
+		for _, other := range []error{run.ErrValidation, run.ErrPreRun, run.ErrServiceCrashed} {
+			if other != tt.want && errors.Is(err, other) {
+				t.Errorf("[%s] Expected no %v, got %v", tt.name, other, err)
+			}
+		}
+	}
+}
+
+func TestServiceCrashedError(t *testing.T) {
+	var (
+		g     = run.Group{Name: "crash", Logger: telemetry.NoopLogger()}
+		crash = errors.New("crash")
+	)
+	g.Register(test.NewIRQService(func() {}), &test.Svc{SvcName: "crashing", Execute: func() error {
+		return crash
+	}})
+
+	err := g.Run("./myService")
+	var sc *run.ServiceCrashedError
+	if !errors.As(err, &sc) {
+		t.Fatalf("Expected ServiceCrashedError, got %v", err)
+	}
+	if sc.Unit != "crashing" || !errors.Is(err, crash) {
+		t.Errorf("Expected crash of unit crashing, got %v", err)
+	}
+}
@@ -0,0 +1,66 @@
+// Copyright (c) Bas van Beek 2024.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package run
+
+import "fmt"
+
+// Resolver provides access to the shared resources published with Provide.
+// It is handed to Unit factories registered with RegisterFactory.
+type Resolver interface {
+	Resolve(key string) (any, error)
+}
+
+// RegisterFactory registers a function constructing a Unit once the Config
+// phase has completed. The factory is called right before the Initialize
+// phase of the run, so it can construct expensive Units from final
+// configuration values. A factory returning a nil Unit is skipped, which
+// allows Units disabled by flags to never be constructed at all. A factory
+// returning an error aborts the run.
+//
+// Units constructed by a factory can not take part in the Config phase. They
+// are deregistered by Reset and constructed again on the next run.
+// RegisterFactory must be called before Run.
+func (g *Group) RegisterFactory(fn func(r Resolver) (Unit, error)) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.factories = append(g.factories, fn)
+}
+
+// instantiate calls the registered Unit factories and registers the Units
+// they return.
+func (g *Group) instantiate() error {
+	g.mu.Lock()
+	fns := append([]func(Resolver) (Unit, error){}, g.factories...)
+	g.mu.Unlock()
+
+	for idx, fn := range fns {
+		l := g.phaseLogger("factory", g.Name, g.itemNr(idx+1, len(fns)))
+		u, err := fn(g)
+		l.exit(err)
+		if err != nil {
+			return fmt.Errorf("factory (%d/%d): %w", idx+1, len(fns), err)
+		}
+		if u == nil {
+			continue
+		}
+		if !g.Register(u)[0] {
+			continue
+		}
+		g.mu.Lock()
+		g.built = append(g.built, u)
+		g.mu.Unlock()
+	}
+	return nil
+}
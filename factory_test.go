@@ -0,0 +1,86 @@
+// Copyright (c) Bas van Beek 2024.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package run_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/basvanbeek/telemetry"
+
+	"github.com/basvanbeek/run"
+	"github.com/basvanbeek/run/pkg/test"
+)
+
+func TestRegisterFactory(t *testing.T) {
+	var (
+		g       = run.Group{Name: "factory", Logger: telemetry.NoopLogger()}
+		fs      = run.NewFlagSet("worker options")
+		enabled bool
+		workers int
+		built   int
+		served  int
+	)
+	fs.BoolVar(&enabled, "worker", false, "enable worker")
+	fs.IntVar(&workers, "workers", 1, "number of workers")
+	g.Register(configUnit{fs: fs})
+	g.RegisterFactory(func(run.Resolver) (run.Unit, error) {
+		if !enabled {
+			return nil, nil
+		}
+		built = workers
+		return &test.Svc{SvcName: "worker", Execute: func() error {
+			served++
+			return run.ErrRequestedShutdown
+		}}, nil
+	})
+
+	if err := g.Run("./myService", "--worker", "--workers", "4"); err != nil {
+		t.Fatalf("Expected proper close, got %v", err)
+	}
+	if built != 4 || served != 1 {
+		t.Errorf("Expected worker built with 4 workers and served once, got %d and %d",
+			built, served)
+	}
+
+	// the factory built worker is dropped by Reset and not constructed when
+	// disabled by flag
+	if err := g.Reset(); err != nil {
+		t.Fatalf("Expected nil error, got %v", err)
+	}
+	g.Register(&test.Svc{SvcName: "once", Execute: func() error {
+		return run.ErrRequestedShutdown
+	}})
+	if err := g.Run("./myService", "--workers", "8"); err != nil {
+		t.Fatalf("Expected proper close, got %v", err)
+	}
+	if built != 4 || served != 1 {
+		t.Errorf("Expected disabled worker not to be built, got %d and %d",
+			built, served)
+	}
+}
+
+func TestRegisterFactoryError(t *testing.T) {
+	var (
+		g = run.Group{Name: "factory", Logger: telemetry.NoopLogger()}
+		e = errors.New("factory failed")
+	)
+	g.Register(test.NewIRQService(func() {}))
+	g.RegisterFactory(func(run.Resolver) (run.Unit, error) { return nil, e })
+
+	if err := g.Run("./myService"); !errors.Is(err, e) {
+		t.Errorf("Expected %v, got %v", e, err)
+	}
+}
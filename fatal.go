@@ -0,0 +1,119 @@
+// Copyright (c) Bas van Beek 2024.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package run
+
+import "runtime"
+
+// Unit states as reported by StateSnapshot.
+const (
+	StateRegistered = "registered"
+	StateConfigured = "configured"
+	StatePreRunning = "pre-running"
+	StatePreRan     = "pre-ran"
+	StateServing    = "serving"
+	StateStopping   = "stopping"
+	StateStopped    = "stopped"
+)
+
+// UnitState describes the lifecycle state of a Unit.
+type UnitState struct {
+	Name   string
+	State  string
+	Timing UnitTiming
+}
+
+// StateSnapshot holds the state of a Group at the time of a fatal exit.
+type StateSnapshot struct {
+	// Reason holds the originating Unit, if known, and error of the fatal exit.
+	Reason ShutdownReason
+	// Units holds the state of all Units that reached at least one of the
+	// tracked lifecycle phases, see UnitTimings.
+	Units []UnitState
+	// Goroutines holds the stack traces of all goroutines, including the ones
+	// of Units that are still running.
+	Goroutines []byte
+}
+
+// OnFatal registers a function invoked before Run returns a fatal error. It
+// receives the error and a snapshot of the Group state, allowing applications
+// to ship a crash report or write a diagnostics bundle. Requested shutdowns
+// and bail early requests are not considered fatal.
+// OnFatal is safe for concurrent use. Functions are invoked in order of
+// registration.
+func (g *Group) OnFatal(fn func(err error, snapshot StateSnapshot)) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.onFatal = append(g.onFatal, fn)
+}
+
+// fatal invokes the registered OnFatal functions.
+func (g *Group) fatal(err error, origin string) {
+	g.mu.Lock()
+	fns := append([]func(error, StateSnapshot){}, g.onFatal...)
+	g.mu.Unlock()
+	if len(fns) == 0 {
+		return
+	}
+	snapshot := StateSnapshot{
+		Reason:     ShutdownReason{Unit: origin, Err: err},
+		Goroutines: stacks(),
+	}
+	snapshot.Units = g.unitStates()
+	for _, fn := range fns {
+		fn(err, snapshot)
+	}
+}
+
+// unitStates returns the lifecycle state of all Units that reached at least
+// one of the tracked lifecycle phases.
+func (g *Group) unitStates() []UnitState {
+	var states []UnitState
+	for _, t := range g.UnitTimings() {
+		states = append(states, UnitState{Name: t.Name, State: t.state(), Timing: t})
+	}
+	return states
+}
+
+// state derives the lifecycle state from the recorded phase transitions.
+func (t UnitTiming) state() string {
+	switch {
+	case !t.StopEnd.IsZero():
+		return StateStopped
+	case !t.StopStart.IsZero():
+		return StateStopping
+	case !t.ServeStart.IsZero():
+		return StateServing
+	case !t.PreRunEnd.IsZero():
+		return StatePreRan
+	case !t.PreRunStart.IsZero():
+		return StatePreRunning
+	case !t.Configured.IsZero():
+		return StateConfigured
+	default:
+		return StateRegistered
+	}
+}
+
+// stacks returns the stack traces of all goroutines.
+func stacks() []byte {
+	buf := make([]byte, 64<<10)
+	for {
+		n := runtime.Stack(buf, true)
+		if n < len(buf) {
+			return buf[:n]
+		}
+		buf = make([]byte, 2*len(buf))
+	}
+}
@@ -0,0 +1,88 @@
+// Copyright (c) Bas van Beek 2024.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package run_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/basvanbeek/telemetry"
+
+	"github.com/basvanbeek/run"
+	"github.com/basvanbeek/run/pkg/test"
+)
+
+func TestOnFatal(t *testing.T) {
+	var (
+		g         = run.Group{Name: "fatal", Logger: telemetry.NoopLogger()}
+		snapshots []run.StateSnapshot
+		started   = make(chan struct{})
+	)
+	g.OnFatal(func(err error, snapshot run.StateSnapshot) {
+		if !errors.Is(err, errIRQ) {
+			t.Errorf("Expected %v, got %v", errIRQ, err)
+		}
+		snapshots = append(snapshots, snapshot)
+	})
+
+	g.Register(run.NewService("blocking", make(chan struct{}),
+		func(quit chan struct{}) error {
+			close(started)
+			<-quit
+			return nil
+		},
+		func(quit chan struct{}) { close(quit) },
+	), &test.Svc{
+		SvcName: "failing",
+		Execute: func() error {
+			<-started
+			return errIRQ
+		},
+	})
+
+	if err := g.Run("./myService"); !errors.Is(err, errIRQ) {
+		t.Fatalf("Expected %v, got %v", errIRQ, err)
+	}
+	if len(snapshots) != 1 {
+		t.Fatalf("Expected a single snapshot, got %d", len(snapshots))
+	}
+	snapshot := snapshots[0]
+	if snapshot.Reason.Unit != "failing" {
+		t.Errorf("Expected failing unit as origin, got %q", snapshot.Reason.Unit)
+	}
+	if len(snapshot.Goroutines) == 0 {
+		t.Error("Expected goroutine stack traces")
+	}
+	states := make(map[string]string)
+	for _, u := range snapshot.Units {
+		states[u.Name] = u.State
+	}
+	if states["blocking"] != run.StateStopped || states["failing"] != run.StateStopped {
+		t.Errorf("Expected stopped units, got %v", states)
+	}
+
+	// requested shutdowns are not fatal
+	g = run.Group{Name: "requested", Logger: telemetry.NoopLogger()}
+	g.OnFatal(func(error, run.StateSnapshot) {
+		t.Error("Expected OnFatal not to be called")
+	})
+	g.Register(&test.Svc{
+		SvcName: "requested",
+		Execute: func() error { return run.ErrRequestedShutdown },
+	})
+	if err := g.Run("./myService"); err != nil {
+		t.Errorf("Expected requested shutdown, got %v", err)
+	}
+}
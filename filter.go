@@ -0,0 +1,67 @@
+// Copyright (c) Bas van Beek 2024.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package run
+
+// ServeErrorFilter is an extension interface that Service and ServiceContext
+// Units can implement to declare certain errors returned by their Serve or
+// ServeContext method as expected or non-fatal.
+// FilterServeError is only called with non-nil errors. Returning an error
+// wrapping ErrRequestedShutdown signals an expected shutdown of the Group.
+// Returning nil marks the error as non-fatal, in which case the Unit exits
+// while the Group keeps running. Any other returned error is treated as the
+// Unit's Serve error.
+type ServeErrorFilter interface {
+	// Unit is embedded for Group registration and identification
+	Unit
+	FilterServeError(err error) error
+}
+
+// MayExitClean is an extension interface that Service and ServiceContext
+// Units can implement to declare a nil return of their Serve or ServeContext
+// method as expected, e.g. for a one-off job. If MayExitClean returns true, the
+// Unit exits while the Group keeps running. Otherwise a nil return outside of
+// a shutdown is treated as the Unit's Serve error, see ErrUnexpectedExit.
+type MayExitClean interface {
+	// Unit is embedded for Group registration and identification
+	Unit
+	MayExitClean() bool
+}
+
+// ErrUnexpectedExit is returned by Run, wrapped in a ServiceCrashedError, if
+// a Service or ServiceContext Unit not implementing MayExitClean returned nil
+// while the Group was not shutting down. See WithExitOnIdle for batch style
+// Groups.
+const ErrUnexpectedExit Error = "returned without explicit error condition"
+
+// mayExitClean returns true if the provided Unit accepts a nil return of its
+// Serve or ServeContext method.
+func mayExitClean(u Unit) bool {
+	m, ok := u.(MayExitClean)
+	return ok && m.MayExitClean()
+}
+
+// filterServeError applies the Unit specific ServeErrorFilter and the Group's
+// ErrorFilter to the provided Serve error.
+func (g *Group) filterServeError(u Unit, err error) error {
+	if f, ok := u.(ServeErrorFilter); ok {
+		if err = f.FilterServeError(err); err == nil {
+			return nil
+		}
+	}
+	if g.ErrorFilter != nil {
+		err = g.ErrorFilter(err)
+	}
+	return err
+}
@@ -0,0 +1,143 @@
+// Copyright (c) Bas van Beek 2024.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package run_test
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/basvanbeek/telemetry"
+
+	"github.com/basvanbeek/run"
+)
+
+var errClosed = errors.New("closed")
+
+type filteringService struct {
+	run.Service
+}
+
+func (f filteringService) FilterServeError(err error) error {
+	if errors.Is(err, errClosed) {
+		return nil
+	}
+	return err
+}
+
+func TestServeErrorFilter(t *testing.T) {
+	var (
+		irq    = make(chan error)
+		exited = make(chan struct{})
+		g      = run.NewGroup("filter",
+			run.WithLogger(telemetry.NoopLogger()),
+			run.WithErrorFilter(func(err error) error {
+				if errors.Is(err, errIRQ) {
+					return fmt.Errorf("%w: %w", run.ErrRequestedShutdown, err)
+				}
+				return err
+			}),
+		)
+	)
+
+	g.Register(
+		filteringService{run.NewActor("non-fatal",
+			func() error {
+				defer close(exited)
+				return errClosed
+			},
+			func(error) {},
+		)},
+		run.NewActor("stopper",
+			func() error {
+				<-exited
+				// give the Group the opportunity to (wrongfully) shut down
+				time.Sleep(10 * time.Millisecond)
+				return errIRQ
+			},
+			func(error) {},
+		),
+	)
+
+	go func() { irq <- g.Run("./myService") }()
+
+	select {
+	case err := <-irq:
+		if err != nil {
+			t.Errorf("Expected requested shutdown, got %v", err)
+		}
+	case <-time.After(100 * time.Millisecond):
+		t.Errorf("timeout")
+	}
+}
+
+type cleanExitService struct {
+	run.Service
+}
+
+func (c cleanExitService) MayExitClean() bool { return true }
+
+func TestMayExitClean(t *testing.T) {
+	var (
+		exited = make(chan struct{})
+		g      = run.Group{Name: "clean-exit", Logger: telemetry.NoopLogger()}
+	)
+	g.Register(
+		cleanExitService{run.NewActor("job",
+			func() error {
+				defer close(exited)
+				return nil
+			},
+			func(error) {},
+		)},
+		run.NewActor("stopper",
+			func() error {
+				<-exited
+				// give the Group the opportunity to (wrongfully) shut down
+				time.Sleep(10 * time.Millisecond)
+				return run.ErrRequestedShutdown
+			},
+			func(error) {},
+		),
+	)
+
+	if err := g.Run("./myService"); err != nil {
+		t.Errorf("Expected requested shutdown, got %v", err)
+	}
+}
+
+func TestUnexpectedExit(t *testing.T) {
+	var (
+		stop = make(chan struct{})
+		g    = run.Group{Name: "unexpected-exit", Logger: telemetry.NoopLogger()}
+	)
+	g.Register(
+		run.NewActor("silent", func() error { return nil }, func(error) {}),
+		run.NewActor("blocking",
+			func() error {
+				<-stop
+				return nil
+			},
+			func(error) { close(stop) },
+		),
+	)
+
+	err := g.Run("./myService")
+	var sc *run.ServiceCrashedError
+	if !errors.Is(err, run.ErrUnexpectedExit) || !errors.As(err, &sc) || sc.Unit != "silent" {
+		t.Errorf("Expected unexpected exit of unit silent, got %v", err)
+	}
+}
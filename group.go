@@ -23,12 +23,16 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"io"
+	"math/rand/v2"
 	"os"
 	"path"
+	"sort"
 	"strings"
+	"sync"
 	"sync/atomic"
+	"time"
 
-	color "github.com/logrusorgru/aurora/v4"
 	"github.com/spf13/pflag"
 
 	"github.com/basvanbeek/multierror"
@@ -66,6 +70,30 @@ const ErrBailEarlyRequest Error = "exit request from flag handler"
 // request a shutdown of the application. Group will then exit without errors.
 const ErrRequestedShutdown Error = "shutdown requested"
 
+// ErrUnitNotRunning is returned when trying to manage a Service or
+// ServiceContext Unit at runtime which is not currently being served.
+const ErrUnitNotRunning Error = "unit not running"
+
+// ErrUnitNotFound is returned when trying to manage a Unit at runtime which
+// has not been registered with Group.
+const ErrUnitNotFound Error = "unit not found"
+
+// ErrNotServing is returned when trying to manage Units at runtime while the
+// Group is not in its Serve phase.
+const ErrNotServing Error = "group not serving"
+
+// ErrShutdownTimeout is returned by Run if the registered Service and
+// ServiceContext Units did not return within the configured shutdown timeout.
+const ErrShutdownTimeout Error = "shutdown timeout exceeded"
+
+// ErrForcedShutdown is returned by Run if a ShutdownAborter Unit requested to
+// abort waiting for the registered Service and ServiceContext Units to return.
+const ErrForcedShutdown Error = "forced shutdown"
+
+// DefaultShutdownProgressInterval is the default interval at which Run logs the
+// Units that have not yet returned during shutdown.
+const DefaultShutdownProgressInterval = 5 * time.Second
+
 // Unit is the default interface an object needs to implement for it to be able
 // to register with a Group.
 // Name should return a short but good identifier of the Unit.
@@ -84,6 +112,31 @@ type Initializer interface {
 	Initialize()
 }
 
+// InitializerE is like Initializer but its Initialize method can fail. An
+// error aborts the startup of the Group, attributed to the Unit.
+type InitializerE interface {
+	// Unit is embedded for Group registration and identification
+	Unit
+	Initialize() error
+}
+
+// DefaultInitializePriority is the priority of Initializer Units not
+// implementing InitializePrioritizer.
+const DefaultInitializePriority = 0
+
+// InitializePrioritizer is an extension interface that Initializer Units can
+// implement to have their Initialize method called independently of
+// registration order. InitializerE Units can opt in as well by implementing
+// the InitializePriority method. Initializers are called in ascending order of
+// priority and in order of registration within the same priority. This allows
+// a library registering Units internally to have its Initializers run before
+// the ones of dependent Units by returning a priority below
+// DefaultInitializePriority.
+type InitializePrioritizer interface {
+	Initializer
+	InitializePriority() int
+}
+
 // Namer is an extension interface that Units can implement if they need to know
 // or want to use the Group.Name. Since Group's name can be updated at runtime
 // by the -n flag, Group first parses its own FlagSet the know if its Name needs
@@ -116,6 +169,20 @@ type PreRunner interface {
 	PreRun() error
 }
 
+// PreRunnerContext interface can be implemented by Group Unit objects that
+// need a pre run stage before starting the Group Services and want to be
+// interrupted when the Group is requested to shut down during startup or the
+// deadline of their PreRun stage expires (see WithPreRunStageTimeout).
+// If a Unit implements both PreRunner and PreRunnerContext, only its
+// PreRunContext method is called.
+// If a Unit's PreRunContext returns an error it will stop the Group
+// immediately.
+type PreRunnerContext interface {
+	// Unit is embedded for Group registration and identification
+	Unit
+	PreRunContext(ctx context.Context) error
+}
+
 // NewPreRunner takes a name and a standalone pre runner compatible function
 // and turns them into a Group compatible PreRunner, ready for registration.
 func NewPreRunner(name string, fn func() error) PreRunner {
@@ -164,6 +231,10 @@ type Service interface {
 // need to run a blocking service until an error occurs or the by Group provided
 // context.Context sends a cancellation signal.
 //
+// The cause of the context cancellation, as returned by context.Cause, holds
+// why the shutdown started, e.g. the error of the originating Unit or the
+// received signal.
+//
 // An alternative to implementing ServiceContext can be found in the Service
 // interface which has specific Serve and GracefulStop methods.
 //
@@ -189,16 +260,141 @@ type Group struct {
 	// when --help is requested.
 	HelpText string
 	Logger   telemetry.Logger
+	// ErrorFilter is optional and allows to centrally inspect and map errors
+	// returned by the Serve and ServeContext methods of all Units, after
+	// any Unit specific ServeErrorFilter has been applied. Returning an error
+	// wrapping ErrRequestedShutdown signals an expected shutdown. Returning
+	// nil marks the error as non-fatal, in which case the Unit exits while
+	// the Group keeps running.
+	ErrorFilter func(error) error
+	// IgnoreUnknownFlags is optional and allows RunConfig to parse the known
+	// flags while collecting unknown flags instead of failing, e.g. flags
+	// destined for a sidecar or plugin subsystem loaded later. The collected
+	// flags are available through UnknownFlags.
+	IgnoreUnknownFlags bool
+	// Stdout and Stderr are optional and allow to redirect the output of the
+	// Group, e.g. to capture help and version output when embedding the
+	// Group. If nil, os.Stdout and os.Stderr are used.
+	Stdout io.Writer
+	Stderr io.Writer
 
-	f *flag.Set
-	i []Initializer
-	n []Namer
-	c []Config
-	p []PreRunner
-	s []Service
-	x []ServiceContext
+	// mu guards the registered Unit slices as well as the Serve phase state
+	// below, allowing Register and Deregister to be called concurrently with
+	// Run.
+	mu sync.Mutex
 
-	configured bool
+	f    *flag.Set
+	sets []*flag.Set
+	i    []Unit // Initializer and InitializerE
+	n    []Namer
+	c    []Config
+	a    []ArgsReceiver
+	v    []FlagSource
+	t    []Interceptor
+	k    []ConfigCommand
+	r    []Reloader
+	q    []Requirer
+	p    []Unit // PreRunner and PreRunnerContext
+	j    []Task
+	s    []Service
+	x    []ServiceContext
+	z    []PostRunner
+
+	configured  bool
+	args        []string
+	remaining   []string
+	passThrough []string
+	unknown     []string
+	initialized int
+	duplicates  []string
+	nameClashes []string
+	nameCount   map[string]int
+	names       []unitName
+	namesMu     sync.RWMutex
+	preRan      []Unit
+	registry    map[any]any
+	timings     []*unitTiming
+	onFatal     []func(error, StateSnapshot)
+	crossChecks []func(units []Unit) error
+	factories   []func(r Resolver) (Unit, error)
+	drainer     *Drainer
+	anomalies   []Anomaly
+	spans       []Span
+	readyAt     time.Time
+	groupCtx    context.Context
+	groupCancel context.CancelCauseFunc
+	minServices int
+	built       []Unit
+	startTime   time.Time
+	runID       string
+	lastStop    *ShutdownReason
+	actors      int
+
+	// behavior set by Option
+	shutdownTimeout  time.Duration
+	drainTimeout     time.Duration
+	shutdownProgress time.Duration
+	staggerDelay     time.Duration
+	staggerJitter    time.Duration
+	staggerBatch     int
+	rnd              *rand.Rand
+	clock            Clock
+	accounting       bool
+	tracing          bool
+	panicOnAnomaly   bool
+	exitOnIdle       bool
+	taskMode         TaskMode
+	stdLogBridge     bool
+	processTitle     bool
+	instanceID       string
+	preRunTimeouts   map[int]time.Duration
+	startupSignals   []os.Signal
+	normalizeFunc    flag.NormalizeFunc
+	noDefaultFlags   bool
+	usageExitCode    int
+	commonFlags      map[CommonFlag][2]string
+	palette          *Palette
+	messages         map[MessageID]string
+	helpTemplate     string
+	configAudit      bool
+	duplicatePolicy  DuplicatePolicy
+	duplicateName    bool
+	namePolicy       NamePolicy
+	flagSources      map[string]string
+
+	inRun    atomic.Bool
+	reloadMu sync.Mutex
+
+	// Serve phase state
+	ctx      context.Context
+	cancel   context.CancelCauseFunc
+	errs     chan ShutdownReason
+	wg       sync.WaitGroup
+	running  []*serving
+	done     chan struct{}
+	started  bool
+	launched bool
+	stopping bool
+
+	// set by Start
+	launch    chan struct{}
+	startDone chan struct{}
+	startErr  error
+}
+
+// serving holds the runtime state of a Service or ServiceContext Unit that
+// has been started by Group.
+type serving struct {
+	unit Unit
+	item string
+	stop func(reason ShutdownReason)
+	exit chan struct{}
+	// reason holds why the Unit was requested to stop
+	reason atomic.Pointer[ShutdownReason]
+	done   bool
+	// detached is set if the Unit was stopped individually by Deregister or
+	// StopUnit, in which case its exit must not tear down the Group.
+	detached bool
 }
 
 // Register will inspect the provided objects implementing the Unit interface to
@@ -209,6 +405,14 @@ type Group struct {
 // Units, signaling for each provided Unit if it successfully registered with
 // Group for at least one of the bootstrap phases or if it was ignored.
 //
+// Register is safe for concurrent use. If the Group is already in its Serve
+// phase, a Service or ServiceContext Unit is started immediately after running
+// its Initialize and PreRun methods (if implemented). A PreRun error of such a
+// Unit is treated like a Serve error and will stop the Group.
+//
+// A Unit which is already registered is handled according to the
+// DuplicatePolicy set by WithDuplicatePolicy, see DuplicateError.
+//
 // Important: It is a design flaw for a Unit implementation to adhere to both
 // the Service and ServiceContext interfaces. Passing along such a Unit will
 // cause Register to throw a panic!
@@ -217,10 +421,28 @@ func (g *Group) Register(units ...Unit) []bool {
 		Service
 		ServiceContext
 	}
+	var misused []string
+	defer func() {
+		// reported once unlocked
+		for _, name := range misused {
+			g.anomaly(Anomaly{Unit: name, Kind: AnomalyMisuse,
+				Err: fmt.Errorf("%w: Config Unit registered after RunConfig", ErrMisuse)})
+		}
+	}()
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
 	hasRegistered := make([]bool, len(units))
 	for idx := range units {
-		if i, ok := units[idx].(Initializer); ok {
-			g.i = append(g.i, i)
+		if svc, ok := units[idx].(ambiguousService); ok {
+			panic("ambiguous service " + svc.Name() + " encountered: " +
+				"a Unit MUST NOT implement both Service and ServiceContext")
+		}
+		if g.duplicate(units[idx]) || !g.assignName(units[idx]) {
+			continue
+		}
+		if isInitializer(units[idx]) {
+			g.i = append(g.i, units[idx])
 			hasRegistered[idx] = true
 		}
 		if !g.configured {
@@ -234,14 +456,45 @@ func (g *Group) Register(units ...Unit) []bool {
 				g.c = append(g.c, c)
 				hasRegistered[idx] = true
 			}
+			if a, ok := units[idx].(ArgsReceiver); ok {
+				g.a = append(g.a, a)
+				hasRegistered[idx] = true
+			}
+			if v, ok := units[idx].(FlagSource); ok {
+				g.v = append(g.v, v)
+				hasRegistered[idx] = true
+			}
+			if t, ok := units[idx].(Interceptor); ok {
+				g.t = append(g.t, t)
+				hasRegistered[idx] = true
+			}
+			if k, ok := units[idx].(ConfigCommand); ok {
+				g.k = append(g.k, k)
+				hasRegistered[idx] = true
+			}
+		} else if _, ok := units[idx].(Config); ok {
+			// its flags will never be parsed
+			misused = append(misused, g.nameOf(units[idx]))
 		}
-		if p, ok := units[idx].(PreRunner); ok {
-			g.p = append(g.p, p)
+		if r, ok := units[idx].(Reloader); ok {
+			g.r = append(g.r, r)
 			hasRegistered[idx] = true
 		}
-		if svc, ok := units[idx].(ambiguousService); ok {
-			panic("ambiguous service " + svc.Name() + " encountered: " +
-				"a Unit MUST NOT implement both Service and ServiceContext")
+		if q, ok := units[idx].(Requirer); ok {
+			g.q = append(g.q, q)
+			hasRegistered[idx] = true
+		}
+		if isPreRunner(units[idx]) {
+			g.p = append(g.p, units[idx])
+			hasRegistered[idx] = true
+		}
+		if j, ok := units[idx].(Task); ok {
+			g.j = append(g.j, j)
+			hasRegistered[idx] = true
+		}
+		if z, ok := units[idx].(PostRunner); ok {
+			g.z = append(g.z, z)
+			hasRegistered[idx] = true
 		}
 		if s, ok := units[idx].(Service); ok {
 			g.s = append(g.s, s)
@@ -251,6 +504,11 @@ func (g *Group) Register(units ...Unit) []bool {
 			g.x = append(g.x, x)
 			hasRegistered[idx] = true
 		}
+		if g.started && !g.stopping {
+			// we are past the PreRun phase, start the Unit if it is a Service
+			// or ServiceContext.
+			g.serveRuntime(units[idx])
+		}
 	}
 	return hasRegistered
 }
@@ -261,52 +519,82 @@ func (g *Group) Register(units ...Unit) []bool {
 // The returned array of booleans is of the same size as the amount of provided
 // Units, signaling for each provided Unit if it successfully de-registered
 // with Group for at least one of the bootstrap phases or if it was ignored.
-// It is generally safe to use Deregister at any bootstrap phase except at Serve
-// time (when it will have no effect).
+// See DeregisterPhases for details on the phases and slot reclamation.
+// It is safe to use Deregister at any bootstrap phase. If a Service or
+// ServiceContext Unit is deregistered while being served, only that Unit is
+// gracefully stopped and its exit will not stop the Group.
 // WARNING: Dependencies between Units can cause a crash as a dependent Unit
 // might expect the other Unit to gone through all the needed bootstrapping
 // phases.
 func (g *Group) Deregister(units ...Unit) []bool {
+	phases := g.DeregisterPhases(units...)
 	hasDeregistered := make([]bool, len(units))
-	for idx := range units {
-		for i := range g.i {
-			if g.i[i] != nil && g.i[i].(Unit) == units[idx] {
-				g.i[i] = nil // can't resize slice during Run, so nil
-				hasDeregistered[idx] = true
-			}
-		}
-		for i := range g.n {
-			if g.n[i] != nil && g.n[i].(Unit) == units[idx] {
-				g.n[i] = nil // can't resize slice during Run, so nil
-				hasDeregistered[idx] = true
-			}
-		}
-		for i := range g.c {
-			if g.c[i] != nil && g.c[i].(Unit) == units[idx] {
-				g.c[i] = nil // can't resize slice during Run, so nil
-				hasDeregistered[idx] = true
-			}
-		}
-		for i := range g.p {
-			if g.p[i] != nil && g.p[i].(Unit) == units[idx] {
-				g.p[i] = nil // can't resize slice during Run, so nil
-				hasDeregistered[idx] = true
-			}
-		}
-		for i := range g.s {
-			if g.s[i] != nil && g.s[i].(Unit) == units[idx] {
-				g.s[i] = nil // can't resize slice during Run, so nil
-				hasDeregistered[idx] = true
+	for idx := range phases {
+		hasDeregistered[idx] = phases[idx] != 0
+	}
+	return hasDeregistered
+}
+
+// DeregisterPhases works like Deregister but returns for each provided Unit
+// the Phase bitmask of the bootstrap phases it was de-registered from.
+// Before RunConfig is called, the registration slots of de-registered Units
+// are reclaimed, so a Unit can be registered again later without leaving
+// traces of its earlier registration. Once configured, Group keeps the slots
+// in place as its phases address Units by index. Group.Reset reclaims them.
+func (g *Group) DeregisterPhases(units ...Unit) []Phase {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	// a running Reload addresses Reloaders by index as well
+	remove, removeReloader := !g.configured, false
+	if remove && g.reloadMu.TryLock() {
+		removeReloader = true
+		defer g.reloadMu.Unlock()
+	}
+
+	phases := make([]Phase, len(units))
+	for idx, u := range units {
+		phases[idx] = g.deregister(u, remove, removeReloader)
+		for _, r := range g.running {
+			if r.unit == u && !r.done && !r.detached && !g.stopping {
+				// only stop this Unit, the Group keeps running
+				r.detached = true
+				go g.gracefulStop(r, ShutdownReason{Err: ErrRequestedShutdown})
 			}
 		}
-		for i := range g.x {
-			if g.x[i] != nil && g.x[i].(Unit) == units[idx] {
-				g.x[i] = nil // can't resize slice during Run, so nil
-				hasDeregistered[idx] = true
-			}
+	}
+	return phases
+}
+
+// deregister removes the provided Unit from all phase slices and returns the
+// phases it was registered for. See the deregister function for the meaning of
+// remove. It must be called while holding the Group lock.
+func (g *Group) deregister(u Unit, remove, removeReloader bool) Phase {
+	var phases Phase
+	for _, d := range []struct {
+		phase Phase
+		found bool
+	}{
+		{PhaseInitialize, deregister(&g.i, u, remove)},
+		{PhaseName, deregister(&g.n, u, remove)},
+		{PhaseConfig, deregister(&g.c, u, remove)},
+		{PhaseArgs, deregister(&g.a, u, remove)},
+		{PhaseFlagSource, deregister(&g.v, u, remove)},
+		{PhaseConfigCommand, deregister(&g.k, u, remove)},
+		{PhaseReload, deregister(&g.r, u, removeReloader)},
+		{PhasePreflight, deregister(&g.q, u, remove)},
+		{PhasePreRun, deregister(&g.p, u, remove)},
+		{PhaseServe, deregister(&g.s, u, remove)},
+		{PhaseServeContext, deregister(&g.x, u, remove)},
+		{PhasePostRun, deregister(&g.z, u, remove)},
+		{PhaseIntercept, deregister(&g.t, u, remove)},
+		{PhaseTask, deregister(&g.j, u, remove)},
+	} {
+		if d.found {
+			phases |= d.phase
 		}
 	}
-	return hasDeregistered
+	return phases
 }
 
 // RunConfig runs the Config phase of all registered Config aware Units.
@@ -319,8 +607,22 @@ func (g *Group) Deregister(units ...Unit) []bool {
 // should clean up and exit without an error code as an ErrBailEarlyRequest
 // is not an actual error but a request for Help, Version or other task that has
 // been finished and there is no more work left to handle.
-func (g *Group) RunConfig(args ...string) (err error) {
+// Receiving one of the startup signals (see WithStartupSignals) aborts the
+// Config phase with an error wrapping ErrRequestedShutdown.
+func (g *Group) RunConfig(args ...string) error {
+	ctx, stop := g.startupContext(context.Background())
+	defer stop()
+	return g.runConfig(ctx, args...)
+}
+
+// runConfig runs the Config phase, aborting if the provided startup context is
+// done.
+func (g *Group) runConfig(ctx context.Context, args ...string) (err error) {
+	g.mu.Lock()
 	g.configured = true
+	g.startTime = g.Clock().Now()
+	g.runID = newRunID()
+	g.mu.Unlock()
 	if g.Logger == nil {
 		g.Logger = &log.Logger{}
 	}
@@ -333,21 +635,39 @@ func (g *Group) RunConfig(args ...string) (err error) {
 	g.HelpText = strings.ReplaceAll(g.HelpText, BinaryName, os.Args[0])
 
 	defer func() {
-		if err != nil && err != ErrBailEarlyRequest {
-			g.Logger.Error("unexpected exit", err)
+		if err != nil && err != ErrBailEarlyRequest && !errors.Is(err, ErrRequestedShutdown) {
+			g.Logger.Error(g.msg(MsgUnexpectedExit), err)
 			err = multierror.SetFormatter(err, multierror.ListFormatFunc)
 		}
 	}()
 
+	// exit on duplicate registrations and Unit name clashes
+	if err = g.checkDuplicates(); err != nil {
+		return err
+	}
+	if err = g.checkNames(); err != nil {
+		return err
+	}
+
 	// run configuration stage
 	g.f = flag.NewSet(g.Name)
 	g.f.SortFlags = false // keep order of flag registration
+	aliases := make(map[string]string)
+	g.f.SetNormalizeFunc(func(f *pflag.FlagSet, name string) pflag.NormalizedName {
+		name = string(g.normalize(f, name))
+		if target, ok := aliases[name]; ok {
+			return g.normalize(f, target)
+		}
+		return pflag.NormalizedName(name)
+	})
+	g.f.SetOutput(g.stderr())
 	g.f.Usage = func() {
-		fmt.Printf("Usage of %s:\n", g.Name)
+		w := g.f.Output()
+		fmt.Fprintln(w, g.msg(MsgUsage, g.Name))
 		if g.HelpText != "" {
-			fmt.Printf("%s\n", g.HelpText)
+			fmt.Fprintf(w, "%s\n", g.HelpText)
 		}
-		fmt.Printf("Flags:\n")
+		fmt.Fprintln(w, g.msg(MsgFlags))
 		g.f.PrintDefaults()
 	}
 
@@ -355,19 +675,66 @@ func (g *Group) RunConfig(args ...string) (err error) {
 	var (
 		name         string
 		showHelp     bool
+		showHelpAll  bool
 		showVersion  bool
 		showRunGroup bool
+		dumpConfig   string
+		generateDocs string
+		diagnostics  string
+		noColor      bool
 	)
 
 	gFS := flag.NewSet("Common Service options")
 	gFS.SortFlags = false
-	gFS.StringVarP(&name, "name", "n", g.Name, `name of this service`)
-	gFS.BoolVarP(&showVersion, "version", "v", false,
-		"show version information and exit.")
-	gFS.BoolVarP(&showHelp, "help", "h", false,
-		"show this help information and exit.")
-	gFS.BoolVar(&showRunGroup, "show-rungroup-units", false, "show run group units")
-	_ = gFS.MarkHidden("show-rungroup-units")
+	gFS.SetOutput(g.stderr())
+	if g.normalizeFunc != nil {
+		gFS.SetNormalizeFunc(g.normalizeFunc)
+	}
+	if n, sh, ok := g.commonFlag(NameFlag); ok {
+		gFS.StringVarP(&name, n, sh, g.Name, `name of this service`)
+	}
+	if n, sh, ok := g.commonFlag(VersionFlag); ok {
+		gFS.BoolVarP(&showVersion, n, sh, false,
+			"show version information and exit.")
+	}
+	if n, sh, ok := g.commonFlag(HelpFlag); ok {
+		gFS.BoolVarP(&showHelp, n, sh, false,
+			"show this help information and exit. An optional argument only\n"+
+				"shows the flags of which the name or usage matches it.")
+	}
+	if n, sh, ok := g.commonFlag(HelpAllFlag); ok {
+		gFS.BoolVarP(&showHelpAll, n, sh, false,
+			"show help information including advanced, experimental and\n"+
+				"hidden flags and exit.")
+	}
+	if n, sh, ok := g.commonFlag(NoColorFlag); ok {
+		gFS.BoolVarP(&noColor, n, sh, false,
+			"disable colored output, also disabled by the NO_COLOR environment\n"+
+				"variable or if output is not a terminal.")
+	}
+	if n, sh, ok := g.commonFlag(ShowUnitsFlag); ok {
+		gFS.BoolVarP(&showRunGroup, n, sh, false, "show run group units")
+		_ = gFS.MarkHidden(n)
+	}
+	if n, sh, ok := g.commonFlag(DumpConfigFlag); ok {
+		gFS.StringVarP(&dumpConfig, n, sh, "",
+			"write the effective configuration to the provided path (JSON, or YAML\n"+
+				"if the path ends in .yaml or .yml; - for stdout) and exit.")
+	}
+	if n, sh, ok := g.commonFlag(DiagnosticsBundleFlag); ok {
+		gFS.StringVarP(&diagnostics, n, sh, "",
+			"write a diagnostics bundle (tar.gz) for support cases to the provided\n"+
+				"path and exit.")
+	}
+	if n, sh, ok := g.commonFlag(GenerateDocsFlag); ok {
+		gFS.StringVarP(&generateDocs, n, sh, "",
+			"write man page and markdown reference docs to the provided directory and exit.")
+		_ = gFS.MarkHidden(n)
+	}
+	commands, err := g.registerCommands(gFS)
+	if err != nil {
+		return err
+	}
 	g.f.AddFlagSet(gFS.FlagSet)
 
 	// default to os.Args if args parameter was omitted
@@ -380,105 +747,207 @@ func (g *Group) RunConfig(args ...string) (err error) {
 	if name != "" {
 		g.Name = name
 	}
+	g.applyProcessTitle()
 
-	// initialize all Units implementing Initializer
-	for idx, i := range g.i {
-		// an Initializer might have been de-registered
-		if i != nil {
-			i.Initialize()
-			// don't call in Run phase again
-			g.i[idx] = nil
-		}
+	// initialize all Units implementing Initializer or InitializerE
+	if err = g.initialize(); err != nil {
+		return err
 	}
 
 	// inform all Units implementing Namer of the parsed Group name
-	for _, n := range g.n {
+	for idx := 0; idx < phaseLen(g, &g.n); idx++ {
 		// a Namer might have been de-registered
-		if n != nil {
+		if n := unitAt(g, &g.n, idx); n != nil {
 			n.GroupName(g.Name)
 		}
 	}
 
 	// register flags from attached Config objects
-	fs := make([]*flag.Set, len(g.c))
-	for idx := range g.c {
+	fs := make([]*flag.Set, phaseLen(g, &g.c))
+	for idx := range fs {
 		// a Config might have been de-registered
-		if g.c[idx] == nil {
+		cfg := unitAt(g, &g.c, idx)
+		if cfg == nil {
 			g.Logger.Debug("flagset",
-				"name", "--deregistered--",
-				"item", fmt.Sprintf("(%d/%d)", idx+1, len(g.c)),
+				"phase", "flagset",
+				"unit", "--deregistered--",
+				"item", g.itemNr(idx+1, len(fs)),
 			)
 			continue
 		}
 		g.Logger.Debug("flagset",
-			"name", g.c[idx].Name(),
-			"item", fmt.Sprintf("(%d/%d)", idx+1, len(g.c)),
+			"phase", "flagset",
+			"unit", g.nameOf(cfg),
+			"item", g.itemNr(idx+1, len(fs)),
 		)
-		fs[idx] = g.c[idx].FlagSet()
+		fs[idx] = cfg.FlagSet()
 		if fs[idx] == nil {
 			// no FlagSet returned
 			g.Logger.Debug("config object did not return a flagset", "index", idx)
 			continue
 		}
 		fs[idx].VisitAll(func(f *pflag.Flag) {
+			if gFS.Lookup(f.Name) != nil ||
+				(f.Shorthand != "" && gFS.ShorthandLookup(f.Shorthand) != nil) {
+				// rename or disable the common flag to free it up
+				err = multierror.Append(err,
+					errors.New(g.msg(MsgFlagClash, g.nameOf(cfg), f.Name)))
+				return
+			}
 			if g.f.Lookup(f.Name) != nil {
 				g.Logger.Debug("ignoring duplicate flag", "name", f.Name, "index", idx)
 				return
 			}
 			g.f.AddFlag(f)
 		})
+		for alias, target := range fs[idx].Aliases() {
+			aliases[string(g.normalize(g.f.FlagSet, alias))] = target
+		}
 	}
 
-	// parse FlagSet and exit on error
-	if err = g.f.Parse(args); err != nil {
+	// exit on flags clashing with the common flags
+	if err != nil {
 		return err
 	}
+	// output format of --show-rungroup-units, unless claimed by a Unit
+	output, outputShort, hasOutput := g.commonFlag(OutputFlag)
+	if hasOutput && g.f.Lookup(output) == nil {
+		g.f.StringP(output, outputShort, "text", "output format of --show-rungroup-units: text or json")
+		_ = g.f.MarkHidden(output)
+	}
+	g.mu.Lock()
+	g.sets = fs
+	g.mu.Unlock()
+
+	// parse FlagSet and exit on error
+	known, unknown := args, []string(nil)
+	if g.IgnoreUnknownFlags {
+		known, unknown = g.splitUnknownFlags(args)
+	}
+	if err = g.f.Parse(known); err != nil {
+		return g.usageError(err)
+	}
+	// arguments following the "--" terminator are passed through as is
+	remaining, passThrough := g.f.Args(), []string(nil)
+	if dash := g.f.ArgsLenAtDash(); dash >= 0 {
+		remaining, passThrough = remaining[:dash], remaining[dash:]
+	}
+	g.mu.Lock()
+	g.args, g.remaining, g.unknown = args, remaining, unknown
+	g.passThrough = passThrough
+	g.mu.Unlock()
 
 	// bail early on help or version requests
 	switch {
-	case showHelp:
-		fmt.Println(color.Cyan(color.Bold(fmt.Sprintf("Usage of %s:", g.Name))))
-		if g.HelpText != "" {
-			fmt.Printf("%s\n", g.HelpText)
-		}
-		fmt.Printf("%s\n\n", color.Cyan(color.Bold("Flags:")))
-		fmt.Printf("%s\n%s\n", color.Cyan("* "+gFS.Name), gFS.FlagUsages())
-		for _, f := range fs {
-			if f != nil {
-				fmt.Printf("%s\n%s\n", color.Cyan("* "+f.Name), f.FlagUsages())
-			}
+	case showHelp, showHelpAll:
+		if err = g.printHelp(gFS, fs, showHelpAll, noColor, g.helpFilter(args)); err != nil {
+			return err
 		}
 		return ErrBailEarlyRequest
 	case showVersion:
-		version.Show(g.Name)
+		version.Fprint(g.stdout(), g.Name)
+		fmt.Fprintln(g.stdout(), "run id "+g.RunID())
 		return ErrBailEarlyRequest
 	case showRunGroup:
-		fmt.Println(g.ListUnits())
+		var format string
+		if f := g.f.Lookup(output); hasOutput && f != nil && f.Changed {
+			format = f.Value.String()
+		}
+		if err = g.listUnits(format); err != nil {
+			return err
+		}
+		return ErrBailEarlyRequest
+	case generateDocs != "":
+		if err = g.generateDocs(generateDocs, append([]*flag.Set{gFS}, fs...)); err != nil {
+			return err
+		}
+		return ErrBailEarlyRequest
+	}
+
+	// load flag values not provided on the command line from FlagSource Units
+	if err = g.loadFlags(); err != nil {
+		return err
+	}
+
+	// let Interceptor Units adjust the parsed flag values
+	if err = g.intercept(); err != nil {
+		return err
+	}
+	if err = startupInterrupted(ctx); err != nil {
+		return err
+	}
+
+	// bail early on effective configuration dump requests
+	if dumpConfig != "" {
+		if err = g.dumpConfig(dumpConfig, fs); err != nil {
+			return err
+		}
+		return ErrBailEarlyRequest
+	}
+
+	// bail early on diagnostics bundle requests
+	if diagnostics != "" {
+		if err = g.diagnosticsBundle(diagnostics); err != nil {
+			return err
+		}
 		return ErrBailEarlyRequest
 	}
 
+	// hand positional arguments to Units implementing ArgsReceiver
+	if err = g.receiveArgs(remaining); err != nil {
+		return err
+	}
+
+	// exit on missing required flags of all Config Units at once
+	if err = g.missingRequired(fs); err != nil {
+		return err
+	}
+
+	// check declarative flag constraints and exit on violations
+	for idx := range fs {
+		cfg := unitAt(g, &g.c, idx)
+		if cfg == nil || fs[idx] == nil {
+			continue
+		}
+		for _, cErr := range fs[idx].ConstraintErrors() {
+			err = multierror.Append(err, &ValidateError{
+				Unit: g.nameOf(cfg), FlagSet: fs[idx].Name, Err: cErr,
+			})
+		}
+	}
+	if err != nil {
+		return err
+	}
+
 	// Validate Config inputs
-	for idx, cfg := range g.c {
+	for idx := range fs {
+		if iErr := startupInterrupted(ctx); iErr != nil {
+			return iErr
+		}
 		func(itemNr int, cfg Config) {
 			// a Config might have been de-registered during Run
 			if cfg == nil {
 				g.Logger.Debug("validate-skip",
-					"name", "--deregistered--",
-					"item", fmt.Sprintf("(%d/%d)", itemNr, len(g.c)),
+					"phase", "validate",
+					"unit", "--deregistered--",
+					"item", g.itemNr(itemNr, len(fs)),
 				)
 				return
 			}
-			var vErr error
-			l := g.Logger.With(
-				"name", cfg.Name(),
-				"item", fmt.Sprintf("(%d/%d)", itemNr, len(g.c)))
-			l.Debug("validate")
-			defer l.Debug("validate-exit", debugLogError(vErr)...)
-			vErr = cfg.Validate()
+			l := g.phaseLogger("validate", g.nameOf(cfg),
+				g.itemNr(itemNr, len(fs)))
+			vErr := cfg.Validate()
+			l.exit(vErr)
 			if vErr != nil {
-				err = multierror.Append(err, vErr)
+				var fsName string
+				if fs[itemNr-1] != nil {
+					fsName = fs[itemNr-1].Name
+				}
+				err = multierror.Append(err, &ValidateError{
+					Unit: g.nameOf(cfg), FlagSet: fsName, Err: vErr,
+				})
 			}
-		}(idx+1, cfg)
+		}(idx+1, unitAt(g, &g.c, idx))
 	}
 
 	// exit on at least one Validate error
@@ -486,8 +955,35 @@ func (g *Group) RunConfig(args ...string) (err error) {
 		return err
 	}
 
+	// validate configuration spanning multiple Units
+	if err = g.crossValidate(); err != nil {
+		return err
+	}
+
+	now := g.Clock().Now()
+	g.mu.Lock()
+	for _, cfg := range g.c {
+		if cfg != nil {
+			g.timing(cfg).Configured = now
+		}
+	}
+	g.mu.Unlock()
+
+	// bail early on config command requests
+	if err = startupInterrupted(ctx); err != nil {
+		return err
+	}
+	if err = g.runCommands(commands); err != nil {
+		return err
+	}
+
 	// log binary name and version
-	g.Logger.Info(g.Name + " " + version.Parse() + " started")
+	g.Logger.Info(g.msg(MsgStarted, g.Name, version.Parse()), "runID", g.RunID())
+
+	// log the effective configuration for auditing
+	if g.configAudit {
+		g.Logger.Info("effective-config", "flags", g.auditConfig(fs))
+	}
 
 	return nil
 }
@@ -499,16 +995,41 @@ func (g *Group) RunConfig(args ...string) (err error) {
 //
 // The following phases are executed in the following sequence:
 //
-//	Initialization phase (serially, in order of Unit registration)
-//	  - Initialize()     Initialize Unit's supporting this interface.
+//	Initialization phase (serially, in order of priority and Unit registration)
+//	  - Initialize()     Initialize Unit's supporting this interface. Exit on
+//	                     first InitializerE error.
 //
 //	Config phase (serially, in order of Unit registration)
 //	  - FlagSet()        Get & register all FlagSets from Config Units.
 //	  - Flag Parsing     Using the provided args (os.Args if empty).
+//	  - LoadFlags()      Load flag values not set on the command line from
+//	                     FlagSource Units. Exit on error.
+//	  - PostParse()      Let Interceptor Units adjust the parsed flag values.
+//	                     Exit on first error.
+//	  - ReceiveArgs()    Check and hand positional arguments to ArgsReceiver
+//	                     Units. Exit on error.
+//	  - Required flags   Check presence of required flags of Config Units.
+//	                     Exit on missing flags.
+//	  - Constraints      Check declarative flag constraints of Config Units.
+//	                     Exit on violations.
 //	  - Validate()       Validate Config Units. Exit on first error.
+//	  - CrossValidate    Validate configuration spanning multiple Units.
+//	                     Exit on error.
+//	  - Execute()        Execute ConfigCommand Units whose flag was set and
+//	                     bail early.
+//
+//	Preflight phase (serially, in order of Unit registration)
+//	  - Requirements()   Check availability of resources required by
+//	                     Requirer Units. Exit on unavailable resources.
 //
-//	PreRunner phase (serially, in order of Unit registration)
-//	  - PreRun()         Execute PreRunner Units. Exit on first error.
+//	PreRunner phase (in stages, see PreRunStager)
+//	  - PreRun()         Execute PreRunner Units. The default stage runs
+//	    PreRunContext()  serially in order of Unit registration, other
+//	                     stages run concurrently. Exit on first failing
+//	                     stage.
+//
+//	Task phase (serially in order of Unit registration, see WithTaskMode)
+//	  - RunTask()        Execute Task Units to completion. Exit on error.
 //
 //	Service and ServiceContext phase (concurrently)
 //	  - Serve()          Execute all Service Units in separate Go routines.
@@ -519,28 +1040,101 @@ func (g *Group) RunConfig(args ...string) (err error) {
 //	                     cancel the context.Context provided to all the
 //	                     ServiceContext units registered.
 //
+//	PostRunner phase (serially, in reverse order of Unit registration)
+//	  - PostRun()        Execute PostRunner Units once all Service and
+//	                     ServiceContext Units have returned, or once PreRun
+//	                     failed. A Unit that is also a PreRunner is only
+//	                     included if its PreRun succeeded.
+//
 //	Run will return with the originating error on:
 //	- first Config.Validate()  returning an error
 //	- first PreRunner.PreRun() returning an error
 //	- first Service.Serve() or ServiceContext.ServeContext() returning
 //
+// Receiving one of the startup signals (see WithStartupSignals) during the
+// Config, Preflight or PreRunner phase aborts startup as if a shutdown was
+// requested, as no signal handling Service is running yet.
+//
 // Note: it is perfectly acceptable to use Group without Service and
 // ServiceContext units. In this case Run will just return immediately after
 // having handled the Config and PreRunner phases of the registered Units. This
 // is particularly convenient if using the common pkg middlewares in a CLI,
 // script, or other ephemeral environment.
-func (g *Group) Run(args ...string) (err error) {
+func (g *Group) Run(args ...string) error {
+	return g.RunContext(context.Background(), args...)
+}
+
+// RunContext is like Run but cancellation of the provided context initiates a
+// graceful shutdown of the Group, as if a Service returned
+// ErrRequestedShutdown. This allows a Group to be embedded in larger
+// applications, tests or orchestration frameworks. Values of the provided
+// context are available to the contexts handed to PreRunnerContext and
+// ServiceContext Units. If cancelled during the PreRunner phase, the context
+// of running PreRunnerContext Units is cancelled and no further PreRunners are
+// started.
+func (g *Group) RunContext(ctx context.Context, args ...string) (err error) {
+	if !g.inRun.CompareAndSwap(false, true) {
+		err = fmt.Errorf("%w: Run called concurrently", ErrMisuse)
+		g.anomaly(Anomaly{Unit: g.Name, Kind: AnomalyMisuse, Err: err})
+		return fmt.Errorf("%w: %w", ErrRunning, err)
+	}
+	defer g.inRun.Store(false)
+
+	// no signal handling Service is running until the Serve phase, so abort
+	// startup ourselves on receiving one of the startup signals
+	startCtx, stopStartup := g.startupContext(ctx)
+	defer stopStartup()
+
 	if !g.configured {
 		// run config registration and flag parsing stages
-		if err = g.RunConfig(args...); err != nil {
+		if err = g.runConfig(startCtx, args...); err != nil {
 			if err == ErrBailEarlyRequest {
 				return nil
 			}
+			if errors.Is(err, ErrRequestedShutdown) {
+				g.Logger.Info(g.msg(MsgShutdownRequest), "details", err)
+				return nil
+			}
+			g.fatal(err, "")
 			return err
 		}
 	}
 
-	var hasServices bool
+	// route standard library log output to the Logger if requested
+	defer g.bridgeStdLog()()
+
+	// setup our error channel, the first Unit to exit (or a call to Shutdown)
+	// is the originator of the Group shutdown
+	g.mu.Lock()
+	g.errs = make(chan ShutdownReason, 1)
+	g.done = make(chan struct{})
+	done, errs := g.done, g.errs
+	g.mu.Unlock()
+	defer close(done)
+	defer func() {
+		g.mu.Lock()
+		g.cancelGroupContext(context.Canceled)
+		g.mu.Unlock()
+	}()
+
+	// cancellation of the parent context initiates a graceful shutdown
+	if ctx.Done() != nil {
+		go func() {
+			select {
+			case <-ctx.Done():
+				select {
+				case errs <- ShutdownReason{Err: requestedShutdown(ctx)}:
+				default:
+				}
+			case <-done:
+			}
+		}()
+	}
+
+	var (
+		hasServices bool
+		origin      string
+	)
 
 	defer func() {
 		if err == nil {
@@ -551,60 +1145,98 @@ func (g *Group) Run(args ...string) (err error) {
 			// is fine.
 			if hasServices {
 				err = errors.New("run terminated without explicit error condition")
-				g.Logger.Error("unexpected exit", err)
+				g.Logger.Error(g.msg(MsgUnexpectedExit), err)
+				g.fatal(err, origin)
 				return
 			}
-			g.Logger.Info("done")
+			g.Logger.Info(g.msg(MsgDone))
 			return
 		}
 		// test if this is a requested / expected shutdown...
 		if errors.Is(err, ErrRequestedShutdown) {
-			g.Logger.Info("received shutdown request", "details", err)
+			g.Logger.Info(g.msg(MsgShutdownRequest), "details", err)
 			err = nil
 			return
 		}
 		// actual fatal error
-		g.Logger.Error("unexpected exit", err)
+		g.Logger.Error(g.msg(MsgUnexpectedExit), err)
+		g.fatal(err, origin)
 		err = multierror.SetFormatter(err, multierror.ListFormatFunc)
 	}()
 
+	g.mu.Lock()
+	g.preRan = nil
+	g.mu.Unlock()
+
+	// construct Units registered by factory now that configuration is final
+	if err = g.instantiate(); err != nil {
+		return err
+	}
+
+	// skip Units disabled by configuration
+	g.disable()
+
+	// fail fast if configuration left too few services to run
+	if err = g.requiredServices(); err != nil {
+		return err
+	}
+
 	// call our Initializer (again)
 	// In case a Unit was registered for PreRun and/or Serve phase after Config
 	// phase was completed, we still want to run the Initializer if existent.
-	for _, i := range g.i {
-		// an Initializer might have been de-registered
-		if i != nil {
-			i.Initialize()
-		}
+	if err = g.initialize(); err != nil {
+		return err
 	}
 
-	// execute pre run stage and exit on error
-	for idx := range g.p {
-		if err = func(itemNr int, pr PreRunner) error {
-			// a PreRunner might have been de-registered during Run
-			if pr == nil {
-				g.Logger.Debug("pre-run-skip",
-					"name", "--deregistered--",
-					"item", fmt.Sprintf("(%d/%d)", itemNr, len(g.p)),
-				)
-				return nil
+	// verify availability of required resources and exit on error
+	if err = g.preflight(); err != nil {
+		return err
+	}
+	if err = startupInterrupted(startCtx); err != nil {
+		return err
+	}
+
+	// run post run stage once all other phases have completed, only for Units
+	// which reached the PreRun phase
+	defer func() {
+		if pErr := g.postRun(); pErr != nil {
+			if err == nil || errors.Is(err, ErrRequestedShutdown) {
+				err = pErr
+				return
 			}
-			var intErr error
-			l := g.Logger.With(
-				"name", pr.Name(),
-				"item", fmt.Sprintf("(%d/%d)", itemNr, len(g.p)))
-			l.Debug("pre-run")
-			defer l.Debug("pre-run-exit", debugLogError(intErr)...)
-			intErr = pr.PreRun()
-			if intErr != nil {
-				return fmt.Errorf("pre-run %s: %w", pr.Name(), intErr)
+			err = multierror.Append(err, pErr)
+		}
+	}()
+
+	// execute pre run stages and exit on error
+	total := phaseLen(g, &g.p)
+	for _, stage := range g.preRunStages(total) {
+		if err = g.runPreRunStage(startCtx, stage, total); err != nil {
+			if iErr := startupInterrupted(startCtx); iErr != nil {
+				return iErr
+			}
+			return err
+		}
+	}
+
+	// run Tasks to completion before starting the Services unless they run
+	// alongside them
+	tasks := g.tasks()
+	if len(tasks) > 0 && g.taskMode&TasksAlongsideServices == 0 {
+		if err = g.runTasks(startCtx, tasks); err != nil {
+			if iErr := startupInterrupted(startCtx); iErr != nil {
+				return iErr
 			}
-			return nil
-		}(idx+1, g.p[idx]); err != nil {
 			return err
 		}
+		if g.taskMode&TasksShutdown != 0 {
+			return nil
+		}
+		tasks = nil
 	}
+	stopStartup()
 
+	g.mu.Lock()
 	var (
 		s []Service
 		x []ServiceContext
@@ -621,144 +1253,669 @@ func (g *Group) Run(args ...string) (err error) {
 			x = append(x, g.x[idx])
 		}
 	}
+	if len(tasks) > 0 {
+		x = append(x, taskRunner{g: g, tasks: tasks})
+	}
 	if len(s)+len(x) == 0 {
+		g.mu.Unlock()
+		g.traceReady()
 		// we have no Service or ServiceContext to run.
 		return nil
 	}
 
-	// setup our cancellable context and error channel
-	ctx, cancel := context.WithCancel(context.Background())
-	errs := make(chan error, len(s)+len(x))
+	// setup our cancellable context, the parent's cancellation is handled
+	// through the errs channel to follow the regular teardown path
+	g.ctx, g.cancel = context.WithCancelCause(context.WithoutCancel(ctx))
+	g.running = nil
+	g.started, g.launched, g.stopping = true, false, false
 	hasServices = true
-	var stopped int32
 
-	// run each Service
+	// run each Service and ServiceContext, in waves of their start stage
+	var units []startUnit
 	for idx, svc := range s {
-		go func(itemNr int, svc Service) {
-			var intErr error
-			l := g.Logger.With(
-				"name", svc.Name(),
-				"item", fmt.Sprintf("(%d/%d)", itemNr, len(s)))
-			l.Debug("serve")
-			defer func() {
-				l.Debug("serve-exit", debugLogError(intErr)...)
-			}()
-			// do not start Serve if other services signaled termination, to prevent
-			// a race where stop may have been called for this unit already as that would leave
-			// the unit running forever
-			if atomic.LoadInt32(&stopped) == 0 {
-				intErr = svc.Serve()
-			}
-			errs <- intErr
-		}(idx+1, svc)
+		units = append(units, startUnit{svc, g.itemNr(idx+1, len(s)), idx})
 	}
-	// run each ServiceContext
 	for idx, svc := range x {
-		go func(itemNr int, svc ServiceContext) {
-			var intErr error
-			l := g.Logger.With(
-				"name", svc.Name(),
-				"item", fmt.Sprintf("(%d/%d)", itemNr, len(x)))
-			l.Debug("serve-context")
-			defer func() {
-				l.Debug("serve-context-exit", debugLogError(intErr)...)
-			}()
-
-			// do not start Serve if other services signaled termination, to prevent
-			// a race where stop may have been called for this unit already as that would leave
-			// the unit running forever
-			if atomic.LoadInt32(&stopped) == 0 {
-				intErr = svc.ServeContext(ctx)
-			}
-			errs <- intErr
-		}(idx+1, svc)
+		units = append(units, startUnit{svc, g.itemNr(idx+1, len(x)), len(s) + idx})
+	}
+	var (
+		waves   = startWaves(units)
+		started = true
+		pending *ShutdownReason
+	)
+	for idx, wave := range waves {
+		if len(waves) > 1 {
+			g.Logger.Debug("start-wave", "stage", wave.stage, "units", len(wave.units))
+		}
+		n := len(g.running)
+		for _, su := range wave.units {
+			g.serve(su.unit, su.item, g.stagger(su.order))
+		}
+		if idx == len(waves)-1 {
+			break
+		}
+		wave := append([]*serving(nil), g.running[n:]...)
+		g.mu.Unlock()
+		pending, started = g.waitReady(ctx, wave, errs)
+		g.mu.Lock()
+		if !started {
+			// the Group is shutting down, do not start the next waves
+			break
+		}
+	}
+	g.launched = started
+	if g.launch != nil {
+		// inform Start the Units have been launched
+		close(g.launch)
+		g.launch = nil
+	}
+	if g.idle() {
+		// all Units completed before the last start wave was launched
+		select {
+		case g.errs <- ShutdownReason{Err: errIdle}:
+		default:
+		}
+	}
+	g.mu.Unlock()
+	if started {
+		g.traceReady()
 	}
 
 	// wait for the first Service or ServiceContext to stop and special case
-	// its error as the originator
-	err = <-errs
-	atomic.SwapInt32(&stopped, 1)
+	// its error as the originator, unless received while starting the waves
+	var reason ShutdownReason
+	if pending != nil {
+		reason = *pending
+	} else {
+		reason = <-g.errs
+	}
+	err, origin = reason.Err, reason.Unit
+	if err != nil && origin != "" && !errors.Is(err, ErrRequestedShutdown) {
+		err = &ServiceCrashedError{Unit: origin, Err: err}
+	}
 
 	// signal all Service and ServiceContext Units to stop
-	cancel()
-	for idx, svc := range s {
-		go func(itemNr int, svc Service) {
-			l := g.Logger.With(
-				"name", svc.Name(),
-				"item", fmt.Sprintf("(%d/%d)", itemNr, len(s)))
-			l.Debug("graceful-stop")
-			defer l.Debug("graceful-stop-exit")
-			svc.GracefulStop()
-		}(idx+1, svc)
+	g.mu.Lock()
+	g.stopping = true
+	g.lastStop = &reason
+	drainer, drainTimeout := g.drainer, g.drainTimeout
+	g.mu.Unlock()
+
+	// let in-flight work tracked by the Drainer complete
+	drainer.drain(g.Logger, drainTimeout)
+
+	g.mu.Lock()
+	for _, r := range g.running {
+		// make the reason available before canceling the context
+		r.reason.CompareAndSwap(nil, &reason)
 	}
+	g.cancel(reason.cause())
+	g.cancelGroupContext(reason.cause())
+	var aborters []ShutdownAborter
+	for _, r := range g.running {
+		if a, ok := r.unit.(ShutdownAborter); ok {
+			aborters = append(aborters, a)
+		}
+		// deregistered Units have already been requested to stop
+		if !r.detached {
+			// include the stop request in the wait for Units to return
+			g.wg.Add(1)
+			go func(r *serving) {
+				defer g.wg.Done()
+				g.gracefulStop(r, reason)
+			}(r)
+		}
+	}
+	g.mu.Unlock()
 
 	// wait for all Service and ServiceContext Units to have returned
-	for i := 1; i < cap(errs); i++ {
-		<-errs
+	switch g.waitStopped(reason, g.abortShutdown(aborters)) {
+	case errTimeout:
+		// not wrapping the originating error as a requested shutdown that
+		// failed to complete in time must be reported as a failure
+		err = fmt.Errorf("%w after %s: %v", ErrShutdownTimeout, g.shutdownTimeout, err) //nolint:errorlint // see above
+	case errAborted:
+		draining := g.draining()
+		g.Logger.Error("forced shutdown", ErrForcedShutdown, "draining", draining)
+		err = fmt.Errorf("%w while draining [%s]: %v", ErrForcedShutdown, strings.Join(draining, ", "), err) //nolint:errorlint // see above
 	}
 
+	g.mu.Lock()
+	g.started, g.launched = false, false
+	g.mu.Unlock()
+
 	// return the originating error
 	return err
 }
 
-// ListUnits returns a list of all Group phases and the Units registered to each
-// of them.
-func (g *Group) ListUnits() string {
+// waitStopped waits for all running Units to return, bounded by the shutdown
+// timeout if configured and the provided abort channel. It returns errTimeout
+// or errAborted if not all Units returned. While waiting, the Units still
+// draining are logged periodically together with the shutdown reason.
+func (g *Group) waitStopped(reason ShutdownReason, abort <-chan struct{}) error {
+	done := make(chan struct{})
+	go func() {
+		g.wg.Wait()
+		close(done)
+	}()
+	var timeout, progress <-chan time.Time
+	if g.shutdownTimeout > 0 {
+		timer := g.Clock().NewTimer(g.shutdownTimeout)
+		defer timer.Stop()
+		timeout = timer.C()
+	}
+	interval := g.shutdownProgress
+	if interval == 0 {
+		interval = DefaultShutdownProgressInterval
+	}
+	if interval > 0 {
+		progress = g.Clock().After(interval)
+	}
+	start := g.Clock().Now()
+	for {
+		select {
+		case <-done:
+			return nil
+		case <-timeout:
+			return errTimeout
+		case <-abort:
+			return errAborted
+		case <-progress:
+			progress = g.Clock().After(interval)
+			g.Logger.Info("shutdown-progress",
+				"draining", g.draining(),
+				"elapsed", g.since(start).Round(time.Millisecond).String(),
+				"reason", reason)
+		}
+	}
+}
+
+// randN returns a random duration in [0, n) from the source set by
+// WithRandSeed or the global source. It must be called while holding the Group
+// lock.
+func (g *Group) randN(n time.Duration) time.Duration {
+	if g.rnd == nil {
+		return rand.N(n)
+	}
+	return time.Duration(g.rnd.Int64N(int64(n)))
+}
+
+// stagger returns the setup delaying the start of the Service or
+// ServiceContext Unit launched at the provided index as configured by
+// WithStaggeredStart. It must be called while holding the Group lock.
+func (g *Group) stagger(idx int) func() error {
+	if g.staggerDelay <= 0 && g.staggerJitter <= 0 {
+		return nil
+	}
+	d := time.Duration(idx/g.staggerBatch) * g.staggerDelay
+	if g.staggerJitter > 0 {
+		d += g.randN(g.staggerJitter)
+	}
+	ctx := g.ctx
+	return func() error {
+		timer := g.Clock().NewTimer(d)
+		defer timer.Stop()
+		select {
+		case <-timer.C():
+		case <-ctx.Done():
+			// the Group is stopping, serve skips starting the Unit
+		}
+		return nil
+	}
+}
+
+// serve starts the provided Service or ServiceContext Unit in its own
+// goroutine. If provided, setup is run before serving the Unit and its error
+// is treated as a Serve error.
+// It must be called while holding the Group lock.
+func (g *Group) serve(u Unit, item string, setup func() error) {
 	var (
-		s string
-		t = "cli"
+		r     = &serving{unit: u, item: item, exit: make(chan struct{})}
+		errs  = g.errs
+		done  = g.done
+		phase string
+		fn    func(ctx context.Context) error
+		ctx   = context.Background()
 	)
+	switch svc := u.(type) {
+	case interrupter:
+		phase, fn = "serve", ignoreContext(svc.Serve)
+		r.stop = func(reason ShutdownReason) { svc.interrupt(reason.Err) }
+	case ReasonStopper:
+		phase, fn, r.stop = "serve", ignoreContext(svc.Serve), svc.StopWithReason
+	case Service:
+		phase, fn = "serve", ignoreContext(svc.Serve)
+		r.stop = func(ShutdownReason) { svc.GracefulStop() }
+	case ServiceContext:
+		var cancel context.CancelCauseFunc
+		ctx, cancel = context.WithCancelCause(
+			context.WithValue(g.ctx, shutdownReasonKey{}, &r.reason))
+		phase, fn = "serve-context", svc.ServeContext
+		r.stop = func(reason ShutdownReason) { cancel(reason.cause()) }
+	default:
+		return
+	}
+	g.running = append(g.running, r)
+	g.wg.Add(1)
+	go func() {
+		defer g.wg.Done()
+		var intErr error
+		l := g.phaseLogger(phase, g.nameOf(u), item)
+		defer func() {
+			l.exit(intErr)
+		}()
+		if ca, ok := u.(clockAware); ok {
+			ca.useClock(g.Clock())
+		}
+		if sa, ok := u.(stdLogAware); ok && g.bridgingStdLog() {
+			sa.useStdLogger(log.NewStdLogger(g.Logger.With("unit", g.nameOf(u))))
+		}
+		if setup != nil {
+			intErr = setup()
+		}
+		// do not start Serve if other services signaled termination, to prevent
+		// a race where stop may have been called for this unit already as that would leave
+		// the unit running forever
+		g.mu.Lock()
+		stopped := g.stopping || r.detached
+		if intErr == nil && !stopped {
+			t := g.timing(u)
+			t.ServeStart, t.StopStart, t.StopEnd = g.Clock().Now(), time.Time{}, time.Time{}
+		}
+		g.mu.Unlock()
+		ignored := false
+		if intErr == nil && !stopped {
+			start := g.Clock().Now()
+			g.withLabels(ctx, u, phase, func(ctx context.Context) {
+				intErr = fn(ctx)
+			})
+			if intErr != nil {
+				if intErr = g.filterServeError(u, intErr); intErr == nil {
+					ignored = true
+					l.Debug(phase + "-error-ignored")
+				}
+			} else if mayExitClean(u) || g.exitOnIdle {
+				ignored = true
+				l.Debug(phase + "-exit-clean")
+			} else if g.unexpectedExit(r) {
+				intErr = fmt.Errorf("%w after %s", ErrUnexpectedExit,
+					g.since(start).Round(time.Millisecond))
+			}
+		}
 
-	if len(g.i) > 0 {
-		s += "\n - initialize: "
-		for _, u := range g.i {
-			if u != nil {
-				s += u.Name() + " "
+		g.mu.Lock()
+		r.done = true
+		if t := g.timing(u); !t.ServeStart.IsZero() {
+			t.StopEnd = g.Clock().Now()
+		}
+		if ignored {
+			// a non-fatal exit, the Group keeps running
+			r.detached = true
+		}
+		detached, stopping, idle := r.detached, g.stopping, g.idle()
+		g.mu.Unlock()
+		close(r.exit)
+		if idle {
+			// the last running Unit completed, shut down the Group
+			select {
+			case errs <- ShutdownReason{Err: errIdle}:
+			default:
 			}
+			return
 		}
-	}
-	if len(g.c) > 0 {
-		s += "\n- config: "
-		for _, u := range g.c {
-			if u != nil {
-				s += u.Name() + " "
+		if detached {
+			// stopped individually, the Group keeps running
+			return
+		}
+		if isClosed(done) {
+			g.anomaly(Anomaly{Unit: g.nameOf(u), Kind: AnomalyLateExit, Err: intErr})
+			return
+		}
+		if stopping {
+			g.droppedError(u, intErr)
+			return
+		}
+		// only the first error is kept as it originates the Group shutdown
+		select {
+		case errs <- ShutdownReason{Unit: g.nameOf(u), Err: intErr}:
+		default:
+			g.droppedError(u, intErr)
+		}
+	}()
+}
+
+// unexpectedExit returns true if the served Unit returned while neither the
+// Group nor the Unit itself was requested to stop.
+func (g *Group) unexpectedExit(r *serving) bool {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return !g.stopping && !r.detached
+}
+
+// StopUnit gracefully stops the Service or ServiceContext Unit identified by
+// name without shutting down the Group. It blocks until the Unit's Serve or
+// ServeContext method has returned or the provided context is done.
+// The Unit stays registered and is listed as stopped by ListUnits.
+// If no running Unit by that name can be found, ErrUnitNotRunning is returned.
+func (g *Group) StopUnit(name string, ctx context.Context) error { //nolint:revive // name is the primary argument
+	g.mu.Lock()
+	var r *serving
+	if !g.stopping {
+		for _, s := range g.running {
+			if g.nameOf(s.unit) == name && !s.done && !s.detached {
+				r = s
+				break
 			}
 		}
 	}
-	if len(g.p) > 0 {
-		s += "\n- pre-run: "
-		for _, u := range g.p {
-			if u != nil {
-				s += u.Name() + " "
-			}
+	if r == nil {
+		g.mu.Unlock()
+		return fmt.Errorf("%s: %w", name, ErrUnitNotRunning)
+	}
+	r.detached = true
+	g.mu.Unlock()
+
+	go g.gracefulStop(r, ShutdownReason{Err: ErrRequestedShutdown})
+
+	select {
+	case <-r.exit:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("stop %s: %w", name, ctx.Err())
+	}
+}
+
+// runPreRunner runs the PreRun or PreRunContext method of the provided Unit as
+// part of the Group's PreRun phase.
+func (g *Group) runPreRunner(ctx context.Context, itemNr, total int, pr Unit) error {
+	// a PreRunner might have been de-registered during Run
+	if pr == nil {
+		g.Logger.Debug("pre-run-skip",
+			"phase", "pre-run",
+			"unit", "--deregistered--",
+			"item", g.itemNr(itemNr, total),
+		)
+		return nil
+	}
+	var intErr error
+	l := g.phaseLogger("pre-run", g.nameOf(pr), g.itemNr(itemNr, total))
+	defer func() {
+		l.exit(intErr)
+	}()
+	if la, ok := pr.(loggerAware); ok {
+		la.useLogger(g.Logger.With("phase", "pre-run", "unit", g.nameOf(pr),
+			"item", fmt.Sprintf("(%d/%d)", itemNr, total)))
+	}
+	if ca, ok := pr.(clockAware); ok {
+		ca.useClock(g.Clock())
+	}
+	g.recordTiming(pr, func(t *UnitTiming) { t.PreRunStart = g.Clock().Now() })
+	g.withLabels(ctx, pr, "pre-run", func(ctx context.Context) {
+		intErr = callPreRun(ctx, pr)
+	})
+	g.recordTiming(pr, func(t *UnitTiming) { t.PreRunEnd = g.Clock().Now() })
+	if intErr != nil {
+		return categorize(fmt.Errorf("%s: %w", g.msg(MsgPreRun, g.nameOf(pr)), intErr), ErrPreRun)
+	}
+	g.mu.Lock()
+	g.preRan = append(g.preRan, pr)
+	g.mu.Unlock()
+	return nil
+}
+
+// serveRuntime starts a Service or ServiceContext Unit registered while the
+// Group is already serving, running its Initialize and PreRun methods first.
+// It must be called while holding the Group lock.
+func (g *Group) serveRuntime(u Unit) {
+	ctx := g.ctx
+	g.serve(u, "(runtime)", func() error {
+		if err := callInitialize(u); err != nil {
+			return fmt.Errorf("initialize %s: %w", g.nameOf(u), err)
 		}
+		return g.preRun(ctx, u)
+	})
+}
+
+// preRun runs the PreRun or PreRunContext method of the provided Unit if it
+// implements PreRunner or PreRunnerContext.
+func (g *Group) preRun(ctx context.Context, u Unit) error {
+	if !isPreRunner(u) {
+		return nil
 	}
-	if len(g.s) > 0 {
-		s += "\n- serve: "
-		for _, u := range g.s {
-			if u != nil {
-				t = "svc"
-				s += u.Name() + " "
-			}
+	if la, ok := u.(loggerAware); ok {
+		la.useLogger(g.Logger.With("phase", "pre-run", "unit", g.nameOf(u)))
+	}
+	if ca, ok := u.(clockAware); ok {
+		ca.useClock(g.Clock())
+	}
+	g.recordTiming(u, func(t *UnitTiming) { t.PreRunStart = g.Clock().Now() })
+	var err error
+	g.withLabels(ctx, u, "pre-run", func(ctx context.Context) {
+		err = callPreRun(ctx, u)
+	})
+	g.recordTiming(u, func(t *UnitTiming) { t.PreRunEnd = g.Clock().Now() })
+	if err != nil {
+		return categorize(fmt.Errorf("%s: %w", g.msg(MsgPreRun, g.nameOf(u)), err), ErrPreRun)
+	}
+	g.mu.Lock()
+	g.preRan = append(g.preRan, u)
+	g.mu.Unlock()
+	return nil
+}
+
+// isPreRunner returns true if the provided Unit implements PreRunner or
+// PreRunnerContext.
+func isPreRunner(u Unit) bool {
+	switch u.(type) {
+	case PreRunnerContext, PreRunner:
+		return true
+	}
+	return false
+}
+
+// callPreRun calls the PreRunContext method of the provided Unit if
+// implemented and its PreRun method otherwise. As PreRun can't be
+// interrupted, it is abandoned once the provided context is done so a startup
+// signal or timeout is not ignored while it blocks.
+func callPreRun(ctx context.Context, u Unit) error {
+	switch pr := u.(type) {
+	case PreRunnerContext:
+		return pr.PreRunContext(ctx)
+	case PreRunner:
+		if ctx.Done() == nil {
+			return pr.PreRun()
+		}
+		res := make(chan error, 1)
+		go func() { res <- pr.PreRun() }()
+		select {
+		case err := <-res:
+			return err
+		case <-ctx.Done():
+			return fmt.Errorf("abandoned PreRun not supporting cancellation: %w",
+				context.Cause(ctx))
 		}
 	}
-	if len(g.x) > 0 {
-		s += "\n- serve-context: "
-		for _, u := range g.x {
-			if u != nil {
-				t = "svc"
-				s += u.Name() + " "
-			}
+	return nil
+}
+
+// requestedShutdown returns the error signaling a shutdown requested through
+// cancellation of the provided context.
+func requestedShutdown(ctx context.Context) error {
+	return fmt.Errorf("%w: %w", ErrRequestedShutdown, context.Cause(ctx))
+}
+
+// gracefulStop requests the provided running Unit to stop for the provided
+// reason.
+func (g *Group) gracefulStop(r *serving, reason ShutdownReason) {
+	l := g.phaseLogger("graceful-stop", g.nameOf(r.unit), r.item, "reason", reason)
+	defer l.exit(nil)
+	r.reason.CompareAndSwap(nil, &reason)
+	g.recordTiming(r.unit, func(t *UnitTiming) {
+		// a Unit which already returned is not stopping
+		if t.StopStart.IsZero() && t.StopEnd.IsZero() {
+			t.StopStart = g.Clock().Now()
+		}
+	})
+	g.withLabels(context.Background(), r.unit, "graceful-stop", func(context.Context) {
+		r.stop(reason)
+	})
+}
+
+// stopped returns true if the provided Unit has been stopped individually
+// while the Group is serving.
+// It must be called while holding the Group lock.
+func (g *Group) stopped(u Unit) bool {
+	if g.stopping {
+		return false
+	}
+	// the last entry reflects the current state of the Unit
+	for idx := len(g.running) - 1; idx >= 0; idx-- {
+		if g.running[idx].unit == u {
+			return g.running[idx].detached
 		}
 	}
+	return false
+}
+
+// phaseLen returns the length of the provided phase slice while holding the
+// Group lock.
+func phaseLen[T any](g *Group, phase *[]T) int {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return len(*phase)
+}
+
+// initialize runs the Initialize method of all Initializer and InitializerE
+// Units which have not been initialized yet, in order of priority. It returns
+// on the first failing InitializerE.
+func (g *Group) initialize() error {
+	for {
+		g.mu.Lock()
+		idx := g.initialized
+		if idx >= len(g.i) {
+			g.mu.Unlock()
+			return nil
+		}
+		// Initializers might have been registered by a previous Initializer
+		sort.SliceStable(g.i[idx:], func(a, b int) bool {
+			return initializePriority(g.i[idx+a]) < initializePriority(g.i[idx+b])
+		})
+		g.initialized++
+		i := g.i[idx]
+		g.mu.Unlock()
+		// an Initializer might have been de-registered
+		if i == nil {
+			continue
+		}
+		if err := callInitialize(i); err != nil {
+			return fmt.Errorf("initialize %s: %w", g.nameOf(i), err)
+		}
+	}
+}
+
+// isInitializer returns true if the provided Unit implements Initializer or
+// InitializerE.
+func isInitializer(u Unit) bool {
+	switch u.(type) {
+	case Initializer, InitializerE:
+		return true
+	}
+	return false
+}
+
+// callInitialize calls the Initialize method of the provided Unit if it
+// implements Initializer or InitializerE.
+func callInitialize(u Unit) error {
+	switch i := u.(type) {
+	case InitializerE:
+		return i.Initialize()
+	case Initializer:
+		i.Initialize()
+	}
+	return nil
+}
+
+// initializePriority returns the priority of the provided Initializer or
+// InitializerE.
+func initializePriority(u Unit) int {
+	if p, ok := u.(interface{ InitializePriority() int }); ok {
+		return p.InitializePriority()
+	}
+	return DefaultInitializePriority
+}
+
+// unitAt returns the Unit found at idx of the provided phase slice while
+// holding the Group lock. De-registered slots hold nil.
+func unitAt[T any](g *Group, phase *[]T, idx int) T {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return (*phase)[idx]
+}
 
-	return fmt.Sprintf("Group: %s [%s]%s", g.Name, t, s)
+// phaseLog logs the lifecycle events of a Unit phase using the stable phase,
+// unit, item, duration and error fields. If debug logging is disabled it skips
+// building the scoped Logger and log values altogether.
+type phaseLog struct {
+	g      *Group
+	logger telemetry.Logger
+	unit   string
+	item   string
+	phase  string
+	start  time.Time
+	debug  bool
 }
 
-func debugLogError(err error) (kv []interface{}) {
-	if err == nil {
+// phaseLogger logs the start of the provided phase for the Unit with the
+// provided name and returns the phaseLog to log its exit with.
+func (g *Group) phaseLogger(phase, name, item string, keyValuePairs ...interface{}) *phaseLog {
+	l := &phaseLog{
+		g:     g,
+		unit:  name,
+		item:  item,
+		phase: phase,
+		start: g.Clock().Now(),
+		debug: g.debugEnabled(),
+	}
+	l.Debug(phase, keyValuePairs...)
+	return l
+}
+
+// Debug logs msg using the Logger scoped to the phase, unit and item fields if
+// debug logging is enabled.
+func (l *phaseLog) Debug(msg string, keyValuePairs ...interface{}) {
+	if !l.debug {
 		return
 	}
-	kv = append(kv, "error", err.Error())
-	return
+	if l.logger == nil {
+		l.logger = l.g.Logger.With("runID", l.g.RunID(), "phase", l.phase,
+			"unit", l.unit, "item", l.item)
+	}
+	l.logger.Debug(msg, keyValuePairs...)
+}
+
+// exit logs the end of the phase with its duration and error if not nil.
+func (l *phaseLog) exit(err error) {
+	if l.debug {
+		kv := []interface{}{"duration", l.g.since(l.start)}
+		if err != nil {
+			kv = append(kv, "error", err.Error())
+		}
+		l.Debug(l.phase+"-exit", kv...)
+	}
+	l.g.traceSpan(l.unit, l.phase, l.start)
+}
+
+// debugEnabled reports whether the Group's Logger emits Debug log lines.
+func (g *Group) debugEnabled() bool {
+	return log.DebugEnabled(g.Logger)
+}
+
+// itemNr returns the item field value of lifecycle log lines for the Unit at
+// position nr out of total. It returns an empty string if debug logging is
+// disabled as the value would never be emitted.
+func (g *Group) itemNr(nr, total int) string {
+	if !g.debugEnabled() {
+		return ""
+	}
+	return fmt.Sprintf("(%d/%d)", nr, total)
 }
@@ -19,13 +19,16 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"reflect"
 	"strings"
 	"testing"
 	"time"
 
 	"github.com/basvanbeek/multierror"
+	"github.com/basvanbeek/telemetry"
 
 	"github.com/basvanbeek/run"
+	"github.com/basvanbeek/run/pkg/flag"
 	"github.com/basvanbeek/run/pkg/test"
 )
 
@@ -117,7 +120,11 @@ func TestRunGroupMultiErrorHandling(t *testing.T) {
 		err3 = errors.New("cfg3 failed")
 
 		mErr = multierror.SetFormatter(
-			multierror.Append(nil, err1, err2, err3),
+			multierror.Append(nil,
+				&run.ValidateError{Unit: err1.Error(), Err: err1},
+				&run.ValidateError{Unit: err2.Error(), Err: err2},
+				&run.ValidateError{Unit: err3.Error(), Err: err3},
+			),
 			multierror.ListFormatFunc,
 		)
 
@@ -131,9 +138,157 @@ func TestRunGroupMultiErrorHandling(t *testing.T) {
 	if mErr == nil {
 		t.Fatalf("unexpected nil error")
 	}
-	if want, have := mErr.Error(), g.Run().Error(); want != have {
+	err := g.Run()
+	if want, have := mErr.Error(), err.Error(); want != have {
 		t.Errorf("invalid error payload returned:\nwant:\n%+v\nhave:\n%+v\n", want, have)
 	}
+	vErrs := run.ValidateErrors(err)
+	if len(vErrs) != 3 {
+		t.Fatalf("Expected 3 validate errors, got %d", len(vErrs))
+	}
+	for idx, e := range []error{err1, err2, err3} {
+		if !errors.Is(vErrs[idx], e) {
+			t.Errorf("Expected %v, got %v", e, vErrs[idx])
+		}
+	}
+}
+
+func TestRunGroupFlagConstraints(t *testing.T) {
+	var (
+		g         = run.Group{Name: "MyService", Logger: telemetry.NoopLogger()}
+		fs        = run.NewFlagSet("constraints")
+		port      int
+		validated bool
+	)
+	fs.IntVar(&port, "port", 8080, "listen port")
+	fs.Constrain("port").Min(1).Max(65535)
+	g.Register(configUnit{fs: fs}, run.NewConfig("validator", &validated, nil,
+		func(v *bool) error { *v = true; return nil }))
+
+	err := g.RunConfig("./myService", "--port", "70000")
+	vErrs := run.ValidateErrors(err)
+	if len(vErrs) != 1 || vErrs[0].Unit != "config-unit" || !errors.Is(err, flag.ErrInvalidVal) {
+		t.Errorf("Expected constraint violation of config-unit, got %v", err)
+	}
+	if validated {
+		t.Error("Expected Validate to be skipped on constraint violations")
+	}
+}
+
+func TestRunGroupRequiredFlags(t *testing.T) {
+	var (
+		g          = run.Group{Name: "MyService", Logger: telemetry.NoopLogger()}
+		fs         = run.NewFlagSet("required")
+		dsn, user  string
+		token, env string
+	)
+	fs.StringVar(&dsn, "dsn", "", "data source name")
+	fs.StringVar(&user, "user", "", "user name")
+	fs.MarkRequired("dsn", "user")
+	g.Register(configUnit{fs: fs}, run.NewConfig("other", &token,
+		func(fs *run.FlagSet, token *string) {
+			fs.StringVar(token, "token", "", "token")
+			fs.StringVar(&env, "env", "", "environment")
+			fs.Constrain("token").Required()
+			fs.MarkRequired("env")
+		}, nil))
+
+	err := g.RunConfig("./myService", "--user", "admin", "--env", "prod")
+	if !errors.Is(err, run.ErrMissingRequiredFlags) {
+		t.Fatalf("Expected %v, got %v", run.ErrMissingRequiredFlags, err)
+	}
+	if want, have := "missing required flags: config-unit (--dsn); other (--token)", err.Error(); want != have {
+		t.Errorf("Expected %q, got %q", want, have)
+	}
+}
+
+func TestRunGroupCrossValidate(t *testing.T) {
+	var (
+		g    = run.Group{Name: "MyService", Logger: telemetry.NoopLogger()}
+		fs   = run.NewFlagSet("tls")
+		tls  bool
+		seen []string
+		e    = errors.New("tls requires a certificate unit")
+	)
+	fs.BoolVar(&tls, "tls", false, "enable TLS")
+	g.Register(configUnit{fs: fs}, run.NewPreRunner("pre-run", func() error { return nil }))
+	g.CrossValidate(func(units []run.Unit) error {
+		for _, u := range units {
+			seen = append(seen, u.Name())
+		}
+		return nil
+	})
+	g.CrossValidate(func([]run.Unit) error {
+		if tls {
+			return e
+		}
+		return nil
+	})
+
+	if err := g.RunConfig("./myService", "--tls"); !errors.Is(err, e) {
+		t.Errorf("Expected %v, got %v", e, err)
+	}
+	if want := []string{"config-unit", "pre-run"}; !reflect.DeepEqual(seen, want) {
+		t.Errorf("Expected units %v, got %v", want, seen)
+	}
+}
+
+type prioritizedInitializer struct {
+	name     string
+	priority int
+	order    *[]string
+}
+
+func (p prioritizedInitializer) Name() string            { return p.name }
+func (p prioritizedInitializer) InitializePriority() int { return p.priority }
+func (p prioritizedInitializer) Initialize() {
+	*p.order = append(*p.order, p.name)
+}
+
+func TestInitializePriority(t *testing.T) {
+	var (
+		g     = run.Group{Name: "MyService", Logger: telemetry.NoopLogger()}
+		order []string
+	)
+	g.Register(
+		prioritizedInitializer{name: "default-1", order: &order},
+		prioritizedInitializer{name: "late", priority: 5, order: &order},
+		prioritizedInitializer{name: "early", priority: -1, order: &order},
+		prioritizedInitializer{name: "default-2", order: &order},
+	)
+	if err := g.RunConfig("./myService"); err != nil {
+		t.Fatalf("Expected nil error, got %v", err)
+	}
+	if want := []string{"early", "default-1", "default-2", "late"}; !reflect.DeepEqual(order, want) {
+		t.Errorf("Expected initialize order %v, got %v", want, order)
+	}
+}
+
+type failingInitializer struct {
+	e error
+}
+
+func (f failingInitializer) Name() string      { return "failing-init" }
+func (f failingInitializer) Initialize() error { return f.e }
+
+func TestInitializerE(t *testing.T) {
+	var (
+		g     = run.Group{Name: "MyService", Logger: telemetry.NoopLogger()}
+		e     = errors.New("init failed")
+		order []string
+	)
+	g.Register(
+		failingInitializer{e: e},
+		prioritizedInitializer{name: "early", priority: -1, order: &order},
+		prioritizedInitializer{name: "skipped", order: &order},
+	)
+	err := g.RunConfig("./myService")
+	if !errors.Is(err, e) || !strings.Contains(err.Error(), "initialize failing-init") {
+		t.Errorf("Expected %v attributed to failing-init, got %v", e, err)
+	}
+	if want := []string{"early"}; !reflect.DeepEqual(order, want) {
+		t.Errorf("Expected initialize order %v, got %v", want, order)
+	}
 }
 
 func TestRunGroupEarlyBailFlags(t *testing.T) {
@@ -147,6 +302,7 @@ func TestRunGroupEarlyBailFlags(t *testing.T) {
 		{flag: "-h"},
 		{flag: "--version"},
 		{flag: "--help"},
+		{flag: "--help-all"},
 		{flag: "--non-existent", hasErr: true},
 	} {
 		g := run.Group{HelpText: "placeholder"}
@@ -359,6 +515,109 @@ func TestRuntimeDeregister(t *testing.T) { //nolint: gocognit,gocyclo // long te
 	}
 }
 
+func TestRuntimeRegister(t *testing.T) {
+	var (
+		g      = run.Group{Logger: telemetry.NoopLogger()}
+		s1, s2 service
+		s2n    = exitNotifier{
+			service: &s2,
+			ready:   make(chan struct{}),
+			exited:  make(chan struct{}),
+		}
+		irq = make(chan error)
+	)
+
+	s1.configItem = 1
+	s2.configItem = 1
+
+	g.Register(&s1)
+	g.Register(&test.Svc{
+		SvcName: "testsvc",
+		Execute: func() error {
+			<-s1.started
+			// register a Service while the Group is serving
+			if reg := g.Register(s2n); !reg[0] {
+				t.Error("runtime register want: true, have: false")
+			}
+			<-s2n.ready
+			<-s2.started
+			// deregister a running Service, only s2 should stop
+			if dereg := g.Deregister(s2n); !dereg[0] {
+				t.Error("runtime deregister want: true, have: false")
+			}
+			<-s2n.exited
+			if s1.gracefulStop {
+				t.Error("Expected service to keep running")
+			}
+			return errIRQ
+		},
+	})
+
+	go func() { irq <- g.Run("./myService", "-f", "1") }()
+
+	select {
+	case err := <-irq:
+		if !errors.Is(err, errIRQ) {
+			t.Errorf("Expected proper close, got %v", err)
+		}
+		if s2.initializer < 1 || !s2.preRun {
+			t.Error("Expected initializer and preRun logic to run for runtime service")
+		}
+		if !s2.gracefulStop {
+			t.Error("Expected graceful stop logic to run for deregistered service")
+		}
+		if !s1.gracefulStop {
+			t.Error("Expected graceful stop logic to run")
+		}
+	case <-time.After(100 * time.Millisecond):
+		t.Errorf("timeout")
+	}
+}
+
+func TestStopUnit(t *testing.T) {
+	var (
+		g   = run.Group{Name: "StopUnit", Logger: telemetry.NoopLogger()}
+		s1  service
+		irq = make(chan error)
+	)
+
+	s1.configItem = 1
+
+	g.Register(&s1)
+	g.Register(&test.Svc{
+		SvcName: "stopper",
+		Execute: func() error {
+			<-s1.started
+			ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+			defer cancel()
+			if err := g.StopUnit("testsvc", ctx); err != nil {
+				t.Errorf("Expected unit to stop, got %v", err)
+			}
+			if err := g.StopUnit("testsvc", ctx); !errors.Is(err, run.ErrUnitNotRunning) {
+				t.Errorf("Expected %v, got %v", run.ErrUnitNotRunning, err)
+			}
+			if !strings.Contains(g.ListUnits(), "testsvc(stopped)") {
+				t.Errorf("Expected stopped unit in list, got %s", g.ListUnits())
+			}
+			return errIRQ
+		},
+	})
+
+	go func() { irq <- g.Run("./myService", "-f", "1") }()
+
+	select {
+	case err := <-irq:
+		if !errors.Is(err, errIRQ) {
+			t.Errorf("Expected proper close, got %v", err)
+		}
+		if !s1.gracefulStop {
+			t.Error("Expected graceful stop logic to run")
+		}
+	case <-time.After(100 * time.Millisecond):
+		t.Errorf("timeout")
+	}
+}
+
 type flagTestConfig struct {
 	value int
 }
@@ -528,3 +787,53 @@ func (s *serviceContext) ServeContext(ctx context.Context) error {
 	s.contextDone = true
 	return nil
 }
+
+// exitNotifier wraps service to signal when its PreRun and Serve methods have
+// returned.
+type exitNotifier struct {
+	*service
+	ready  chan struct{}
+	exited chan struct{}
+}
+
+func (e exitNotifier) PreRun() error {
+	defer close(e.ready)
+	return e.service.PreRun()
+}
+
+func (e exitNotifier) Serve() error {
+	defer close(e.exited)
+	return e.service.Serve()
+}
+
+func TestDeregisterPhases(t *testing.T) {
+	var (
+		g = run.Group{Logger: telemetry.NoopLogger()}
+		s = &service{}
+	)
+
+	g.Register(s)
+	want := run.PhaseInitialize | run.PhaseName | run.PhaseConfig |
+		run.PhasePreRun | run.PhaseServe
+	if phases := g.DeregisterPhases(s); phases[0] != want {
+		t.Errorf("Expected %v, got %v", want, phases[0])
+	}
+	// slots are reclaimed before the Config phase
+	if units := g.ListUnits(); strings.Contains(units, "testsvc") {
+		t.Errorf("Expected no registrations, got %q", units)
+	}
+
+	g.Register(s)
+	if units := g.ListUnits(); strings.Count(units, "testsvc") != 5 {
+		t.Errorf("Expected a single registration per phase, got %q", units)
+	}
+	if err := g.RunConfig("./myService", "-f", "1"); err != nil {
+		t.Fatalf("Expected nil, got %v", err)
+	}
+	if phases := g.DeregisterPhases(s); phases[0] != want {
+		t.Errorf("Expected %v, got %v", want, phases[0])
+	}
+	if phases := g.DeregisterPhases(s); phases[0] != 0 {
+		t.Errorf("Expected %v, got %v", run.Phase(0), phases[0])
+	}
+}
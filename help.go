@@ -0,0 +1,195 @@
+// Copyright (c) Bas van Beek 2024.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package run
+
+import (
+	"fmt"
+	"strings"
+	"text/template"
+
+	"github.com/basvanbeek/run/pkg/flag"
+)
+
+// HelpData holds the information available to a help template set by
+// WithHelpTemplate.
+type HelpData struct {
+	// Name holds the Group name.
+	Name string
+	// HelpText holds the Group's additional help context.
+	HelpText string
+	// All is true if --help-all was requested.
+	All bool
+	// Filter holds the filter provided as argument to --help, if any. Only
+	// flags of which the name or usage matches the filter are included, from
+	// all tiers.
+	Filter string
+	// Common holds the common service options. Its Tiers are empty if none
+	// match the Filter.
+	Common HelpSection
+	// Sections holds the flags of the registered Config Units.
+	Sections []HelpSection
+	// Units holds the names of the registered Config Units.
+	Units []string
+	// Arguments holds the positional argument usage lines of the registered
+	// ArgsReceiver Units.
+	Arguments string
+	// HasMore is true if flags are hidden from the output because All is
+	// false.
+	HasMore bool
+	// HelpAllFlag holds the name of the flag showing all flags.
+	HelpAllFlag string
+}
+
+// HelpSection holds the flags of a FlagSet.
+type HelpSection struct {
+	// Name holds the FlagSet name.
+	Name string
+	// Tiers holds the flag usages per tier. Unless All is requested, only
+	// stable flags are included.
+	Tiers []HelpTier
+}
+
+// HelpTier holds the flag usages of a single flag tier.
+type HelpTier struct {
+	Tier  flag.Tier
+	Usage string
+}
+
+// WithHelpTemplate sets a text/template used to render the --help output
+// instead of the default layout. The template is executed with HelpData and
+// can use the heading, section and tier functions to apply the Palette set by
+// WithPalette and the msg function to render a message set by WithMessages,
+// e.g. {{ msg "usage" .Name }}.
+func WithHelpTemplate(tmpl string) Option {
+	return func(g *Group) {
+		g.helpTemplate = tmpl
+	}
+}
+
+// printHelp writes the --help output to the Group's stdout. A non-empty
+// filter limits the output to the matching flags.
+func (g *Group) printHelp(gFS *flag.Set, fs []*flag.Set, all, noColor bool, filter string) error {
+	data := g.helpData(gFS, fs, all, filter)
+	p := g.colors(noColor)
+	w := g.stdout()
+	if g.helpTemplate != "" {
+		t, err := template.New("help").Funcs(template.FuncMap{
+			"heading": func(s string) string { return style(p.Heading, s) },
+			"section": func(s string) string { return style(p.Section, s) },
+			"tier":    func(s string) string { return style(p.Tier, s) },
+			"msg": func(id string, args ...any) string {
+				return g.msg(MessageID(id), args...)
+			},
+		}).Parse(g.helpTemplate)
+		if err != nil {
+			return fmt.Errorf("help template: %w", err)
+		}
+		if err = t.Execute(w, data); err != nil {
+			return fmt.Errorf("help template: %w", err)
+		}
+		return nil
+	}
+
+	fmt.Fprintln(w, style(p.Heading, g.msg(MsgUsage, data.Name)))
+	if data.HelpText != "" {
+		fmt.Fprintf(w, "%s\n", data.HelpText)
+	}
+	fmt.Fprintf(w, "%s\n\n", style(p.Heading, g.msg(MsgFlags)))
+	matched := false
+	for _, s := range append([]HelpSection{data.Common}, data.Sections...) {
+		if len(s.Tiers) == 0 {
+			continue
+		}
+		matched = true
+		fmt.Fprintf(w, "%s\n", style(p.Section, "* "+s.Name))
+		for _, t := range s.Tiers {
+			if t.Tier != flag.TierStable {
+				fmt.Fprintf(w, "  %s\n", style(p.Tier, "["+t.Tier.String()+"]"))
+			}
+			fmt.Fprintf(w, "%s\n", t.Usage)
+		}
+	}
+	if !matched {
+		fmt.Fprintf(w, "%s\n\n", g.msg(MsgNoMatchingFlags, data.Filter))
+	}
+	if data.HasMore && data.HelpAllFlag != "" {
+		fmt.Fprintf(w, "%s\n\n", g.msg(MsgMoreFlags, data.HelpAllFlag))
+	}
+	if data.Arguments != "" {
+		fmt.Fprintf(w, "%s\n%s\n", style(p.Heading, g.msg(MsgArguments)), data.Arguments)
+	}
+	return nil
+}
+
+// helpFilter returns the filter provided as argument directly following the
+// help flags in args, if any.
+func (g *Group) helpFilter(args []string) string {
+	n, sh, _ := g.commonFlag(HelpFlag)
+	all, _, _ := g.commonFlag(HelpAllFlag)
+	for idx, arg := range args {
+		if arg == "--" {
+			break
+		}
+		if (n == "" || arg != "--"+n) && (all == "" || arg != "--"+all) && (sh == "" || arg != "-"+sh) {
+			continue
+		}
+		if idx+1 < len(args) && !strings.HasPrefix(args[idx+1], "-") {
+			return args[idx+1]
+		}
+	}
+	return ""
+}
+
+// helpData collects the information shown by --help. Unless all is true or a
+// filter is provided, only stable flags are included.
+func (g *Group) helpData(gFS *flag.Set, fs []*flag.Set, all bool, filter string) HelpData {
+	n, _, _ := g.commonFlag(HelpAllFlag)
+	data := HelpData{
+		Name:        g.Name,
+		HelpText:    g.HelpText,
+		All:         all,
+		Filter:      filter,
+		Common:      HelpSection{Name: gFS.Name},
+		Arguments:   g.argsUsage(),
+		HelpAllFlag: n,
+	}
+	if usage := gFS.MatchingTierUsages(flag.TierStable, filter); usage != "" {
+		data.Common.Tiers = []HelpTier{{Tier: flag.TierStable, Usage: usage}}
+	}
+	for idx := 0; idx < phaseLen(g, &g.c); idx++ {
+		if c := unitAt(g, &g.c, idx); c != nil {
+			data.Units = append(data.Units, g.nameOf(c))
+		}
+	}
+	for _, f := range fs {
+		if f == nil {
+			continue
+		}
+		section := HelpSection{Name: f.Name}
+		for _, tier := range flag.Tiers {
+			usage := f.MatchingTierUsages(tier, filter)
+			if usage == "" {
+				continue
+			}
+			if tier != flag.TierStable && !all && filter == "" {
+				data.HasMore = true
+				continue
+			}
+			section.Tiers = append(section.Tiers, HelpTier{Tier: tier, Usage: usage})
+		}
+		data.Sections = append(data.Sections, section)
+	}
+	return data
+}
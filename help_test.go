@@ -0,0 +1,130 @@
+// Copyright (c) Bas van Beek 2024.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package run_test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/basvanbeek/telemetry"
+
+	"github.com/basvanbeek/run"
+	"github.com/basvanbeek/run/pkg/flag"
+)
+
+func TestHelpTemplate(t *testing.T) {
+	const tmpl = `{{ heading "USAGE" }}: {{ .Name }}
+{{ range .Sections }}{{ section .Name }}
+{{ range .Tiers }}[{{ .Tier }}]
+{{ .Usage }}{{ end }}{{ end }}units: {{ range .Units }}{{ . }} {{ end }}
+more: {{ .HasMore }}
+`
+	var (
+		g = run.NewGroup("template",
+			run.WithLogger(telemetry.NoopLogger()),
+			run.WithHelpTemplate(tmpl),
+		)
+		fs     = run.NewFlagSet("Template options")
+		addr   string
+		tuning int
+	)
+	fs.StringVar(&addr, "addr", ":8080", "listen address")
+	fs.IntVar(&tuning, "tuning", 0, "tuning knob")
+	fs.SetTier(flag.TierAdvanced, "tuning")
+	g.Register(configUnit{fs: fs})
+
+	out := captureStdout(t, func() {
+		if err := g.Run("./myService", "--help"); err != nil {
+			t.Errorf("Expected bail early, got %v", err)
+		}
+	})
+
+	for _, want := range []string{
+		"USAGE: template\n",
+		"Template options\n[stable]\n",
+		"--addr string",
+		"units: config-unit \n",
+		"more: true\n",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("Expected %q in help output, got %q", want, out)
+		}
+	}
+	if strings.Contains(out, "--tuning") {
+		t.Errorf("Expected advanced flags to be hidden, got %q", out)
+	}
+
+	g = run.NewGroup("template",
+		run.WithLogger(telemetry.NoopLogger()),
+		run.WithHelpTemplate("{{ .Unknown }}"),
+	)
+	captureStdout(t, func() {
+		if err := g.Run("./myService", "--help"); err == nil {
+			t.Error("Expected template execution error")
+		}
+	})
+}
+
+func TestHelpFilter(t *testing.T) {
+	tests := []struct {
+		name     string
+		filter   string
+		included []string
+		excluded []string
+	}{
+		{"name", "addr", []string{"Filter options", "--addr"},
+			[]string{"--tuning", "--version", "Common Service options"}},
+		{"usage and tier", "KNOB", []string{"[advanced]", "--tuning"},
+			[]string{"--addr"}},
+		{"common", "version", []string{"Common Service options", "--version"},
+			[]string{"Filter options"}},
+		{"no match", "nothing", []string{`No flags matching "nothing".`},
+			[]string{"--addr", "--version"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var (
+				stdout bytes.Buffer
+				g      = run.NewGroup("filter",
+					run.WithLogger(telemetry.NoopLogger()),
+					run.WithOutput(&stdout, &stdout),
+				)
+				fs     = run.NewFlagSet("Filter options")
+				addr   string
+				tuning int
+			)
+			fs.StringVar(&addr, "addr", ":8080", "listen address")
+			fs.IntVar(&tuning, "tuning", 0, "tuning knob")
+			fs.SetTier(flag.TierAdvanced, "tuning")
+			g.Register(configUnit{fs: fs})
+
+			if err := g.Run("./myService", "--help", tt.filter); err != nil {
+				t.Fatalf("Expected bail early, got %v", err)
+			}
+			out := stdout.String()
+			for _, want := range tt.included {
+				if !strings.Contains(out, want) {
+					t.Errorf("Expected %q in help output, got %q", want, out)
+				}
+			}
+			for _, unwanted := range tt.excluded {
+				if strings.Contains(out, unwanted) {
+					t.Errorf("Expected %q not in help output, got %q", unwanted, out)
+				}
+			}
+		})
+	}
+}
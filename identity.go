@@ -0,0 +1,80 @@
+// Copyright (c) Bas van Beek 2024.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package run
+
+import (
+	"os"
+	"strconv"
+)
+
+// Label keys used by Group.Labels, following the OpenTelemetry resource
+// semantic conventions.
+const (
+	LabelServiceName       = "service.name"
+	LabelServiceInstanceID = "service.instance.id"
+)
+
+// WithInstanceID sets the id distinguishing this instance of the Group from
+// other instances running under the same name. It defaults to the hostname
+// and process id of the instance.
+func WithInstanceID(id string) Option {
+	return func(g *Group) {
+		g.instanceID = id
+	}
+}
+
+// WithProcessTitle sets the OS process title to the Group name once it is
+// final, i.e. after the name flag has been parsed. This is only supported on
+// Linux, where the title is truncated to 15 bytes; on other platforms it is a
+// no-op.
+func WithProcessTitle() Option {
+	return func(g *Group) {
+		g.processTitle = true
+	}
+}
+
+// InstanceID returns the id of this instance of the Group, see
+// WithInstanceID.
+func (g *Group) InstanceID() string {
+	if g.instanceID != "" {
+		return g.instanceID
+	}
+	host, err := os.Hostname()
+	if err != nil || host == "" {
+		host = "localhost"
+	}
+	return host + "-" + strconv.Itoa(os.Getpid())
+}
+
+// Labels returns the default labels identifying this instance of the Group,
+// to be attached to metrics and traces. This allows telling apart multiple
+// instances of the same deployment.
+func (g *Group) Labels() map[string]string {
+	return map[string]string{
+		LabelServiceName:       g.Name,
+		LabelServiceInstanceID: g.InstanceID(),
+	}
+}
+
+// applyProcessTitle sets the process title to the Group name if requested.
+// Failing to do so is not fatal.
+func (g *Group) applyProcessTitle() {
+	if !g.processTitle || g.Name == "" {
+		return
+	}
+	if err := setProcessTitle(g.Name); err != nil {
+		g.Logger.Debug("unable to set process title", "error", err)
+	}
+}
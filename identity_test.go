@@ -0,0 +1,70 @@
+// Copyright (c) Bas van Beek 2024.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package run_test
+
+import (
+	"os"
+	"runtime"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/basvanbeek/telemetry"
+
+	"github.com/basvanbeek/run"
+)
+
+func TestLabels(t *testing.T) {
+	g := run.NewGroup("labels", run.WithLogger(telemetry.NoopLogger()))
+	if id := g.InstanceID(); !strings.HasSuffix(id, "-"+strconv.Itoa(os.Getpid())) {
+		t.Errorf("Expected default instance id to end in the pid, got %q", id)
+	}
+	g = run.NewGroup("labels", run.WithLogger(telemetry.NoopLogger()),
+		run.WithInstanceID("blue"))
+	g.Register(configUnit{fs: run.NewFlagSet("labels")})
+	if err := g.RunConfig("./myService", "-n", "renamed"); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	labels := g.Labels()
+	if labels[run.LabelServiceName] != "renamed" ||
+		labels[run.LabelServiceInstanceID] != "blue" {
+		t.Errorf("Unexpected labels: %v", labels)
+	}
+	if s := g.Status(); s.Instance != "blue" {
+		t.Errorf("Expected status instance blue, got %q", s.Instance)
+	}
+}
+
+func TestWithProcessTitle(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("process title only supported on linux")
+	}
+	orig, err := os.ReadFile("/proc/self/comm")
+	if err != nil {
+		t.Skipf("unable to read process title: %v", err)
+	}
+	defer func() { _ = os.WriteFile("/proc/self/comm", orig, 0) }()
+
+	g := run.NewGroup("title", run.WithLogger(telemetry.NoopLogger()),
+		run.WithProcessTitle())
+	g.Register(configUnit{fs: run.NewFlagSet("title")})
+	if err = g.RunConfig("./myService", "-n", "a-rather-long-title"); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	title, _ := os.ReadFile("/proc/self/comm")
+	if got := strings.TrimSpace(string(title)); got != "a-rather-long-t" {
+		t.Errorf("Expected truncated process title, got %q", got)
+	}
+}
@@ -0,0 +1,36 @@
+// Copyright (c) Bas van Beek 2024.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package run
+
+import "fmt"
+
+// errIdle is the shutdown reason of a Group configured with WithExitOnIdle
+// once all served Units have completed.
+var errIdle = fmt.Errorf("%w: all services completed", ErrRequestedShutdown)
+
+// idle returns true if the Group exits on idle, all start waves have been
+// launched and all served Units have returned.
+// It must be called while holding the Group lock.
+func (g *Group) idle() bool {
+	if !g.exitOnIdle || !g.launched || g.stopping {
+		return false
+	}
+	for _, r := range g.running {
+		if !r.done {
+			return false
+		}
+	}
+	return true
+}
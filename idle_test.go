@@ -0,0 +1,96 @@
+// Copyright (c) Bas van Beek 2024.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package run_test
+
+import (
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/basvanbeek/telemetry"
+
+	"github.com/basvanbeek/run"
+)
+
+func TestExitOnIdle(t *testing.T) {
+	var (
+		completed atomic.Int32
+		block     = make(chan struct{})
+		irq       = make(chan error)
+		g         = run.NewGroup("idle",
+			run.WithLogger(telemetry.NoopLogger()),
+			run.WithExitOnIdle(),
+		)
+	)
+	worker := func() error {
+		completed.Add(1)
+		return nil
+	}
+	g.Register(
+		run.NewActor("worker-1", worker, func(error) {}),
+		run.NewActor("worker-2", worker, func(error) {}),
+		run.NewActor("slow-worker", func() error {
+			<-block
+			return worker()
+		}, func(error) {}),
+	)
+
+	go func() { irq <- g.Run("./myService") }()
+
+	select {
+	case err := <-irq:
+		t.Fatalf("Expected Group to wait for the slow worker, got %v", err)
+	case <-time.After(50 * time.Millisecond):
+	}
+	close(block)
+
+	select {
+	case err := <-irq:
+		if err != nil {
+			t.Errorf("Expected clean exit, got %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("timeout")
+	}
+	if want, have := int32(3), completed.Load(); want != have {
+		t.Errorf("completed want: %d, have: %d", want, have)
+	}
+}
+
+func TestExitOnIdleError(t *testing.T) {
+	var (
+		errJob = errors.New("job failed")
+		block  = make(chan struct{})
+		g      = run.NewGroup("idle",
+			run.WithLogger(telemetry.NoopLogger()),
+			run.WithExitOnIdle(),
+		)
+	)
+	g.Register(
+		run.NewActor("worker", func() error { return nil }, func(error) {}),
+		run.NewActor("failing", func() error { return errJob }, func(error) {}),
+		run.NewActor("blocking", func() error {
+			<-block
+			return nil
+		}, func(error) { close(block) }),
+	)
+
+	err := g.Run("./myService")
+	var crashed *run.ServiceCrashedError
+	if !errors.As(err, &crashed) || crashed.Unit != "failing" || !errors.Is(err, errJob) {
+		t.Errorf("Expected %v of unit failing, got %v", errJob, err)
+	}
+}
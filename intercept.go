@@ -0,0 +1,69 @@
+// Copyright (c) Bas van Beek 2024.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package run
+
+import "fmt"
+
+// Interceptor is an extension interface that Units can implement to adjust
+// flag values after flag parsing and before validation, e.g. to normalize
+// values, resolve relative paths or derive defaults from other flags. The
+// provided FlagSet holds the flags of all Config Units as well as the common
+// Group flags. Interceptors are called serially in order of Unit registration,
+// after the values of FlagSource Units have been loaded. If an Interceptor
+// returns an error, the Group exits.
+type Interceptor interface {
+	// Unit is embedded for Group registration and identification
+	Unit
+	PostParse(fs *FlagSet) error
+}
+
+// NewInterceptor takes a name and a standalone post parse compatible function
+// and turns them into a Group compatible Interceptor, ready for registration.
+func NewInterceptor(name string, fn func(fs *FlagSet) error) Interceptor {
+	return interceptor{name: name, fn: fn}
+}
+
+type interceptor struct {
+	name string
+	fn   func(fs *FlagSet) error
+}
+
+func (i interceptor) Name() string {
+	return i.name
+}
+
+func (i interceptor) PostParse(fs *FlagSet) error {
+	return i.fn(fs)
+}
+
+// intercept calls the PostParse method of all registered Interceptor Units
+// and exits on the first error.
+func (g *Group) intercept() error {
+	total := phaseLen(g, &g.t)
+	for idx := 0; idx < total; idx++ {
+		// an Interceptor might have been de-registered
+		i := unitAt(g, &g.t, idx)
+		if i == nil {
+			continue
+		}
+		l := g.phaseLogger("post-parse", g.nameOf(i), g.itemNr(idx+1, total))
+		err := i.PostParse(g.f)
+		l.exit(err)
+		if err != nil {
+			return fmt.Errorf("post-parse %s: %w", g.nameOf(i), err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,80 @@
+// Copyright (c) Bas van Beek 2024.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package run_test
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"github.com/basvanbeek/telemetry"
+
+	"github.com/basvanbeek/run"
+)
+
+type pathConfig struct {
+	base, data string
+	validated  string
+}
+
+func (p *pathConfig) Name() string { return "paths" }
+func (p *pathConfig) FlagSet() *run.FlagSet {
+	fs := run.NewFlagSet("Path options")
+	fs.StringVar(&p.base, "base-dir", "/srv", "base directory")
+	fs.StringVar(&p.data, "data-dir", "", "data directory, relative to base-dir")
+	return fs
+}
+func (p *pathConfig) Validate() error {
+	p.validated = p.data
+	return nil
+}
+func (p *pathConfig) PostParse(fs *run.FlagSet) error {
+	if !filepath.IsAbs(p.data) {
+		return fs.Set("data-dir", filepath.Join(p.base, p.data))
+	}
+	return nil
+}
+
+func TestInterceptor(t *testing.T) {
+	var (
+		g     = run.Group{Name: "intercept", Logger: telemetry.NoopLogger()}
+		paths = &pathConfig{}
+		seen  string
+	)
+	g.Register(paths, run.NewInterceptor("app", func(fs *run.FlagSet) error {
+		seen = fs.Lookup("data-dir").Value.String()
+		return nil
+	}))
+
+	if err := g.RunConfig("./myService", "--base-dir", "/var/lib", "--data-dir", "app"); err != nil {
+		t.Fatalf("Expected nil error, got %v", err)
+	}
+	if want := "/var/lib/app"; paths.validated != want || seen != want {
+		t.Errorf("Expected data-dir %q before validation, got %q and %q",
+			want, paths.validated, seen)
+	}
+}
+
+func TestInterceptorError(t *testing.T) {
+	var (
+		g = run.Group{Name: "intercept", Logger: telemetry.NoopLogger()}
+		e = errors.New("intercept failed")
+	)
+	g.Register(run.NewInterceptor("failing", func(*run.FlagSet) error { return e }))
+
+	if err := g.RunConfig("./myService"); !errors.Is(err, e) {
+		t.Errorf("Expected %v, got %v", e, err)
+	}
+}
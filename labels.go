@@ -0,0 +1,39 @@
+// Copyright (c) Bas van Beek 2024.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package run
+
+import (
+	"context"
+	"runtime/pprof"
+)
+
+// pprof label keys set on the goroutines running Unit lifecycle methods.
+const (
+	labelUnit  = "unit"
+	labelPhase = "phase"
+)
+
+// withLabels runs fn with the pprof labels identifying the provided Unit and
+// lifecycle phase added to the labels of ctx. Goroutines started by fn inherit
+// the labels, allowing CPU and goroutine profiles of a composite binary to be
+// sliced per Unit without any changes to the Units themselves.
+func (g *Group) withLabels(ctx context.Context, u Unit, phase string, fn func(ctx context.Context)) {
+	pprof.Do(ctx, pprof.Labels(labelUnit, g.nameOf(u), labelPhase, phase), fn)
+}
+
+// ignoreContext adapts a Serve method to the signature of ServeContext.
+func ignoreContext(fn func() error) func(context.Context) error {
+	return func(context.Context) error { return fn() }
+}
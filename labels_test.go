@@ -0,0 +1,56 @@
+// Copyright (c) Bas van Beek 2024.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package run_test
+
+import (
+	"context"
+	"runtime/pprof"
+	"testing"
+
+	"github.com/basvanbeek/telemetry"
+
+	"github.com/basvanbeek/run"
+)
+
+type labeledService struct {
+	labels map[string]string
+}
+
+func (l *labeledService) Name() string { return "labeled" }
+func (l *labeledService) ServeContext(ctx context.Context) error {
+	for _, key := range []string{"unit", "phase"} {
+		l.labels[key], _ = pprof.Label(ctx, key)
+	}
+	return run.ErrRequestedShutdown
+}
+
+func TestPprofLabels(t *testing.T) {
+	var (
+		g   = run.Group{Name: "labels", Logger: telemetry.NoopLogger()}
+		svc = &labeledService{labels: make(map[string]string)}
+	)
+	g.Register(svc)
+	if err := g.Run("./myService"); err != nil {
+		t.Fatalf("Expected nil error, got %v", err)
+	}
+	for key, want := range map[string]string{
+		"unit":  "labeled",
+		"phase": "serve-context",
+	} {
+		if svc.labels[key] != want {
+			t.Errorf("Expected label %s %q, got %q", key, want, svc.labels[key])
+		}
+	}
+}
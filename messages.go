@@ -0,0 +1,107 @@
+// Copyright (c) Bas van Beek 2024.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package run
+
+import "fmt"
+
+// MessageID identifies a user facing message of Group which can be localized
+// or re-worded using WithMessages.
+type MessageID string
+
+// Localizable messages of Group. The default English texts are listed in
+// DefaultMessages, with fmt verbs where the message is formatted.
+const (
+	// MsgUsage is the --help heading, formatted with the Group name.
+	MsgUsage MessageID = "usage"
+	// MsgFlags is the --help flags heading.
+	MsgFlags MessageID = "flags"
+	// MsgArguments is the --help positional arguments heading.
+	MsgArguments MessageID = "arguments"
+	// MsgMoreFlags is the --help hint for hidden flag tiers, formatted with
+	// the name of the --help-all flag.
+	MsgMoreFlags MessageID = "more-flags"
+	// MsgFlagClash is the error prefix for flags clashing with the common
+	// service options, formatted with the Unit and flag name.
+	MsgFlagClash MessageID = "flag-clash"
+	// MsgPreRun is the error prefix of PreRun errors, formatted with the Unit
+	// name.
+	MsgPreRun MessageID = "pre-run"
+	// MsgPostRun is the error prefix of PostRun errors, formatted with the
+	// Unit name.
+	MsgPostRun MessageID = "post-run"
+	// MsgStarted is logged once the Config phase completed, formatted with the
+	// Group name and version.
+	MsgStarted MessageID = "started"
+	// MsgDone is logged when Run returns without error.
+	MsgDone MessageID = "done"
+	// MsgShutdownRequest is logged when Run returns on a requested shutdown.
+	MsgShutdownRequest MessageID = "shutdown-request"
+	// MsgUnexpectedExit is logged when Run returns a fatal error.
+	MsgUnexpectedExit MessageID = "unexpected-exit"
+	// MsgUnitDisabled is logged for each Enabler Unit skipped by Run as it
+	// reported itself disabled.
+	MsgUnitDisabled MessageID = "unit-disabled"
+	// MsgFlagSuggestion is appended to unknown flag errors, formatted with the
+	// suggested flags.
+	MsgFlagSuggestion MessageID = "flag-suggestion"
+	// MsgNoMatchingFlags is the --help output if no flag matches the filter,
+	// formatted with the filter.
+	MsgNoMatchingFlags MessageID = "no-matching-flags"
+)
+
+// DefaultMessages holds the default English texts of the localizable
+// messages.
+var DefaultMessages = map[MessageID]string{
+	MsgUsage:           "Usage of %s:",
+	MsgFlags:           "Flags:",
+	MsgArguments:       "Arguments:",
+	MsgMoreFlags:       "Use --%s to show all flags.",
+	MsgFlagClash:       "%s: flag --%s clashes with common service options",
+	MsgPreRun:          "pre-run %s",
+	MsgPostRun:         "post-run %s",
+	MsgStarted:         "%s %s started",
+	MsgDone:            "done",
+	MsgShutdownRequest: "received shutdown request",
+	MsgUnexpectedExit:  "unexpected exit",
+	MsgUnitDisabled:    "unit disabled",
+	MsgFlagSuggestion:  "did you mean %s?",
+	MsgNoMatchingFlags: "No flags matching %q.",
+}
+
+// WithMessages overrides the texts of the provided messages, allowing them to
+// be localized or re-worded. Texts must hold the same fmt verbs as their
+// default in DefaultMessages. Messages not provided keep their default text.
+func WithMessages(messages map[MessageID]string) Option {
+	return func(g *Group) {
+		if g.messages == nil {
+			g.messages = make(map[MessageID]string)
+		}
+		for id, text := range messages {
+			g.messages[id] = text
+		}
+	}
+}
+
+// msg returns the text of the provided message formatted with args.
+func (g *Group) msg(id MessageID, args ...any) string {
+	text, ok := g.messages[id]
+	if !ok {
+		text = DefaultMessages[id]
+	}
+	if len(args) == 0 {
+		return text
+	}
+	return fmt.Sprintf(text, args...)
+}
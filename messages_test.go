@@ -0,0 +1,64 @@
+// Copyright (c) Bas van Beek 2024.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package run_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/basvanbeek/telemetry"
+
+	"github.com/basvanbeek/run"
+)
+
+func TestWithMessages(t *testing.T) {
+	messages := map[run.MessageID]string{
+		run.MsgUsage:  "Gebruik van %s:",
+		run.MsgFlags:  "Opties:",
+		run.MsgPreRun: "voorbereiding %s",
+	}
+
+	g := run.NewGroup("messages",
+		run.WithLogger(telemetry.NoopLogger()),
+		run.WithMessages(messages),
+	)
+	g.Register(configUnit{fs: run.NewFlagSet("Message options")})
+
+	out := captureStdout(t, func() {
+		if err := g.Run("./myService", "--help", "--no-color"); err != nil {
+			t.Errorf("Expected bail early, got %v", err)
+		}
+	})
+	for _, want := range []string{"Gebruik van messages:\n", "Opties:\n"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("Expected %q in help output, got %q", want, out)
+		}
+	}
+
+	e := errors.New("preRun failed")
+	g = run.NewGroup("messages",
+		run.WithLogger(telemetry.NoopLogger()),
+		run.WithMessages(messages),
+	)
+	g.Register(failingPreRun{e: e})
+	err := g.Run("./myService")
+	if !errors.Is(err, e) {
+		t.Fatalf("Expected %v, got %v", e, err)
+	}
+	if want := "voorbereiding preRun failed: preRun failed"; err.Error() != want {
+		t.Errorf("Expected %q, got %q", want, err.Error())
+	}
+}
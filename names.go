@@ -0,0 +1,144 @@
+// Copyright (c) Bas van Beek 2024.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package run
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+)
+
+// ErrDuplicateName is returned by RunConfig if Units sharing the same name
+// were registered while NamesUnique is the active NamePolicy.
+const ErrDuplicateName Error = "duplicate unit name"
+
+// NamePolicy determines how Group handles different Units sharing the same
+// name. Logs, introspection, timings and Unit management by name all key off
+// the Unit names.
+type NamePolicy int
+
+// Available Unit name policies.
+const (
+	// NamesShared allows Units to share the same name. This is the default
+	// policy.
+	NamesShared NamePolicy = iota
+	// NamesUnique rejects a Unit named like an already registered Unit. The
+	// registration is ignored and RunConfig returns ErrDuplicateName. Clashes
+	// after the Config phase are ignored and logged as error.
+	NamesUnique
+	// NamesSuffix registers a Unit named like an earlier registered Unit
+	// under its name suffixed with its occurrence, e.g. worker#2. Units of a
+	// type which is not comparable can't be told apart and keep their name.
+	NamesSuffix
+)
+
+// WithNamePolicy sets how Group handles different Units sharing the same name.
+func WithNamePolicy(p NamePolicy) Option {
+	return func(g *Group) {
+		g.namePolicy = p
+	}
+}
+
+// unitName holds the name assigned by Group to a Unit.
+type unitName struct {
+	unit Unit
+	name string
+}
+
+// assignName applies the NamePolicy to the provided Unit. It returns false if
+// the Unit must not be registered. It must be called while holding the Group
+// lock.
+func (g *Group) assignName(u Unit) bool {
+	switch g.namePolicy {
+	case NamesUnique:
+		if !g.nameClash(u) {
+			return true
+		}
+		if g.configured {
+			g.Logger.Error("ignoring unit", ErrDuplicateName, "unit", u.Name())
+		} else {
+			g.nameClashes = append(g.nameClashes, u.Name())
+		}
+		return false
+	case NamesSuffix:
+		if g.nameOf(u) != u.Name() || !reflect.TypeOf(u).Comparable() {
+			// already named or can't be told apart
+			return true
+		}
+		if g.nameCount == nil {
+			g.nameCount = make(map[string]int)
+		}
+		g.nameCount[u.Name()]++
+		if n := g.nameCount[u.Name()]; n > 1 {
+			g.namesMu.Lock()
+			g.names = append(g.names, unitName{
+				unit: u,
+				name: u.Name() + "#" + strconv.Itoa(n),
+			})
+			g.namesMu.Unlock()
+		}
+	}
+	return true
+}
+
+// nameClash returns true if a different Unit with the same name as the
+// provided Unit is registered. It must be called while holding the Group lock.
+func (g *Group) nameClash(u Unit) bool {
+	clash := func(v Unit) bool { return !identical(v, u) && v.Name() == u.Name() }
+	return anyUnit(g.i, clash) || anyUnit(g.n, clash) ||
+		anyUnit(g.c, clash) || anyUnit(g.a, clash) ||
+		anyUnit(g.v, clash) || anyUnit(g.t, clash) ||
+		anyUnit(g.k, clash) || anyUnit(g.r, clash) ||
+		anyUnit(g.q, clash) || anyUnit(g.p, clash) ||
+		anyUnit(g.j, clash) ||
+		anyUnit(g.s, clash) || anyUnit(g.x, clash) ||
+		anyUnit(g.z, clash)
+}
+
+// checkNames reports the name clashes found before the Config phase.
+func (g *Group) checkNames() error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	clashes := g.nameClashes
+	g.nameClashes = nil
+	if len(clashes) == 0 {
+		return nil
+	}
+	return fmt.Errorf("%w: %v", ErrDuplicateName, clashes)
+}
+
+// nameOf returns the name of the provided Unit as assigned by Group.
+func (g *Group) nameOf(u Unit) string {
+	g.namesMu.RLock()
+	defer g.namesMu.RUnlock()
+	for _, n := range g.names {
+		if identical(n.unit, u) {
+			return n.name
+		}
+	}
+	return u.Name()
+}
+
+// anyUnit returns true if fn returns true for any Unit of the provided phase
+// slice.
+func anyUnit[T any](phase []T, fn func(Unit) bool) bool {
+	for _, p := range phase {
+		// a de-registered slot holds nil
+		if v, ok := any(p).(Unit); ok && fn(v) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,74 @@
+// Copyright (c) Bas van Beek 2024.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package run_test
+
+import (
+	"errors"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/basvanbeek/telemetry"
+
+	"github.com/basvanbeek/run"
+	"github.com/basvanbeek/run/pkg/test"
+)
+
+func TestNamePolicy(t *testing.T) {
+	g := run.NewGroup("names", run.WithLogger(telemetry.NoopLogger()))
+	g.Register(&test.Svc{SvcName: "worker"}, &test.Svc{SvcName: "worker"})
+	if units := g.ListUnits(); strings.Count(units, "worker ") != 2 {
+		t.Errorf("Expected shared names, got %q", units)
+	}
+
+	g = run.NewGroup("names",
+		run.WithLogger(telemetry.NoopLogger()),
+		run.WithNamePolicy(run.NamesUnique),
+	)
+	if reg := g.Register(
+		&test.Svc{SvcName: "worker"}, &test.Svc{SvcName: "worker"},
+	); !reg[0] || reg[1] {
+		t.Errorf("Expected name clash to be ignored, got %v", reg)
+	}
+	if err := g.Run("./myService"); !errors.Is(err, run.ErrDuplicateName) {
+		t.Errorf("Expected %v, got %v", run.ErrDuplicateName, err)
+	}
+
+	g = run.NewGroup("names",
+		run.WithLogger(telemetry.NoopLogger()),
+		run.WithNamePolicy(run.NamesSuffix),
+	)
+	w1 := &test.Svc{SvcName: "worker", Execute: func() error {
+		return run.ErrRequestedShutdown
+	}}
+	w2 := &test.Svc{SvcName: "worker", Execute: func() error {
+		return run.ErrRequestedShutdown
+	}}
+	g.Register(w1, w2)
+	if units := g.ListUnits(); !strings.Contains(units, "worker worker#2 ") {
+		t.Errorf("Expected suffixed name, got %q", units)
+	}
+	if err := g.Run("./myService"); err != nil {
+		t.Fatalf("Expected nil, got %v", err)
+	}
+	var names []string
+	for _, timing := range g.UnitTimings() {
+		names = append(names, timing.Name)
+	}
+	sort.Strings(names)
+	if strings.Join(names, ",") != "worker,worker#2" {
+		t.Errorf("Expected timings of worker and worker#2, got %v", names)
+	}
+}
@@ -0,0 +1,293 @@
+// Copyright (c) Bas van Beek 2024.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package run
+
+import (
+	"math/rand/v2"
+	"os"
+	"time"
+
+	"github.com/spf13/pflag"
+
+	"github.com/basvanbeek/telemetry"
+
+	"github.com/basvanbeek/run/pkg/flag"
+	"github.com/basvanbeek/run/pkg/log"
+)
+
+// Option configures a Group created by NewGroup.
+type Option func(*Group)
+
+// NewGroup returns a Group with the provided name, configured by the provided
+// options. If name is empty, the binary name will be used as found at runtime.
+func NewGroup(name string, opts ...Option) *Group {
+	g := &Group{Name: name}
+	for _, opt := range opts {
+		opt(g)
+	}
+	return g
+}
+
+// WithLogger sets the Logger used by the Group.
+func WithLogger(logger telemetry.Logger) Option {
+	return func(g *Group) {
+		g.Logger = logger
+	}
+}
+
+// WithJSONLogging sets a Logger writing each log line to the stderr output of
+// the Group (see WithOutput) as a JSON object, suitable for log pipelines.
+// Group lifecycle events carry the phase, unit and item fields, while phase
+// exits add the duration and error fields.
+func WithJSONLogging() Option {
+	return func(g *Group) {
+		g.Logger = log.NewJSON(stderrWriter{g})
+	}
+}
+
+// WithHelpText sets additional help context displayed when --help is
+// requested. Occurrences of BinaryName will be replaced by the binary name.
+func WithHelpText(text string) Option {
+	return func(g *Group) {
+		g.HelpText = text
+	}
+}
+
+// WithErrorFilter sets the function used to centrally inspect and map errors
+// returned by Service and ServiceContext Units. See Group.ErrorFilter.
+func WithErrorFilter(fn func(error) error) Option {
+	return func(g *Group) {
+		g.ErrorFilter = fn
+	}
+}
+
+// WithIgnoreUnknownFlags has RunConfig collect unknown flags instead of
+// failing. See Group.IgnoreUnknownFlags.
+func WithIgnoreUnknownFlags() Option {
+	return func(g *Group) {
+		g.IgnoreUnknownFlags = true
+	}
+}
+
+// WithShutdownTimeout bounds the time Run waits for all Service and
+// ServiceContext Units to return once shutdown has been initiated. If
+// exceeded, Run returns an ErrShutdownTimeout.
+func WithShutdownTimeout(d time.Duration) Option {
+	return func(g *Group) {
+		g.shutdownTimeout = d
+	}
+}
+
+// WithDrainTimeout bounds the time Run waits for in-flight work tracked by
+// the Group's Drainer to complete before stopping the Service and
+// ServiceContext Units. It defaults to DefaultDrainTimeout.
+func WithDrainTimeout(d time.Duration) Option {
+	return func(g *Group) {
+		g.drainTimeout = d
+	}
+}
+
+// WithPanicOnAnomaly makes the Group panic on detecting an Anomaly instead of
+// only logging and recording it. This is useful in tests to surface
+// misbehaving Units.
+func WithPanicOnAnomaly() Option {
+	return func(g *Group) {
+		g.panicOnAnomaly = true
+	}
+}
+
+// WithExitOnIdle treats a nil return of the Serve or ServeContext method of
+// all Service and ServiceContext Units as completion and shuts down the Group
+// cleanly once all of them have completed. This supports batch and job style
+// binaries running several finite workers. An error returned by any of the
+// Units still shuts down the Group as usual.
+func WithExitOnIdle() Option {
+	return func(g *Group) {
+		g.exitOnIdle = true
+	}
+}
+
+// WithStaggeredStart staggers the start of the Service and ServiceContext
+// Units in batches of the provided size: the Units of batch n start after n
+// times delay plus a random duration up to jitter. This avoids thundering herd
+// effects when many pollers or consumers start hammering downstream
+// dependencies at once. A batch size below 1 starts one Unit per batch.
+func WithStaggeredStart(delay, jitter time.Duration, batch int) Option {
+	return func(g *Group) {
+		g.staggerDelay, g.staggerJitter = delay, jitter
+		g.staggerBatch = max(batch, 1)
+	}
+}
+
+// WithRandSeed seeds the random source used by the Group, e.g. for the
+// jitter of WithStaggeredStart, so its behavior is reproducible in tests. By
+// default the Group uses the randomly seeded global source.
+func WithRandSeed(seed uint64) Option {
+	return func(g *Group) {
+		g.rnd = rand.New(rand.NewPCG(seed, seed))
+	}
+}
+
+// WithPreRunStageTimeout sets a deadline for the provided PreRun stage (see
+// PreRunStager), measured from the start of the stage. The context provided to
+// the PreRunContext method of PreRunnerContext Units in the stage is cancelled
+// once the deadline expires. PreRunner Units cannot be interrupted and are not
+// affected by the deadline.
+func WithPreRunStageTimeout(stage int, d time.Duration) Option {
+	return func(g *Group) {
+		if g.preRunTimeouts == nil {
+			g.preRunTimeouts = make(map[int]time.Duration)
+		}
+		g.preRunTimeouts[stage] = d
+	}
+}
+
+// WithStartupSignals sets the signals which abort the startup of the Group
+// during its Config and PreRun phases, in which case Run returns as if a
+// shutdown was requested. It defaults to DefaultStartupSignals. Calling it
+// without signals disables startup signal handling.
+func WithStartupSignals(sigs ...os.Signal) Option {
+	return func(g *Group) {
+		g.startupSignals = append([]os.Signal{}, sigs...)
+	}
+}
+
+// WithResourceAccounting allows Group.ResourceUsage to report per Unit
+// resource usage, e.g. for capacity debugging of elegant monoliths. Goroutines
+// are attributed to Units by the pprof "unit" label set on all goroutines
+// running Unit lifecycle methods and inherited by the goroutines they start.
+func WithResourceAccounting() Option {
+	return func(g *Group) {
+		g.accounting = true
+	}
+}
+
+// WithShutdownProgressInterval sets the interval at which Run logs the
+// Service and ServiceContext Units that have not yet returned once shutdown
+// has been initiated. It defaults to DefaultShutdownProgressInterval, a
+// negative duration disables progress logging.
+func WithShutdownProgressInterval(d time.Duration) Option {
+	return func(g *Group) {
+		g.shutdownProgress = d
+	}
+}
+
+// WithFlagNormalization sets the function used to normalize flag names of all
+// registered FlagSets, e.g. to have "--my_flag" resolve to "--my-flag".
+// See pkg/flag for ready to use normalization functions.
+func WithFlagNormalization(fn flag.NormalizeFunc) Option {
+	return func(g *Group) {
+		g.normalizeFunc = fn
+	}
+}
+
+// CommonFlag identifies one of the common flags registered by Group.
+type CommonFlag int
+
+// Common flags registered by Group.
+const (
+	// NameFlag is the -n, --name flag overriding the Group name.
+	NameFlag CommonFlag = iota
+	// VersionFlag is the -v, --version flag showing version information.
+	VersionFlag
+	// HelpFlag is the -h, --help flag showing help information.
+	HelpFlag
+	// NoColorFlag is the --no-color flag disabling colored output.
+	NoColorFlag
+	// DumpConfigFlag is the --dump-config flag writing the effective
+	// configuration.
+	DumpConfigFlag
+	// DiagnosticsBundleFlag is the --diagnostics-bundle flag writing a
+	// diagnostics bundle.
+	DiagnosticsBundleFlag
+	// HelpAllFlag is the hidden flag showing all help information. It
+	// defaults to the name of HelpFlag suffixed with "-all".
+	HelpAllFlag
+	// ShowUnitsFlag is the hidden --show-rungroup-units flag listing the
+	// registered Units.
+	ShowUnitsFlag
+	// OutputFlag is the hidden --output flag setting the format of
+	// ShowUnitsFlag. It is omitted if a Unit defines a flag of the same name.
+	OutputFlag
+	// GenerateDocsFlag is the hidden --generate-docs flag writing reference
+	// docs.
+	GenerateDocsFlag
+)
+
+// commonFlags holds the default name and shorthand of the common flags.
+var commonFlags = map[CommonFlag][2]string{
+	NameFlag:              {"name", "n"},
+	VersionFlag:           {"version", "v"},
+	HelpFlag:              {"help", "h"},
+	NoColorFlag:           {"no-color", ""},
+	DumpConfigFlag:        {"dump-config", ""},
+	DiagnosticsBundleFlag: {"diagnostics-bundle", ""},
+	HelpAllFlag:           {"help-all", ""},
+	ShowUnitsFlag:         {"show-rungroup-units", ""},
+	OutputFlag:            {"output", ""},
+	GenerateDocsFlag:      {"generate-docs", ""},
+}
+
+// WithCommonFlag renames one of the common flags registered by Group. An empty
+// name disables the flag and an empty shorthand removes its shorthand. Flags
+// of registered Units clashing with a common flag will make RunConfig fail,
+// so use this option to free up names and shorthands needed by the Units.
+func WithCommonFlag(flag CommonFlag, name, shorthand string) Option {
+	return func(g *Group) {
+		if g.commonFlags == nil {
+			g.commonFlags = make(map[CommonFlag][2]string)
+		}
+		g.commonFlags[flag] = [2]string{name, shorthand}
+	}
+}
+
+// WithoutDefaultFlags omits the common flags such as -n/--name, -v/--version
+// and -h/--help, freeing them up for use by the registered Units. Use
+// WithCommonFlag to disable or rename individual common flags.
+func WithoutDefaultFlags() Option {
+	return func(g *Group) {
+		g.noDefaultFlags = true
+	}
+}
+
+// commonFlag returns the name and shorthand to use for the provided common
+// flag and if it is enabled.
+func (g *Group) commonFlag(flag CommonFlag) (name, shorthand string, enabled bool) {
+	if g.noDefaultFlags {
+		return "", "", false
+	}
+	f, ok := g.commonFlags[flag]
+	if !ok {
+		f = commonFlags[flag]
+		if flag == HelpAllFlag {
+			// follow the name of the help flag unless set explicitly
+			help, _, _ := g.commonFlag(HelpFlag)
+			f[0] = ""
+			if help != "" {
+				f[0] = help + "-all"
+			}
+		}
+	}
+	return f[0], f[1], f[0] != ""
+}
+
+// normalize normalizes the provided flag name using the configured
+// normalization function.
+func (g *Group) normalize(f *pflag.FlagSet, name string) pflag.NormalizedName {
+	if g.normalizeFunc == nil {
+		return pflag.NormalizedName(name)
+	}
+	return g.normalizeFunc(f, name)
+}
@@ -0,0 +1,356 @@
+// Copyright (c) Bas van Beek 2024.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package run_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/basvanbeek/telemetry"
+
+	"github.com/basvanbeek/run"
+	"github.com/basvanbeek/run/pkg/flag"
+	"github.com/basvanbeek/run/pkg/log"
+	"github.com/basvanbeek/run/pkg/test"
+)
+
+func TestNewGroupShutdownTimeout(t *testing.T) {
+	var (
+		g = run.NewGroup("ShutdownTimeout",
+			run.WithLogger(telemetry.NoopLogger()),
+			run.WithShutdownTimeout(10*time.Millisecond),
+		)
+		block   = make(chan struct{})
+		started = make(chan struct{})
+		irq     = make(chan error)
+	)
+	defer close(block)
+
+	g.Register(&test.Svc{
+		SvcName: "stuck",
+		Execute: func() error {
+			close(started)
+			<-block
+			return nil
+		},
+	}, &test.Svc{
+		SvcName: "irqsvc",
+		Execute: func() error {
+			<-started
+			return run.ErrRequestedShutdown
+		},
+	})
+
+	go func() { irq <- g.Run("./myService") }()
+
+	select {
+	case err := <-irq:
+		if !errors.Is(err, run.ErrShutdownTimeout) {
+			t.Errorf("Expected %v, got %v", run.ErrShutdownTimeout, err)
+		}
+	case <-time.After(100 * time.Millisecond):
+		t.Errorf("timeout")
+	}
+}
+
+// infoLogger captures the Info messages logged directly by Group.
+type infoLogger struct {
+	telemetry.Logger
+	infos chan []interface{}
+}
+
+func (l infoLogger) Info(msg string, keyValuePairs ...interface{}) {
+	select {
+	case l.infos <- append([]interface{}{msg}, keyValuePairs...):
+	default:
+	}
+}
+
+func TestNewGroupWithShutdownProgressInterval(t *testing.T) {
+	var (
+		logger = infoLogger{Logger: telemetry.NoopLogger(), infos: make(chan []interface{}, 10)}
+		g      = run.NewGroup("ShutdownProgress",
+			run.WithLogger(logger),
+			run.WithShutdownProgressInterval(5*time.Millisecond),
+		)
+		block   = make(chan struct{})
+		started = make(chan struct{})
+		irq     = make(chan error)
+	)
+
+	g.Register(&test.Svc{
+		SvcName: "stuck",
+		Execute: func() error {
+			close(started)
+			<-block
+			return nil
+		},
+	}, &test.Svc{
+		SvcName: "irqsvc",
+		Execute: func() error {
+			<-started
+			return run.ErrRequestedShutdown
+		},
+	})
+
+	go func() { irq <- g.Run("./myService") }()
+
+	timeout := time.After(100 * time.Millisecond)
+	for progress := false; !progress; {
+		select {
+		case kv := <-logger.infos:
+			if kv[0] != "shutdown-progress" {
+				continue
+			}
+			progress = true
+			if got := fmt.Sprint(kv[1:3]); got != "[draining [stuck]]" {
+				t.Errorf("Expected draining stuck unit, got %s", got)
+			}
+			if got := fmt.Sprint(kv[5:7]); got != "[reason unit irqsvc: shutdown requested]" {
+				t.Errorf("Expected shutdown reason, got %s", got)
+			}
+		case <-timeout:
+			t.Fatal("timeout waiting for shutdown progress")
+		}
+	}
+	close(block)
+
+	select {
+	case err := <-irq:
+		if err != nil {
+			t.Errorf("Expected requested shutdown, got %v", err)
+		}
+	case <-time.After(100 * time.Millisecond):
+		t.Errorf("timeout")
+	}
+}
+
+func TestNewGroupWithoutDefaultFlags(t *testing.T) {
+	var (
+		g = run.NewGroup("NoDefaultFlags",
+			run.WithLogger(telemetry.NoopLogger()),
+			run.WithoutDefaultFlags(),
+		)
+		verbose bool
+	)
+
+	fs := run.NewFlagSet("verbosity")
+	fs.BoolVarP(&verbose, "verbose", "v", false, "verbose output")
+	g.Register(configUnit{fs: fs})
+
+	if err := g.Run("./myService", "-v"); err != nil {
+		t.Fatalf("Expected proper close, got %v", err)
+	}
+	if !verbose {
+		t.Error("Expected -v to be handled by the registered unit")
+	}
+}
+
+func TestCommonFlagClash(t *testing.T) {
+	for idx, tt := range []struct {
+		opts   []run.Option
+		hasErr bool
+	}{
+		{hasErr: true},
+		{opts: []run.Option{run.WithCommonFlag(run.VersionFlag, "version", "V")}},
+		{opts: []run.Option{run.WithCommonFlag(run.VersionFlag, "", "")}},
+	} {
+		var (
+			g       = run.NewGroup("CommonFlag", append(tt.opts, run.WithLogger(telemetry.NoopLogger()))...)
+			verbose bool
+		)
+
+		fs := run.NewFlagSet("verbosity")
+		fs.BoolVarP(&verbose, "verbose", "v", false, "verbose output")
+		g.Register(configUnit{fs: fs})
+
+		err := g.Run("./myService", "-v")
+		if tt.hasErr && err == nil {
+			t.Errorf("[%d] Expected flag clash error, got nil", idx)
+		}
+		if !tt.hasErr && (err != nil || !verbose) {
+			t.Errorf("[%d] Expected -v to be handled by the registered unit, got %v", idx, err)
+		}
+	}
+}
+
+func TestCommonFlagDisabled(t *testing.T) {
+	for _, tt := range []struct {
+		flag run.CommonFlag
+		name string
+	}{
+		{run.NoColorFlag, "no-color"},
+		{run.DumpConfigFlag, "dump-config"},
+		{run.DiagnosticsBundleFlag, "diagnostics-bundle"},
+		{run.HelpAllFlag, "help-all"},
+		{run.ShowUnitsFlag, "show-rungroup-units"},
+		{run.OutputFlag, "output"},
+		{run.GenerateDocsFlag, "generate-docs"},
+	} {
+		var (
+			g = run.NewGroup("CommonFlag",
+				run.WithLogger(telemetry.NoopLogger()),
+				run.WithCommonFlag(tt.flag, "", ""),
+			)
+			value string
+		)
+
+		fs := run.NewFlagSet("unit")
+		fs.StringVar(&value, tt.name, "", "unit specific flag")
+		g.Register(configUnit{fs: fs})
+
+		if err := g.Run("./myService", "--"+tt.name+"=unit"); err != nil {
+			t.Fatalf("[%s] Expected proper close, got %v", tt.name, err)
+		}
+		if value != "unit" {
+			t.Errorf("[%s] Expected flag to be handled by the registered unit", tt.name)
+		}
+	}
+}
+
+func TestFlagNormalization(t *testing.T) {
+	for _, arg := range []string{"--my-flag=1", "--my_flag=1", "--My.Flag=1", "--old-flag=1"} {
+		var (
+			g = run.NewGroup("Normalization",
+				run.WithLogger(telemetry.NoopLogger()),
+				run.WithFlagNormalization(flag.TolerantNormalization),
+			)
+			value int
+		)
+
+		fs := run.NewFlagSet("normalization")
+		fs.IntVar(&value, "my-flag", 0, "flag with tolerant naming")
+		fs.Alias("old-flag", "my-flag")
+		g.Register(configUnit{fs: fs})
+
+		if err := g.Run("./myService", arg); err != nil {
+			t.Errorf("[%s] Expected proper close, got %v", arg, err)
+		}
+		if value != 1 {
+			t.Errorf("[%s] Expected flag value 1, got %d", arg, value)
+		}
+	}
+}
+
+type configUnit struct {
+	fs *run.FlagSet
+}
+
+func (c configUnit) Name() string          { return "config-unit" }
+func (c configUnit) FlagSet() *run.FlagSet { return c.fs }
+func (c configUnit) Validate() error       { return nil }
+
+func TestNewGroupWithJSONLogging(t *testing.T) {
+	g := run.NewGroup("json", run.WithJSONLogging())
+	if _, ok := g.Logger.(*log.JSONLogger); !ok {
+		t.Fatalf("Expected JSON logger, got %T", g.Logger)
+	}
+
+	var (
+		buf bytes.Buffer
+		e   = errors.New("serve failed")
+	)
+	// the stderr output is resolved when logging, not when setting up
+	g = run.NewGroup("json", run.WithJSONLogging(), run.WithOutput(nil, &buf))
+	g.Register(&test.Svc{
+		SvcName: "json-svc",
+		Execute: func() error { return e },
+	})
+	if err := g.Run("./myService"); !errors.Is(err, e) {
+		t.Fatalf("Expected %v, got %v", e, err)
+	}
+
+	var found bool
+	for _, line := range bytes.Split(bytes.TrimSpace(buf.Bytes()), []byte("\n")) {
+		var event map[string]interface{}
+		if err := json.Unmarshal(line, &event); err != nil {
+			t.Fatalf("Expected JSON log line, got %q: %v", line, err)
+		}
+		if event["msg"] != "serve-exit" {
+			continue
+		}
+		found = true
+		for key, want := range map[string]interface{}{
+			"level": "debug",
+			"phase": "serve",
+			"unit":  "json-svc",
+			"item":  "(1/1)",
+			"error": e.Error(),
+		} {
+			if event[key] != want {
+				t.Errorf("Expected %s %q, got %v", key, want, event[key])
+			}
+		}
+		if _, ok := event["duration"].(string); !ok {
+			t.Errorf("Expected duration, got %v", event["duration"])
+		}
+	}
+	if !found {
+		t.Errorf("Expected serve-exit event, got %s", buf.String())
+	}
+}
+
+func TestNewGroupWithStaggeredStart(t *testing.T) {
+	var (
+		delay   = 20 * time.Millisecond
+		g       = run.NewGroup("stagger", run.WithStaggeredStart(delay, 0, 2))
+		irq     = test.NewIRQService(func() {})
+		started = make(chan struct{}, 4)
+		res     = make(chan error)
+	)
+	g.Logger = telemetry.NoopLogger()
+	for i := 0; i < 4; i++ {
+		g.Register(run.NewService(fmt.Sprintf("svc-%d", i), make(chan struct{}),
+			func(quit chan struct{}) error {
+				started <- struct{}{}
+				<-quit
+				return nil
+			},
+			func(quit chan struct{}) { close(quit) },
+		))
+	}
+	g.Register(irq)
+
+	go func() { res <- g.Run("./myService") }()
+	for i := 0; i < 4; i++ {
+		select {
+		case <-started:
+		case <-time.After(time.Second):
+			t.Fatal("timeout")
+		}
+	}
+	_ = irq.Close()
+	if err := <-res; err != nil {
+		t.Fatalf("Expected nil error, got %v", err)
+	}
+
+	timings := make(map[string]run.UnitTiming)
+	for _, ut := range g.UnitTimings() {
+		timings[ut.Name] = ut
+	}
+	first := timings["svc-0"].ServeStart
+	if d := timings["svc-1"].ServeStart.Sub(first); d >= delay {
+		t.Errorf("Expected svc-1 to start in the first batch, got %s", d)
+	}
+	for _, name := range []string{"svc-2", "svc-3"} {
+		if d := timings[name].ServeStart.Sub(first); d < delay {
+			t.Errorf("Expected %s to start at least %s later, got %s", name, delay, d)
+		}
+	}
+}
@@ -0,0 +1,55 @@
+// Copyright (c) Bas van Beek 2024.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package run
+
+import (
+	"io"
+	"os"
+)
+
+// WithOutput sets the io.Writers receiving the output of the Group, such as
+// help, version and unit listings (stdout) and flag parsing errors and usage
+// (stderr). A nil io.Writer keeps the default of os.Stdout or os.Stderr.
+func WithOutput(stdout, stderr io.Writer) Option {
+	return func(g *Group) {
+		g.Stdout, g.Stderr = stdout, stderr
+	}
+}
+
+// stdout returns the io.Writer to write regular Group output to.
+func (g *Group) stdout() io.Writer {
+	if g.Stdout == nil {
+		return os.Stdout
+	}
+	return g.Stdout
+}
+
+// stderr returns the io.Writer to write Group usage and error output to.
+func (g *Group) stderr() io.Writer {
+	if g.Stderr == nil {
+		return os.Stderr
+	}
+	return g.Stderr
+}
+
+// stderrWriter writes to the stderr output of the Group as configured at the
+// time of writing, so it can be set up before the output is configured.
+type stderrWriter struct {
+	g *Group
+}
+
+func (w stderrWriter) Write(p []byte) (int, error) {
+	return w.g.stderr().Write(p)
+}
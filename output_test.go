@@ -0,0 +1,63 @@
+// Copyright (c) Bas van Beek 2024.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package run_test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/basvanbeek/telemetry"
+
+	"github.com/basvanbeek/run"
+)
+
+func TestWithOutput(t *testing.T) {
+	tests := []struct {
+		name   string
+		opts   []run.Option
+		args   []string
+		stdout string
+		stderr string
+	}{
+		{"help", nil, []string{"--help"}, "Usage of output:", ""},
+		{"version", nil, []string{"--version"}, "output ", ""},
+		{"units", nil, []string{"--show-rungroup-units"}, "config-unit", ""},
+		{"dump config", nil, []string{"--dump-config", "-"}, `"config-unit"`, ""},
+		{"usage", []run.Option{run.WithoutDefaultFlags()}, []string{"--help"}, "", "Usage of output:"},
+		{"help all renamed", []run.Option{run.WithCommonFlag(run.HelpFlag, "usage", "")}, []string{"--usage-all"}, "Usage of output:", ""},
+		{"units renamed", []run.Option{run.WithCommonFlag(run.ShowUnitsFlag, "units", "")}, []string{"--units"}, "config-unit", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var stdout, stderr bytes.Buffer
+			g := run.NewGroup("output", append(tt.opts,
+				run.WithLogger(telemetry.NoopLogger()),
+				run.WithOutput(&stdout, &stderr),
+			)...)
+			g.Register(configUnit{fs: run.NewFlagSet("output")})
+
+			_ = g.RunConfig(append([]string{"./myService"}, tt.args...)...)
+			if !strings.Contains(stdout.String(), tt.stdout) ||
+				(tt.stdout == "" && stdout.Len() > 0) {
+				t.Errorf("Expected stdout to hold %q, got %q", tt.stdout, stdout.String())
+			}
+			if !strings.Contains(stderr.String(), tt.stderr) ||
+				(tt.stderr == "" && stderr.Len() > 0) {
+				t.Errorf("Expected stderr to hold %q, got %q", tt.stderr, stderr.String())
+			}
+		})
+	}
+}
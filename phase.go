@@ -0,0 +1,131 @@
+// Copyright (c) Bas van Beek 2024.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package run
+
+import (
+	"slices"
+	"strings"
+)
+
+// Phase is a bitmask of the Group phases a Unit is registered for.
+type Phase uint
+
+// Group phases as reported by DeregisterPhases.
+const (
+	PhaseInitialize Phase = 1 << iota
+	PhaseName
+	PhaseConfig
+	PhaseArgs
+	PhaseFlagSource
+	PhaseConfigCommand
+	PhaseReload
+	PhasePreflight
+	PhasePreRun
+	PhaseServe
+	PhaseServeContext
+	PhasePostRun
+	PhaseIntercept
+	PhaseTask
+)
+
+var phaseNames = []struct {
+	phase Phase
+	name  string
+}{
+	{PhaseInitialize, "Initialize"},
+	{PhaseName, "Name"},
+	{PhaseConfig, "Config"},
+	{PhaseArgs, "Args"},
+	{PhaseFlagSource, "FlagSource"},
+	{PhaseConfigCommand, "ConfigCommand"},
+	{PhaseReload, "Reload"},
+	{PhasePreflight, "Preflight"},
+	{PhasePreRun, "PreRun"},
+	{PhaseServe, "Serve"},
+	{PhaseServeContext, "ServeContext"},
+	{PhasePostRun, "PostRun"},
+	{PhaseIntercept, "Intercept"},
+	{PhaseTask, "Task"},
+}
+
+// String implements fmt.Stringer.
+func (p Phase) String() string {
+	var names []string
+	for _, n := range phaseNames {
+		if p&n.phase != 0 {
+			names = append(names, n.name)
+		}
+	}
+	if len(names) == 0 {
+		return "none"
+	}
+	return strings.Join(names, "|")
+}
+
+// deregister removes the provided Unit from the provided phase slice. Unless
+// remove is set, its slot is set to nil instead of being removed, as the phase
+// loops of a configured Group address Units by index.
+func deregister[T comparable](phase *[]T, u Unit, remove bool) bool {
+	var (
+		zero  T
+		found bool
+	)
+	for i := range *phase {
+		if (*phase)[i] != zero && any((*phase)[i]) == any(u) {
+			(*phase)[i] = zero
+			found = true
+		}
+	}
+	if found && remove {
+		*phase = compact(*phase)
+	}
+	return found
+}
+
+// units returns the distinct registered Units in order of phase and
+// registration. It must be called while holding the Group lock.
+func (g *Group) units() []Unit {
+	var units []Unit
+	units = appendUnits(units, g.i)
+	units = appendUnits(units, g.n)
+	units = appendUnits(units, g.c)
+	units = appendUnits(units, g.a)
+	units = appendUnits(units, g.v)
+	units = appendUnits(units, g.t)
+	units = appendUnits(units, g.k)
+	units = appendUnits(units, g.r)
+	units = appendUnits(units, g.q)
+	units = appendUnits(units, g.p)
+	units = appendUnits(units, g.j)
+	units = appendUnits(units, g.s)
+	units = appendUnits(units, g.x)
+	return appendUnits(units, g.z)
+}
+
+// appendUnits appends the Units of the provided phase slice which are not yet
+// part of units.
+func appendUnits[T any](units []Unit, phase []T) []Unit {
+	for _, p := range phase {
+		// a de-registered slot holds nil
+		u, ok := any(p).(Unit)
+		if !ok {
+			continue
+		}
+		if !slices.ContainsFunc(units, func(v Unit) bool { return sameUnit(v, u) }) {
+			units = append(units, u)
+		}
+	}
+	return units
+}
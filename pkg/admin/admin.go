@@ -0,0 +1,243 @@
+// Copyright (c) Bas van Beek 2024.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package admin implements a run.Group unit accepting simple administrative
+// commands on a unix domain socket. It offers an alternative to signals in
+// environments where sending them is awkward, such as distroless containers
+// without a shell or Windows.
+package admin
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/basvanbeek/run"
+)
+
+// Commands understood by Socket.
+const (
+	// CommandStop initiates a graceful shutdown of the Group.
+	CommandStop = "stop"
+	// CommandReload calls the ReloadCallback.
+	CommandReload = "reload"
+	// CommandStatus responds with the Status of the Group as JSON.
+	CommandStatus = "status"
+)
+
+// Responses of Socket, optionally followed by details.
+const (
+	ResponseOK    = "ok"
+	ResponseError = "error"
+)
+
+// connTimeout bounds the handling of a single admin connection.
+const connTimeout = 5 * time.Second
+
+// Socket implements run.Config, run.Requirer, run.PreRunner, run.PostRunner
+// and run.ServiceContext. It listens on the unix socket configured with
+// --admin-socket for a single command per connection and responds with a
+// single line. The socket is only accessible by the owner of the process.
+// Use Send to issue commands from CLI tooling.
+type Socket struct {
+	// Group is optional and used to respond to the status command.
+	Group *run.Group
+	// ReloadCallback is called on the reload command, typically
+	// run.Group.Reload. A returned error is reported to the client and does
+	// not stop the Group.
+	ReloadCallback func() error
+
+	path string
+
+	mu  sync.Mutex
+	lis net.Listener
+}
+
+// Name implements run.Unit.
+func (s *Socket) Name() string {
+	return "admin-socket"
+}
+
+// FlagSet implements run.Config.
+func (s *Socket) FlagSet() *run.FlagSet {
+	flags := run.NewFlagSet("Admin socket options")
+	flags.StringVar(&s.path, "admin-socket", "",
+		"path of the unix socket accepting stop, reload and status commands,\n"+
+			"disabled if empty")
+	return flags
+}
+
+// Validate implements run.Config.
+func (s *Socket) Validate() error {
+	return nil
+}
+
+// Requirements implements run.Requirer.
+func (s *Socket) Requirements() []run.Requirement {
+	if s.path == "" {
+		return nil
+	}
+	return []run.Requirement{run.RequireUnixSocket(s.path)}
+}
+
+// PreRun implements run.PreRunner and starts listening on the socket.
+func (s *Socket) PreRun() error {
+	if s.path == "" {
+		return nil
+	}
+	// a stale socket file has been found unused by the pre-flight check
+	_ = os.Remove(s.path)
+	lis, err := net.Listen("unix", s.path)
+	if err != nil {
+		return fmt.Errorf("admin socket %s: %w", s.path, err)
+	}
+	if err = os.Chmod(s.path, 0o600); err != nil {
+		_ = lis.Close()
+		return fmt.Errorf("admin socket %s: %w", s.path, err)
+	}
+	s.mu.Lock()
+	s.lis = lis
+	s.mu.Unlock()
+	return nil
+}
+
+// PostRun implements run.PostRunner and removes the socket.
+func (s *Socket) PostRun() error {
+	s.close()
+	return nil
+}
+
+// ServeContext implements run.ServiceContext and handles incoming commands.
+// The stop command returns an error wrapping run.ErrRequestedShutdown.
+func (s *Socket) ServeContext(ctx context.Context) error {
+	s.mu.Lock()
+	lis := s.lis
+	s.mu.Unlock()
+	if lis == nil {
+		// admin socket disabled
+		<-ctx.Done()
+		return nil
+	}
+
+	stop := make(chan struct{})
+	go func() {
+		select {
+		case <-ctx.Done():
+			s.close()
+		case <-stop:
+		}
+	}()
+	defer close(stop)
+
+	for {
+		conn, err := lis.Accept()
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return fmt.Errorf("admin socket %s: %w", s.path, err)
+		}
+		if cmd := s.handle(conn); cmd == CommandStop {
+			return fmt.Errorf("admin %s %w", cmd, run.ErrRequestedShutdown)
+		}
+	}
+}
+
+// handle reads a single command from conn, writes the response and returns
+// the command.
+func (s *Socket) handle(conn net.Conn) string {
+	defer func() { _ = conn.Close() }()
+	_ = conn.SetDeadline(time.Now().Add(connTimeout))
+
+	line, err := bufio.NewReader(conn).ReadString('\n')
+	if err != nil && line == "" {
+		return ""
+	}
+	cmd := strings.TrimSpace(line)
+	var resp string
+	switch cmd {
+	case CommandStop:
+		resp = ResponseOK
+	case CommandReload:
+		resp = ResponseOK
+		if s.ReloadCallback == nil {
+			resp = ResponseError + " reload not supported"
+		} else if err = s.ReloadCallback(); err != nil {
+			resp = ResponseError + " " + strings.ReplaceAll(err.Error(), "\n", "; ")
+		}
+	case CommandStatus:
+		resp = ResponseOK
+		if s.Group != nil {
+			b, _ := json.Marshal(s.Group.Status())
+			resp = string(b)
+		}
+	default:
+		resp = fmt.Sprintf("%s unknown command %q", ResponseError, cmd)
+	}
+	_, _ = io.WriteString(conn, resp+"\n")
+	return cmd
+}
+
+// close stops listening and removes the socket.
+func (s *Socket) close() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.lis != nil {
+		_ = s.lis.Close()
+		s.lis = nil
+	}
+}
+
+// Path returns the path of the admin socket.
+func (s *Socket) Path() string {
+	return s.path
+}
+
+// Send issues the provided command to the admin socket at path and returns
+// the response line. A response reporting an error is returned as error.
+func Send(path, command string) (string, error) {
+	conn, err := net.DialTimeout("unix", path, connTimeout)
+	if err != nil {
+		return "", err
+	}
+	defer func() { _ = conn.Close() }()
+	_ = conn.SetDeadline(time.Now().Add(connTimeout))
+	if _, err = io.WriteString(conn, command+"\n"); err != nil {
+		return "", err
+	}
+	line, err := bufio.NewReader(conn).ReadString('\n')
+	if err != nil && line == "" {
+		return "", err
+	}
+	line = strings.TrimSpace(line)
+	if msg, ok := strings.CutPrefix(line, ResponseError+" "); ok {
+		return "", fmt.Errorf("admin %s: %s", command, msg)
+	}
+	return line, nil
+}
+
+var (
+	_ run.Config         = (*Socket)(nil)
+	_ run.Requirer       = (*Socket)(nil)
+	_ run.PreRunner      = (*Socket)(nil)
+	_ run.PostRunner     = (*Socket)(nil)
+	_ run.ServiceContext = (*Socket)(nil)
+)
@@ -0,0 +1,96 @@
+// Copyright (c) Bas van Beek 2024.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package admin_test
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/basvanbeek/telemetry"
+
+	"github.com/basvanbeek/run"
+	"github.com/basvanbeek/run/pkg/admin"
+	"github.com/basvanbeek/run/pkg/test"
+)
+
+func TestSocket(t *testing.T) {
+	// keep the socket path short, unix socket paths are limited in length
+	dir, err := os.MkdirTemp("", "admin")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = os.RemoveAll(dir) }()
+
+	var (
+		path    = filepath.Join(dir, "admin.sock")
+		reloads atomic.Int32
+		g       = &run.Group{Name: "admin", Logger: telemetry.NoopLogger()}
+		irq     = make(chan error)
+	)
+	g.Register(
+		&admin.Socket{Group: g, ReloadCallback: func() error {
+			if reloads.Add(1) > 1 {
+				return errors.New("bad config")
+			}
+			return nil
+		}},
+		test.NewIRQService(func() {}),
+	)
+
+	go func() { irq <- g.Run("./myService", "--admin-socket", path) }()
+
+	var resp string
+	for start := time.Now(); time.Since(start) < time.Second; time.Sleep(5 * time.Millisecond) {
+		if resp, err = admin.Send(path, admin.CommandStatus); err == nil {
+			break
+		}
+	}
+	if err != nil || !strings.Contains(resp, `"name":"admin"`) {
+		t.Fatalf("Expected status, got %q: %v", resp, err)
+	}
+	if fi, err := os.Stat(path); err != nil || fi.Mode().Perm() != 0o600 {
+		t.Errorf("Expected socket only accessible by its owner, got %v", err)
+	}
+
+	if resp, err = admin.Send(path, admin.CommandReload); err != nil || resp != admin.ResponseOK {
+		t.Errorf("Expected reload to succeed, got %q: %v", resp, err)
+	}
+	if _, err = admin.Send(path, admin.CommandReload); err == nil || !strings.Contains(err.Error(), "bad config") {
+		t.Errorf("Expected reload error, got %v", err)
+	}
+	if _, err = admin.Send(path, "dance"); err == nil {
+		t.Error("Expected unknown command error, got nil")
+	}
+
+	if resp, err = admin.Send(path, admin.CommandStop); err != nil || resp != admin.ResponseOK {
+		t.Errorf("Expected stop to succeed, got %q: %v", resp, err)
+	}
+	select {
+	case err = <-irq:
+		if err != nil {
+			t.Errorf("Expected clean shutdown, got %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("timeout")
+	}
+	if _, err = os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("Expected socket to be removed, got %v", err)
+	}
+}
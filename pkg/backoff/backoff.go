@@ -0,0 +1,146 @@
+// Copyright (c) Bas van Beek 2024.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package backoff provides a small exponential backoff implementation used to
+// retry operations such as waiting for a dependency to become reachable.
+package backoff
+
+import (
+	"context"
+	"errors"
+	"math/rand/v2"
+	"time"
+)
+
+// Default values used for zero value Config fields.
+const (
+	DefaultInitialInterval = 100 * time.Millisecond
+	DefaultMultiplier      = 2.0
+)
+
+// ErrMaxElapsedTime is returned when retrying stopped because the maximum
+// elapsed time was reached.
+var ErrMaxElapsedTime = errors.New("max elapsed time reached")
+
+// ErrMaxAttempts is returned when retrying stopped because the maximum amount
+// of attempts was reached.
+var ErrMaxAttempts = errors.New("max attempts reached")
+
+// Config holds the exponential backoff settings. The zero value retries
+// indefinitely, starting with DefaultInitialInterval and doubling the delay
+// after each failed attempt.
+type Config struct {
+	// InitialInterval is the delay after the first failed attempt.
+	InitialInterval time.Duration
+	// MaxInterval caps the delay between attempts. 0 means no cap.
+	MaxInterval time.Duration
+	// Multiplier is applied to the delay after each failed attempt.
+	Multiplier float64
+	// MaxElapsedTime stops retrying once exceeded. 0 means no limit.
+	MaxElapsedTime time.Duration
+	// MaxAttempts stops retrying after this amount of attempts. 0 means no
+	// limit.
+	MaxAttempts int
+	// Jitter randomizes each delay by up to the provided fraction of the delay
+	// in either direction, e.g. 0.1 for +/- 10%. 0 disables jitter.
+	Jitter float64
+	// Rand is the source of the jitter. Defaults to the global source. Set a
+	// seeded source for reproducible delays in tests; as rand.Rand is not safe
+	// for concurrent use, it must not be shared between concurrent retries.
+	Rand *rand.Rand
+	// Clock measures the elapsed time and waits between attempts. Defaults to
+	// the time package. Set a fake Clock to test retries without sleeping.
+	Clock Clock
+}
+
+// Clock provides the time for Retry. It is satisfied by run.Clock, allowing
+// the Clock of a run.Group to be used.
+type Clock interface {
+	// Now returns the current time.
+	Now() time.Time
+	// After waits for the duration to elapse and then sends the current time
+	// on the returned channel.
+	After(d time.Duration) <-chan time.Time
+}
+
+// NotifyFunc is called after each failed attempt with the attempt number, the
+// error returned by the attempt and the delay until the next attempt.
+type NotifyFunc func(attempt int, err error, next time.Duration)
+
+// Retry calls fn until it succeeds, the context is canceled or the limits set
+// in Config are reached. If retrying stops, the last error returned by fn is
+// joined with the reason for stopping. Notify is optional.
+func (c Config) Retry(ctx context.Context, fn func() error, notify NotifyFunc) error {
+	var (
+		clock = c.clock()
+		start = clock.Now()
+		delay = c.InitialInterval
+		mult  = c.Multiplier
+	)
+	if delay <= 0 {
+		delay = DefaultInitialInterval
+	}
+	if mult < 1 {
+		mult = DefaultMultiplier
+	}
+	for attempt := 1; ; attempt++ {
+		err := fn()
+		if err == nil {
+			return nil
+		}
+		if c.MaxAttempts > 0 && attempt >= c.MaxAttempts {
+			return errors.Join(err, ErrMaxAttempts)
+		}
+		if c.MaxElapsedTime > 0 && clock.Now().Sub(start)+delay > c.MaxElapsedTime {
+			return errors.Join(err, ErrMaxElapsedTime)
+		}
+		next := c.jitter(delay)
+		if notify != nil {
+			notify(attempt, err, next)
+		}
+		select {
+		case <-clock.After(next):
+		case <-ctx.Done():
+			return errors.Join(err, ctx.Err())
+		}
+		if delay = time.Duration(float64(delay) * mult); c.MaxInterval > 0 && delay > c.MaxInterval {
+			delay = c.MaxInterval
+		}
+	}
+}
+
+// clock returns the configured Clock or the time package based one.
+func (c Config) clock() Clock {
+	if c.Clock == nil {
+		return systemClock{}
+	}
+	return c.Clock
+}
+
+type systemClock struct{}
+
+func (systemClock) Now() time.Time                         { return time.Now() }
+func (systemClock) After(d time.Duration) <-chan time.Time { return time.After(d) }
+
+// jitter returns the provided delay randomized as configured by Jitter.
+func (c Config) jitter(d time.Duration) time.Duration {
+	if c.Jitter <= 0 {
+		return d
+	}
+	f := rand.Float64
+	if c.Rand != nil {
+		f = c.Rand.Float64
+	}
+	return time.Duration(float64(d) * (1 + c.Jitter*(2*f()-1)))
+}
@@ -0,0 +1,147 @@
+// Copyright (c) Bas van Beek 2024.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package backoff
+
+import (
+	"context"
+	"errors"
+	"math/rand/v2"
+	"testing"
+	"time"
+)
+
+var errDown = errors.New("down")
+
+func TestRetry(t *testing.T) {
+	var (
+		calls  int
+		delays []time.Duration
+		cfg    = Config{
+			InitialInterval: time.Millisecond,
+			MaxInterval:     3 * time.Millisecond,
+		}
+	)
+	err := cfg.Retry(context.Background(), func() error {
+		if calls++; calls < 4 {
+			return errDown
+		}
+		return nil
+	}, func(_ int, _ error, next time.Duration) {
+		delays = append(delays, next)
+	})
+	if err != nil {
+		t.Fatalf("Expected success, got %v", err)
+	}
+	want := []time.Duration{time.Millisecond, 2 * time.Millisecond, 3 * time.Millisecond}
+	if len(delays) != len(want) {
+		t.Fatalf("Expected delays %v, got %v", want, delays)
+	}
+	for i := range want {
+		if delays[i] != want[i] {
+			t.Errorf("Expected delays %v, got %v", want, delays)
+		}
+	}
+}
+
+func TestRetryLimits(t *testing.T) {
+	fail := func() error { return errDown }
+
+	err := Config{InitialInterval: time.Millisecond, MaxAttempts: 3}.
+		Retry(context.Background(), fail, nil)
+	if !errors.Is(err, ErrMaxAttempts) || !errors.Is(err, errDown) {
+		t.Errorf("Expected %v and %v, got %v", ErrMaxAttempts, errDown, err)
+	}
+
+	err = Config{InitialInterval: 5 * time.Millisecond, MaxElapsedTime: 12 * time.Millisecond}.
+		Retry(context.Background(), fail, nil)
+	if !errors.Is(err, ErrMaxElapsedTime) {
+		t.Errorf("Expected %v, got %v", ErrMaxElapsedTime, err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	err = Config{}.Retry(ctx, fail, nil)
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected %v, got %v", context.Canceled, err)
+	}
+}
+
+func TestRetryJitter(t *testing.T) {
+	delays := func(seed uint64) []time.Duration {
+		var (
+			res []time.Duration
+			cfg = Config{
+				InitialInterval: time.Millisecond,
+				MaxAttempts:     4,
+				Jitter:          0.5,
+				Rand:            rand.New(rand.NewPCG(seed, seed)),
+			}
+		)
+		_ = cfg.Retry(context.Background(), func() error { return errDown },
+			func(_ int, _ error, next time.Duration) { res = append(res, next) })
+		return res
+	}
+	first, second := delays(42), delays(42)
+	if len(first) != 3 {
+		t.Fatalf("Expected 3 delays, got %v", first)
+	}
+	base := time.Millisecond
+	for i := range first {
+		if first[i] != second[i] {
+			t.Errorf("Expected reproducible delays, got %v and %v", first, second)
+		}
+		if first[i] < base/2 || first[i] > base*3/2 {
+			t.Errorf("Expected delay %d within 50%% of %s, got %s", i, base, first[i])
+		}
+		base *= 2
+	}
+}
+
+// stepClock advances its time by the requested delay instead of sleeping.
+type stepClock struct {
+	now time.Time
+}
+
+func (c *stepClock) Now() time.Time {
+	return c.now
+}
+
+func (c *stepClock) After(d time.Duration) <-chan time.Time {
+	c.now = c.now.Add(d)
+	ch := make(chan time.Time, 1)
+	ch <- c.now
+	return ch
+}
+
+func TestRetryClock(t *testing.T) {
+	var (
+		calls int
+		start = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+		clock = &stepClock{now: start}
+		cfg   = Config{
+			InitialInterval: 10 * time.Second,
+			MaxElapsedTime:  time.Minute,
+			Clock:           clock,
+		}
+	)
+	err := cfg.Retry(context.Background(), func() error { calls++; return errDown }, nil)
+	if !errors.Is(err, ErrMaxElapsedTime) {
+		t.Errorf("Expected %v, got %v", ErrMaxElapsedTime, err)
+	}
+	// waited 10s and 20s, the next delay of 40s exceeds the max elapsed time
+	if d := clock.Now().Sub(start); calls != 3 || d != 30*time.Second {
+		t.Errorf("Expected 3 attempts within 30s, got %d within %s", calls, d)
+	}
+}
@@ -0,0 +1,181 @@
+// Copyright (c) Bas van Beek 2024.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package sqldb implements a run.Group unit managing the lifecycle of a
+// database/sql connection pool.
+package sqldb
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/basvanbeek/run"
+	"github.com/basvanbeek/run/pkg/flag"
+)
+
+// DB implements run.Config, run.PreRunner and run.PostRunner to manage a
+// *sql.DB. The connection pool is opened and verified during PreRun, retrying
+// with exponential backoff until the database is reachable, and closed during
+// PostRun once all services have stopped.
+// The database driver needs to be registered by importing it.
+type DB struct {
+	// Prefix is used for the flag names, allowing multiple DB units in a
+	// single Group. Defaults to "db".
+	Prefix string
+	// Driver holds the default database/sql driver name.
+	Driver string
+
+	dsn             string
+	maxOpenConns    int
+	maxIdleConns    int
+	connMaxLifetime time.Duration
+	connectTimeout  time.Duration
+	connectBackoff  time.Duration
+
+	db *sql.DB
+}
+
+// Name implements run.Unit.
+func (d *DB) Name() string {
+	return d.prefix() + "-sqldb"
+}
+
+// FlagSet implements run.Config.
+func (d *DB) FlagSet() *run.FlagSet {
+	p := d.prefix()
+	flags := run.NewFlagSet("SQL database options (" + p + ")")
+	flags.StringVar(&d.Driver, p+"-driver", d.Driver, "database/sql driver name")
+	flags.SensitiveStringVar(&d.dsn, p+"-dsn", "", "data source name")
+	flags.IntVar(&d.maxOpenConns, p+"-max-open-conns", 0,
+		"maximum number of open connections, 0 is unlimited")
+	flags.IntVar(&d.maxIdleConns, p+"-max-idle-conns", 2,
+		"maximum number of idle connections")
+	flags.DurationVar(&d.connMaxLifetime, p+"-conn-max-lifetime", 0,
+		"maximum amount of time a connection may be reused, 0 is unlimited")
+	flags.DurationVar(&d.connectTimeout, p+"-connect-timeout", 30*time.Second,
+		"maximum amount of time to wait for the database to become reachable")
+	flags.DurationVar(&d.connectBackoff, p+"-connect-backoff", 500*time.Millisecond,
+		"initial delay between connection attempts")
+	return flags
+}
+
+// Validate implements run.Config.
+func (d *DB) Validate() error {
+	p := d.prefix()
+	if d.Driver == "" {
+		return flag.NewValidationError(p+"-driver", flag.ErrRequired)
+	}
+	if d.dsn == "" {
+		return flag.NewValidationError(p+"-dsn", flag.ErrRequired)
+	}
+	if d.maxOpenConns < 0 {
+		return flag.NewValidationError(p+"-max-open-conns", flag.ErrInvalidVal)
+	}
+	if d.connectBackoff <= 0 {
+		return flag.NewValidationError(p+"-connect-backoff", flag.ErrInvalidVal)
+	}
+	return nil
+}
+
+// PreRun implements run.PreRunner. It opens the connection pool and pings
+// the database until it is reachable or the connect timeout expires.
+// If the connection pool has already been opened by Open, PreRun is a no-op.
+func (d *DB) PreRun() error {
+	if d.db != nil {
+		return nil
+	}
+	db, err := sql.Open(d.Driver, d.dsn)
+	if err != nil {
+		return err
+	}
+	db.SetMaxOpenConns(d.maxOpenConns)
+	db.SetMaxIdleConns(d.maxIdleConns)
+	db.SetConnMaxLifetime(d.connMaxLifetime)
+
+	ctx, cancel := context.WithTimeout(context.Background(), d.connectTimeout)
+	defer cancel()
+
+	backoff := d.connectBackoff
+	for {
+		if err = db.PingContext(ctx); err == nil {
+			d.db = db
+			return nil
+		}
+		select {
+		case <-ctx.Done():
+			_ = db.Close()
+			return fmt.Errorf("database unreachable: %w", err)
+		case <-time.After(backoff):
+		}
+		if backoff *= 2; backoff > 10*time.Second {
+			backoff = 10 * time.Second
+		}
+	}
+}
+
+// PostRun implements run.PostRunner and closes the connection pool.
+func (d *DB) PostRun() error {
+	if d.db == nil {
+		return nil
+	}
+	db := d.db
+	d.db = nil
+	return db.Close()
+}
+
+// Open returns the managed connection pool, connecting to the database first
+// if this has not been done by PreRun yet. This allows the connection pool to
+// be used before the PreRun phase, e.g. by a run.ConfigCommand.
+func (d *DB) Open() (*sql.DB, error) {
+	if err := d.PreRun(); err != nil {
+		return nil, err
+	}
+	return d.db, nil
+}
+
+// DB returns the managed connection pool. It is available after PreRun.
+func (d *DB) DB() *sql.DB {
+	return d.db
+}
+
+// Check reports the health of the database connection by pinging it.
+func (d *DB) Check(ctx context.Context) error {
+	if d.db == nil {
+		return fmt.Errorf("%s: not connected", d.Name())
+	}
+	return d.db.PingContext(ctx)
+}
+
+// Stats returns the connection pool statistics.
+func (d *DB) Stats() sql.DBStats {
+	if d.db == nil {
+		return sql.DBStats{}
+	}
+	return d.db.Stats()
+}
+
+func (d *DB) prefix() string {
+	if d.Prefix == "" {
+		return "db"
+	}
+	return d.Prefix
+}
+
+var (
+	_ run.Config     = (*DB)(nil)
+	_ run.PreRunner  = (*DB)(nil)
+	_ run.PostRunner = (*DB)(nil)
+)
@@ -0,0 +1,70 @@
+// Copyright (c) Bas van Beek 2024.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sqldb
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"sync/atomic"
+	"testing"
+
+	"github.com/basvanbeek/telemetry"
+
+	"github.com/basvanbeek/run"
+)
+
+// fakeDriver becomes reachable after a number of failed pings.
+type fakeDriver struct {
+	failures int32
+}
+
+func (f *fakeDriver) Open(string) (driver.Conn, error) { return &fakeConn{d: f}, nil }
+
+type fakeConn struct {
+	d *fakeDriver
+}
+
+func (c *fakeConn) Prepare(string) (driver.Stmt, error) { return nil, errors.New("not supported") }
+func (c *fakeConn) Begin() (driver.Tx, error)           { return nil, errors.New("not supported") }
+func (c *fakeConn) Close() error                        { return nil }
+
+func (c *fakeConn) Ping(context.Context) error {
+	if atomic.AddInt32(&c.d.failures, -1) >= 0 {
+		return driver.ErrBadConn
+	}
+	return nil
+}
+
+func TestDBLifecycle(t *testing.T) {
+	fd := &fakeDriver{failures: 2}
+	sql.Register("fake", fd)
+
+	var (
+		g  = run.Group{Name: "sqldb", Logger: telemetry.NoopLogger()}
+		db = DB{Driver: "fake"}
+	)
+	g.Register(&db, run.NewPreRunner("check", func() error {
+		return db.Check(context.Background())
+	}))
+
+	if err := g.Run("--db-dsn", "fake://", "--db-connect-backoff", "1ms"); err != nil {
+		t.Fatalf("Expected proper close, got %v", err)
+	}
+	if err := db.Check(context.Background()); err == nil {
+		t.Error("Expected closed database after PostRun")
+	}
+}
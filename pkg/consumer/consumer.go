@@ -0,0 +1,237 @@
+// Copyright (c) Bas van Beek 2024.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package consumer implements a run.ServiceContext managing the lifecycle of a
+// message queue consumer with pluggable transports, e.g. Kafka or NATS.
+package consumer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/basvanbeek/run"
+	"github.com/basvanbeek/run/pkg/flag"
+)
+
+// ErrDrainTimeout is returned if in-flight messages were not handled within
+// the drain timeout on shutdown.
+const ErrDrainTimeout run.Error = "drain timeout exceeded"
+
+// Transport abstracts the message queue client used by Consumer. M is the
+// transport specific message type.
+type Transport[M any] interface {
+	// Connect establishes the connection to the message queue.
+	Connect(ctx context.Context) error
+	// Subscribe subscribes to the provided topics.
+	Subscribe(ctx context.Context, topics []string) error
+	// Receive blocks until a message is available or the provided context is
+	// canceled.
+	Receive(ctx context.Context) (M, error)
+	// Commit acknowledges the successful handling of the provided message.
+	// With a concurrency above 1, messages can be committed out of order.
+	Commit(ctx context.Context, msg M) error
+	// Close closes the connection. It is called once all in-flight messages
+	// have been drained.
+	Close() error
+}
+
+// Handler handles a single message. The provided context is canceled if the
+// message could not be handled within the drain timeout on shutdown.
+type Handler[M any] func(ctx context.Context, msg M) error
+
+// Consumer implements run.Config and run.ServiceContext to consume messages
+// using the provided Transport. Messages are handled by Handler with a bounded
+// concurrency and committed after successful handling. On shutdown no new
+// messages are received and in-flight messages are drained before the
+// Transport is closed.
+type Consumer[M any] struct {
+	// Prefix is used for the flag names and Unit name, allowing multiple
+	// consumers in a single Group. Defaults to "consumer".
+	Prefix string
+	// Transport holds the message queue client.
+	Transport Transport[M]
+	// Handler is called for each received message.
+	Handler Handler[M]
+	// Topics holds the topics to subscribe to. Topics provided by flag are
+	// added to these.
+	Topics []string
+	// OnError is optional and called if Handler or Commit returns an error.
+	// Returning nil skips the message and continues consuming, returning an
+	// error stops the Consumer. By default the Consumer stops on the first
+	// error.
+	OnError func(msg M, err error) error
+	// Clock is optional and used for the drain timeout. Defaults to
+	// run.SystemClock.
+	Clock run.Clock
+
+	flagTopics   []string
+	concurrency  int
+	drainTimeout time.Duration
+}
+
+// Name implements run.Unit.
+func (c *Consumer[M]) Name() string {
+	return c.prefix()
+}
+
+// FlagSet implements run.Config.
+func (c *Consumer[M]) FlagSet() *run.FlagSet {
+	p := c.prefix()
+	flags := run.NewFlagSet("Message consumer options (" + p + ")")
+	flags.StringSliceVar(&c.flagTopics, p+"-topic", nil,
+		"topic to subscribe to (repeatable)")
+	flags.IntVar(&c.concurrency, p+"-concurrency", 1,
+		"maximum number of messages handled concurrently")
+	flags.DurationVar(&c.drainTimeout, p+"-drain-timeout", 30*time.Second,
+		"maximum amount of time to wait for in-flight messages on shutdown")
+	return flags
+}
+
+// Validate implements run.Config.
+func (c *Consumer[M]) Validate() error {
+	p := c.prefix()
+	if c.Transport == nil || c.Handler == nil {
+		return fmt.Errorf("%s: transport and handler are required", c.Name())
+	}
+	if c.concurrency < 1 {
+		return flag.NewValidationError(p+"-concurrency", flag.ErrInvalidVal)
+	}
+	if c.drainTimeout <= 0 {
+		return flag.NewValidationError(p+"-drain-timeout", flag.ErrInvalidVal)
+	}
+	c.Topics = append(c.Topics, c.flagTopics...)
+	if len(c.Topics) == 0 {
+		return flag.NewValidationError(p+"-topic", flag.ErrRequired)
+	}
+	return nil
+}
+
+// ServeContext implements run.ServiceContext.
+func (c *Consumer[M]) ServeContext(ctx context.Context) (err error) {
+	if err = c.Transport.Connect(ctx); err != nil {
+		return fmt.Errorf("connect: %w", err)
+	}
+	// in-flight messages are handled with a context which is only canceled
+	// once the drain timeout has been exceeded
+	hCtx, hCancel := context.WithCancel(context.WithoutCancel(ctx))
+	defer hCancel()
+
+	var (
+		wg  sync.WaitGroup
+		sem = make(chan struct{}, c.concurrency)
+		// rCtx is canceled on shutdown or when handling a message failed
+		rCtx, rCancel = context.WithCancelCause(ctx)
+	)
+	defer rCancel(nil)
+	defer func() {
+		if dErr := c.drain(&wg, hCancel); dErr != nil {
+			err = errors.Join(err, dErr)
+		}
+		if cErr := c.Transport.Close(); cErr != nil {
+			err = errors.Join(err, fmt.Errorf("close: %w", cErr))
+		}
+	}()
+
+	if err = c.Transport.Subscribe(ctx, c.Topics); err != nil {
+		return fmt.Errorf("subscribe: %w", err)
+	}
+
+	for {
+		select {
+		case sem <- struct{}{}:
+		case <-rCtx.Done():
+			return stopped(ctx, rCtx)
+		}
+		msg, rErr := c.Transport.Receive(rCtx)
+		if rErr != nil {
+			<-sem
+			if rCtx.Err() != nil {
+				return stopped(ctx, rCtx)
+			}
+			return fmt.Errorf("receive: %w", rErr)
+		}
+		wg.Add(1)
+		go func() {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+			if hErr := c.handle(hCtx, msg); hErr != nil {
+				rCancel(hErr)
+			}
+		}()
+	}
+}
+
+// stopped returns nil if the Consumer was requested to stop and the failure
+// which canceled the receive context otherwise.
+func stopped(ctx, rCtx context.Context) error {
+	if ctx.Err() != nil {
+		return nil
+	}
+	return context.Cause(rCtx)
+}
+
+// handle handles and commits the provided message.
+func (c *Consumer[M]) handle(ctx context.Context, msg M) error {
+	err := c.Handler(ctx, msg)
+	if err == nil {
+		if err = c.Transport.Commit(ctx, msg); err != nil {
+			err = fmt.Errorf("commit: %w", err)
+		}
+	}
+	if err != nil && c.OnError != nil {
+		err = c.OnError(msg, err)
+	}
+	return err
+}
+
+// drain waits for in-flight messages to be handled, canceling their context
+// once the drain timeout has been exceeded.
+func (c *Consumer[M]) drain(wg *sync.WaitGroup, cancel context.CancelFunc) error {
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+	clock := c.Clock
+	if clock == nil {
+		clock = run.SystemClock
+	}
+	timer := clock.NewTimer(c.drainTimeout)
+	defer timer.Stop()
+	select {
+	case <-done:
+		return nil
+	case <-timer.C():
+		cancel()
+		<-done
+		return fmt.Errorf("%w after %s", ErrDrainTimeout, c.drainTimeout)
+	}
+}
+
+func (c *Consumer[M]) prefix() string {
+	if c.Prefix == "" {
+		return "consumer"
+	}
+	return c.Prefix
+}
+
+var (
+	_ run.Config         = (*Consumer[any])(nil)
+	_ run.ServiceContext = (*Consumer[any])(nil)
+)
@@ -0,0 +1,199 @@
+// Copyright (c) Bas van Beek 2024.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package consumer
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/basvanbeek/telemetry"
+
+	"github.com/basvanbeek/run"
+	"github.com/basvanbeek/run/pkg/test"
+)
+
+// chanTransport delivers messages from a channel.
+type chanTransport struct {
+	msgs   chan string
+	topics []string
+	closed atomic.Bool
+
+	mu        sync.Mutex
+	committed []string
+}
+
+func (c *chanTransport) Connect(context.Context) error { return nil }
+
+func (c *chanTransport) Subscribe(_ context.Context, topics []string) error {
+	c.topics = topics
+	return nil
+}
+
+func (c *chanTransport) Receive(ctx context.Context) (string, error) {
+	select {
+	case msg := <-c.msgs:
+		return msg, nil
+	case <-ctx.Done():
+		return "", ctx.Err()
+	}
+}
+
+func (c *chanTransport) Commit(_ context.Context, msg string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.committed = append(c.committed, msg)
+	return nil
+}
+
+func (c *chanTransport) Close() error {
+	c.closed.Store(true)
+	return nil
+}
+
+func (c *chanTransport) commits() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.committed)
+}
+
+func TestConsumer(t *testing.T) {
+	var (
+		g         = run.Group{Name: "consumer", Logger: telemetry.NoopLogger()}
+		tr        = &chanTransport{msgs: make(chan string)}
+		active    atomic.Int32
+		maxActive atomic.Int32
+		release   = make(chan struct{})
+		c         = Consumer[string]{
+			Transport: tr,
+			Handler: func(context.Context, string) error {
+				if n := active.Add(1); n > maxActive.Load() {
+					maxActive.Store(n)
+				}
+				defer active.Add(-1)
+				<-release
+				return nil
+			},
+		}
+		irq = make(chan error)
+	)
+	g.Register(&c)
+
+	go func() {
+		irq <- g.Run("./myService", "--consumer-topic", "orders", "--consumer-concurrency", "2")
+	}()
+
+	tr.msgs <- "msg"
+	tr.msgs <- "msg"
+	for active.Load() != 2 {
+		time.Sleep(time.Millisecond)
+	}
+	// the third message is received once a handler slot frees up
+	close(release)
+	tr.msgs <- "msg"
+	for tr.commits() != 3 {
+		time.Sleep(time.Millisecond)
+	}
+	if m := maxActive.Load(); m != 2 {
+		t.Errorf("Expected 2 concurrent handlers, got %d", m)
+	}
+	if len(tr.topics) != 1 || tr.topics[0] != "orders" {
+		t.Errorf("Expected subscription to orders, got %v", tr.topics)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	if err := g.Shutdown(ctx); err != nil {
+		t.Fatalf("Expected successful shutdown, got %v", err)
+	}
+	if err := <-irq; err != nil {
+		t.Errorf("Expected requested shutdown, got %v", err)
+	}
+	if !tr.closed.Load() {
+		t.Error("Expected transport to be closed")
+	}
+}
+
+func newConsumer(t *testing.T, handler Handler[string], args ...string) (*Consumer[string], *chanTransport) {
+	tr := &chanTransport{msgs: make(chan string, 1)}
+	c := &Consumer[string]{Transport: tr, Handler: handler, Topics: []string{"orders"}}
+	if err := c.FlagSet().Parse(args); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	return c, tr
+}
+
+func TestConsumerHandlerError(t *testing.T) {
+	errHandler := errors.New("handler failed")
+	c, tr := newConsumer(t, func(context.Context, string) error { return errHandler })
+
+	tr.msgs <- "msg"
+	if err := c.ServeContext(context.Background()); !errors.Is(err, errHandler) {
+		t.Errorf("Expected %v, got %v", errHandler, err)
+	}
+	if tr.commits() != 0 {
+		t.Error("Expected failed message not to be committed")
+	}
+
+	// skip failing messages
+	c.OnError = func(string, error) error { return nil }
+	ctx, cancel := context.WithCancel(context.Background())
+	tr.msgs <- "msg"
+	go func() {
+		for len(tr.msgs) > 0 {
+			time.Sleep(time.Millisecond)
+		}
+		cancel()
+	}()
+	if err := c.ServeContext(ctx); err != nil {
+		t.Errorf("Expected skipped message, got %v", err)
+	}
+}
+
+func TestConsumerDrainTimeout(t *testing.T) {
+	var (
+		received = make(chan struct{})
+		canceled atomic.Bool
+	)
+	c, tr := newConsumer(t, func(ctx context.Context, _ string) error {
+		close(received)
+		<-ctx.Done()
+		canceled.Store(true)
+		return nil
+	}, "--consumer-drain-timeout", "1h")
+	clock := test.NewClock(time.Now())
+	c.Clock = clock
+
+	ctx, cancel := context.WithCancel(context.Background())
+	tr.msgs <- "msg"
+	go func() {
+		<-received
+		cancel()
+		clock.BlockUntil(1)
+		clock.Advance(time.Hour)
+	}()
+	if err := c.ServeContext(ctx); !errors.Is(err, ErrDrainTimeout) {
+		t.Errorf("Expected %v, got %v", ErrDrainTimeout, err)
+	}
+	if !canceled.Load() {
+		t.Error("Expected handler context to be canceled")
+	}
+}
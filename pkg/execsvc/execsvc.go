@@ -0,0 +1,308 @@
+// Copyright (c) Bas van Beek 2024.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package execsvc implements a run.Group unit running and supervising an
+// external command, so sidecar binaries can be managed inside the same Group
+// lifecycle.
+package execsvc
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/basvanbeek/telemetry"
+
+	"github.com/basvanbeek/run"
+	"github.com/basvanbeek/run/pkg/flag"
+	"github.com/basvanbeek/run/pkg/log"
+)
+
+// Restart policies of the supervised command.
+const (
+	RestartNever     = "never"
+	RestartOnFailure = "on-failure"
+	RestartAlways    = "always"
+)
+
+// ErrExited is returned by ServeContext if the supervised command exited and
+// is not restarted according to the restart policy.
+const ErrExited run.Error = "process exited"
+
+// ErrNotRunning is returned by Signal if the supervised command is not
+// running.
+const ErrNotRunning run.Error = "process not running"
+
+// Service implements run.Config, run.PreRunner and run.ServiceContext. It runs
+// the configured command, restarting it according to the restart policy, and
+// logs its stdout and stderr output line by line. On shutdown the command is
+// sent SIGTERM and killed if it did not exit within the stop timeout.
+type Service struct {
+	// Prefix is used for the flag names and Unit name, allowing multiple
+	// supervised commands in a single Group. Defaults to "exec".
+	Prefix string
+	// Command holds the default command to run, it can be overridden by flag.
+	Command string
+	// Args holds the default arguments of the command, they can be
+	// overridden by flag.
+	Args []string
+	// Env holds additional environment variables in the form key=value.
+	Env []string
+	// Dir holds the working directory of the command. Defaults to the working
+	// directory of the Group process.
+	Dir string
+	// Logger is used to log the command output and restarts. Defaults to the
+	// bare bones logger used by run.Group.
+	Logger telemetry.Logger
+	// PassThrough takes the command and its arguments from the arguments
+	// following the "--" terminator on the command line, if provided, e.g.
+	// "myservice --exec-restart always -- sidecar --port 9000". Requires
+	// Group.
+	PassThrough bool
+	// Group provides the pass-through arguments if PassThrough is set.
+	Group *run.Group
+	// Clock is optional and used for the restart delay and stop timeout.
+	// Defaults to the Clock of Group if set, or run.SystemClock otherwise.
+	Clock run.Clock
+
+	command      string
+	args         []string
+	restart      string
+	restartDelay time.Duration
+	maxRestarts  int
+	restartReset time.Duration
+	stopTimeout  time.Duration
+
+	mu   sync.Mutex
+	proc *os.Process
+}
+
+// Name implements run.Unit.
+func (s *Service) Name() string {
+	return s.prefix()
+}
+
+// FlagSet implements run.Config.
+func (s *Service) FlagSet() *run.FlagSet {
+	p := s.prefix()
+	flags := run.NewFlagSet("External process options (" + p + ")")
+	flags.StringVar(&s.command, p+"-command", s.Command,
+		"command to run")
+	flags.StringSliceVar(&s.args, p+"-args", s.Args,
+		"arguments of the command")
+	flags.StringVar(&s.restart, p+"-restart", RestartOnFailure,
+		"restart policy: "+RestartNever+", "+RestartOnFailure+" or "+RestartAlways)
+	flags.DurationVar(&s.restartDelay, p+"-restart-delay", time.Second,
+		"delay before restarting the command")
+	flags.IntVar(&s.maxRestarts, p+"-max-restarts", 0,
+		"maximum amount of consecutive restarts, 0 is unlimited")
+	flags.DurationVar(&s.restartReset, p+"-restart-reset", time.Minute,
+		"uptime after which the command is considered stable and the count of\n"+
+			"consecutive restarts is reset, 0 never resets")
+	flags.DurationVar(&s.stopTimeout, p+"-stop-timeout", 10*time.Second,
+		"time to wait for the command to exit after SIGTERM before killing it")
+	return flags
+}
+
+// Validate implements run.Config.
+func (s *Service) Validate() error {
+	p := s.prefix()
+	if s.PassThrough && s.Group != nil {
+		if args := s.Group.PassThroughArgs(); len(args) > 0 {
+			s.command, s.args = args[0], args[1:]
+		}
+	}
+	if s.command == "" {
+		return flag.NewValidationError(p+"-command", flag.ErrRequired)
+	}
+	switch s.restart {
+	case RestartNever, RestartOnFailure, RestartAlways:
+	default:
+		return flag.NewValidationError(p+"-restart", flag.ErrInvalidVal)
+	}
+	if s.restartDelay < 0 {
+		return flag.NewValidationError(p+"-restart-delay", flag.ErrInvalidVal)
+	}
+	if s.maxRestarts < 0 {
+		return flag.NewValidationError(p+"-max-restarts", flag.ErrInvalidVal)
+	}
+	if s.restartReset < 0 {
+		return flag.NewValidationError(p+"-restart-reset", flag.ErrInvalidVal)
+	}
+	if s.stopTimeout <= 0 {
+		return flag.NewValidationError(p+"-stop-timeout", flag.ErrInvalidVal)
+	}
+	return nil
+}
+
+// PreRun implements run.PreRunner.
+func (s *Service) PreRun() error {
+	if s.Logger == nil {
+		s.Logger = &log.Logger{}
+	}
+	s.Logger = s.Logger.With("unit", s.Name(), "command", s.command)
+	return nil
+}
+
+// ServeContext implements run.ServiceContext.
+func (s *Service) ServeContext(ctx context.Context) error {
+	for restarts := 0; ; restarts++ {
+		start := s.clock().Now()
+		err := s.run(ctx)
+		if ctx.Err() != nil {
+			return nil
+		}
+		if s.restartReset > 0 && s.clock().Now().Sub(start) >= s.restartReset {
+			// the command ran stable for a while, only count consecutive
+			// restarts
+			restarts = 0
+		}
+		switch {
+		case s.restart == RestartNever,
+			s.restart == RestartOnFailure && err == nil:
+			return exited(err)
+		case s.maxRestarts > 0 && restarts >= s.maxRestarts:
+			return fmt.Errorf("%w after %d restarts", exited(err), restarts)
+		}
+		s.Logger.Info("restarting process", "attempt", restarts+1, "exit", fmt.Sprint(err))
+		select {
+		case <-s.clock().After(s.restartDelay):
+		case <-ctx.Done():
+			return nil
+		}
+	}
+}
+
+// Signal forwards the provided signal to the running command, e.g. to have it
+// reload its configuration on SIGHUP.
+func (s *Service) Signal(sig os.Signal) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.proc == nil {
+		return ErrNotRunning
+	}
+	return s.proc.Signal(sig)
+}
+
+// run starts the command and waits for it to exit. If the provided context is
+// done, the command is stopped gracefully.
+func (s *Service) run(ctx context.Context) error {
+	var (
+		stdout = &lineWriter{log: func(line string) { s.Logger.Info(line, "stream", "stdout") }}
+		stderr = &lineWriter{log: func(line string) { s.Logger.Info(line, "stream", "stderr") }}
+		cmd    = exec.Command(s.command, s.args...) //nolint:gosec // operator provided command
+	)
+	cmd.Env = append(os.Environ(), s.Env...)
+	cmd.Dir = s.Dir
+	cmd.Stdout, cmd.Stderr = stdout, stderr
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	s.proc = cmd.Process
+	s.mu.Unlock()
+	defer func() {
+		s.mu.Lock()
+		s.proc = nil
+		s.mu.Unlock()
+		stdout.flush()
+		stderr.flush()
+	}()
+
+	done := make(chan error, 1)
+	go func() { done <- cmd.Wait() }()
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+	}
+
+	// graceful stop, SIGTERM is not supported on all platforms
+	if err := cmd.Process.Signal(syscall.SIGTERM); err != nil {
+		_ = cmd.Process.Kill()
+	}
+	timer := s.clock().NewTimer(s.stopTimeout)
+	defer timer.Stop()
+	select {
+	case err := <-done:
+		return err
+	case <-timer.C():
+		s.Logger.Info("killing process", "stop-timeout", s.stopTimeout.String())
+		_ = cmd.Process.Kill()
+		return <-done
+	}
+}
+
+func (s *Service) clock() run.Clock {
+	switch {
+	case s.Clock != nil:
+		return s.Clock
+	case s.Group != nil:
+		return s.Group.Clock()
+	default:
+		return run.SystemClock
+	}
+}
+
+func (s *Service) prefix() string {
+	if s.Prefix == "" {
+		return "exec"
+	}
+	return s.Prefix
+}
+
+// exited wraps the exit error of the command with ErrExited.
+func exited(err error) error {
+	if err == nil {
+		return ErrExited
+	}
+	return fmt.Errorf("%w: %w", ErrExited, err)
+}
+
+// lineWriter logs the written output line by line.
+type lineWriter struct {
+	log func(line string)
+	buf []byte
+}
+
+func (w *lineWriter) Write(p []byte) (int, error) {
+	w.buf = append(w.buf, p...)
+	for {
+		idx := bytes.IndexByte(w.buf, '\n')
+		if idx < 0 {
+			return len(p), nil
+		}
+		w.log(string(bytes.TrimRight(w.buf[:idx], "\r")))
+		w.buf = w.buf[idx+1:]
+	}
+}
+
+// flush logs a remaining partial line.
+func (w *lineWriter) flush() {
+	if len(w.buf) > 0 {
+		w.log(string(w.buf))
+		w.buf = nil
+	}
+}
+
+var (
+	_ run.Config         = (*Service)(nil)
+	_ run.PreRunner      = (*Service)(nil)
+	_ run.ServiceContext = (*Service)(nil)
+)
@@ -0,0 +1,204 @@
+// Copyright (c) Bas van Beek 2024.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package execsvc_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+	"sync"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/basvanbeek/telemetry"
+
+	"github.com/basvanbeek/run"
+	"github.com/basvanbeek/run/pkg/execsvc"
+)
+
+// TestHelperProcess is not a real test, it is the external command run by
+// the tests below.
+func TestHelperProcess(_ *testing.T) {
+	if os.Getenv("EXECSVC_HELPER") == "" {
+		return
+	}
+	switch os.Getenv("EXECSVC_HELPER") {
+	case "fail":
+		fmt.Println("hello")
+		fmt.Fprint(os.Stderr, "oops")
+		os.Exit(3)
+	case "hang":
+		signal.Ignore(syscall.SIGTERM)
+		fmt.Println("ready")
+		time.Sleep(time.Minute)
+	}
+	os.Exit(0)
+}
+
+func TestService(t *testing.T) {
+	for _, tt := range []struct {
+		name    string
+		helper  string
+		flags   []string
+		err     error
+		runs    int
+		timeout time.Duration
+	}{
+		{name: "never", helper: "fail", flags: []string{"--exec-restart", "never"}, err: execsvc.ErrExited, runs: 1},
+		{name: "max-restarts", helper: "fail", flags: []string{"--exec-max-restarts", "2"}, err: execsvc.ErrExited, runs: 3},
+		{name: "clean-exit", helper: "exit", err: execsvc.ErrExited, runs: 1},
+		{name: "stop-timeout", helper: "hang", flags: []string{"--exec-stop-timeout", "50ms"}, runs: 1},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("EXECSVC_HELPER", tt.helper)
+			var (
+				logger = &lineLogger{Logger: telemetry.NoopLogger()}
+				s      = &execsvc.Service{
+					Command: os.Args[0],
+					Args:    []string{"-test.run=TestHelperProcess"},
+					Logger:  logger,
+				}
+				g   = run.NewGroup("exec", run.WithLogger(telemetry.NoopLogger()))
+				ctx = context.Background()
+			)
+			if tt.helper == "hang" {
+				var cancel context.CancelFunc
+				ctx, cancel = context.WithCancel(ctx)
+				go func() {
+					logger.wait("ready")
+					cancel()
+				}()
+			}
+			g.Register(s)
+			args := append([]string{"./myService", "--exec-restart-delay", "1ms"}, tt.flags...)
+			start := time.Now()
+			if err := g.RunContext(ctx, args...); !errors.Is(err, tt.err) {
+				t.Fatalf("Expected %v, got %v", tt.err, err)
+			}
+			if tt.helper == "hang" && time.Since(start) > 10*time.Second {
+				t.Errorf("Expected process to be killed after stop timeout")
+			}
+			if tt.helper == "fail" {
+				if have := logger.count("hello"); have != tt.runs {
+					t.Errorf("Expected %d runs, got %d", tt.runs, have)
+				}
+				if have := logger.count("oops"); have != tt.runs {
+					t.Errorf("Expected %d stderr lines, got %d", tt.runs, have)
+				}
+			}
+			if err := s.Signal(syscall.SIGHUP); !errors.Is(err, execsvc.ErrNotRunning) {
+				t.Errorf("Expected %v, got %v", execsvc.ErrNotRunning, err)
+			}
+		})
+	}
+}
+
+func TestServicePassThrough(t *testing.T) {
+	t.Setenv("EXECSVC_HELPER", "fail")
+	var (
+		logger = &lineLogger{Logger: telemetry.NoopLogger()}
+		g      = run.NewGroup("exec", run.WithLogger(telemetry.NoopLogger()))
+		s      = &execsvc.Service{PassThrough: true, Group: g, Logger: logger}
+	)
+	g.Register(s)
+	if err := g.Run("--exec-restart", "never", "--",
+		os.Args[0], "-test.run=TestHelperProcess"); !errors.Is(err, execsvc.ErrExited) {
+		t.Fatalf("Expected %v, got %v", execsvc.ErrExited, err)
+	}
+	if have := logger.count("hello"); have != 1 {
+		t.Errorf("Expected the pass-through command to run once, got %d", have)
+	}
+}
+
+// stepClock advances its time by a minute on each call to Now, so each run of
+// the command appears to be stable.
+type stepClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func (c *stepClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(time.Minute)
+	return c.now
+}
+
+func (c *stepClock) After(d time.Duration) <-chan time.Time { return run.SystemClock.After(d) }
+func (c *stepClock) NewTimer(d time.Duration) run.Timer     { return run.SystemClock.NewTimer(d) }
+
+func TestServiceRestartReset(t *testing.T) {
+	t.Setenv("EXECSVC_HELPER", "fail")
+	var (
+		logger = &lineLogger{Logger: telemetry.NoopLogger()}
+		s      = &execsvc.Service{
+			Command: os.Args[0],
+			Args:    []string{"-test.run=TestHelperProcess"},
+			Logger:  logger,
+			Clock:   &stepClock{},
+		}
+		g           = run.NewGroup("exec", run.WithLogger(telemetry.NoopLogger()))
+		ctx, cancel = context.WithCancel(context.Background())
+	)
+	defer cancel()
+	go func() {
+		// keep restarting beyond the max restarts as each run was stable
+		for logger.count("hello") < 4 {
+			time.Sleep(time.Millisecond)
+		}
+		cancel()
+	}()
+	g.Register(s)
+	if err := g.RunContext(ctx, "./myService", "--exec-restart-delay", "1ms",
+		"--exec-max-restarts", "1", "--exec-restart-reset", "1m"); err != nil {
+		t.Fatalf("Expected requested shutdown, got %v", err)
+	}
+}
+
+// lineLogger records the logged Info messages.
+type lineLogger struct {
+	telemetry.Logger
+	mu    sync.Mutex
+	lines []string
+}
+
+func (l *lineLogger) With(...interface{}) telemetry.Logger { return l }
+
+func (l *lineLogger) Info(msg string, _ ...interface{}) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.lines = append(l.lines, msg)
+}
+
+func (l *lineLogger) count(line string) (n int) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for _, have := range l.lines {
+		if strings.TrimSpace(have) == line {
+			n++
+		}
+	}
+	return n
+}
+
+func (l *lineLogger) wait(line string) {
+	for l.count(line) == 0 {
+		time.Sleep(time.Millisecond)
+	}
+}
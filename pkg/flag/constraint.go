@@ -0,0 +1,256 @@
+// Copyright (c) Bas van Beek 2024.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package flag
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/pflag"
+)
+
+// Constraint holds declarative checks for the value of a flag. Group checks
+// the Constraints of all Config Units after flag parsing and before calling
+// their Validate methods.
+type Constraint struct {
+	set    *Set
+	name   string
+	checks []func(f *pflag.Flag) error
+}
+
+// Constrain returns the Constraint of the flag identified by name, allowing for
+// chained declarations like:
+//
+//	fs.IntVar(&port, "port", 8080, "listen port")
+//	fs.Constrain("port").Min(1).Max(65535)
+func (s *Set) Constrain(name string) *Constraint {
+	for _, c := range s.constraints {
+		if c.name == name {
+			return c
+		}
+	}
+	c := &Constraint{set: s, name: name}
+	s.constraints = append(s.constraints, c)
+	return c
+}
+
+// Min requires the numeric value of the flag to be at least n. Durations are
+// compared in nanoseconds and byte sizes in bytes, e.g. Min(float64(time.Second)).
+func (c *Constraint) Min(n float64) *Constraint {
+	return c.numeric(func(v float64, format func(float64) string) error {
+		if v < n {
+			return fmt.Errorf("%w: must be at least %s", ErrInvalidVal, format(n))
+		}
+		return nil
+	})
+}
+
+// Max requires the numeric value of the flag to be at most n. Durations are
+// compared in nanoseconds and byte sizes in bytes, e.g. Max(float64(1 * GiB)).
+func (c *Constraint) Max(n float64) *Constraint {
+	return c.numeric(func(v float64, format func(float64) string) error {
+		if v > n {
+			return fmt.Errorf("%w: must be at most %s", ErrInvalidVal, format(n))
+		}
+		return nil
+	})
+}
+
+// OneOf requires the value of the flag to be one of the provided values.
+func (c *Constraint) OneOf(values ...string) *Constraint {
+	return c.Check(func(value string) error {
+		for _, v := range values {
+			if value == v {
+				return nil
+			}
+		}
+		return fmt.Errorf("%w: must be one of %s", ErrInvalidVal, strings.Join(values, ", "))
+	})
+}
+
+// Required requires the flag to be provided, e.g. on the command line.
+// See Set.MarkRequired.
+func (c *Constraint) Required() *Constraint {
+	c.set.MarkRequired(c.name)
+	return c
+}
+
+// Check adds a custom check of the flag's value. Sensitive flags are checked
+// using their actual, unredacted value.
+func (c *Constraint) Check(fn func(value string) error) *Constraint {
+	c.checks = append(c.checks, func(f *pflag.Flag) error {
+		if s, ok := f.Value.(*sensitiveString); ok {
+			return fn(*s.p)
+		}
+		return fn(f.Value.String())
+	})
+	return c
+}
+
+// numeric adds a check of the numeric value of the flag. The check is provided
+// with a function formatting numbers in the unit of the flag.
+func (c *Constraint) numeric(fn func(v float64, format func(float64) string) error) *Constraint {
+	c.checks = append(c.checks, func(f *pflag.Flag) error {
+		v, format, err := number(f)
+		if err != nil {
+			return err
+		}
+		return fn(v, format)
+	})
+	return c
+}
+
+// number returns the numeric value of the flag based on its value type, and a
+// function formatting numbers in the unit of the flag.
+func number(f *pflag.Flag) (float64, func(float64) string, error) {
+	switch v := f.Value.(type) {
+	case *byteSizeValue:
+		return float64(*v), func(n float64) string { return ByteSize(n).String() }, nil
+	case *sensitiveString:
+		return 0, nil, fmt.Errorf("%w: sensitive value can't be compared", ErrInvalidVal)
+	}
+	if f.Value.Type() == "duration" {
+		d, err := time.ParseDuration(f.Value.String())
+		if err != nil {
+			return 0, nil, fmt.Errorf("%w: not a duration", ErrInvalidVal)
+		}
+		return float64(d), func(n float64) string { return time.Duration(n).String() }, nil
+	}
+	v, err := strconv.ParseFloat(f.Value.String(), 64)
+	if err != nil {
+		return 0, nil, fmt.Errorf("%w: not a number", ErrInvalidVal)
+	}
+	return v, formatFloat, nil
+}
+
+// ConstraintErrors checks the Constraints of all flags as well as the declared
+// flag groups (see MutuallyExclusive, RequiredTogether and OneRequired) and
+// returns the violations found.
+func (s *Set) ConstraintErrors() []error {
+	var errs []error
+	for _, c := range s.constraints {
+		f := s.Lookup(c.name)
+		if f == nil {
+			errs = append(errs, NewValidationError(c.name, fmt.Errorf("constraint on unknown flag")))
+			continue
+		}
+		for _, check := range c.checks {
+			if err := check(f); err != nil {
+				errs = append(errs, NewValidationError(c.name, err))
+				// report the first violation per flag only
+				break
+			}
+		}
+	}
+	for _, g := range s.groups {
+		if err := g.check(s); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errs
+}
+
+type groupKind int
+
+const (
+	mutuallyExclusive groupKind = iota
+	requiredTogether
+	oneRequired
+)
+
+// flagGroup holds a cross-flag dependency.
+type flagGroup struct {
+	kind  groupKind
+	names []string
+}
+
+// MutuallyExclusive declares that at most one of the flags identified by names
+// may be provided.
+func (s *Set) MutuallyExclusive(names ...string) {
+	s.groups = append(s.groups, flagGroup{kind: mutuallyExclusive, names: names})
+}
+
+// RequiredTogether declares that if one of the flags identified by names is
+// provided, all of them must be provided.
+func (s *Set) RequiredTogether(names ...string) {
+	s.groups = append(s.groups, flagGroup{kind: requiredTogether, names: names})
+}
+
+// OneRequired declares that at least one of the flags identified by names must
+// be provided. Combine with MutuallyExclusive to require exactly one.
+func (s *Set) OneRequired(names ...string) {
+	s.groups = append(s.groups, flagGroup{kind: oneRequired, names: names})
+}
+
+func (g flagGroup) check(s *Set) error {
+	var provided, missing []string
+	for _, name := range g.names {
+		if s.Lookup(name) == nil {
+			return NewValidationError(name, fmt.Errorf("flag group on unknown flag"))
+		}
+		if s.Changed(name) {
+			provided = append(provided, name)
+		} else {
+			missing = append(missing, name)
+		}
+	}
+	switch {
+	case g.kind == mutuallyExclusive && len(provided) > 1:
+		return fmt.Errorf("%w: only one of %s may be provided, got %s",
+			ErrMutuallyExclusive, flagList(g.names), flagList(provided))
+	case g.kind == requiredTogether && len(provided) > 0 && len(missing) > 0:
+		return fmt.Errorf("%w: %s must be provided together, missing %s",
+			ErrRequiredTogether, flagList(g.names), flagList(missing))
+	case g.kind == oneRequired && len(provided) == 0:
+		return fmt.Errorf("%w: one of %s must be provided",
+			ErrRequired, flagList(g.names))
+	}
+	return nil
+}
+
+func flagList(names []string) string {
+	return "--" + strings.Join(names, ", --")
+}
+
+func formatFloat(f float64) string {
+	return strconv.FormatFloat(f, 'f', -1, 64)
+}
+
+// MarkRequired marks the flags identified by names as required. Group reports
+// all missing required flags of all Config Units in a single error, after
+// flag parsing and before checking Constraints and calling Validate.
+// A flag value loaded by a FlagSource Unit counts as provided.
+func (s *Set) MarkRequired(names ...string) {
+	for _, name := range names {
+		if !slices.Contains(s.required, name) {
+			s.required = append(s.required, name)
+		}
+	}
+}
+
+// MissingRequired returns the names of the required flags which have not been
+// provided.
+func (s *Set) MissingRequired() []string {
+	var missing []string
+	for _, name := range s.required {
+		if !s.Changed(name) {
+			missing = append(missing, name)
+		}
+	}
+	return missing
+}
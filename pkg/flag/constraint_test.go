@@ -0,0 +1,161 @@
+// Copyright (c) Bas van Beek 2024.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package flag
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestConstraints(t *testing.T) {
+	var (
+		s      = NewSet("constraints")
+		port   int
+		format string
+		dsn    string
+	)
+	s.IntVar(&port, "port", 8080, "listen port")
+	s.StringVar(&format, "format", "json", "log format")
+	s.StringVar(&dsn, "dsn", "", "data source name")
+	s.Constrain("port").Min(1).Max(65535)
+	s.Constrain("format").OneOf("json", "text")
+	s.Constrain("dsn").Required()
+
+	if err := s.Parse([]string{"--dsn", "db"}); err != nil {
+		t.Fatal(err)
+	}
+	if errs := s.ConstraintErrors(); len(errs) != 0 {
+		t.Errorf("expected no violations, got %v", errs)
+	}
+	if missing := s.MissingRequired(); len(missing) != 0 {
+		t.Errorf("expected no missing flags, got %v", missing)
+	}
+
+	s = NewSet("constraints")
+	s.IntVar(&port, "port", 8080, "listen port")
+	s.StringVar(&format, "format", "json", "log format")
+	s.StringVar(&dsn, "dsn", "", "data source name")
+	s.Constrain("port").Min(1).Max(65535)
+	s.Constrain("format").OneOf("json", "text")
+	s.Constrain("dsn").Required()
+
+	if err := s.Parse([]string{"--port", "0", "--format", "xml"}); err != nil {
+		t.Fatal(err)
+	}
+	errs := s.ConstraintErrors()
+	if len(errs) != 2 {
+		t.Fatalf("expected 2 violations, got %v", errs)
+	}
+	if !errors.Is(errs[0], ErrInvalidVal) || !errors.Is(errs[1], ErrInvalidVal) {
+		t.Errorf("unexpected violations: %v", errs)
+	}
+	if missing := s.MissingRequired(); len(missing) != 1 || missing[0] != "dsn" {
+		t.Errorf("expected missing dsn flag, got %v", missing)
+	}
+	if want, have := "--port error: invalid value: must be at least 1", errs[0].Error(); want != have {
+		t.Errorf("expected %q, got %q", want, have)
+	}
+}
+
+func TestConstraintValueTypes(t *testing.T) {
+	var (
+		s       = NewSet("constraints")
+		timeout time.Duration
+		size    ByteSize
+		token   string
+	)
+	s.DurationVar(&timeout, "timeout", time.Second, "timeout")
+	s.ByteSizeVar(&size, "max-size", MiB, "max size")
+	s.SensitiveStringVar(&token, "token", "", "token")
+	s.Constrain("timeout").Min(float64(time.Second)).Max(float64(time.Minute))
+	s.Constrain("max-size").Max(float64(GiB))
+	s.Constrain("token").OneOf("secret")
+
+	if err := s.Parse([]string{"--timeout", "30s", "--max-size", "512MiB", "--token", "secret"}); err != nil {
+		t.Fatal(err)
+	}
+	if errs := s.ConstraintErrors(); len(errs) != 0 {
+		t.Errorf("expected no violations, got %v", errs)
+	}
+
+	if err := s.Parse([]string{"--timeout", "2m", "--max-size", "2GiB", "--token", "other"}); err != nil {
+		t.Fatal(err)
+	}
+	errs := s.ConstraintErrors()
+	if len(errs) != 3 {
+		t.Fatalf("expected 3 violations, got %v", errs)
+	}
+	for idx, want := range []string{
+		"--timeout error: invalid value: must be at most 1m0s",
+		"--max-size error: invalid value: must be at most 1GiB",
+		"--token error: invalid value: must be one of secret",
+	} {
+		if have := errs[idx].Error(); want != have {
+			t.Errorf("expected %q, got %q", want, have)
+		}
+	}
+
+	s.Constrain("token").Min(1)
+	if err := s.Parse([]string{"--token", "secret"}); err != nil {
+		t.Fatal(err)
+	}
+	errs = s.ConstraintErrors()
+	if want, have := "--token error: invalid value: sensitive value can't be compared",
+		errs[len(errs)-1].Error(); want != have {
+		t.Errorf("expected %q, got %q", want, have)
+	}
+}
+
+func TestFlagGroups(t *testing.T) {
+	for idx, tt := range []struct {
+		args []string
+		err  error
+	}{
+		{args: []string{"--tls-acme"}},
+		{args: []string{"--tls-cert", "c", "--tls-key", "k"}},
+		{args: []string{"--tls-cert", "c", "--tls-key", "k", "--tls-acme"}, err: ErrMutuallyExclusive},
+		{args: []string{"--tls-cert", "c"}, err: ErrRequiredTogether},
+		{args: []string{}, err: ErrRequired},
+	} {
+		var (
+			s             = NewSet("groups")
+			cert, key     string
+			acme          bool
+			expectedCount = 0
+		)
+		s.StringVar(&cert, "tls-cert", "", "certificate")
+		s.StringVar(&key, "tls-key", "", "key")
+		s.BoolVar(&acme, "tls-acme", false, "use ACME")
+		s.MutuallyExclusive("tls-cert", "tls-acme")
+		s.RequiredTogether("tls-cert", "tls-key")
+		s.OneRequired("tls-cert", "tls-acme")
+
+		if err := s.Parse(tt.args); err != nil {
+			t.Fatal(err)
+		}
+		if tt.err != nil {
+			expectedCount = 1
+		}
+		errs := s.ConstraintErrors()
+		if len(errs) != expectedCount {
+			t.Errorf("[%d] expected %d violations, got %v", idx, expectedCount, errs)
+			continue
+		}
+		if tt.err != nil && !errors.Is(errs[0], tt.err) {
+			t.Errorf("[%d] expected %v, got %v", idx, tt.err, errs[0])
+		}
+	}
+}
@@ -44,4 +44,12 @@ const (
 
 	// ErrInvalidVal is returned when the value passed into a flag argument is invalid.
 	ErrInvalidVal ValidationError = "invalid value"
+
+	// ErrMutuallyExclusive is returned when more than one flag of a mutually
+	// exclusive group is provided.
+	ErrMutuallyExclusive ValidationError = "mutually exclusive"
+
+	// ErrRequiredTogether is returned when only some of the flags of a group
+	// that must be provided together are provided.
+	ErrRequiredTogether ValidationError = "required together"
 )
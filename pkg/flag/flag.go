@@ -9,6 +9,12 @@ import (
 type Set struct {
 	*pflag.FlagSet
 	Name string
+
+	aliases     map[string]string
+	constraints []*Constraint
+	required    []string
+	groups      []flagGroup
+	tiers       map[string]Tier
 }
 
 // NewSet returns a new FlagSet for usage in Config objects.
@@ -19,14 +25,20 @@ func NewSet(name string) *Set {
 	}
 }
 
-type sensitiveString string
+// sensitiveString holds a string flag value which is redacted when printed.
+// It keeps its default value so it can be restored by Reset, as the default
+// can't be recovered from the redacted flag.DefValue.
+type sensitiveString struct {
+	p   *string
+	def string
+}
 
 func (s *sensitiveString) String() string {
 	return "********"
 }
 
 func (s *sensitiveString) Set(value string) error {
-	*s = sensitiveString(value)
+	*s.p = value
 	return nil
 }
 
@@ -34,12 +46,38 @@ func (s *sensitiveString) Type() string {
 	return "string"
 }
 
+// Reset restores the default value.
+func (s *sensitiveString) Reset() {
+	*s.p = s.def
+}
+
 func (s *Set) SensitiveStringVar(p *string, name, value, usage string) {
 	*p = value
-	s.VarP((*sensitiveString)(p), name, "", usage)
+	s.VarP(&sensitiveString{p: p, def: value}, name, "", usage)
 }
 
 func (s *Set) SensitiveStringVarP(p *string, name, shorthand, value, usage string) {
 	*p = value
-	s.VarP((*sensitiveString)(p), name, shorthand, usage)
+	s.VarP(&sensitiveString{p: p, def: value}, name, shorthand, usage)
+}
+
+// IsSensitive returns true if the provided flag holds a sensitive value which
+// is redacted when printed.
+func IsSensitive(f *pflag.Flag) bool {
+	_, ok := f.Value.(*sensitiveString)
+	return ok
+}
+
+// SetUnchanged sets the value of the flag identified by name if it has not
+// been changed before, e.g. by the command line. It returns true if the value
+// has been set. Unknown flags are ignored.
+func (s *Set) SetUnchanged(name, value string) (bool, error) {
+	f := s.Lookup(name)
+	if f == nil || f.Changed {
+		return false, nil
+	}
+	if err := s.Set(name, value); err != nil {
+		return false, err
+	}
+	return true, nil
 }
@@ -0,0 +1,73 @@
+// Copyright (c) Bas van Beek 2024.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package flag
+
+import (
+	"strings"
+
+	"github.com/spf13/pflag"
+)
+
+// NormalizeFunc normalizes flag names so different spellings of a flag
+// resolve to the same flag.
+type NormalizeFunc = func(f *pflag.FlagSet, name string) pflag.NormalizedName
+
+var separators = strings.NewReplacer("_", "-", ".", "-")
+
+// WordSeparatorNormalization normalizes the "_" and "." word separators found
+// in flag names to "-", e.g. "--my_flag" resolves to "--my-flag".
+func WordSeparatorNormalization(_ *pflag.FlagSet, name string) pflag.NormalizedName {
+	return pflag.NormalizedName(separators.Replace(name))
+}
+
+// CaseInsensitiveNormalization lower cases flag names, e.g. "--My-Flag"
+// resolves to "--my-flag".
+func CaseInsensitiveNormalization(_ *pflag.FlagSet, name string) pflag.NormalizedName {
+	return pflag.NormalizedName(strings.ToLower(name))
+}
+
+// TolerantNormalization combines WordSeparatorNormalization and
+// CaseInsensitiveNormalization.
+func TolerantNormalization(_ *pflag.FlagSet, name string) pflag.NormalizedName {
+	return pflag.NormalizedName(separators.Replace(strings.ToLower(name)))
+}
+
+// Alias registers alias as an alternative name for the flag identified by
+// name. Aliases are honored both when parsing the Set directly and when the
+// Set is registered with run.Group. When parsing the Set directly, a custom
+// normalization function must be set before registering aliases.
+func (s *Set) Alias(alias, name string) {
+	if s.aliases == nil {
+		s.aliases = make(map[string]string)
+		normalize := s.GetNormalizeFunc()
+		s.FlagSet.SetNormalizeFunc(func(f *pflag.FlagSet, n string) pflag.NormalizedName {
+			if target, ok := s.aliases[n]; ok {
+				n = target
+			}
+			return normalize(f, n)
+		})
+	}
+	s.aliases[alias] = name
+}
+
+// Aliases returns the registered flag aliases, mapping each alias to the name
+// of the flag it resolves to.
+func (s *Set) Aliases() map[string]string {
+	aliases := make(map[string]string, len(s.aliases))
+	for alias, name := range s.aliases {
+		aliases[alias] = name
+	}
+	return aliases
+}
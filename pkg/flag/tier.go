@@ -0,0 +1,103 @@
+// Copyright (c) Bas van Beek 2024.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package flag
+
+import (
+	"strings"
+
+	"github.com/spf13/pflag"
+)
+
+// Tier classifies flags by their intended audience. By default only stable
+// flags are shown in --help, while --help-all shows all flags grouped by Tier.
+type Tier int
+
+// Flag tiers.
+const (
+	// TierStable is the default Tier of flags.
+	TierStable Tier = iota
+	// TierAdvanced holds flags for fine-tuning, rarely needed by most users.
+	TierAdvanced
+	// TierExperimental holds flags of features which may change or be removed.
+	TierExperimental
+	// TierHidden holds flags for internal use, e.g. debugging.
+	TierHidden
+)
+
+// Tiers lists all flag tiers in order of presentation.
+var Tiers = []Tier{TierStable, TierAdvanced, TierExperimental, TierHidden}
+
+var tierNames = map[Tier]string{
+	TierStable:       "stable",
+	TierAdvanced:     "advanced",
+	TierExperimental: "experimental",
+	TierHidden:       "hidden",
+}
+
+// String implements fmt.Stringer.
+func (t Tier) String() string {
+	return tierNames[t]
+}
+
+// SetTier assigns the provided Tier to the flags identified by names. Flags of
+// TierHidden are also marked hidden in the underlying pflag.FlagSet.
+func (s *Set) SetTier(tier Tier, names ...string) {
+	if s.tiers == nil {
+		s.tiers = make(map[string]Tier)
+	}
+	for _, name := range names {
+		s.tiers[name] = tier
+		if tier == TierHidden {
+			_ = s.MarkHidden(name)
+		}
+	}
+}
+
+// Tier returns the Tier of the flag identified by name.
+func (s *Set) Tier(name string) Tier {
+	return s.tiers[name]
+}
+
+// TierUsages returns the usage information of the flags of the provided Tier.
+// Unlike FlagUsages, flags of TierHidden are included if requested. Flags
+// hidden without assigning TierHidden remain hidden.
+func (s *Set) TierUsages(tier Tier) string {
+	return s.MatchingTierUsages(tier, "")
+}
+
+// MatchingTierUsages returns the usage information of the flags of the
+// provided Tier whose name or usage contains filter, ignoring case. An empty
+// filter matches all flags.
+func (s *Set) MatchingTierUsages(tier Tier, filter string) string {
+	filter = strings.ToLower(filter)
+	fs := pflag.NewFlagSet(s.Name, pflag.ContinueOnError)
+	fs.SortFlags = s.SortFlags
+	s.VisitAll(func(f *pflag.Flag) {
+		if s.tiers[f.Name] != tier {
+			return
+		}
+		if filter != "" && !strings.Contains(strings.ToLower(f.Name), filter) &&
+			!strings.Contains(strings.ToLower(f.Usage), filter) {
+			return
+		}
+		if tier == TierHidden {
+			c := *f
+			c.Hidden = false
+			f = &c
+		}
+		fs.AddFlag(f)
+	})
+	return fs.FlagUsages()
+}
@@ -0,0 +1,83 @@
+// Copyright (c) Bas van Beek 2024.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package flag
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestTierUsages(t *testing.T) {
+	var (
+		s                              = NewSet("tiers")
+		addr, cacheSize, engine, trace string
+	)
+	s.StringVar(&addr, "addr", ":8080", "listen address")
+	s.StringVar(&cacheSize, "cache-size", "64MiB", "cache size")
+	s.StringVar(&engine, "engine", "v1", "engine version")
+	s.StringVar(&trace, "trace", "", "trace internals")
+	s.SetTier(TierAdvanced, "cache-size")
+	s.SetTier(TierExperimental, "engine")
+	s.SetTier(TierHidden, "trace")
+
+	for _, tt := range []struct {
+		tier     Tier
+		flag     string
+		excluded []string
+	}{
+		{TierStable, "--addr", []string{"--cache-size", "--engine", "--trace"}},
+		{TierAdvanced, "--cache-size", []string{"--addr"}},
+		{TierExperimental, "--engine", []string{"--addr"}},
+		{TierHidden, "--trace", []string{"--addr"}},
+	} {
+		usage := s.TierUsages(tt.tier)
+		if !strings.Contains(usage, tt.flag) {
+			t.Errorf("%s: expected %s in usage, got %q", tt.tier, tt.flag, usage)
+		}
+		for _, f := range tt.excluded {
+			if strings.Contains(usage, f) {
+				t.Errorf("%s: expected %s not in usage, got %q", tt.tier, f, usage)
+			}
+		}
+	}
+	if strings.Contains(s.FlagUsages(), "--trace") {
+		t.Error("expected hidden tier flag to be hidden from FlagUsages")
+	}
+}
+
+func TestMatchingTierUsages(t *testing.T) {
+	var (
+		s                  = NewSet("tiers")
+		addr, cache, trace string
+	)
+	s.StringVar(&addr, "addr", ":8080", "listen address")
+	s.StringVar(&cache, "cache-size", "64MiB", "size of the LRU cache")
+	s.StringVar(&trace, "trace", "", "trace cache internals")
+	s.SetTier(TierHidden, "trace")
+
+	usage := s.MatchingTierUsages(TierStable, "LRU")
+	if !strings.Contains(usage, "--cache-size") || strings.Contains(usage, "--addr") {
+		t.Errorf("expected only --cache-size to match usage, got %q", usage)
+	}
+	if usage = s.MatchingTierUsages(TierStable, "ADDR"); !strings.Contains(usage, "--addr") {
+		t.Errorf("expected --addr to match name, got %q", usage)
+	}
+	if usage = s.MatchingTierUsages(TierHidden, "cache"); !strings.Contains(usage, "--trace") {
+		t.Errorf("expected hidden --trace to match, got %q", usage)
+	}
+	if usage = s.MatchingTierUsages(TierStable, "nothing"); usage != "" {
+		t.Errorf("expected no matches, got %q", usage)
+	}
+}
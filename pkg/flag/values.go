@@ -0,0 +1,280 @@
+// Copyright (c) Bas van Beek 2024.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package flag
+
+import (
+	"fmt"
+	"net"
+	"net/netip"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// ByteSize holds an amount of bytes. It is parsed from values like "512MiB",
+// "1.5GB" or "1024", where decimal units (KB, MB, ...) use powers of 1000 and
+// binary units (KiB, MiB, ...) use powers of 1024.
+type ByteSize uint64
+
+// Common byte sizes.
+const (
+	Byte ByteSize = 1
+	KiB           = 1024 * Byte
+	MiB           = 1024 * KiB
+	GiB           = 1024 * MiB
+	TiB           = 1024 * GiB
+	PiB           = 1024 * TiB
+)
+
+var byteUnits = map[string]float64{
+	"":    1,
+	"b":   1,
+	"k":   1e3,
+	"kb":  1e3,
+	"m":   1e6,
+	"mb":  1e6,
+	"g":   1e9,
+	"gb":  1e9,
+	"t":   1e12,
+	"tb":  1e12,
+	"p":   1e15,
+	"pb":  1e15,
+	"kib": float64(KiB),
+	"mib": float64(MiB),
+	"gib": float64(GiB),
+	"tib": float64(TiB),
+	"pib": float64(PiB),
+}
+
+// ParseByteSize parses a byte size value like "512MiB".
+func ParseByteSize(s string) (ByteSize, error) {
+	s = strings.TrimSpace(s)
+	idx := strings.IndexFunc(s, func(r rune) bool {
+		return (r < '0' || r > '9') && r != '.'
+	})
+	if idx == -1 {
+		idx = len(s)
+	}
+	unit, ok := byteUnits[strings.ToLower(strings.TrimSpace(s[idx:]))]
+	if !ok {
+		return 0, fmt.Errorf("%w: unknown byte size unit in %q", ErrInvalidVal, s)
+	}
+	n, err := strconv.ParseFloat(s[:idx], 64)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("%w: invalid byte size %q", ErrInvalidVal, s)
+	}
+	return ByteSize(n * unit), nil
+}
+
+// String returns the byte size using the largest binary unit that represents
+// it without loss of precision.
+func (b ByteSize) String() string {
+	for _, u := range []struct {
+		name string
+		size ByteSize
+	}{{"PiB", PiB}, {"TiB", TiB}, {"GiB", GiB}, {"MiB", MiB}, {"KiB", KiB}} {
+		if b >= u.size && b%u.size == 0 {
+			return strconv.FormatUint(uint64(b/u.size), 10) + u.name
+		}
+	}
+	return strconv.FormatUint(uint64(b), 10) + "B"
+}
+
+type byteSizeValue ByteSize
+
+func (b *byteSizeValue) String() string { return ByteSize(*b).String() }
+func (b *byteSizeValue) Type() string   { return "bytes" }
+func (b *byteSizeValue) Set(value string) error {
+	v, err := ParseByteSize(value)
+	if err != nil {
+		return err
+	}
+	*b = byteSizeValue(v)
+	return nil
+}
+
+// ByteSizeVar defines a byte size flag accepting values like "512MiB".
+func (s *Set) ByteSizeVar(p *ByteSize, name string, value ByteSize, usage string) {
+	s.ByteSizeVarP(p, name, "", value, usage)
+}
+
+// ByteSizeVarP is like ByteSizeVar, but accepts a shorthand letter.
+func (s *Set) ByteSizeVarP(p *ByteSize, name, shorthand string, value ByteSize, usage string) {
+	*p = value
+	s.VarP((*byteSizeValue)(p), name, shorthand, usage)
+}
+
+type urlValue struct {
+	p **url.URL
+}
+
+func (u urlValue) String() string {
+	if *u.p == nil {
+		return ""
+	}
+	return (*u.p).String()
+}
+
+func (u urlValue) Type() string { return "url" }
+
+func (u urlValue) Set(value string) error {
+	v, err := url.Parse(value)
+	if err != nil || v.Scheme == "" || v.Host == "" {
+		return fmt.Errorf("%w: expected absolute URL, got %q", ErrInvalidVal, value)
+	}
+	*u.p = v
+	return nil
+}
+
+// URLVar defines a flag holding an absolute URL, requiring both a scheme and
+// a host. An empty default value results in a nil URL.
+func (s *Set) URLVar(p **url.URL, name, value, usage string) {
+	s.URLVarP(p, name, "", value, usage)
+}
+
+// URLVarP is like URLVar, but accepts a shorthand letter.
+func (s *Set) URLVarP(p **url.URL, name, shorthand, value, usage string) {
+	*p = nil
+	if value != "" {
+		*p, _ = url.Parse(value)
+	}
+	s.VarP(urlValue{p: p}, name, shorthand, usage)
+}
+
+type hostPortValue string
+
+func (h *hostPortValue) String() string { return string(*h) }
+func (h *hostPortValue) Type() string   { return "host:port" }
+func (h *hostPortValue) Set(value string) error {
+	_, port, err := net.SplitHostPort(value)
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrInvalidVal, err)
+	}
+	if p, err := strconv.ParseUint(port, 10, 16); err != nil || (p == 0 && port != "0") {
+		return fmt.Errorf("%w: invalid port in %q", ErrInvalidVal, value)
+	}
+	*h = hostPortValue(value)
+	return nil
+}
+
+// HostPortVar defines a flag holding a "host:port" address. The host may be
+// omitted (e.g. ":8080") and the port must be numeric.
+func (s *Set) HostPortVar(p *string, name, value, usage string) {
+	s.HostPortVarP(p, name, "", value, usage)
+}
+
+// HostPortVarP is like HostPortVar, but accepts a shorthand letter.
+func (s *Set) HostPortVarP(p *string, name, shorthand, value, usage string) {
+	*p = value
+	s.VarP((*hostPortValue)(p), name, shorthand, usage)
+}
+
+type cidrListValue struct {
+	p       *[]netip.Prefix
+	changed bool
+}
+
+func (c *cidrListValue) String() string {
+	out := make([]string, len(*c.p))
+	for i, p := range *c.p {
+		out[i] = p.String()
+	}
+	return "[" + strings.Join(out, ",") + "]"
+}
+
+func (c *cidrListValue) Type() string { return "cidrs" }
+
+func (c *cidrListValue) Set(value string) error {
+	var list []netip.Prefix
+	for _, v := range strings.Split(value, ",") {
+		p, err := netip.ParsePrefix(strings.TrimSpace(v))
+		if err != nil {
+			return fmt.Errorf("%w: %w", ErrInvalidVal, err)
+		}
+		list = append(list, p)
+	}
+	if !c.changed {
+		// the first occurrence on the command line replaces the default
+		*c.p, c.changed = nil, true
+	}
+	*c.p = append(*c.p, list...)
+	return nil
+}
+
+// CIDRListVar defines a flag holding a list of CIDR prefixes. It accepts
+// comma separated values and can be repeated.
+func (s *Set) CIDRListVar(p *[]netip.Prefix, name string, value []netip.Prefix, usage string) {
+	s.CIDRListVarP(p, name, "", value, usage)
+}
+
+// CIDRListVarP is like CIDRListVar, but accepts a shorthand letter.
+func (s *Set) CIDRListVarP(p *[]netip.Prefix, name, shorthand string, value []netip.Prefix, usage string) {
+	*p = append([]netip.Prefix(nil), value...)
+	s.VarP(&cidrListValue{p: p}, name, shorthand, usage)
+}
+
+type stringMapValue struct {
+	p       *map[string]string
+	changed bool
+}
+
+func (m *stringMapValue) String() string {
+	keys := make([]string, 0, len(*m.p))
+	for k := range *m.p {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for i, k := range keys {
+		keys[i] = k + "=" + (*m.p)[k]
+	}
+	return "[" + strings.Join(keys, ",") + "]"
+}
+
+func (m *stringMapValue) Type() string { return "key=value" }
+
+func (m *stringMapValue) Set(value string) error {
+	kv := make(map[string]string)
+	for _, pair := range strings.Split(value, ",") {
+		k, v, ok := strings.Cut(pair, "=")
+		if k = strings.TrimSpace(k); !ok || k == "" {
+			return fmt.Errorf("%w: expected key=value, got %q", ErrInvalidVal, pair)
+		}
+		kv[k] = v
+	}
+	if !m.changed {
+		// the first occurrence on the command line replaces the default
+		*m.p, m.changed = make(map[string]string), true
+	}
+	for k, v := range kv {
+		(*m.p)[k] = v
+	}
+	return nil
+}
+
+// StringMapVar defines a flag holding key=value pairs. It accepts comma
+// separated pairs and can be repeated.
+func (s *Set) StringMapVar(p *map[string]string, name string, value map[string]string, usage string) {
+	s.StringMapVarP(p, name, "", value, usage)
+}
+
+// StringMapVarP is like StringMapVar, but accepts a shorthand letter.
+func (s *Set) StringMapVarP(p *map[string]string, name, shorthand string, value map[string]string, usage string) {
+	*p = make(map[string]string, len(value))
+	for k, v := range value {
+		(*p)[k] = v
+	}
+	s.VarP(&stringMapValue{p: p}, name, shorthand, usage)
+}
@@ -0,0 +1,107 @@
+// Copyright (c) Bas van Beek 2024.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package flag
+
+import (
+	"errors"
+	"net/netip"
+	"net/url"
+	"reflect"
+	"testing"
+)
+
+func TestParseByteSize(t *testing.T) {
+	for _, tt := range []struct {
+		in   string
+		want ByteSize
+		err  bool
+	}{
+		{in: "1024", want: KiB},
+		{in: "512MiB", want: 512 * MiB},
+		{in: "1.5GB", want: 1500 * 1000 * 1000},
+		{in: "2 kib", want: 2 * KiB},
+		{in: "10XB", err: true},
+		{in: "MiB", err: true},
+	} {
+		have, err := ParseByteSize(tt.in)
+		if tt.err {
+			if !errors.Is(err, ErrInvalidVal) {
+				t.Errorf("%q: expected %v, got %v", tt.in, ErrInvalidVal, err)
+			}
+			continue
+		}
+		if err != nil || have != tt.want {
+			t.Errorf("%q: expected %d, got %d (%v)", tt.in, tt.want, have, err)
+		}
+	}
+	if want, have := "512MiB", (512 * MiB).String(); want != have {
+		t.Errorf("expected %s, got %s", want, have)
+	}
+}
+
+func TestStructuredValues(t *testing.T) {
+	var (
+		s      = NewSet("values")
+		size   ByteSize
+		u      *url.URL
+		addr   string
+		cidrs  []netip.Prefix
+		labels map[string]string
+	)
+	s.ByteSizeVar(&size, "size", 64*MiB, "size")
+	s.URLVar(&u, "url", "", "url")
+	s.HostPortVar(&addr, "addr", ":8080", "address")
+	s.CIDRListVar(&cidrs, "allow", []netip.Prefix{netip.MustParsePrefix("0.0.0.0/0")}, "allowed")
+	s.StringMapVar(&labels, "label", map[string]string{"env": "dev"}, "labels")
+
+	err := s.Parse([]string{
+		"--size", "1GiB",
+		"--url", "https://example.com/path",
+		"--addr", "localhost:9090",
+		"--allow", "10.0.0.0/8,192.168.0.0/16", "--allow", "fd00::/8",
+		"--label", "env=prod,team=core", "--label", "tier=1",
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if size != GiB {
+		t.Errorf("expected size %s, got %s", GiB, size)
+	}
+	if u == nil || u.Host != "example.com" {
+		t.Errorf("expected url host example.com, got %v", u)
+	}
+	if addr != "localhost:9090" {
+		t.Errorf("expected addr localhost:9090, got %s", addr)
+	}
+	if len(cidrs) != 3 || cidrs[2].String() != "fd00::/8" {
+		t.Errorf("expected 3 CIDRs, got %v", cidrs)
+	}
+	if want := map[string]string{"env": "prod", "team": "core", "tier": "1"}; !reflect.DeepEqual(want, labels) {
+		t.Errorf("expected labels %v, got %v", want, labels)
+	}
+
+	for _, args := range [][]string{
+		{"--size", "lots"},
+		{"--url", "/relative"},
+		{"--addr", "localhost"},
+		{"--addr", "localhost:99999"},
+		{"--allow", "10.0.0.0/33"},
+		{"--label", "=value"},
+	} {
+		if err := s.Parse(args); err == nil {
+			t.Errorf("%v: expected validation error, got nil", args)
+		}
+	}
+}
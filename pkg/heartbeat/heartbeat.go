@@ -0,0 +1,142 @@
+// Copyright (c) Bas van Beek 2024.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package heartbeat implements a run.Group unit periodically reporting uptime,
+// unit counts and memory statistics, providing a cheap liveness breadcrumb in
+// log pipelines for otherwise quiet services.
+package heartbeat
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/basvanbeek/telemetry"
+
+	"github.com/basvanbeek/run"
+	"github.com/basvanbeek/run/pkg/flag"
+	"github.com/basvanbeek/run/pkg/log"
+)
+
+// Beat holds the figures reported on each heartbeat.
+type Beat struct {
+	// Uptime holds the time passed since the start of the Group.
+	Uptime time.Duration
+	// Units holds the number of Units that reached a tracked lifecycle phase
+	// and Serving the number of those currently being served.
+	Units   int
+	Serving int
+	// Goroutines holds the total number of goroutines of the process.
+	Goroutines int
+	// HeapLiveBytes holds the bytes occupied by live and not yet swept heap
+	// objects.
+	HeapLiveBytes uint64
+}
+
+// Heartbeat implements run.Config, run.PreRunner and run.ServiceContext. On
+// each configured interval it logs a Beat of the Group it is registered with
+// and hands it to Emit, if set, e.g. to update metrics.
+type Heartbeat struct {
+	// Group is the Group to report on. Required.
+	Group *run.Group
+	// Logger is used to log the heartbeats. Defaults to the bare bones logger
+	// used by run.Group.
+	Logger telemetry.Logger
+	// Emit is called with each Beat, if set.
+	Emit func(Beat)
+
+	interval time.Duration
+}
+
+// Name implements run.Unit.
+func (h *Heartbeat) Name() string {
+	return "heartbeat"
+}
+
+// FlagSet implements run.Config.
+func (h *Heartbeat) FlagSet() *run.FlagSet {
+	flags := run.NewFlagSet("Heartbeat options")
+	flags.DurationVar(&h.interval, "heartbeat-interval", time.Minute,
+		"interval at which to report a heartbeat, 0 disables heartbeats")
+	return flags
+}
+
+// Validate implements run.Config.
+func (h *Heartbeat) Validate() error {
+	if h.interval < 0 {
+		return flag.NewValidationError("heartbeat-interval", flag.ErrInvalidVal)
+	}
+	return nil
+}
+
+// PreRun implements run.PreRunner.
+func (h *Heartbeat) PreRun() error {
+	if h.Group == nil {
+		return errors.New("heartbeat requires a Group to report on")
+	}
+	if h.Logger == nil {
+		h.Logger = &log.Logger{}
+	}
+	return nil
+}
+
+// ServeContext implements run.ServiceContext.
+func (h *Heartbeat) ServeContext(ctx context.Context) error {
+	if h.interval == 0 {
+		<-ctx.Done()
+		return nil
+	}
+	clock := h.Group.Clock()
+	for {
+		timer := clock.NewTimer(h.interval)
+		select {
+		case <-timer.C():
+			b := h.Beat()
+			h.Logger.Info("heartbeat",
+				"uptime", b.Uptime.Round(time.Second).String(),
+				"units", b.Units, "serving", b.Serving,
+				"goroutines", b.Goroutines, "heapLiveBytes", b.HeapLiveBytes)
+			if h.Emit != nil {
+				h.Emit(b)
+			}
+		case <-ctx.Done():
+			timer.Stop()
+			return nil
+		}
+	}
+}
+
+// Beat returns the current figures of the Group.
+func (h *Heartbeat) Beat() Beat {
+	status := h.Group.Status()
+	usage := h.Group.ResourceUsage()
+	b := Beat{
+		Uptime:        time.Duration(status.UptimeSeconds * float64(time.Second)),
+		Units:         len(status.Units),
+		Goroutines:    usage.Goroutines,
+		HeapLiveBytes: usage.HeapLiveBytes,
+	}
+	for _, u := range status.Units {
+		if u.State == run.StateServing {
+			b.Serving++
+		}
+	}
+	return b
+}
+
+var (
+	_ run.Config         = (*Heartbeat)(nil)
+	_ run.PreRunner      = (*Heartbeat)(nil)
+	_ run.ServiceContext = (*Heartbeat)(nil)
+)
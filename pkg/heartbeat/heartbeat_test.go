@@ -0,0 +1,64 @@
+// Copyright (c) Bas van Beek 2024.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package heartbeat
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/basvanbeek/telemetry"
+
+	"github.com/basvanbeek/run"
+	"github.com/basvanbeek/run/pkg/test"
+)
+
+func TestHeartbeat(t *testing.T) {
+	var (
+		clock = test.NewClock(time.Now())
+		g     = run.NewGroup("heartbeat", run.WithClock(clock),
+			run.WithLogger(telemetry.NoopLogger()))
+		beats   = make(chan Beat, 1)
+		errDone = errors.New("done")
+		h       = Heartbeat{Group: g, Logger: telemetry.NoopLogger(), Emit: func(b Beat) {
+			select {
+			case beats <- b:
+			default:
+			}
+		}}
+	)
+
+	var beat Beat
+	g.Register(&h, &test.Svc{
+		SvcName: "waiter",
+		Execute: func() error {
+			clock.BlockUntil(1)
+			clock.Advance(time.Minute)
+			select {
+			case beat = <-beats:
+				return errDone
+			case <-time.After(time.Second):
+				return errors.New("no heartbeat received")
+			}
+		},
+	})
+
+	if err := g.Run("./myService", "--heartbeat-interval", "1m"); !errors.Is(err, errDone) {
+		t.Fatalf("Expected %v, got %v", errDone, err)
+	}
+	if beat.Uptime != time.Minute || beat.Serving != 2 || beat.Goroutines == 0 {
+		t.Errorf("Unexpected heartbeat: %+v", beat)
+	}
+}
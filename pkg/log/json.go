@@ -0,0 +1,151 @@
+// Copyright (c) Bas van Beek 2024.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package log
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/basvanbeek/telemetry"
+)
+
+// JSONLogger is a telemetry.Logger implementation writing each log line as a
+// single JSON object holding the time, level, msg and provided key-value
+// pairs. It is used by run.Group when configured with run.WithJSONLogging.
+type JSONLogger struct {
+	out   *jsonOutput
+	level *atomic.Int32
+	args  []interface{}
+}
+
+// jsonOutput serializes writes of JSONLogger instances sharing the same
+// io.Writer.
+type jsonOutput struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+// NewJSON returns a JSONLogger writing to w at debug level.
+func NewJSON(w io.Writer) *JSONLogger {
+	l := &JSONLogger{
+		out:   &jsonOutput{w: w},
+		level: &atomic.Int32{},
+	}
+	l.level.Store(int32(telemetry.LevelDebug))
+	return l
+}
+
+func (l *JSONLogger) Debug(msg string, keyValuePairs ...interface{}) {
+	l.write(telemetry.LevelDebug, msg, nil, keyValuePairs)
+}
+
+func (l *JSONLogger) Info(msg string, keyValuePairs ...interface{}) {
+	l.write(telemetry.LevelInfo, msg, nil, keyValuePairs)
+}
+
+func (l *JSONLogger) Error(msg string, err error, keyValuePairs ...interface{}) {
+	l.write(telemetry.LevelError, msg, err, keyValuePairs)
+}
+
+func (l *JSONLogger) With(keyValuePairs ...interface{}) telemetry.Logger {
+	newLogger := l.Clone().(*JSONLogger)
+	newLogger.args = append(newLogger.args, keyValuePairs...)
+	return newLogger
+}
+
+func (l *JSONLogger) Clone() telemetry.Logger {
+	return &JSONLogger{
+		out:   l.out,
+		level: l.level,
+		args:  append([]interface{}(nil), l.args...),
+	}
+}
+
+func (l *JSONLogger) Level() telemetry.Level {
+	return telemetry.Level(l.level.Load())
+}
+
+func (l *JSONLogger) SetLevel(lvl telemetry.Level) {
+	l.level.Store(int32(lvl))
+}
+
+func (l *JSONLogger) Context(_ context.Context) telemetry.Logger {
+	// not used by run.Group
+	return l
+}
+
+func (l *JSONLogger) Metric(_ telemetry.Metric) telemetry.Logger {
+	// not used by run.Group
+	return l
+}
+
+// write encodes the log line with its fields in a stable order: time, level,
+// msg, error followed by the Logger's and the provided key-value pairs.
+func (l *JSONLogger) write(lvl telemetry.Level, msg string, err error, keyValuePairs []interface{}) {
+	if lvl > l.Level() {
+		return
+	}
+	var buf bytes.Buffer
+	buf.WriteString(`{"time":`)
+	writeJSON(&buf, time.Now().UTC().Format(time.RFC3339Nano))
+	buf.WriteString(`,"level":`)
+	writeJSON(&buf, lvl.String())
+	buf.WriteString(`,"msg":`)
+	writeJSON(&buf, msg)
+	if err != nil {
+		buf.WriteString(`,"error":`)
+		writeJSON(&buf, err.Error())
+	}
+	kv := append(append([]interface{}(nil), l.args...), keyValuePairs...)
+	if len(kv)%2 != 0 {
+		kv = append(kv, "(MISSING)")
+	}
+	for i := 0; i < len(kv); i += 2 {
+		buf.WriteByte(',')
+		writeJSON(&buf, fmt.Sprint(kv[i]))
+		buf.WriteByte(':')
+		writeJSON(&buf, kv[i+1])
+	}
+	buf.WriteString("}\n")
+
+	l.out.mu.Lock()
+	defer l.out.mu.Unlock()
+	_, _ = l.out.w.Write(buf.Bytes())
+}
+
+// writeJSON encodes v into buf. Errors and fmt.Stringer values are encoded by
+// their string representation, values which can't be encoded are formatted
+// using fmt.
+func writeJSON(buf *bytes.Buffer, v interface{}) {
+	switch t := v.(type) {
+	case error:
+		v = t.Error()
+	case fmt.Stringer:
+		v = t.String()
+	}
+	b, err := json.Marshal(v)
+	if err != nil {
+		b, _ = json.Marshal(fmt.Sprint(v))
+	}
+	buf.Write(b)
+}
+
+var _ telemetry.Logger = (*JSONLogger)(nil)
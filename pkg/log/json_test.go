@@ -0,0 +1,68 @@
+// Copyright (c) Bas van Beek 2024.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package log_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/basvanbeek/telemetry"
+
+	"github.com/basvanbeek/run/pkg/log"
+)
+
+func TestJSONLogger(t *testing.T) {
+	var (
+		buf bytes.Buffer
+		l   = log.NewJSON(&buf)
+		w   = l.With("unit", "svc")
+	)
+
+	w.Error("failed", errors.New("boom"), "duration", 1500*time.Millisecond, "odd")
+	var event map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &event); err != nil {
+		t.Fatalf("Expected JSON log line, got %q: %v", buf.String(), err)
+	}
+	for key, want := range map[string]interface{}{
+		"level":    "error",
+		"msg":      "failed",
+		"error":    "boom",
+		"unit":     "svc",
+		"duration": "1.5s",
+		"odd":      "(MISSING)",
+	} {
+		if event[key] != want {
+			t.Errorf("Expected %s %q, got %v", key, want, event[key])
+		}
+	}
+	if _, err := time.Parse(time.RFC3339Nano, event["time"].(string)); err != nil {
+		t.Errorf("Expected RFC3339 time, got %v", event["time"])
+	}
+
+	// level is shared between derived loggers
+	buf.Reset()
+	w.SetLevel(telemetry.LevelInfo)
+	l.Debug("hidden")
+	w.Info("shown")
+	if l.Level() != telemetry.LevelInfo {
+		t.Errorf("Expected level %v, got %v", telemetry.LevelInfo, l.Level())
+	}
+	if bytes.Contains(buf.Bytes(), []byte("hidden")) || !bytes.Contains(buf.Bytes(), []byte("shown")) {
+		t.Errorf("Expected only info line, got %q", buf.String())
+	}
+}
@@ -77,6 +77,12 @@ func (l *Logger) Level() telemetry.Level {
 	return telemetry.LevelNone
 }
 
+// DebugEnabled implements DebugChecker. The bare bones Logger does not filter
+// by level and always emits Debug log lines.
+func (l *Logger) DebugEnabled() bool {
+	return true
+}
+
 func (l *Logger) SetLevel(telemetry.Level) {
 	// not used by run.Group
 }
@@ -96,4 +102,27 @@ func (l *Logger) Metric(_ telemetry.Metric) telemetry.Logger {
 	return l
 }
 
-var _ telemetry.Logger = (*Logger)(nil)
+// DebugChecker can be implemented by telemetry.Logger implementations which
+// don't report their effective level through Level.
+type DebugChecker interface {
+	DebugEnabled() bool
+}
+
+// DebugEnabled reports whether the provided Logger emits Debug log lines. It
+// allows callers to skip building log values and scoped loggers on hot paths
+// if debug logging is disabled. Loggers implementing DebugChecker are asked
+// directly, all others are checked using their Level.
+func DebugEnabled(l telemetry.Logger) bool {
+	if l == nil {
+		return false
+	}
+	if c, ok := l.(DebugChecker); ok {
+		return c.DebugEnabled()
+	}
+	return l.Level() >= telemetry.LevelDebug
+}
+
+var (
+	_ telemetry.Logger = (*Logger)(nil)
+	_ DebugChecker     = (*Logger)(nil)
+)
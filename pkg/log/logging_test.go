@@ -0,0 +1,77 @@
+// Copyright (c) Bas van Beek 2024.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package log_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"slices"
+	"testing"
+
+	"github.com/basvanbeek/telemetry"
+
+	"github.com/basvanbeek/run/pkg/log"
+)
+
+func TestDebugEnabled(t *testing.T) {
+	jsonInfo := log.NewJSON(io.Discard)
+	jsonInfo.SetLevel(telemetry.LevelInfo)
+	noopDebug := telemetry.NoopLogger()
+	noopDebug.SetLevel(telemetry.LevelDebug)
+
+	tests := []struct {
+		name   string
+		logger telemetry.Logger
+		want   bool
+	}{
+		{"nil", nil, false},
+		{"bare bones", &log.Logger{}, true},
+		{"json debug", log.NewJSON(io.Discard), true},
+		{"json info", jsonInfo, false},
+		{"noop", telemetry.NoopLogger(), false},
+		{"noop debug", noopDebug, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if have := log.DebugEnabled(tt.logger); have != tt.want {
+				t.Errorf("Expected %t, got %t", tt.want, have)
+			}
+		})
+	}
+}
+
+func TestStdLogger(t *testing.T) {
+	var buf bytes.Buffer
+	l := log.NewStdLogger(log.NewJSON(&buf).With("unit", "http"))
+	l.Printf("http: TLS handshake error from %s", "127.0.0.1:1234")
+	l.Print("first\nsecond")
+
+	var lines []string
+	for _, b := range bytes.Split(bytes.TrimSpace(buf.Bytes()), []byte("\n")) {
+		var event map[string]interface{}
+		if err := json.Unmarshal(b, &event); err != nil {
+			t.Fatalf("Expected JSON log line, got %q: %v", b, err)
+		}
+		if event["level"] != "info" || event["unit"] != "http" {
+			t.Errorf("Expected info line of unit http, got %v", event)
+		}
+		lines = append(lines, event["msg"].(string))
+	}
+	want := []string{"http: TLS handshake error from 127.0.0.1:1234", "first", "second"}
+	if !slices.Equal(want, lines) {
+		t.Errorf("Expected %v, got %v", want, lines)
+	}
+}
@@ -0,0 +1,46 @@
+// Copyright (c) Bas van Beek 2024.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package log
+
+import (
+	"log"
+	"strings"
+
+	"github.com/basvanbeek/telemetry"
+)
+
+// Writer is an io.Writer logging each written line as message of an Info log
+// line of the wrapped telemetry.Logger. It allows output of the standard
+// library log package, such as http.Server.ErrorLog, to end up in structured
+// logging.
+type Writer struct {
+	Logger telemetry.Logger
+}
+
+// Write implements io.Writer.
+func (w Writer) Write(p []byte) (int, error) {
+	for _, line := range strings.Split(string(p), "\n") {
+		if line = strings.TrimRight(line, "\r"); line != "" {
+			w.Logger.Info(line)
+		}
+	}
+	return len(p), nil
+}
+
+// NewStdLogger returns a standard library log.Logger writing to the provided
+// telemetry.Logger. Timestamps are left to the telemetry.Logger.
+func NewStdLogger(l telemetry.Logger) *log.Logger {
+	return log.New(Writer{Logger: l}, "", 0)
+}
@@ -0,0 +1,287 @@
+// Copyright (c) Bas van Beek 2024.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package migrate implements a run.Group unit running database schema
+// migrations.
+package migrate
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/basvanbeek/run"
+	"github.com/basvanbeek/run/pkg/flag"
+)
+
+// ErrInvalidTarget is returned if the target version is lower than the current
+// schema version, as Migrator only migrates forward.
+const ErrInvalidTarget run.Error = "target version below current schema version"
+
+// Migration holds a single schema migration.
+type Migration struct {
+	Version uint64
+	Name    string
+	SQL     string
+}
+
+// Migrator implements run.Config, run.ConfigCommand and run.PreRunner to run
+// database schema migrations. Migrations are read from Source and applied in
+// order of version during PreRun, or when the --migrate command flag is set, in
+// which case the Group bails early after migrating.
+//
+// Migration files must be named <version>_<description>.sql, e.g.
+// 0001_create_users.sql. Each migration is applied in its own transaction and
+// recorded in the migrations table.
+type Migrator struct {
+	// Prefix is used for the flag names. Defaults to "migrate".
+	Prefix string
+	// Source holds the migration files, e.g. an embed.FS.
+	Source fs.FS
+	// Dir is the directory in Source holding the migration files. Defaults to
+	// the root of Source.
+	Dir string
+	// DB returns the database to migrate. Use sqldb.DB.Open to support the
+	// --migrate command as it runs before the PreRun phase.
+	DB func() (*sql.DB, error)
+	// Table holds the name of the table recording the applied migrations.
+	// Defaults to "schema_migrations".
+	Table string
+	// Output receives the SQL of pending migrations in dry-run mode. Defaults
+	// to os.Stdout.
+	Output io.Writer
+
+	dryRun bool
+	target uint64
+	skip   bool
+}
+
+// Name implements run.Unit.
+func (m *Migrator) Name() string {
+	return m.prefix()
+}
+
+// FlagSet implements run.Config.
+func (m *Migrator) FlagSet() *run.FlagSet {
+	p := m.prefix()
+	flags := run.NewFlagSet("Database migration options")
+	flags.BoolVar(&m.dryRun, p+"-dry-run", false,
+		"print the SQL of pending migrations instead of applying them")
+	flags.Uint64Var(&m.target, p+"-target", 0,
+		"schema version to migrate to, 0 migrates to the latest version")
+	flags.BoolVar(&m.skip, p+"-skip", false,
+		"skip running migrations during pre-run")
+	return flags
+}
+
+// Validate implements run.Config.
+func (m *Migrator) Validate() error {
+	if m.Source == nil || m.DB == nil {
+		return fmt.Errorf("%s: migration source and database are required", m.Name())
+	}
+	migrations, err := m.Migrations()
+	if err != nil {
+		return err
+	}
+	if m.target != 0 && !hasVersion(migrations, m.target) {
+		return flag.NewValidationError(m.prefix()+"-target", flag.ErrInvalidVal)
+	}
+	return nil
+}
+
+// Flag implements run.ConfigCommand.
+func (m *Migrator) Flag() (string, string) {
+	return m.prefix(), "run database migrations and exit."
+}
+
+// Execute implements run.ConfigCommand.
+func (m *Migrator) Execute() error {
+	return m.Migrate(context.Background())
+}
+
+// PreRun implements run.PreRunner.
+func (m *Migrator) PreRun() error {
+	if m.skip {
+		return nil
+	}
+	return m.Migrate(context.Background())
+}
+
+// Migrations returns the migrations found in Source, ordered by version.
+func (m *Migrator) Migrations() ([]Migration, error) {
+	dir := m.Dir
+	if dir == "" {
+		dir = "."
+	}
+	files, err := fs.Glob(m.Source, path.Join(dir, "*.sql"))
+	if err != nil {
+		return nil, err
+	}
+	migrations := make([]Migration, 0, len(files))
+	for _, file := range files {
+		base := strings.TrimSuffix(path.Base(file), ".sql")
+		v, name, _ := strings.Cut(base, "_")
+		version, err := strconv.ParseUint(v, 10, 64)
+		if err != nil || version == 0 {
+			return nil, fmt.Errorf("migration %s: invalid version %q", file, v)
+		}
+		b, err := fs.ReadFile(m.Source, file)
+		if err != nil {
+			return nil, fmt.Errorf("migration %s: %w", file, err)
+		}
+		migrations = append(migrations, Migration{
+			Version: version, Name: name, SQL: string(b),
+		})
+	}
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].Version < migrations[j].Version
+	})
+	for i := 1; i < len(migrations); i++ {
+		if migrations[i].Version == migrations[i-1].Version {
+			return nil, fmt.Errorf("duplicate migration version %d",
+				migrations[i].Version)
+		}
+	}
+	return migrations, nil
+}
+
+// Migrate applies all pending migrations up to the target version. In dry-run
+// mode the SQL of the pending migrations is written to Output instead.
+func (m *Migrator) Migrate(ctx context.Context) error {
+	migrations, err := m.Migrations()
+	if err != nil {
+		return err
+	}
+	db, err := m.DB()
+	if err != nil {
+		return err
+	}
+	if !m.dryRun {
+		if _, err = db.ExecContext(ctx, fmt.Sprintf(
+			"CREATE TABLE IF NOT EXISTS %s (version BIGINT NOT NULL PRIMARY KEY)",
+			m.table()),
+		); err != nil {
+			return fmt.Errorf("create migrations table: %w", err)
+		}
+	}
+	current, err := m.Version(ctx, db)
+	if err != nil && !m.dryRun {
+		return err
+	}
+	target := m.target
+	if target == 0 && len(migrations) > 0 {
+		target = migrations[len(migrations)-1].Version
+	}
+	if target < current {
+		return fmt.Errorf("%w: %d < %d", ErrInvalidTarget, target, current)
+	}
+
+	for _, migration := range migrations {
+		if migration.Version <= current || migration.Version > target {
+			continue
+		}
+		if m.dryRun {
+			if err = m.print(migration); err != nil {
+				return err
+			}
+			continue
+		}
+		if err = m.apply(ctx, db, migration); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Version returns the current schema version of the provided database.
+func (m *Migrator) Version(ctx context.Context, db *sql.DB) (uint64, error) {
+	var version sql.NullInt64
+	if err := db.QueryRowContext(ctx, fmt.Sprintf(
+		"SELECT MAX(version) FROM %s", m.table()),
+	).Scan(&version); err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return 0, fmt.Errorf("schema version: %w", err)
+	}
+	return uint64(version.Int64), nil
+}
+
+// apply runs the provided migration in a transaction.
+func (m *Migrator) apply(ctx context.Context, db *sql.DB, migration Migration) (err error) {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+			err = fmt.Errorf("migration %d_%s: %w", migration.Version, migration.Name, err)
+		}
+	}()
+	if _, err = tx.ExecContext(ctx, migration.SQL); err != nil {
+		return err
+	}
+	if _, err = tx.ExecContext(ctx, fmt.Sprintf(
+		"INSERT INTO %s (version) VALUES (%d)", m.table(), migration.Version),
+	); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// print writes the provided migration to Output.
+func (m *Migrator) print(migration Migration) error {
+	w := m.Output
+	if w == nil {
+		w = os.Stdout
+	}
+	_, err := fmt.Fprintf(w, "-- %d_%s\n%s\n", migration.Version, migration.Name,
+		strings.TrimSpace(migration.SQL))
+	return err
+}
+
+func (m *Migrator) prefix() string {
+	if m.Prefix == "" {
+		return "migrate"
+	}
+	return m.Prefix
+}
+
+func (m *Migrator) table() string {
+	if m.Table == "" {
+		return "schema_migrations"
+	}
+	return m.Table
+}
+
+func hasVersion(migrations []Migration, version uint64) bool {
+	for _, migration := range migrations {
+		if migration.Version == version {
+			return true
+		}
+	}
+	return false
+}
+
+var (
+	_ run.Config        = (*Migrator)(nil)
+	_ run.ConfigCommand = (*Migrator)(nil)
+	_ run.PreRunner     = (*Migrator)(nil)
+)
@@ -0,0 +1,186 @@
+// Copyright (c) Bas van Beek 2024.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package migrate
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+	"testing/fstest"
+
+	"github.com/basvanbeek/telemetry"
+
+	"github.com/basvanbeek/run"
+)
+
+// fakeDriver records executed statements and tracks applied versions.
+type fakeDriver struct {
+	mu       sync.Mutex
+	stmts    []string
+	versions []int64
+}
+
+func (f *fakeDriver) Open(string) (driver.Conn, error) { return &fakeConn{d: f}, nil }
+
+func (f *fakeDriver) executed() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]string(nil), f.stmts...)
+}
+
+type fakeConn struct {
+	d *fakeDriver
+}
+
+func (c *fakeConn) Prepare(string) (driver.Stmt, error) { return nil, errors.New("not supported") }
+func (c *fakeConn) Begin() (driver.Tx, error)           { return c, nil }
+func (c *fakeConn) Close() error                        { return nil }
+func (c *fakeConn) Commit() error                       { return nil }
+func (c *fakeConn) Rollback() error                     { return nil }
+
+func (c *fakeConn) ExecContext(_ context.Context, query string, _ []driver.NamedValue) (driver.Result, error) {
+	c.d.mu.Lock()
+	defer c.d.mu.Unlock()
+	c.d.stmts = append(c.d.stmts, query)
+	var version int64
+	if n, _ := fmt.Sscanf(query, "INSERT INTO schema_migrations (version) VALUES (%d)", &version); n == 1 {
+		c.d.versions = append(c.d.versions, version)
+	}
+	return driver.RowsAffected(1), nil
+}
+
+func (c *fakeConn) QueryContext(context.Context, string, []driver.NamedValue) (driver.Rows, error) {
+	c.d.mu.Lock()
+	defer c.d.mu.Unlock()
+	var version any
+	for _, v := range c.d.versions {
+		if max, ok := version.(int64); !ok || v > max {
+			version = v
+		}
+	}
+	return &fakeRows{values: []any{version}}, nil
+}
+
+type fakeRows struct {
+	values []any
+	read   bool
+}
+
+func (r *fakeRows) Columns() []string { return []string{"version"} }
+func (r *fakeRows) Close() error      { return nil }
+func (r *fakeRows) Next(dest []driver.Value) error {
+	if r.read {
+		return io.EOF
+	}
+	r.read = true
+	dest[0] = r.values[0]
+	return nil
+}
+
+var source = fstest.MapFS{
+	"migrations/0001_create_users.sql":  {Data: []byte("CREATE TABLE users (id INT);")},
+	"migrations/0002_add_email.sql":     {Data: []byte("ALTER TABLE users ADD email TEXT;")},
+	"migrations/0003_create_groups.sql": {Data: []byte("CREATE TABLE groups (id INT);")},
+	"migrations/README.md":              {Data: []byte("not a migration")},
+}
+
+func newMigrator(name string) (*Migrator, *fakeDriver) {
+	fd := &fakeDriver{}
+	sql.Register(name, fd)
+	return &Migrator{
+		Source: source,
+		Dir:    "migrations",
+		DB:     func() (*sql.DB, error) { return sql.Open(name, "") },
+	}, fd
+}
+
+func TestMigratePreRun(t *testing.T) {
+	var (
+		g     = run.Group{Name: "migrate", Logger: telemetry.NoopLogger()}
+		m, fd = newMigrator("fake-pre-run")
+	)
+	g.Register(m)
+
+	if err := g.Run("./myService", "--migrate-target", "2"); err != nil {
+		t.Fatalf("Expected successful run, got %v", err)
+	}
+	stmts := fd.executed()
+	if len(stmts) != 5 ||
+		stmts[1] != "CREATE TABLE users (id INT);" ||
+		stmts[3] != "ALTER TABLE users ADD email TEXT;" {
+		t.Errorf("Unexpected statements: %q", stmts)
+	}
+
+	// run again, only the remaining migration is to be applied
+	m.target = 0
+	if err := m.Migrate(context.Background()); err != nil {
+		t.Fatalf("Expected successful migration, got %v", err)
+	}
+	m.target = 1
+	if err := m.Migrate(context.Background()); !errors.Is(err, ErrInvalidTarget) {
+		t.Errorf("Expected %v, got %v", ErrInvalidTarget, err)
+	}
+	if stmts = fd.executed(); stmts[6] != "CREATE TABLE groups (id INT);" {
+		t.Errorf("Unexpected statements: %q", stmts)
+	}
+}
+
+func TestMigrateCommand(t *testing.T) {
+	var (
+		g     = run.Group{Name: "migrate", Logger: telemetry.NoopLogger()}
+		m, fd = newMigrator("fake-command")
+		out   bytes.Buffer
+		ran   bool
+	)
+	m.Output = &out
+	g.Register(m, run.NewPreRunner("not-run", func() error {
+		ran = true
+		return nil
+	}))
+
+	if err := g.Run("./myService", "--migrate", "--migrate-dry-run"); err != nil {
+		t.Fatalf("Expected bail early, got %v", err)
+	}
+	if ran {
+		t.Error("Expected PreRun phase to be skipped")
+	}
+	if len(fd.executed()) != 0 {
+		t.Errorf("Expected no statements in dry-run mode, got %q", fd.executed())
+	}
+	if !strings.Contains(out.String(), "-- 3_create_groups\nCREATE TABLE groups (id INT);") {
+		t.Errorf("Expected pending migrations in output, got %q", out.String())
+	}
+}
+
+func TestMigrateInvalidSource(t *testing.T) {
+	m := Migrator{Source: fstest.MapFS{
+		"1_a.sql":  {Data: []byte("SELECT 1;")},
+		"01_b.sql": {Data: []byte("SELECT 1;")},
+	}}
+	if _, err := m.Migrations(); err == nil {
+		t.Error("Expected duplicate version error")
+	}
+	m.Source = fstest.MapFS{"x_a.sql": {Data: []byte("SELECT 1;")}}
+	if _, err := m.Migrations(); err == nil {
+		t.Error("Expected invalid version error")
+	}
+}
@@ -0,0 +1,228 @@
+// Copyright (c) Bas van Beek 2024.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package otel implements a run.Group unit bootstrapping OpenTelemetry OTLP
+// trace and metric exporters from flags and flushing them at Group teardown.
+//
+// To keep the run module free of the OpenTelemetry SDK dependency tree, the
+// construction of the exporters and providers is delegated to Setup functions
+// provided by the application, e.g.:
+//
+//	t := &otel.Telemetry{
+//		ServiceName: "my-service",
+//		Traces: func(ctx context.Context, s otel.Settings) (otel.Provider, error) {
+//			exp, err := otlptracegrpc.New(ctx,
+//				otlptracegrpc.WithEndpoint(s.Endpoint),
+//				otlptracegrpc.WithHeaders(s.Headers))
+//			if err != nil {
+//				return nil, err
+//			}
+//			tp := sdktrace.NewTracerProvider(
+//				sdktrace.WithBatcher(exp),
+//				sdktrace.WithSampler(sdktrace.TraceIDRatioBased(s.SampleRatio)))
+//			otelapi.SetTracerProvider(tp)
+//			return tp, nil
+//		},
+//	}
+package otel
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/basvanbeek/run"
+)
+
+// Supported OTLP protocols.
+const (
+	ProtocolGRPC = "grpc"
+	ProtocolHTTP = "http/protobuf"
+)
+
+// Settings holds the exporter settings as configured by flag.
+type Settings struct {
+	// ServiceName holds the service name to report.
+	ServiceName string
+	// Endpoint holds the OTLP collector endpoint.
+	Endpoint string
+	// Protocol holds the OTLP protocol, ProtocolGRPC or ProtocolHTTP.
+	Protocol string
+	// Insecure disables TLS towards the collector.
+	Insecure bool
+	// Headers holds additional headers to send to the collector.
+	Headers map[string]string
+	// SampleRatio holds the ratio of traces to sample, between 0 and 1.
+	SampleRatio float64
+	// Attributes holds the default resource attributes identifying the
+	// instance, such as run.LabelServiceInstanceID. Only set if Telemetry
+	// has a Group.
+	Attributes map[string]string
+}
+
+// Provider is implemented by OpenTelemetry SDK providers such as
+// sdktrace.TracerProvider and sdkmetric.MeterProvider.
+type Provider interface {
+	ForceFlush(ctx context.Context) error
+	Shutdown(ctx context.Context) error
+}
+
+// Setup constructs the exporter and Provider for the provided Settings and
+// installs the Provider as global provider.
+type Setup func(ctx context.Context, s Settings) (Provider, error)
+
+// Telemetry implements run.Namer, run.Config, run.PreRunner and
+// run.PostRunner. During
+// PreRun it calls the configured Setup functions, during PostRun the resulting
+// Providers are flushed and shut down in reverse order, bounded by the
+// configured shutdown timeout. Flag defaults are taken from the standard
+// OTEL_* environment variables. Without endpoint, no exporters are set up.
+type Telemetry struct {
+	// ServiceName holds the default service name to report. Defaults to the
+	// Group name.
+	ServiceName string
+	// Group, if set, provides the default resource attributes of the
+	// Settings, see run.Group.Labels.
+	Group *run.Group
+	// Traces sets up trace exporting, if set.
+	Traces Setup
+	// Metrics sets up metric exporting, if set.
+	Metrics Setup
+
+	settings        Settings
+	traces          bool
+	metrics         bool
+	setupTimeout    time.Duration
+	shutdownTimeout time.Duration
+
+	providers []Provider
+}
+
+// Name implements run.Unit.
+func (t *Telemetry) Name() string {
+	return "otel"
+}
+
+// GroupName implements run.Namer.
+func (t *Telemetry) GroupName(name string) {
+	if t.ServiceName == "" {
+		t.ServiceName = name
+	}
+}
+
+// FlagSet implements run.Config.
+func (t *Telemetry) FlagSet() *run.FlagSet {
+	flags := run.NewFlagSet("OpenTelemetry options")
+	flags.StringVar(&t.settings.ServiceName, "otel-service-name",
+		env("OTEL_SERVICE_NAME", t.ServiceName), "service name to report")
+	flags.StringVar(&t.settings.Endpoint, "otel-endpoint",
+		env("OTEL_EXPORTER_OTLP_ENDPOINT", ""),
+		"OTLP collector endpoint, empty disables exporting")
+	flags.StringVar(&t.settings.Protocol, "otel-protocol",
+		env("OTEL_EXPORTER_OTLP_PROTOCOL", ProtocolGRPC),
+		"OTLP protocol ("+ProtocolGRPC+" or "+ProtocolHTTP+")")
+	flags.BoolVar(&t.settings.Insecure, "otel-insecure", false,
+		"disable TLS towards the OTLP collector")
+	flags.StringToStringVar(&t.settings.Headers, "otel-headers", nil,
+		"additional headers to send to the OTLP collector")
+	flags.Float64Var(&t.settings.SampleRatio, "otel-sample-ratio", 1,
+		"ratio of traces to sample")
+	flags.BoolVar(&t.traces, "otel-traces", true, "export traces")
+	flags.BoolVar(&t.metrics, "otel-metrics", true, "export metrics")
+	flags.DurationVar(&t.setupTimeout, "otel-setup-timeout", 10*time.Second,
+		"maximum amount of time to set up the exporters")
+	flags.DurationVar(&t.shutdownTimeout, "otel-shutdown-timeout", 5*time.Second,
+		"maximum amount of time to flush and shut down the exporters")
+	flags.Constrain("otel-protocol").OneOf(ProtocolGRPC, ProtocolHTTP)
+	flags.Constrain("otel-sample-ratio").Min(0).Max(1)
+	return flags
+}
+
+// Validate implements run.Config.
+func (t *Telemetry) Validate() error {
+	return nil
+}
+
+// PreRun implements run.PreRunner. If a Setup function fails, the Providers
+// set up before are shut down.
+func (t *Telemetry) PreRun() error {
+	if t.settings.Endpoint == "" {
+		return nil
+	}
+	if t.Group != nil {
+		t.settings.Attributes = t.Group.Labels()
+		t.settings.Attributes[run.LabelServiceName] = t.settings.ServiceName
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), t.setupTimeout)
+	defer cancel()
+
+	for _, s := range []struct {
+		name    string
+		setup   Setup
+		enabled bool
+	}{
+		{"traces", t.Traces, t.traces},
+		{"metrics", t.Metrics, t.metrics},
+	} {
+		if s.setup == nil || !s.enabled {
+			continue
+		}
+		p, err := s.setup(ctx, t.settings)
+		if err != nil {
+			return errors.Join(fmt.Errorf("%s setup: %w", s.name, err), t.PostRun())
+		}
+		t.providers = append(t.providers, p)
+	}
+	return nil
+}
+
+// PostRun implements run.PostRunner. It flushes and shuts down the Providers.
+func (t *Telemetry) PostRun() error {
+	if len(t.providers) == 0 {
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), t.shutdownTimeout)
+	defer cancel()
+
+	var err error
+	for idx := len(t.providers) - 1; idx >= 0; idx-- {
+		p := t.providers[idx]
+		err = errors.Join(err, p.ForceFlush(ctx), p.Shutdown(ctx))
+	}
+	t.providers = nil
+	return err
+}
+
+// Settings returns the exporter settings as configured by flag.
+func (t *Telemetry) Settings() Settings {
+	return t.settings
+}
+
+// env returns the value of the provided environment variable or def if not
+// set.
+func env(key, def string) string {
+	if v, ok := os.LookupEnv(key); ok {
+		return v
+	}
+	return def
+}
+
+var (
+	_ run.Namer      = (*Telemetry)(nil)
+	_ run.Config     = (*Telemetry)(nil)
+	_ run.PreRunner  = (*Telemetry)(nil)
+	_ run.PostRunner = (*Telemetry)(nil)
+)
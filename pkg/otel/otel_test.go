@@ -0,0 +1,156 @@
+// Copyright (c) Bas van Beek 2024.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package otel
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/basvanbeek/telemetry"
+
+	"github.com/basvanbeek/run"
+	"github.com/basvanbeek/run/pkg/test"
+)
+
+type provider struct {
+	name  string
+	calls *[]string
+}
+
+func (p provider) ForceFlush(context.Context) error {
+	*p.calls = append(*p.calls, p.name+"-flush")
+	return nil
+}
+
+func (p provider) Shutdown(context.Context) error {
+	*p.calls = append(*p.calls, p.name+"-shutdown")
+	return nil
+}
+
+func setup(name string, calls *[]string, settings *Settings) Setup {
+	return func(_ context.Context, s Settings) (Provider, error) {
+		*settings = s
+		*calls = append(*calls, name+"-setup")
+		return provider{name: name, calls: calls}, nil
+	}
+}
+
+func TestTelemetry(t *testing.T) {
+	var (
+		g        = run.Group{Name: "otel", Logger: telemetry.NoopLogger()}
+		calls    []string
+		settings Settings
+		tel      = &Telemetry{ServiceName: "svc"}
+	)
+	tel.Traces = setup("traces", &calls, &settings)
+	tel.Metrics = setup("metrics", &calls, &settings)
+	g.Register(tel, &test.Svc{SvcName: "done", Execute: func() error {
+		return run.ErrRequestedShutdown
+	}})
+
+	if err := g.Run("./myService", "--otel-endpoint", "collector:4317",
+		"--otel-sample-ratio", "0.5", "--otel-headers", "x-key=secret"); err != nil {
+		t.Fatalf("Expected proper close, got %v", err)
+	}
+	want := []string{"traces-setup", "metrics-setup",
+		"metrics-flush", "metrics-shutdown", "traces-flush", "traces-shutdown"}
+	if !reflect.DeepEqual(calls, want) {
+		t.Errorf("Expected %v, got %v", want, calls)
+	}
+	if settings.ServiceName != "svc" || settings.Endpoint != "collector:4317" ||
+		settings.Protocol != ProtocolGRPC || settings.SampleRatio != 0.5 ||
+		settings.Headers["x-key"] != "secret" {
+		t.Errorf("Unexpected settings: %+v", settings)
+	}
+	if settings.Attributes != nil {
+		t.Errorf("Expected no attributes without Group, got %v", settings.Attributes)
+	}
+}
+
+func TestTelemetryGroup(t *testing.T) {
+	var (
+		g        = run.NewGroup("otel", run.WithLogger(telemetry.NoopLogger()), run.WithInstanceID("blue"))
+		calls    []string
+		settings Settings
+		tel      = &Telemetry{Group: g}
+	)
+	tel.Traces = setup("traces", &calls, &settings)
+	g.Register(tel, &test.Svc{SvcName: "done", Execute: func() error {
+		return run.ErrRequestedShutdown
+	}})
+
+	if err := g.Run("./myService", "-n", "renamed", "--otel-endpoint", "collector:4317"); err != nil {
+		t.Fatalf("Expected proper close, got %v", err)
+	}
+	if settings.ServiceName != "renamed" ||
+		settings.Attributes[run.LabelServiceName] != "renamed" ||
+		settings.Attributes[run.LabelServiceInstanceID] != "blue" {
+		t.Errorf("Unexpected settings: %+v", settings)
+	}
+}
+
+func TestTelemetryDisabled(t *testing.T) {
+	var (
+		g     = run.Group{Name: "otel", Logger: telemetry.NoopLogger()}
+		calls []string
+		s     Settings
+		tel   = &Telemetry{}
+	)
+	tel.Traces = setup("traces", &calls, &s)
+	tel.Metrics = setup("metrics", &calls, &s)
+	g.Register(tel)
+
+	if err := g.Run("./myService", "--otel-endpoint", "collector:4317",
+		"--otel-metrics=false"); err != nil {
+		t.Fatalf("Expected proper close, got %v", err)
+	}
+	if want := []string{"traces-setup", "traces-flush", "traces-shutdown"}; !reflect.DeepEqual(calls, want) {
+		t.Errorf("Expected %v, got %v", want, calls)
+	}
+
+	calls = nil
+	if err := g.Reset(); err != nil {
+		t.Fatalf("Expected nil error, got %v", err)
+	}
+	if err := g.Run("./myService"); err != nil {
+		t.Fatalf("Expected proper close, got %v", err)
+	}
+	if len(calls) != 0 {
+		t.Errorf("Expected no exporters without endpoint, got %v", calls)
+	}
+}
+
+func TestTelemetrySetupError(t *testing.T) {
+	var (
+		g     = run.Group{Name: "otel", Logger: telemetry.NoopLogger()}
+		e     = errors.New("no collector")
+		calls []string
+		s     Settings
+		tel   = &Telemetry{}
+	)
+	tel.Traces = setup("traces", &calls, &s)
+	tel.Metrics = func(context.Context, Settings) (Provider, error) { return nil, e }
+	g.Register(tel)
+
+	if err := g.Run("./myService", "--otel-endpoint", "collector:4317",
+		"--otel-protocol", ProtocolHTTP); !errors.Is(err, e) {
+		t.Errorf("Expected %v, got %v", e, err)
+	}
+	if want := []string{"traces-setup", "traces-flush", "traces-shutdown"}; !reflect.DeepEqual(calls, want) {
+		t.Errorf("Expected %v, got %v", want, calls)
+	}
+}
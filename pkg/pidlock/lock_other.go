@@ -0,0 +1,44 @@
+// Copyright (c) Bas van Beek 2024.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build !unix
+
+package pidlock
+
+import (
+	"errors"
+	"os"
+)
+
+// lockFile exclusively creates the lock file. As the file is not removed if
+// the process dies, a stale lock file must be removed manually.
+func lockFile(path string) (*os.File, error) {
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE|os.O_EXCL, 0o644) //nolint:gosec // operator provided path
+	if errors.Is(err, os.ErrExist) {
+		if f, err = os.Open(path); err != nil { //nolint:gosec // operator provided path
+			return nil, err
+		}
+		defer func() { _ = f.Close() }()
+		return nil, locked(f, path)
+	}
+	return f, err
+}
+
+// releaseFile closes and removes the lock file.
+func releaseFile(f *os.File, path string) error {
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return os.Remove(path)
+}
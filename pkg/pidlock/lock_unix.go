@@ -0,0 +1,70 @@
+// Copyright (c) Bas van Beek 2024.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build unix
+
+package pidlock
+
+import (
+	"errors"
+	"os"
+	"syscall"
+)
+
+// lockFile opens the lock file and acquires an exclusive flock on it. As
+// releaseFile removes the lock file before unlocking it, the flock might have
+// been acquired on a file which has since been removed, in which case locking
+// is retried on the file currently at path.
+func lockFile(path string) (*os.File, error) {
+	for {
+		f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0o644) //nolint:gosec // operator provided path
+		if err != nil {
+			return nil, err
+		}
+		if err = syscall.Flock(int(f.Fd()), syscall.LOCK_EX|syscall.LOCK_NB); err != nil {
+			defer func() { _ = f.Close() }()
+			if errors.Is(err, syscall.EWOULDBLOCK) {
+				return nil, locked(f, path)
+			}
+			return nil, err
+		}
+		if current(f, path) {
+			return f, nil
+		}
+		_ = f.Close()
+	}
+}
+
+// current returns true if the provided file is the file currently found at
+// path.
+func current(f *os.File, path string) bool {
+	fi, err := f.Stat()
+	if err != nil {
+		return false
+	}
+	pi, err := os.Stat(path)
+	return err == nil && os.SameFile(fi, pi)
+}
+
+// releaseFile removes the lock file, releases the flock and closes the file.
+// The file is removed before unlocking, so a new instance can't acquire a lock
+// on the removed file.
+func releaseFile(f *os.File, path string) error {
+	_ = os.Remove(path)
+	err := syscall.Flock(int(f.Fd()), syscall.LOCK_UN)
+	if cErr := f.Close(); err == nil {
+		err = cErr
+	}
+	return err
+}
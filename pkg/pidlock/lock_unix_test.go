@@ -0,0 +1,46 @@
+// Copyright (c) Bas van Beek 2024.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build unix
+
+package pidlock
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestCurrent(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "svc.pid")
+	f, err := lockFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = f.Close() }()
+	if !current(f, path) {
+		t.Error("Expected locked file to be current")
+	}
+
+	// a file replacing the removed lock file is a different file
+	if err = os.Remove(path); err != nil {
+		t.Fatal(err)
+	}
+	if err = os.WriteFile(path, nil, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if current(f, path) {
+		t.Error("Expected removed lock file not to be current")
+	}
+}
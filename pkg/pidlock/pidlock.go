@@ -0,0 +1,135 @@
+// Copyright (c) Bas van Beek 2024.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package pidlock implements a run.Group unit holding an exclusive lock file
+// to prevent concurrent instances of a daemon.
+package pidlock
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/basvanbeek/run"
+	"github.com/basvanbeek/run/pkg/flag"
+)
+
+// ErrLocked is returned by PreRun if another instance holds the lock.
+const ErrLocked run.Error = "another instance is already running"
+
+// Lock implements run.Namer, run.Config, run.PreRunner and run.PostRunner. It
+// acquires an exclusive lock on the lock file during PreRun, writes the
+// process id to it and releases the lock once the Group has stopped. On
+// platforms supporting flock, the lock is released by the operating system if
+// the process dies, so stale lock files don't block new instances.
+type Lock struct {
+	groupName string
+	path      string
+
+	release func() error
+}
+
+// Name implements run.Unit.
+func (l *Lock) Name() string {
+	return "pidlock"
+}
+
+// GroupName implements run.Namer.
+func (l *Lock) GroupName(name string) {
+	l.groupName = name
+}
+
+// FlagSet implements run.Config.
+func (l *Lock) FlagSet() *run.FlagSet {
+	name := l.groupName
+	if name == "" {
+		name = filepath.Base(os.Args[0])
+	}
+	flags := run.NewFlagSet("PID lock options")
+	flags.StringVar(&l.path, "pidlock-path", filepath.Join(os.TempDir(), name+".pid"),
+		"path of the lock file preventing concurrent instances")
+	return flags
+}
+
+// Validate implements run.Config.
+func (l *Lock) Validate() error {
+	if l.path == "" {
+		return flag.NewValidationError("pidlock-path", flag.ErrRequired)
+	}
+	return nil
+}
+
+// PreRun implements run.PreRunner and acquires the lock.
+func (l *Lock) PreRun() error {
+	release, err := Acquire(l.path)
+	if err != nil {
+		return err
+	}
+	l.release = release
+	return nil
+}
+
+// PostRun implements run.PostRunner and releases the lock.
+func (l *Lock) PostRun() error {
+	if l.release == nil {
+		return nil
+	}
+	release := l.release
+	l.release = nil
+	return release()
+}
+
+// Acquire acquires an exclusive lock on the lock file at path and writes the
+// process id to it, for Units managing lock files of their own. The returned
+// release function removes the lock file and releases the lock. If another
+// instance holds the lock, an error wrapping ErrLocked is returned.
+func Acquire(path string) (release func() error, err error) {
+	f, err := lockFile(path)
+	if err != nil {
+		return nil, err
+	}
+	if err = f.Truncate(0); err == nil {
+		_, err = f.WriteString(strconv.Itoa(os.Getpid()) + "\n")
+	}
+	if err != nil {
+		_ = releaseFile(f, path)
+		return nil, fmt.Errorf("pidlock %s: %w", path, err)
+	}
+	return func() error { return releaseFile(f, path) }, nil
+}
+
+// Path returns the path of the lock file.
+func (l *Lock) Path() string {
+	return l.path
+}
+
+// locked returns ErrLocked decorated with the process id found in the lock
+// file.
+func locked(f *os.File, path string) error {
+	b, _ := io.ReadAll(io.LimitReader(f, 32))
+	if pid := strings.TrimSpace(string(b)); pid != "" {
+		return fmt.Errorf("%w: %s held by pid %s", ErrLocked, path, pid)
+	}
+	return fmt.Errorf("%w: %s is locked", ErrLocked, path)
+}
+
+var (
+	_ run.Namer      = (*Lock)(nil)
+	_ run.Config     = (*Lock)(nil)
+	_ run.PreRunner  = (*Lock)(nil)
+	_ run.PostRunner = (*Lock)(nil)
+)
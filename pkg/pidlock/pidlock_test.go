@@ -0,0 +1,76 @@
+// Copyright (c) Bas van Beek 2024.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pidlock_test
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/basvanbeek/run/pkg/pidlock"
+)
+
+func TestLock(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "svc.pid")
+	first, second := &pidlock.Lock{}, &pidlock.Lock{}
+	for _, l := range []*pidlock.Lock{first, second} {
+		l.GroupName("svc")
+		fs := l.FlagSet()
+		if err := fs.Parse([]string{"--pidlock-path", path}); err != nil {
+			t.Fatal(err)
+		}
+		if err := l.Validate(); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := first.PreRun(); err != nil {
+		t.Fatalf("Expected nil, got %v", err)
+	}
+	b, err := os.ReadFile(path)
+	if err != nil || strings.TrimSpace(string(b)) != strconv.Itoa(os.Getpid()) {
+		t.Errorf("Expected pid in lock file, got %q: %v", b, err)
+	}
+
+	err = second.PreRun()
+	if !errors.Is(err, pidlock.ErrLocked) {
+		t.Fatalf("Expected %v, got %v", pidlock.ErrLocked, err)
+	}
+	if !strings.Contains(err.Error(), "pid "+strconv.Itoa(os.Getpid())) {
+		t.Errorf("Expected pid of lock holder, got %v", err)
+	}
+
+	if err = first.PostRun(); err != nil {
+		t.Fatalf("Expected nil, got %v", err)
+	}
+	if _, err = os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("Expected lock file to be removed, got %v", err)
+	}
+	if err = second.PreRun(); err != nil {
+		t.Fatalf("Expected lock to be acquired after release, got %v", err)
+	}
+	if err = second.PostRun(); err != nil {
+		t.Fatalf("Expected nil, got %v", err)
+	}
+
+	l := &pidlock.Lock{}
+	l.GroupName("svc")
+	if l.FlagSet(); l.Path() != filepath.Join(os.TempDir(), "svc.pid") {
+		t.Errorf("Expected default path in temp dir, got %s", l.Path())
+	}
+}
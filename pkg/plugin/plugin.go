@@ -0,0 +1,183 @@
+// Copyright (c) Bas van Beek 2024.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package plugin implements loading of additional run.Group Units at startup
+// from Go plugins, turning a Group into a platform for extensible monoliths.
+package plugin
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"plugin"
+	"sort"
+	"strings"
+
+	"github.com/basvanbeek/run"
+	"github.com/basvanbeek/run/pkg/flag"
+)
+
+// DefaultSymbol is the symbol looked up in plugins if Loader.Symbol is empty.
+const DefaultSymbol = "Units"
+
+// ErrPluginNotFound is returned by Load if an enabled plugin can't be found
+// in the plugin directory.
+const ErrPluginNotFound run.Error = "plugin not found"
+
+// Loader implements run.Config. It discovers Go plugins (.so files) in the
+// plugin directory and registers the Units they provide with a Group, so
+// their Config, PreRun and Serve phases take part in the Group lifecycle.
+//
+// A plugin exports a function returning its Units under the Symbol name:
+//
+//	func Units() []run.Unit { return []run.Unit{&mySvc{}} }
+//
+// Plugins must be built with the same Go toolchain and package versions as
+// the host binary, see the standard library plugin package for details.
+type Loader struct {
+	// Symbol holds the name of the exported func() []run.Unit looked up in
+	// each plugin. Defaults to DefaultSymbol.
+	Symbol string
+	// Open returns the Units of the plugin found at path. It defaults to
+	// using the standard library plugin package and can be replaced to
+	// support other plugin mechanisms, e.g. subprocess based plugins.
+	Open func(path, symbol string) ([]run.Unit, error)
+
+	dir     string
+	enabled []string
+	loaded  []string
+}
+
+// Name implements run.Unit.
+func (l *Loader) Name() string {
+	return "plugin-loader"
+}
+
+// FlagSet implements run.Config.
+func (l *Loader) FlagSet() *run.FlagSet {
+	flags := run.NewFlagSet("Plugin options")
+	flags.StringVar(&l.dir, "plugin-dir", "",
+		"directory to load plugins (.so files) from")
+	flags.StringSliceVar(&l.enabled, "plugin", nil,
+		"name of plugin to load, loads all plugins found if omitted (repeatable)")
+	return flags
+}
+
+// Validate implements run.Config.
+func (l *Loader) Validate() error {
+	if len(l.enabled) > 0 && l.dir == "" {
+		return flag.NewValidationError("plugin-dir", flag.ErrRequired)
+	}
+	return nil
+}
+
+// Load registers the Loader with the provided Group and registers the Units of
+// the plugins selected by the plugin flags found in args (os.Args if empty).
+// As the Units of plugins can take part in the Config phase, Load must be
+// called before the Group's Run or RunConfig method.
+func (l *Loader) Load(g *run.Group, args ...string) error {
+	if len(args) == 0 {
+		args = os.Args[1:]
+	}
+	g.Register(l)
+
+	// parse our plugin flags only, the Group parses all flags later on
+	fs := l.FlagSet()
+	fs.ParseErrorsWhitelist.UnknownFlags = true
+	fs.SetOutput(io.Discard)
+	fs.Usage = func() {}
+	_ = fs.Parse(args)
+	if l.dir == "" {
+		return nil
+	}
+
+	paths, err := l.discover()
+	if err != nil {
+		return err
+	}
+	open, symbol := l.Open, l.Symbol
+	if open == nil {
+		open = openPlugin
+	}
+	if symbol == "" {
+		symbol = DefaultSymbol
+	}
+	for _, name := range sortedNames(paths) {
+		units, err := open(paths[name], symbol)
+		if err != nil {
+			return fmt.Errorf("plugin %s: %w", name, err)
+		}
+		g.Register(units...)
+		l.loaded = append(l.loaded, name)
+	}
+	return nil
+}
+
+// Plugins returns the names of the loaded plugins.
+func (l *Loader) Plugins() []string {
+	return l.loaded
+}
+
+// discover returns the paths of the enabled plugins keyed by plugin name.
+func (l *Loader) discover() (map[string]string, error) {
+	matches, err := filepath.Glob(filepath.Join(l.dir, "*.so"))
+	if err != nil {
+		return nil, fmt.Errorf("plugin-dir: %w", err)
+	}
+	found := make(map[string]string)
+	for _, path := range matches {
+		found[strings.TrimSuffix(filepath.Base(path), ".so")] = path
+	}
+	if len(l.enabled) == 0 {
+		return found, nil
+	}
+	paths := make(map[string]string)
+	for _, name := range l.enabled {
+		path, ok := found[name]
+		if !ok {
+			return nil, fmt.Errorf("%s: %w in %s", name, ErrPluginNotFound, l.dir)
+		}
+		paths[name] = path
+	}
+	return paths, nil
+}
+
+// openPlugin opens a Go plugin and calls its exported Units function.
+func openPlugin(path, symbol string) ([]run.Unit, error) {
+	p, err := plugin.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	sym, err := p.Lookup(symbol)
+	if err != nil {
+		return nil, err
+	}
+	fn, ok := sym.(func() []run.Unit)
+	if !ok {
+		return nil, fmt.Errorf("symbol %s: expected func() []run.Unit, got %T", symbol, sym)
+	}
+	return fn(), nil
+}
+
+func sortedNames(paths map[string]string) []string {
+	names := make([]string, 0, len(paths))
+	for name := range paths {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+var _ run.Config = (*Loader)(nil)
@@ -0,0 +1,85 @@
+// Copyright (c) Bas van Beek 2024.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package plugin_test
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/basvanbeek/telemetry"
+
+	"github.com/basvanbeek/run"
+	"github.com/basvanbeek/run/pkg/plugin"
+	"github.com/basvanbeek/run/pkg/test"
+)
+
+func TestLoader(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"alpha.so", "beta.so", "notes.txt"} {
+		if err := os.WriteFile(filepath.Join(dir, name), nil, 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	var opened []string
+	open := func(path, symbol string) ([]run.Unit, error) {
+		if symbol != plugin.DefaultSymbol {
+			t.Errorf("Expected symbol %s, got %s", plugin.DefaultSymbol, symbol)
+		}
+		name := strings.TrimSuffix(filepath.Base(path), ".so")
+		opened = append(opened, name)
+		return []run.Unit{&test.Svc{SvcName: name + "-svc", Execute: func() error {
+			return run.ErrRequestedShutdown
+		}}}, nil
+	}
+
+	for idx, tt := range []struct {
+		args    []string
+		plugins string
+		err     error
+	}{
+		{args: nil, plugins: ""},
+		{args: []string{"--plugin-dir", dir}, plugins: "alpha,beta"},
+		{args: []string{"--plugin-dir=" + dir, "--plugin", "beta"}, plugins: "beta"},
+		{args: []string{"--plugin-dir", dir, "--plugin", "gamma"}, err: plugin.ErrPluginNotFound},
+	} {
+		opened = nil
+		var (
+			g = run.NewGroup("plugins", run.WithLogger(telemetry.NoopLogger()))
+			l = &plugin.Loader{Open: open}
+		)
+		args := append([]string{"./myService"}, tt.args...)
+		err := l.Load(g, args...)
+		if !errors.Is(err, tt.err) {
+			t.Errorf("[%d] Expected %v, got %v", idx, tt.err, err)
+		}
+		if err != nil {
+			continue
+		}
+		if want, have := tt.plugins, strings.Join(l.Plugins(), ","); want != have {
+			t.Errorf("[%d] plugins want: %q, have: %q", idx, want, have)
+		}
+		for _, name := range l.Plugins() {
+			if !strings.Contains(g.ListUnits(), name+"-svc") {
+				t.Errorf("[%d] Expected %s Unit to be registered, got %s", idx, name, g.ListUnits())
+			}
+		}
+		if err = g.Run(args...); err != nil {
+			t.Errorf("[%d] Expected nil, got %v", idx, err)
+		}
+	}
+}
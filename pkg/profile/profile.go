@@ -0,0 +1,240 @@
+// Copyright (c) Bas van Beek 2024.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package profile implements a run.FlagSource loading flag values from named
+// configuration profiles persisted per user, allowing operators of CLI tools
+// to switch between environments such as staging and production.
+package profile
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/pflag"
+
+	"github.com/basvanbeek/run"
+	"github.com/basvanbeek/run/pkg/flag"
+)
+
+// Flag names registered by Config.
+const (
+	FlagProfile = "profile"
+	FlagSave    = "profile-save"
+)
+
+// ErrNotFound is returned if the selected profile does not exist.
+var ErrNotFound = errors.New("profile not found")
+
+// Config implements run.Config, run.Namer and run.FlagSource. The profile
+// selected with --profile is read from <Dir>/<profile>.yaml and provides the
+// values of flags not provided on the command line. With --profile-save the
+// flags changed so far are persisted into the profile first, which are the
+// flags provided on the command line if Config is registered before other
+// FlagSources. Sensitive flag values are never persisted.
+type Config struct {
+	// Dir holds the directory with profiles. If empty, the profiles directory
+	// below the Group name in the user's configuration directory is used,
+	// e.g. ~/.config/<name>/profiles.
+	Dir string
+
+	groupName string
+	profile   string
+	save      bool
+}
+
+// Name implements run.Unit.
+func (c *Config) Name() string {
+	return "profile"
+}
+
+// GroupName implements run.Namer.
+func (c *Config) GroupName(name string) {
+	c.groupName = name
+}
+
+// FlagSet implements run.Config.
+func (c *Config) FlagSet() *run.FlagSet {
+	flags := run.NewFlagSet("Profile options")
+	flags.StringVar(&c.profile, FlagProfile, "",
+		"name of the configuration profile providing flag values not set on\n"+
+			"the command line, disabled if empty")
+	flags.BoolVar(&c.save, FlagSave, false,
+		"persist the flags provided on the command line into the selected\n"+
+			"profile")
+	return flags
+}
+
+// Validate implements run.Config.
+func (c *Config) Validate() error {
+	if c.profile == "" {
+		if c.save {
+			return flag.NewValidationError(FlagProfile, flag.ErrRequired)
+		}
+		return nil
+	}
+	if strings.ContainsAny(c.profile, `/\`) || strings.HasPrefix(c.profile, ".") {
+		return flag.NewValidationError(FlagProfile, flag.ErrInvalidVal)
+	}
+	return nil
+}
+
+// Profile returns the name of the selected profile.
+func (c *Config) Profile() string {
+	return c.profile
+}
+
+// Path returns the file path of the provided profile.
+func (c *Config) Path(profile string) (string, error) {
+	dir := c.Dir
+	if dir == "" {
+		base, err := os.UserConfigDir()
+		if err != nil {
+			return "", err
+		}
+		dir = filepath.Join(base, c.groupName, "profiles")
+	}
+	return filepath.Join(dir, profile+".yaml"), nil
+}
+
+// Profiles returns the names of the available profiles in sorted order.
+func (c *Config) Profiles() ([]string, error) {
+	path, err := c.Path("")
+	if err != nil {
+		return nil, err
+	}
+	entries, err := os.ReadDir(filepath.Dir(path))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var profiles []string
+	for _, e := range entries {
+		if name, ok := strings.CutSuffix(e.Name(), ".yaml"); ok && !e.IsDir() {
+			profiles = append(profiles, name)
+		}
+	}
+	return profiles, nil
+}
+
+// LoadFlags implements run.FlagSource.
+func (c *Config) LoadFlags(fs *run.FlagSet) error {
+	if c.profile == "" {
+		return nil
+	}
+	if err := c.Validate(); err != nil {
+		return err
+	}
+	path, err := c.Path(c.profile)
+	if err != nil {
+		return err
+	}
+	values, err := Read(path)
+	switch {
+	case errors.Is(err, os.ErrNotExist) && c.save:
+		values = make(map[string]string)
+	case errors.Is(err, os.ErrNotExist):
+		return fmt.Errorf("%w: %s", ErrNotFound, c.profile)
+	case err != nil:
+		return err
+	}
+
+	if c.save {
+		fs.Visit(func(f *pflag.Flag) {
+			if f.Name == FlagProfile || f.Name == FlagSave || flag.IsSensitive(f) {
+				return
+			}
+			values[f.Name] = f.Value.String()
+		})
+		if err = Write(path, values); err != nil {
+			return err
+		}
+	}
+
+	for _, name := range sortedKeys(values) {
+		if _, err = fs.SetUnchanged(name, values[name]); err != nil {
+			return flag.NewValidationError(name, err)
+		}
+	}
+	return nil
+}
+
+// Read returns the flag values held by the profile at path. Profiles hold a
+// single "name: value" pair per line, values may be quoted. Empty lines and
+// lines starting with # are ignored.
+func Read(path string) (map[string]string, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	values := make(map[string]string)
+	scanner := bufio.NewScanner(bytes.NewReader(b))
+	for nr := 1; scanner.Scan(); nr++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		name, value, ok := strings.Cut(line, ":")
+		if !ok {
+			return nil, fmt.Errorf("%s:%d: expected name: value", path, nr)
+		}
+		value = strings.TrimSpace(value)
+		if len(value) > 1 && (value[0] == '"' || value[0] == '\'') &&
+			value[len(value)-1] == value[0] {
+			if value[0] == '\'' {
+				value = strings.ReplaceAll(value[1:len(value)-1], "''", "'")
+			} else if value, err = strconv.Unquote(value); err != nil {
+				return nil, fmt.Errorf("%s:%d: %w", path, nr, err)
+			}
+		}
+		values[strings.TrimSpace(name)] = value
+	}
+	return values, scanner.Err()
+}
+
+// Write persists the provided flag values as profile at path, creating its
+// directory if needed.
+func Write(path string, values map[string]string) error {
+	var sb strings.Builder
+	for _, name := range sortedKeys(values) {
+		sb.WriteString(name + ": " + strconv.Quote(values[name]) + "\n")
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return err
+	}
+	return os.WriteFile(path, []byte(sb.String()), 0o600)
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+var (
+	_ run.Config     = (*Config)(nil)
+	_ run.Namer      = (*Config)(nil)
+	_ run.FlagSource = (*Config)(nil)
+)
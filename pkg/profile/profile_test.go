@@ -0,0 +1,132 @@
+// Copyright (c) Bas van Beek 2024.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package profile_test
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/basvanbeek/telemetry"
+
+	"github.com/basvanbeek/run"
+	"github.com/basvanbeek/run/pkg/profile"
+)
+
+type appConfig struct {
+	fs *run.FlagSet
+}
+
+func (a appConfig) Name() string          { return "app" }
+func (a appConfig) FlagSet() *run.FlagSet { return a.fs }
+func (a appConfig) Validate() error       { return nil }
+
+func runProfile(t *testing.T, dir string, args ...string) (endpoint, level, token string, err error) {
+	t.Helper()
+	g := run.Group{Name: "profile", Logger: telemetry.NoopLogger()}
+	fs := run.NewFlagSet("app")
+	fs.StringVar(&endpoint, "endpoint", "localhost", "endpoint")
+	fs.StringVar(&level, "level", "info", "log level")
+	fs.SensitiveStringVar(&token, "token", "", "access token")
+	g.Register(&profile.Config{Dir: dir}, appConfig{fs: fs})
+	err = g.Run(args...)
+	return endpoint, level, token, err
+}
+
+func TestProfile(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "staging.yaml"), []byte(
+		"# staging environment\n"+
+			"endpoint: \"staging.example.com\"\n"+
+			"level: 'debug'\n"+
+			"unknown: ignored\n",
+	), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	endpoint, level, _, err := runProfile(t, dir, "--profile", "staging", "--level", "warn")
+	if err != nil {
+		t.Fatalf("Expected proper close, got %v", err)
+	}
+	if want, have := "staging.example.com", endpoint; want != have {
+		t.Errorf("endpoint want: %s, have: %s", want, have)
+	}
+	// command line takes precedence
+	if want, have := "warn", level; want != have {
+		t.Errorf("level want: %s, have: %s", want, have)
+	}
+
+	// no profile selected
+	if endpoint, _, _, err = runProfile(t, dir); err != nil {
+		t.Fatalf("Expected proper close, got %v", err)
+	}
+	if want, have := "localhost", endpoint; want != have {
+		t.Errorf("endpoint want: %s, have: %s", want, have)
+	}
+}
+
+func TestProfileSave(t *testing.T) {
+	dir := t.TempDir()
+
+	if _, _, _, err := runProfile(t, dir, "--profile", "prod", "--profile-save",
+		"--endpoint", "prod.example.com", "--token", "secret"); err != nil {
+		t.Fatalf("Expected proper close, got %v", err)
+	}
+	values, err := profile.Read(filepath.Join(dir, "prod.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want, have := map[string]string{"endpoint": "prod.example.com"}, values; !reflect.DeepEqual(want, have) {
+		t.Errorf("values want: %v, have: %v", want, have)
+	}
+
+	// saving merges with the existing profile
+	if _, _, _, err = runProfile(t, dir, "--profile", "prod", "--profile-save",
+		"--level", "error"); err != nil {
+		t.Fatalf("Expected proper close, got %v", err)
+	}
+	endpoint, level, token, err := runProfile(t, dir, "--profile", "prod")
+	if err != nil {
+		t.Fatalf("Expected proper close, got %v", err)
+	}
+	if endpoint != "prod.example.com" || level != "error" || token != "" {
+		t.Errorf("unexpected values: endpoint=%s level=%s token=%s", endpoint, level, token)
+	}
+
+	c := profile.Config{Dir: dir}
+	profiles, err := c.Profiles()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want, have := []string{"prod"}, profiles; !reflect.DeepEqual(want, have) {
+		t.Errorf("profiles want: %v, have: %v", want, have)
+	}
+}
+
+func TestProfileErrors(t *testing.T) {
+	dir := t.TempDir()
+
+	if _, _, _, err := runProfile(t, dir, "--profile", "missing"); !errors.Is(err, profile.ErrNotFound) {
+		t.Errorf("Expected %v, got %v", profile.ErrNotFound, err)
+	}
+	if _, _, _, err := runProfile(t, dir, "--profile", "../escape"); err == nil {
+		t.Error("Expected invalid profile name error, got nil")
+	}
+	if _, _, _, err := runProfile(t, dir, "--profile-save"); err == nil {
+		t.Error("Expected required profile error, got nil")
+	}
+}
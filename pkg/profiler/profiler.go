@@ -0,0 +1,183 @@
+// Copyright (c) Bas van Beek 2024.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package profiler implements a run.Group unit capturing CPU, heap and
+// goroutine profiles on demand, for environments where attaching pprof over
+// HTTP is not possible.
+package profiler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"runtime/pprof"
+	"sync"
+	"time"
+
+	"github.com/basvanbeek/telemetry"
+
+	"github.com/basvanbeek/run"
+	"github.com/basvanbeek/run/pkg/flag"
+	"github.com/basvanbeek/run/pkg/log"
+)
+
+// Profiler implements run.Config, run.PreRunner and run.ServiceContext. On
+// receiving SIGUSR1, or when Capture is called (e.g. from an admin API), it
+// writes CPU, heap and goroutine profiles to the configured directory using
+// timestamped filenames and logs where they were written.
+type Profiler struct {
+	// Logger is used to report captured profiles. Defaults to the bare bones
+	// logger used by run.Group.
+	Logger telemetry.Logger
+
+	dir         string
+	cpuDuration time.Duration
+
+	signal chan os.Signal
+	mu     sync.Mutex // serializes captures
+}
+
+// Name implements run.Unit.
+func (p *Profiler) Name() string {
+	return "profiler"
+}
+
+// FlagSet implements run.Config.
+func (p *Profiler) FlagSet() *run.FlagSet {
+	flags := run.NewFlagSet("Profiler options")
+	flags.StringVar(&p.dir, "profiler-dir", os.TempDir(),
+		"directory to write captured profiles to")
+	flags.DurationVar(&p.cpuDuration, "profiler-cpu-duration", 10*time.Second,
+		"duration of captured CPU profiles, 0 disables CPU profiling")
+	return flags
+}
+
+// Validate implements run.Config.
+func (p *Profiler) Validate() error {
+	if p.dir == "" {
+		return flag.NewValidationError("profiler-dir", flag.ErrRequired)
+	}
+	if p.cpuDuration < 0 {
+		return flag.NewValidationError("profiler-cpu-duration", flag.ErrInvalidVal)
+	}
+	return nil
+}
+
+// PreRun implements run.PreRunner.
+func (p *Profiler) PreRun() error {
+	if p.Logger == nil {
+		p.Logger = &log.Logger{}
+	}
+	if err := os.MkdirAll(p.dir, 0o700); err != nil {
+		return err
+	}
+	p.signal = make(chan os.Signal, 1)
+	if len(captureSignals) > 0 {
+		signal.Notify(p.signal, captureSignals...)
+	}
+	return nil
+}
+
+// ServeContext implements run.ServiceContext. Capture failures are logged and
+// do not stop the Profiler.
+func (p *Profiler) ServeContext(ctx context.Context) error {
+	defer signal.Stop(p.signal)
+	for {
+		select {
+		case <-p.signal:
+			if _, err := p.Capture(ctx); err != nil && ctx.Err() == nil {
+				p.Logger.Error("profile capture failed", err)
+			}
+		case <-ctx.Done():
+			return nil
+		}
+	}
+}
+
+// Capture writes heap, goroutine and, if enabled, CPU profiles to the
+// configured directory and returns the paths of the written files. Capturing
+// the CPU profile blocks for the configured duration or until the provided
+// context is done. Concurrent calls are serialized.
+func (p *Profiler) Capture(ctx context.Context) (paths []string, err error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	ts := time.Now().UTC().Format("20060102T150405.000")
+	for _, name := range []string{"heap", "goroutine"} {
+		path := filepath.Join(p.dir, name+"-"+ts+".pprof")
+		profile := pprof.Lookup(name)
+		if wErr := writeFile(path, func(w io.Writer) error {
+			return profile.WriteTo(w, 0)
+		}); wErr != nil {
+			err = errors.Join(err, fmt.Errorf("%s profile: %w", name, wErr))
+			continue
+		}
+		paths = append(paths, path)
+	}
+	if p.cpuDuration > 0 {
+		path := filepath.Join(p.dir, "cpu-"+ts+".pprof")
+		if wErr := writeFile(path, func(w io.Writer) error {
+			return p.cpuProfile(ctx, w)
+		}); wErr != nil {
+			err = errors.Join(err, fmt.Errorf("cpu profile: %w", wErr))
+		} else {
+			paths = append(paths, path)
+		}
+	}
+	if len(paths) > 0 && p.Logger != nil {
+		p.Logger.Info("profiles written", "paths", paths)
+	}
+	return paths, err
+}
+
+// cpuProfile records a CPU profile into the provided writer.
+func (p *Profiler) cpuProfile(ctx context.Context, w io.Writer) error {
+	if err := pprof.StartCPUProfile(w); err != nil {
+		return err
+	}
+	defer pprof.StopCPUProfile()
+	select {
+	case <-time.After(p.cpuDuration):
+	case <-ctx.Done():
+	}
+	return nil
+}
+
+// writeFile creates the file at path and writes to it using the provided
+// function. The file is removed if writing fails.
+func writeFile(path string, write func(w io.Writer) error) error {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0o600)
+	if err != nil {
+		return err
+	}
+	if err = write(f); err == nil {
+		err = f.Close()
+	} else {
+		_ = f.Close()
+	}
+	if err != nil {
+		_ = os.Remove(path)
+	}
+	return err
+}
+
+var (
+	_ run.Config         = (*Profiler)(nil)
+	_ run.PreRunner      = (*Profiler)(nil)
+	_ run.ServiceContext = (*Profiler)(nil)
+)
@@ -0,0 +1,86 @@
+// Copyright (c) Bas van Beek 2024.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package profiler
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/basvanbeek/telemetry"
+
+	"github.com/basvanbeek/run"
+	"github.com/basvanbeek/run/pkg/test"
+)
+
+func TestProfiler(t *testing.T) {
+	var (
+		g       = run.Group{Name: "profiler", Logger: telemetry.NoopLogger()}
+		p       = Profiler{Logger: telemetry.NoopLogger()}
+		dir     = t.TempDir()
+		errDone = errors.New("done")
+		irq     = make(chan error)
+	)
+
+	g.Register(&p, &test.Svc{
+		SvcName: "trigger",
+		Execute: func() error {
+			// simulate receiving SIGUSR1
+			p.signal <- os.Interrupt
+			for {
+				if files, _ := filepath.Glob(filepath.Join(dir, "cpu-*.pprof")); len(files) > 0 {
+					return errDone
+				}
+				time.Sleep(time.Millisecond)
+			}
+		},
+	})
+
+	go func() {
+		irq <- g.Run("./myService", "--profiler-dir", dir,
+			"--profiler-cpu-duration", "10ms")
+	}()
+
+	select {
+	case err := <-irq:
+		if !errors.Is(err, errDone) {
+			t.Errorf("Expected %v, got %v", errDone, err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("timeout")
+	}
+
+	for _, kind := range []string{"heap", "goroutine", "cpu"} {
+		files, _ := filepath.Glob(filepath.Join(dir, kind+"-*.pprof"))
+		if len(files) != 1 {
+			t.Errorf("Expected a single %s profile, got %v", kind, files)
+			continue
+		}
+		if fi, err := os.Stat(files[0]); err != nil || fi.Size() == 0 {
+			t.Errorf("Expected non-empty %s profile", kind)
+		}
+	}
+
+	// a capture canceled by its context still writes all profiles
+	p.cpuDuration = time.Hour
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if paths, err := p.Capture(ctx); err != nil || len(paths) != 3 {
+		t.Errorf("Expected 3 profiles, got %v (%v)", paths, err)
+	}
+}
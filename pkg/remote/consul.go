@@ -0,0 +1,113 @@
+// Copyright (c) Bas van Beek 2024.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package remote
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+)
+
+// Consul implements Source and Watcher using the Consul KV HTTP API.
+// Changes are detected using blocking queries.
+type Consul struct {
+	// Endpoint of the Consul agent, e.g. http://127.0.0.1:8500.
+	Endpoint string
+	// Token is the optional ACL token.
+	Token string
+	// Client is the HTTP client to use, defaults to http.DefaultClient.
+	Client *http.Client
+
+	mu    sync.Mutex
+	index string
+}
+
+type consulKV struct {
+	Key   string
+	Value []byte
+}
+
+// Values implements Source.
+func (c *Consul) Values(ctx context.Context, prefix string) (map[string]string, error) {
+	return c.get(ctx, prefix, "")
+}
+
+// Watch implements Watcher.
+func (c *Consul) Watch(ctx context.Context, prefix string) (map[string]string, error) {
+	c.mu.Lock()
+	index := c.index
+	c.mu.Unlock()
+	return c.get(ctx, prefix, index)
+}
+
+func (c *Consul) get(ctx context.Context, prefix, index string) (map[string]string, error) {
+	q := url.Values{"recurse": []string{"true"}}
+	if index != "" {
+		q.Set("index", index)
+	}
+	u := strings.TrimSuffix(c.Endpoint, "/") + "/v1/kv/" +
+		strings.TrimPrefix(prefix, "/") + "?" + q.Encode()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, http.NoBody)
+	if err != nil {
+		return nil, err
+	}
+	if c.Token != "" {
+		req.Header.Set("X-Consul-Token", c.Token)
+	}
+	client := c.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	res, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = res.Body.Close() }()
+
+	c.mu.Lock()
+	c.index = res.Header.Get("X-Consul-Index")
+	c.mu.Unlock()
+
+	values := make(map[string]string)
+	switch res.StatusCode {
+	case http.StatusOK:
+	case http.StatusNotFound:
+		// no keys found below prefix
+		return values, nil
+	default:
+		return nil, fmt.Errorf("consul: unexpected status %s", res.Status)
+	}
+
+	var kvs []consulKV
+	if err = json.NewDecoder(res.Body).Decode(&kvs); err != nil {
+		return nil, fmt.Errorf("consul: %w", err)
+	}
+	for _, kv := range kvs {
+		if key := stripPrefix(kv.Key, prefix); key != "" {
+			values[key] = string(kv.Value)
+		}
+	}
+	return values, nil
+}
+
+// stripPrefix returns the flag name for the provided key.
+func stripPrefix(key, prefix string) string {
+	key = strings.TrimPrefix(strings.TrimPrefix(key, "/"), strings.TrimPrefix(prefix, "/"))
+	return strings.TrimPrefix(key, "/")
+}
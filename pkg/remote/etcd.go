@@ -0,0 +1,105 @@
+// Copyright (c) Bas van Beek 2024.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package remote
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// Etcd implements Source using the etcd v3 JSON gateway. As the gateway
+// streams watch events, changes are detected by polling instead.
+type Etcd struct {
+	// Endpoint of the etcd gateway, e.g. http://127.0.0.1:2379.
+	Endpoint string
+	// Token is the optional authentication token.
+	Token string
+	// Client is the HTTP client to use, defaults to http.DefaultClient.
+	Client *http.Client
+}
+
+type etcdRangeRequest struct {
+	Key      []byte `json:"key"`
+	RangeEnd []byte `json:"range_end"`
+}
+
+type etcdRangeResponse struct {
+	Kvs []struct {
+		Key   []byte `json:"key"`
+		Value []byte `json:"value"`
+	} `json:"kvs"`
+}
+
+// Values implements Source.
+func (e *Etcd) Values(ctx context.Context, prefix string) (map[string]string, error) {
+	body, err := json.Marshal(etcdRangeRequest{
+		Key:      []byte(prefix),
+		RangeEnd: prefixEnd([]byte(prefix)),
+	})
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost,
+		strings.TrimSuffix(e.Endpoint, "/")+"/v3/kv/range", bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if e.Token != "" {
+		req.Header.Set("Authorization", e.Token)
+	}
+	client := e.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	res, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = res.Body.Close() }()
+
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("etcd: unexpected status %s", res.Status)
+	}
+	var rr etcdRangeResponse
+	if err = json.NewDecoder(res.Body).Decode(&rr); err != nil {
+		return nil, fmt.Errorf("etcd: %w", err)
+	}
+	values := make(map[string]string, len(rr.Kvs))
+	for _, kv := range rr.Kvs {
+		if key := stripPrefix(string(kv.Key), prefix); key != "" {
+			values[key] = string(kv.Value)
+		}
+	}
+	return values, nil
+}
+
+// prefixEnd returns the range end for querying all keys with the provided
+// prefix.
+func prefixEnd(prefix []byte) []byte {
+	end := append([]byte(nil), prefix...)
+	for i := len(end) - 1; i >= 0; i-- {
+		if end[i] < 0xff {
+			end[i]++
+			return end[:i+1]
+		}
+	}
+	// prefix consists of 0xff bytes only, request all keys
+	return []byte{0}
+}
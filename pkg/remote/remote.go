@@ -0,0 +1,262 @@
+// Copyright (c) Bas van Beek 2024.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package remote implements a run.FlagSource populating flag values from a
+// remote key/value configuration backend such as Consul or etcd.
+package remote
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/basvanbeek/run"
+	"github.com/basvanbeek/run/pkg/flag"
+)
+
+// Source is a remote key/value configuration backend.
+type Source interface {
+	// Values returns all key/value pairs found below prefix, with the prefix
+	// stripped from the keys.
+	Values(ctx context.Context, prefix string) (map[string]string, error)
+}
+
+// Watcher is optionally implemented by a Source able to report changes. Watch
+// blocks until the values below prefix change and returns them, or until the
+// context is done.
+type Watcher interface {
+	Watch(ctx context.Context, prefix string) (map[string]string, error)
+}
+
+// Supported backends.
+const (
+	BackendConsul = "consul"
+	BackendEtcd   = "etcd"
+)
+
+// Config implements run.Config and run.FlagSource. Each key found below the
+// configured prefix holds the value of the flag with the same name. Flags
+// provided on the command line take precedence over remote values.
+type Config struct {
+	// Source allows for providing a custom backend. If nil, the backend is
+	// selected by flag.
+	Source Source
+	// OnChange is called after changed remote values have been applied by
+	// the Watcher unit, typically run.Group.Reload.
+	OnChange func() error
+	// Clock is optional and paces the polling of backends not supporting
+	// change notifications. Defaults to run.SystemClock.
+	Clock run.Clock
+
+	backend      string
+	endpoint     string
+	token        string
+	prefix       string
+	pollInterval time.Duration
+
+	mu      sync.Mutex
+	fs      *run.FlagSet
+	owned   map[string]bool
+	applied map[string]string
+}
+
+// Name implements run.Unit.
+func (c *Config) Name() string {
+	return "remote-config"
+}
+
+// FlagSet implements run.Config.
+func (c *Config) FlagSet() *run.FlagSet {
+	flags := run.NewFlagSet("Remote configuration options")
+	flags.StringVar(&c.backend, "remote-config-backend", "",
+		fmt.Sprintf("remote configuration backend (%s|%s), disabled if empty",
+			BackendConsul, BackendEtcd))
+	flags.StringVar(&c.endpoint, "remote-config-endpoint", "",
+		"remote configuration backend endpoint, e.g. http://127.0.0.1:8500")
+	flags.SensitiveStringVar(&c.token, "remote-config-token", "",
+		"remote configuration backend access token")
+	flags.StringVar(&c.prefix, "remote-config-prefix", "",
+		"key prefix holding the flag values")
+	flags.DurationVar(&c.pollInterval, "remote-config-poll-interval", 30*time.Second,
+		"poll interval for backends not supporting change notifications")
+	return flags
+}
+
+// Validate implements run.Config.
+func (c *Config) Validate() error {
+	if c.Source != nil || c.backend == "" {
+		return nil
+	}
+	if c.endpoint == "" {
+		return flag.NewValidationError("remote-config-endpoint", flag.ErrRequired)
+	}
+	if c.pollInterval <= 0 {
+		return flag.NewValidationError("remote-config-poll-interval", flag.ErrInvalidVal)
+	}
+	return nil
+}
+
+// LoadFlags implements run.FlagSource.
+func (c *Config) LoadFlags(fs *run.FlagSet) error {
+	src, err := c.source()
+	if src == nil || err != nil {
+		return err
+	}
+	values, err := src.Values(context.Background(), c.prefix)
+	if err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.fs = fs
+	c.owned = make(map[string]bool)
+	c.applied = make(map[string]string)
+	for name, value := range values {
+		set, err := fs.SetUnchanged(name, value)
+		if err != nil {
+			return flag.NewValidationError(name, err)
+		}
+		// remember the flags we own so we can update them on changes
+		c.owned[name] = set
+		if set {
+			c.applied[name] = value
+		}
+	}
+	return nil
+}
+
+// Watcher returns a run.ServiceContext watching the remote backend for
+// changes. Changed values are applied to the flags not provided on the command
+// line after which OnChange is called. Register it with run.Group next to
+// Config if changes need to be picked up at runtime.
+// Note that Units reading flag values outside their Reloader logic need to be
+// able to handle concurrent updates.
+func (c *Config) Watcher() run.ServiceContext {
+	return watcher{c: c}
+}
+
+func (c *Config) source() (Source, error) {
+	if c.Source != nil {
+		return c.Source, nil
+	}
+	switch strings.ToLower(c.backend) {
+	case "":
+		return nil, nil
+	case BackendConsul:
+		c.Source = &Consul{Endpoint: c.endpoint, Token: c.token}
+	case BackendEtcd:
+		c.Source = &Etcd{Endpoint: c.endpoint, Token: c.token}
+	default:
+		return nil, flag.NewValidationError("remote-config-backend", flag.ErrInvalidVal)
+	}
+	return c.Source, nil
+}
+
+// apply updates the owned flags with the provided values and returns true if
+// any of the values changed. Values are compared against the raw value last
+// applied, as the rendered value of sensitive flags is masked.
+func (c *Config) apply(values map[string]string) (bool, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var changed bool
+	for name, value := range values {
+		f := c.fs.Lookup(name)
+		if f == nil || (f.Changed && !c.owned[name]) {
+			continue
+		}
+		if applied, ok := c.applied[name]; ok && applied == value {
+			continue
+		}
+		if _, ok := c.applied[name]; !ok && !flag.IsSensitive(f) && f.Value.String() == value {
+			// a new key holding the current value
+			c.applied[name] = value
+			continue
+		}
+		if err := c.fs.Set(name, value); err != nil {
+			return changed, flag.NewValidationError(name, err)
+		}
+		c.owned[name] = true
+		c.applied[name] = value
+		changed = true
+	}
+	return changed, nil
+}
+
+type watcher struct {
+	c *Config
+}
+
+func (w watcher) Name() string {
+	return "remote-config-watcher"
+}
+
+func (w watcher) ServeContext(ctx context.Context) error {
+	src, err := w.c.source()
+	if err != nil {
+		return err
+	}
+	if src == nil {
+		// remote configuration disabled
+		<-ctx.Done()
+		return nil
+	}
+	clock := w.c.Clock
+	if clock == nil {
+		clock = run.SystemClock
+	}
+	for {
+		var values map[string]string
+		if wt, ok := src.(Watcher); ok {
+			values, err = wt.Watch(ctx, w.c.prefix)
+		} else {
+			select {
+			case <-ctx.Done():
+				return nil
+			case <-clock.After(w.c.pollInterval):
+			}
+			values, err = src.Values(ctx, w.c.prefix)
+		}
+		if ctx.Err() != nil {
+			return nil
+		}
+		if err != nil {
+			// transient backend errors should not bring down the Group
+			select {
+			case <-ctx.Done():
+				return nil
+			case <-clock.After(w.c.pollInterval):
+			}
+			continue
+		}
+		changed, err := w.c.apply(values)
+		if err != nil {
+			return err
+		}
+		if changed && w.c.OnChange != nil {
+			if err = w.c.OnChange(); err != nil {
+				return fmt.Errorf("remote config change: %w", err)
+			}
+		}
+	}
+}
+
+var (
+	_ run.Config         = (*Config)(nil)
+	_ run.FlagSource     = (*Config)(nil)
+	_ run.ServiceContext = watcher{}
+)
@@ -0,0 +1,115 @@
+// Copyright (c) Bas van Beek 2024.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package remote
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/basvanbeek/telemetry"
+
+	"github.com/basvanbeek/run"
+)
+
+func TestConsulFlagSource(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/kv/myapp/config" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Header().Set("X-Consul-Index", "1")
+		_ = json.NewEncoder(w).Encode([]consulKV{
+			{Key: "myapp/config/listen", Value: []byte(":9090")},
+			{Key: "myapp/config/level", Value: []byte("debug")},
+		})
+	}))
+	defer srv.Close()
+
+	var (
+		g             = run.Group{Name: "remote", Logger: telemetry.NoopLogger()}
+		c             Config
+		listen, level string
+	)
+
+	fs := run.NewFlagSet("app")
+	fs.StringVar(&listen, "listen", ":8080", "listen address")
+	fs.StringVar(&level, "level", "info", "log level")
+	g.Register(&c, appConfig{fs: fs})
+
+	if err := g.Run(
+		"--remote-config-backend", BackendConsul,
+		"--remote-config-endpoint", srv.URL,
+		"--remote-config-prefix", "myapp/config",
+		"--level", "warn",
+	); err != nil {
+		t.Fatalf("Expected proper close, got %v", err)
+	}
+	if want, have := ":9090", listen; want != have {
+		t.Errorf("listen want: %s, have: %s", want, have)
+	}
+	// command line takes precedence
+	if want, have := "warn", level; want != have {
+		t.Errorf("level want: %s, have: %s", want, have)
+	}
+}
+
+type mapSource map[string]string
+
+func (m mapSource) Values(context.Context, string) (map[string]string, error) {
+	return m, nil
+}
+
+func TestApplySensitive(t *testing.T) {
+	var (
+		c        = Config{Source: mapSource{"password": "s3cret"}}
+		fs       = run.NewFlagSet("app")
+		password string
+	)
+	fs.SensitiveStringVar(&password, "password", "", "backend password")
+	if err := c.LoadFlags(fs); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	for _, tt := range []struct {
+		value   string
+		changed bool
+	}{
+		{"s3cret", false},
+		{"n3w", true},
+		{"n3w", false},
+	} {
+		changed, err := c.apply(map[string]string{"password": tt.value})
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if changed != tt.changed {
+			t.Errorf("%s: expected changed %t, got %t", tt.value, tt.changed, changed)
+		}
+		if password != tt.value {
+			t.Errorf("Expected password %q, got %q", tt.value, password)
+		}
+	}
+}
+
+type appConfig struct {
+	fs *run.FlagSet
+}
+
+func (a appConfig) Name() string          { return "app" }
+func (a appConfig) FlagSet() *run.FlagSet { return a.fs }
+func (a appConfig) Validate() error       { return nil }
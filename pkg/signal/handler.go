@@ -21,20 +21,44 @@ import (
 	"fmt"
 	"os"
 	"os/signal"
+	"sync"
 	"syscall"
+	"time"
 
 	"github.com/basvanbeek/run"
 )
 
+// ErrRefreshTimeout is returned if a RefreshCallback exceeds RefreshTimeout.
+const ErrRefreshTimeout run.Error = "refresh timeout"
+
 // Handler implements a unix signal handler as run.GroupService.
+// The first SIGINT, SIGQUIT or SIGTERM initiates a graceful shutdown. When used
+// in a run.Group, a repeated signal aborts waiting for the remaining Units to
+// stop, in which case Run returns an error wrapping run.ErrForcedShutdown.
+// If the shutdown was initiated otherwise, the Handler stops listening for
+// signals once requested to stop, so a signal terminates the process as usual.
 type Handler struct {
 	// RefreshCallback is called when a syscall.SIGHUP is received.
 	// If the callback returns an error, the signal handler is stopped. In a
 	// run.Group environment this means the entire run.Group is requested to
 	// stop.
 	RefreshCallback func() error
+	// RefreshDebounce is optional and sets the quiet period to wait for after
+	// receiving a SIGHUP before calling RefreshCallback. Bursts of SIGHUP
+	// within the quiet period result in a single RefreshCallback call.
+	RefreshDebounce time.Duration
+	// RefreshTimeout is optional and bounds the duration of a RefreshCallback
+	// call. If exceeded, the signal handler is stopped with ErrRefreshTimeout.
+	RefreshTimeout time.Duration
+	// Clock is optional and used for RefreshDebounce and RefreshTimeout.
+	// Defaults to run.SystemClock.
+	Clock run.Clock
 
-	signal chan os.Signal
+	mu       sync.Mutex
+	signal   chan os.Signal
+	served   chan struct{}
+	done     chan struct{}
+	signaled bool // shutdown was initiated by a received signal
 }
 
 // Name implements run.Unit.
@@ -48,35 +72,194 @@ func (h *Handler) PreRun() error {
 	// an INT shortly after, it might get lost if we don't use a buffered
 	// channel here.
 	// E.g. https://gist.github.com/basvanbeek/c0e2ef60b73c8a5d5028ee0cf1afb576
+	h.mu.Lock()
+	defer h.mu.Unlock()
 	h.signal = make(chan os.Signal, 2)
+	h.served = make(chan struct{})
+	h.done = make(chan struct{})
+	h.signaled = false
 	signal.Notify(h.signal,
 		syscall.SIGHUP, syscall.SIGINT, syscall.SIGQUIT, syscall.SIGTERM)
 	return nil
 }
 
+// PostRun implements run.PostRunner and stops listening for unix signals.
+func (h *Handler) PostRun() error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.signal == nil || isDone(h.done) {
+		return nil
+	}
+	signal.Stop(h.signal)
+	close(h.done)
+	return nil
+}
+
+// AbortShutdown implements run.ShutdownAborter. If the Handler initiated the
+// shutdown because of a received SIGINT, SIGQUIT or SIGTERM, the returned
+// channel is closed once such a signal is received again, allowing a repeated
+// signal to abort a graceful shutdown that takes too long. If the shutdown was
+// initiated otherwise, the returned channel is never closed.
+func (h *Handler) AbortShutdown() <-chan struct{} {
+	h.mu.Lock()
+	sigs, served, done := h.signal, h.served, h.done
+	h.mu.Unlock()
+	abort := make(chan struct{})
+	if sigs == nil {
+		return abort
+	}
+	go func() {
+		// leave signals received while serving to ServeContext
+		select {
+		case <-served:
+		case <-done:
+			return
+		}
+		h.mu.Lock()
+		signaled := h.signaled
+		h.mu.Unlock()
+		if !signaled {
+			return
+		}
+		for {
+			select {
+			case sig := <-sigs:
+				if sig != syscall.SIGHUP {
+					close(abort)
+					return
+				}
+			case <-done:
+				return
+			}
+		}
+	}()
+	return abort
+}
+
+// Inject delivers the provided signal to the Handler as if it was received
+// from the operating system. This allows tests to deterministically simulate
+// signals like SIGHUP and SIGTERM without sending process-wide signals.
+// Inject blocks until the signal is queued for handling and returns
+// run.ErrNotServing if the Handler is not active. If the Handler initiated the
+// shutdown, signals can be injected until PostRun is called, allowing to
+// simulate a repeated signal during shutdown.
+func (h *Handler) Inject(sig os.Signal) error {
+	h.mu.Lock()
+	sigs, done := h.signal, h.done
+	h.mu.Unlock()
+	if sigs == nil || isDone(done) {
+		return fmt.Errorf("inject %s: %w", sig, run.ErrNotServing)
+	}
+	select {
+	case sigs <- sig:
+		return nil
+	case <-done:
+		return fmt.Errorf("inject %s: %w", sig, run.ErrNotServing)
+	}
+}
+
 // ServeContext implements run.ServiceContext and listens for incoming unix
 // signals.
 // If a callback handler was registered it will be executed if a "SIGHUP" is
 // received. If the callback handler returns an error it will exit in error and
 // initiate Group shutdown if used in a run.Group environment.
+// Refreshes never overlap: a SIGHUP received while a refresh is in progress
+// results in a single follow-up refresh once the current one has completed.
 func (h *Handler) ServeContext(ctx context.Context) error {
+	defer close(h.served)
+
+	var (
+		refreshing <-chan error // non-nil while a refresh is in progress
+		pending    bool         // a refresh was requested during a refresh
+		debounce   <-chan time.Time
+		timeout    <-chan time.Time
+	)
+	refresh := func() {
+		if h.RefreshDebounce > 0 {
+			// (re)start the quiet period, coalescing bursts of SIGHUP
+			debounce = h.clock().After(h.RefreshDebounce)
+			return
+		}
+		refreshing, timeout = h.refresh()
+	}
+
 	for {
 		select {
 		case sig := <-h.signal:
 			switch sig {
 			case syscall.SIGHUP:
-				if h.RefreshCallback != nil {
-					if err := h.RefreshCallback(); err != nil {
-						return fmt.Errorf("error on signal %s: %w", sig, err)
-					}
+				if h.RefreshCallback == nil {
+					continue
 				}
+				if refreshing != nil {
+					pending = true
+					continue
+				}
+				refresh()
 			case syscall.SIGINT, syscall.SIGQUIT, syscall.SIGTERM:
+				h.mu.Lock()
+				h.signaled = true
+				h.mu.Unlock()
 				return fmt.Errorf("%s %w", sig, run.ErrRequestedShutdown)
 			}
+		case <-debounce:
+			debounce = nil
+			refreshing, timeout = h.refresh()
+		case err := <-refreshing:
+			refreshing, timeout = nil, nil
+			if err != nil {
+				return fmt.Errorf("error on signal %s: %w", syscall.SIGHUP, err)
+			}
+			if pending {
+				pending = false
+				refresh()
+			}
+		case <-timeout:
+			return fmt.Errorf("error on signal %s: %w after %s",
+				syscall.SIGHUP, ErrRefreshTimeout, h.RefreshTimeout)
 		case <-ctx.Done():
-			signal.Stop(h.signal)
-			close(h.signal)
+			h.stop()
 			return nil
 		}
 	}
 }
+
+// stop stops listening for signals if the shutdown was not initiated by a
+// signal, restoring their default behavior so a hanging shutdown can still be
+// interrupted.
+func (h *Handler) stop() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.signaled || isDone(h.done) {
+		return
+	}
+	signal.Stop(h.signal)
+	close(h.done)
+}
+
+// refresh runs the RefreshCallback in the background and returns its result
+// channel and, if RefreshTimeout is set, its timeout channel.
+func (h *Handler) refresh() (<-chan error, <-chan time.Time) {
+	res := make(chan error, 1)
+	go func() { res <- h.RefreshCallback() }()
+	if h.RefreshTimeout > 0 {
+		return res, h.clock().After(h.RefreshTimeout)
+	}
+	return res, nil
+}
+
+func (h *Handler) clock() run.Clock {
+	if h.Clock == nil {
+		return run.SystemClock
+	}
+	return h.Clock
+}
+
+func isDone(done chan struct{}) bool {
+	select {
+	case <-done:
+		return true
+	default:
+		return false
+	}
+}
@@ -16,11 +16,18 @@
 package signal
 
 import (
+	"context"
 	"errors"
+	"os"
+	"os/signal"
+	"strings"
+	"sync/atomic"
 	"syscall"
 	"testing"
 	"time"
 
+	"github.com/basvanbeek/telemetry"
+
 	"github.com/basvanbeek/run"
 	"github.com/basvanbeek/run/pkg/test"
 )
@@ -120,3 +127,251 @@ func (h *Handler) sendHUP() {
 func (h *Handler) sendQUIT() {
 	h.signal <- syscall.SIGQUIT
 }
+
+func TestSignalHandlerInject(t *testing.T) {
+	var (
+		g       = run.Group{Logger: telemetry.NoopLogger()}
+		s       Handler
+		reloads = make(chan struct{}, 1)
+	)
+
+	if err := s.Inject(syscall.SIGHUP); !errors.Is(err, run.ErrNotServing) {
+		t.Errorf("expected %v, got %v", run.ErrNotServing, err)
+	}
+
+	s.RefreshCallback = func() error {
+		reloads <- struct{}{}
+		return nil
+	}
+	g.Register(&s)
+
+	res := make(chan error)
+	go func() { res <- g.Run("./myService") }()
+
+	// wait for the handler to become active
+	for s.Inject(syscall.SIGHUP) != nil {
+		time.Sleep(time.Millisecond)
+	}
+	<-reloads
+
+	if err := s.Inject(syscall.SIGTERM); err != nil {
+		t.Fatalf("unexpected inject error: %v", err)
+	}
+
+	select {
+	case err := <-res:
+		if err != nil {
+			t.Errorf("expected requested shutdown, got %v", err)
+		}
+	case <-time.After(100 * time.Millisecond):
+		t.Errorf("timeout")
+	}
+	if err := s.Inject(syscall.SIGHUP); !errors.Is(err, run.ErrNotServing) {
+		t.Errorf("expected %v, got %v", run.ErrNotServing, err)
+	}
+}
+
+func TestSignalHandlerRefresh(t *testing.T) {
+	serve := func(s *Handler) (chan error, context.CancelFunc) {
+		ctx, cancel := context.WithCancel(context.Background())
+		res := make(chan error, 1)
+		if err := s.PreRun(); err != nil {
+			t.Fatal(err)
+		}
+		go func() { res <- s.ServeContext(ctx) }()
+		return res, cancel
+	}
+
+	t.Run("debounce", func(t *testing.T) {
+		var (
+			calls atomic.Int32
+			s     = Handler{
+				RefreshDebounce: 20 * time.Millisecond,
+				RefreshCallback: func() error { calls.Add(1); return nil },
+			}
+		)
+		res, cancel := serve(&s)
+		for i := 0; i < 5; i++ {
+			_ = s.Inject(syscall.SIGHUP)
+		}
+		time.Sleep(60 * time.Millisecond)
+		cancel()
+		if err := <-res; err != nil {
+			t.Errorf("unexpected error: %v", err)
+		}
+		if n := calls.Load(); n != 1 {
+			t.Errorf("expected 1 coalesced refresh, got %d", n)
+		}
+	})
+
+	t.Run("serialized", func(t *testing.T) {
+		var (
+			active, overlaps, calls atomic.Int32
+			s                       = Handler{
+				RefreshCallback: func() error {
+					if active.Add(1) > 1 {
+						overlaps.Add(1)
+					}
+					calls.Add(1)
+					time.Sleep(10 * time.Millisecond)
+					active.Add(-1)
+					return nil
+				},
+			}
+		)
+		res, cancel := serve(&s)
+		for i := 0; i < 5; i++ {
+			_ = s.Inject(syscall.SIGHUP)
+		}
+		time.Sleep(50 * time.Millisecond)
+		cancel()
+		<-res
+		if overlaps.Load() != 0 {
+			t.Error("expected refreshes not to overlap")
+		}
+		// the first refresh plus a single follow-up for the burst
+		if n := calls.Load(); n != 2 {
+			t.Errorf("expected 2 refreshes, got %d", n)
+		}
+	})
+
+	t.Run("timeout", func(t *testing.T) {
+		var (
+			block = make(chan struct{})
+			clock = test.NewClock(time.Now())
+			s     = Handler{
+				RefreshTimeout:  time.Hour,
+				RefreshCallback: func() error { <-block; return nil },
+				Clock:           clock,
+			}
+		)
+		defer close(block)
+		res, cancel := serve(&s)
+		defer cancel()
+		_ = s.Inject(syscall.SIGHUP)
+		clock.BlockUntil(1)
+		clock.Advance(time.Hour)
+		select {
+		case err := <-res:
+			if !errors.Is(err, ErrRefreshTimeout) {
+				t.Errorf("expected %v, got %v", ErrRefreshTimeout, err)
+			}
+		case <-time.After(100 * time.Millisecond):
+			t.Error("timeout")
+		}
+	})
+}
+
+func TestSignalHandlerForcedShutdown(t *testing.T) {
+	var (
+		g        = run.Group{Logger: telemetry.NoopLogger()}
+		s        Handler
+		stopping = make(chan struct{})
+		release  = make(chan struct{})
+	)
+	defer close(release)
+
+	g.Register(&s, run.NewService("stuck", struct{}{},
+		func(struct{}) error {
+			<-release
+			return nil
+		},
+		func(struct{}) { close(stopping) },
+	))
+
+	res := make(chan error)
+	go func() { res <- g.Run("./myService") }()
+
+	// wait for the handler to become active
+	for s.Inject(syscall.SIGHUP) != nil {
+		time.Sleep(time.Millisecond)
+	}
+	if err := s.Inject(syscall.SIGINT); err != nil {
+		t.Fatalf("unexpected inject error: %v", err)
+	}
+	<-stopping
+	if err := s.Inject(syscall.SIGINT); err != nil {
+		t.Fatalf("unexpected inject error: %v", err)
+	}
+
+	select {
+	case err := <-res:
+		if !errors.Is(err, run.ErrForcedShutdown) {
+			t.Errorf("expected %v, got %v", run.ErrForcedShutdown, err)
+		}
+		if !strings.Contains(err.Error(), "[stuck]") {
+			t.Errorf("expected draining unit in error, got %v", err)
+		}
+	case <-time.After(100 * time.Millisecond):
+		t.Errorf("timeout")
+	}
+}
+
+func TestSignalHandlerUnitInitiatedShutdown(t *testing.T) {
+	var (
+		g        = run.Group{Logger: telemetry.NoopLogger()}
+		s        Handler
+		errUnit  = errors.New("unit failure")
+		active   = make(chan struct{})
+		stopping = make(chan struct{})
+		release  = make(chan struct{})
+	)
+
+	g.Register(&s, run.NewService("stuck", struct{}{},
+		func(struct{}) error {
+			<-release
+			return nil
+		},
+		func(struct{}) { close(stopping) },
+	), &test.Svc{
+		SvcName: "irqsvc",
+		Execute: func() error {
+			<-active
+			return errUnit
+		},
+	})
+
+	res := make(chan error)
+	go func() { res <- g.Run("./myService") }()
+
+	// wait for the handler to become active
+	for s.Inject(syscall.SIGHUP) != nil {
+		time.Sleep(time.Millisecond)
+	}
+	close(active)
+	<-stopping
+
+	// the shutdown was not initiated by a signal, so the handler stops
+	// listening for signals, restoring their default behavior
+	for deadline := time.Now().Add(time.Second); ; time.Sleep(time.Millisecond) {
+		if err := s.Inject(syscall.SIGINT); errors.Is(err, run.ErrNotServing) {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("expected handler to stop listening for signals")
+		}
+	}
+	sigs := make(chan os.Signal, 1)
+	signal.Notify(sigs, syscall.SIGINT)
+	defer signal.Stop(sigs)
+	if err := syscall.Kill(syscall.Getpid(), syscall.SIGINT); err != nil {
+		t.Fatal(err)
+	}
+	<-sigs
+	s.mu.Lock()
+	n := len(s.signal)
+	s.mu.Unlock()
+	if n != 0 {
+		t.Errorf("expected SIGINT not to be caught by the handler, got %d", n)
+	}
+
+	close(release)
+	select {
+	case err := <-res:
+		if !errors.Is(err, errUnit) || errors.Is(err, run.ErrForcedShutdown) {
+			t.Errorf("expected %v, got %v", errUnit, err)
+		}
+	case <-time.After(100 * time.Millisecond):
+		t.Errorf("timeout")
+	}
+}
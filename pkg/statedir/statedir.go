@@ -0,0 +1,243 @@
+// Copyright (c) Bas van Beek 2024.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package statedir implements a run.Group unit managing the state directory
+// of an application. It creates the directory, checks its permissions and
+// free disk space, guards it against concurrent instances with a lock file
+// and cleans up on graceful shutdown according to the configured policy.
+package statedir
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"github.com/basvanbeek/run"
+	"github.com/basvanbeek/run/pkg/flag"
+	"github.com/basvanbeek/run/pkg/pidlock"
+)
+
+// Cleanup policies applied on graceful shutdown.
+const (
+	// CleanupKeep keeps the state directory and its contents.
+	CleanupKeep = "keep"
+	// CleanupTemp removes the temp directory holding scratch files.
+	CleanupTemp = "temp"
+	// CleanupAll removes the state directory altogether.
+	CleanupAll = "all"
+)
+
+// Names of the entries managed inside the state directory.
+const (
+	LockFile = ".lock"
+	TempDir  = "tmp"
+)
+
+// ErrInsecure is returned if the state directory is writable by all users.
+const ErrInsecure run.Error = "state directory is world writable"
+
+// Dir implements run.Namer, run.Config, run.Requirer, run.PreRunner and
+// run.PostRunner. During PreRun it creates the state directory, empties its
+// temp directory left behind by an earlier instance and acquires the lock file
+// preventing concurrent instances from using the same state. The lock is
+// released once the Group has stopped, after which the cleanup policy is
+// applied if the Group shut down gracefully.
+type Dir struct {
+	// Group is optional. If set, the Dir is published during PreRun so Units
+	// registered later can resolve it with run.Resolve[*statedir.Dir] in their
+	// own PreRun. It also allows the Dir to only clean up after a graceful
+	// shutdown.
+	Group *run.Group
+
+	groupName string
+	path      string
+	minFree   flag.ByteSize
+	cleanup   string
+
+	release func() error
+}
+
+// Name implements run.Unit.
+func (d *Dir) Name() string {
+	return "statedir"
+}
+
+// GroupName implements run.Namer.
+func (d *Dir) GroupName(name string) {
+	d.groupName = name
+}
+
+// FlagSet implements run.Config.
+func (d *Dir) FlagSet() *run.FlagSet {
+	flags := run.NewFlagSet("State directory options")
+	flags.StringVar(&d.path, "state-dir", defaultPath(d.groupName),
+		"directory holding the application state")
+	flags.ByteSizeVar(&d.minFree, "state-dir-min-free", 0,
+		"minimum free disk space required for the state directory, e.g. 1GiB;\n"+
+			"unchecked if 0")
+	flags.StringVar(&d.cleanup, "state-dir-cleanup", CleanupKeep,
+		fmt.Sprintf("cleanup policy on graceful shutdown (%s|%s|%s)",
+			CleanupKeep, CleanupTemp, CleanupAll))
+	flags.Constrain("state-dir-cleanup").OneOf(CleanupKeep, CleanupTemp, CleanupAll)
+	return flags
+}
+
+// Validate implements run.Config.
+func (d *Dir) Validate() error {
+	if d.path == "" {
+		return flag.NewValidationError("state-dir", flag.ErrRequired)
+	}
+	return nil
+}
+
+// Requirements implements run.Requirer.
+func (d *Dir) Requirements() []run.Requirement {
+	reqs := []run.Requirement{{
+		Name: "state directory " + d.path,
+		Check: func() error {
+			err := d.check()
+			if errors.Is(err, os.ErrNotExist) {
+				// created by PreRun
+				return nil
+			}
+			return err
+		},
+	}}
+	if d.minFree > 0 {
+		reqs = append(reqs, run.RequireFreeSpace(d.path, uint64(d.minFree)))
+	}
+	return reqs
+}
+
+// PreRun implements run.PreRunner.
+func (d *Dir) PreRun() error {
+	if err := os.RemoveAll(d.Temp()); err != nil {
+		return fmt.Errorf("statedir %s: %w", d.path, err)
+	}
+	if err := os.MkdirAll(d.Temp(), 0o700); err != nil {
+		return fmt.Errorf("statedir %s: %w", d.path, err)
+	}
+	if err := d.check(); err != nil {
+		return fmt.Errorf("statedir %s: %w", d.path, err)
+	}
+	release, err := pidlock.Acquire(filepath.Join(d.path, LockFile))
+	if err != nil {
+		return err
+	}
+	if d.Group != nil {
+		if err = run.Provide(d.Group, d); err != nil {
+			_ = release()
+			return err
+		}
+	}
+	d.release = release
+	return nil
+}
+
+// PostRun implements run.PostRunner.
+func (d *Dir) PostRun() error {
+	if d.release == nil {
+		return nil
+	}
+	var (
+		cleanup = d.cleanup
+		err     error
+	)
+	if !d.graceful() {
+		cleanup = CleanupKeep
+	}
+	if cleanup == CleanupTemp {
+		err = os.RemoveAll(d.Temp())
+	}
+	if rErr := d.release(); err == nil {
+		err = rErr
+	}
+	d.release = nil
+	if cleanup == CleanupAll && err == nil {
+		err = os.RemoveAll(d.path)
+	}
+	return err
+}
+
+// Path returns the path of the state directory.
+func (d *Dir) Path() string {
+	return d.path
+}
+
+// File returns the path of the named file inside the state directory.
+func (d *Dir) File(name string) string {
+	return filepath.Join(d.path, name)
+}
+
+// Temp returns the path of the directory for scratch files inside the state
+// directory. It is emptied on startup.
+func (d *Dir) Temp() string {
+	return filepath.Join(d.path, TempDir)
+}
+
+// check verifies the state directory is a directory not writable by all
+// users.
+func (d *Dir) check() error {
+	fi, err := os.Stat(d.path)
+	if err != nil {
+		return err
+	}
+	if !fi.IsDir() {
+		return fmt.Errorf("%s is not a directory", d.path)
+	}
+	if runtime.GOOS != "windows" && fi.Mode().Perm()&0o002 != 0 {
+		return fmt.Errorf("%w: %s has mode %s", ErrInsecure, d.path, fi.Mode().Perm())
+	}
+	return nil
+}
+
+// graceful returns true unless the Group shut down because of a failure.
+func (d *Dir) graceful() bool {
+	if d.Group == nil {
+		return true
+	}
+	cause := context.Cause(d.Group.Context())
+	return cause == nil || errors.Is(cause, context.Canceled) ||
+		errors.Is(cause, run.ErrRequestedShutdown)
+}
+
+// defaultPath returns the state directory provided by systemd's StateDirectory
+// setting or the XDG state directory of the current user.
+func defaultPath(name string) string {
+	if name == "" {
+		name = filepath.Base(os.Args[0])
+	}
+	if dir := os.Getenv("STATE_DIRECTORY"); dir != "" {
+		return strings.Split(dir, ":")[0]
+	}
+	if dir := os.Getenv("XDG_STATE_HOME"); dir != "" {
+		return filepath.Join(dir, name)
+	}
+	if home, err := os.UserHomeDir(); err == nil {
+		return filepath.Join(home, ".local", "state", name)
+	}
+	return filepath.Join(os.TempDir(), name)
+}
+
+var (
+	_ run.Namer      = (*Dir)(nil)
+	_ run.Config     = (*Dir)(nil)
+	_ run.Requirer   = (*Dir)(nil)
+	_ run.PreRunner  = (*Dir)(nil)
+	_ run.PostRunner = (*Dir)(nil)
+)
@@ -0,0 +1,108 @@
+// Copyright (c) Bas van Beek 2024.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package statedir_test
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/basvanbeek/telemetry"
+
+	"github.com/basvanbeek/run"
+	"github.com/basvanbeek/run/pkg/pidlock"
+	"github.com/basvanbeek/run/pkg/statedir"
+)
+
+// writer resolves the state directory and writes a file to it.
+type writer struct {
+	g *run.Group
+}
+
+func (w writer) Name() string { return "writer" }
+
+func (w writer) PreRun() error {
+	d, err := run.Resolve[*statedir.Dir](w.g)
+	if err != nil {
+		return err
+	}
+	if err = os.WriteFile(d.File("state.json"), []byte("{}"), 0o600); err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(d.Temp(), "scratch"), nil, 0o600)
+}
+
+func runDir(t *testing.T, args ...string) error {
+	t.Helper()
+	g := &run.Group{Name: "statedir", Logger: telemetry.NoopLogger()}
+	g.Register(&statedir.Dir{Group: g}, writer{g: g})
+	return g.Run(append([]string{"./myService"}, args...)...)
+}
+
+func TestDir(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state")
+
+	if err := runDir(t, "--state-dir", path, "--state-dir-cleanup", statedir.CleanupTemp); err != nil {
+		t.Fatalf("Expected clean exit, got %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(path, "state.json")); err != nil {
+		t.Errorf("Expected state file to be kept, got %v", err)
+	}
+	for _, name := range []string{statedir.LockFile, statedir.TempDir} {
+		if _, err := os.Stat(filepath.Join(path, name)); !os.IsNotExist(err) {
+			t.Errorf("Expected %s to be removed, got %v", name, err)
+		}
+	}
+
+	if err := runDir(t, "--state-dir", path, "--state-dir-cleanup", statedir.CleanupAll); err != nil {
+		t.Fatalf("Expected clean exit, got %v", err)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("Expected state directory to be removed, got %v", err)
+	}
+}
+
+func TestDirLocked(t *testing.T) {
+	path := t.TempDir()
+	release, err := pidlock.Acquire(filepath.Join(path, statedir.LockFile))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = release() }()
+
+	if err = runDir(t, "--state-dir", path); !errors.Is(err, pidlock.ErrLocked) {
+		t.Errorf("Expected %v, got %v", pidlock.ErrLocked, err)
+	}
+}
+
+func TestDirPreflight(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("permissions are not checked on windows")
+	}
+	path := t.TempDir()
+	if err := os.Chmod(path, 0o777); err != nil {
+		t.Fatal(err)
+	}
+	err := runDir(t, "--state-dir", path)
+	if !errors.Is(err, run.ErrPreflight) || !errors.Is(err, statedir.ErrInsecure) {
+		t.Errorf("Expected %v, got %v", statedir.ErrInsecure, err)
+	}
+
+	if err = runDir(t, "--state-dir", t.TempDir(), "--state-dir-cleanup", "never"); err == nil {
+		t.Error("Expected invalid cleanup policy error, got nil")
+	}
+}
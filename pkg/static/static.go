@@ -0,0 +1,203 @@
+// Copyright (c) Bas van Beek 2024.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package static implements a run.Group unit serving static assets, such as
+// an embedded single page application frontend or documentation, over HTTP.
+package static
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net"
+	"net/http"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/basvanbeek/run"
+	"github.com/basvanbeek/run/pkg/flag"
+)
+
+const indexFile = "index.html"
+
+// Server implements run.Config, run.PreRunner and run.Service to serve the
+// files of an fs.FS, e.g. an embed.FS, over HTTP. The listener is bound during
+// PreRun so address conflicts surface before any Service starts. Multiple
+// addresses can be served by repeating the listen flag. On shutdown
+// in-flight requests are drained up to the configured drain timeout.
+// Use Handler to mount the assets on an existing http.ServeMux instead.
+type Server struct {
+	// Prefix is used for the flag names and Unit name, allowing multiple
+	// static servers in a single Group. Defaults to "static".
+	Prefix string
+	// Files holds the assets to serve.
+	Files fs.FS
+	// SPA enables single page application mode: requests for paths not found
+	// in Files are served the root index.html, so client side routing works.
+	SPA bool
+
+	listen       []string
+	pathPrefix   string
+	maxAge       time.Duration
+	drainTimeout time.Duration
+
+	lis []net.Listener
+	srv *http.Server
+}
+
+// Name implements run.Unit.
+func (s *Server) Name() string {
+	return s.prefix()
+}
+
+// FlagSet implements run.Config.
+func (s *Server) FlagSet() *run.FlagSet {
+	p := s.prefix()
+	flags := run.NewFlagSet("Static asset server options (" + p + ")")
+	flags.StringSliceVar(&s.listen, p+"-listen", []string{":8080"},
+		"address to serve the static assets on, repeatable, unix: prefix for unix sockets")
+	flags.StringVar(&s.pathPrefix, p+"-path-prefix", "/",
+		"URL path prefix to serve the static assets under")
+	flags.DurationVar(&s.maxAge, p+"-cache-max-age", time.Hour,
+		"Cache-Control max-age of served assets, 0 disables caching")
+	flags.DurationVar(&s.drainTimeout, p+"-drain-timeout", 10*time.Second,
+		"maximum amount of time to wait for in-flight requests on shutdown")
+	return flags
+}
+
+// Validate implements run.Config.
+func (s *Server) Validate() error {
+	p := s.prefix()
+	if s.Files == nil {
+		return fmt.Errorf("%s: files to serve are required", s.Name())
+	}
+	if len(s.listen) == 0 {
+		return flag.NewValidationError(p+"-listen", flag.ErrRequired)
+	}
+	if !strings.HasPrefix(s.pathPrefix, "/") {
+		return flag.NewValidationError(p+"-path-prefix", flag.ErrInvalidVal)
+	}
+	if s.maxAge < 0 {
+		return flag.NewValidationError(p+"-cache-max-age", flag.ErrInvalidVal)
+	}
+	if s.drainTimeout <= 0 {
+		return flag.NewValidationError(p+"-drain-timeout", flag.ErrInvalidVal)
+	}
+	return nil
+}
+
+// PreRun implements run.PreRunner and binds the listeners.
+func (s *Server) PreRun() (err error) {
+	mux := http.NewServeMux()
+	mux.Handle(s.mountPath(), s.Handler())
+	s.srv = &http.Server{Handler: mux, ReadHeaderTimeout: 10 * time.Second}
+	s.lis, err = run.Listen(s.listen...)
+	return err
+}
+
+// Serve implements run.Service and serves all listeners.
+func (s *Server) Serve() error {
+	if err := run.ServeListeners(s.srv, s.lis...); !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return fmt.Errorf("%s: %w", s.Name(), run.ErrRequestedShutdown)
+}
+
+// GracefulStop implements run.Service and drains in-flight requests.
+func (s *Server) GracefulStop() {
+	ctx, cancel := context.WithTimeout(context.Background(), s.drainTimeout)
+	defer cancel()
+	if err := s.srv.Shutdown(ctx); err != nil {
+		_ = s.srv.Close()
+	}
+}
+
+// Addr returns the first address the server listens on. It is available
+// after PreRun.
+func (s *Server) Addr() net.Addr {
+	if len(s.lis) == 0 {
+		return nil
+	}
+	return s.lis[0].Addr()
+}
+
+// Addrs returns all addresses the server listens on. It is available after
+// PreRun.
+func (s *Server) Addrs() []net.Addr {
+	addrs := make([]net.Addr, 0, len(s.lis))
+	for _, lis := range s.lis {
+		addrs = append(addrs, lis.Addr())
+	}
+	return addrs
+}
+
+// Handler returns an http.Handler serving the assets under the configured path
+// prefix, including cache headers and single page application fallback.
+func (s *Server) Handler() http.Handler {
+	fileServer := http.FileServer(http.FS(s.Files))
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		name := strings.TrimPrefix(path.Clean(r.URL.Path), "/")
+		if name == "" {
+			name = "."
+		}
+		if s.SPA {
+			if _, err := fs.Stat(s.Files, name); err != nil {
+				// let the client side router handle unknown paths
+				r = r.Clone(r.Context())
+				r.URL.Path, name = "/", "."
+			}
+		}
+		if name == "." || path.Base(name) == indexFile {
+			// index documents reference versioned assets and must be
+			// revalidated to pick up new deployments
+			w.Header().Set("Cache-Control", "no-cache")
+		} else if s.maxAge > 0 {
+			w.Header().Set("Cache-Control",
+				"public, max-age="+strconv.Itoa(int(s.maxAge.Seconds())))
+		}
+		fileServer.ServeHTTP(w, r)
+	})
+	if prefix := strings.TrimSuffix(s.mountPath(), "/"); prefix != "" {
+		return http.StripPrefix(prefix, handler)
+	}
+	return handler
+}
+
+// mountPath returns the path prefix with a trailing slash.
+func (s *Server) mountPath() string {
+	p := s.pathPrefix
+	if p == "" {
+		p = "/"
+	}
+	if !strings.HasSuffix(p, "/") {
+		p += "/"
+	}
+	return p
+}
+
+func (s *Server) prefix() string {
+	if s.Prefix == "" {
+		return "static"
+	}
+	return s.Prefix
+}
+
+var (
+	_ run.Config    = (*Server)(nil)
+	_ run.PreRunner = (*Server)(nil)
+	_ run.Service   = (*Server)(nil)
+)
@@ -0,0 +1,91 @@
+// Copyright (c) Bas van Beek 2024.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package static
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/basvanbeek/telemetry"
+
+	"github.com/basvanbeek/run"
+	"github.com/basvanbeek/run/pkg/test"
+)
+
+func TestServer(t *testing.T) {
+	var (
+		g = run.Group{Name: "static", Logger: telemetry.NoopLogger()}
+		s = Server{
+			Files: fstest.MapFS{
+				"index.html": {Data: []byte("<html>app</html>")},
+				"app.js":     {Data: []byte("console.log('app')")},
+			},
+			SPA: true,
+		}
+		errDone = errors.New("done")
+		irq     = make(chan error)
+	)
+
+	get := func(path string) (string, string) {
+		res, err := http.Get("http://" + s.Addr().String() + path) //nolint:noctx // test
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		defer func() { _ = res.Body.Close() }()
+		b, _ := io.ReadAll(res.Body)
+		return string(b), res.Header.Get("Cache-Control")
+	}
+
+	g.Register(&s, &test.Svc{
+		SvcName: "client",
+		Execute: func() error {
+			tests := []struct {
+				path, body, cache string
+			}{
+				{"/ui/app.js", "console.log('app')", "public, max-age=60"},
+				{"/ui/", "<html>app</html>", "no-cache"},
+				{"/ui/orders/42", "<html>app</html>", "no-cache"},
+			}
+			for _, tt := range tests {
+				if body, cache := get(tt.path); body != tt.body || cache != tt.cache {
+					t.Errorf("%s: expected %q (%s), got %q (%s)",
+						tt.path, tt.body, tt.cache, body, cache)
+				}
+			}
+			if n := len(s.Addrs()); n != 2 {
+				t.Errorf("expected 2 listen addresses, got %d", n)
+			}
+			return errDone
+		},
+	})
+
+	go func() {
+		irq <- g.Run("./myService", "--static-listen", "127.0.0.1:0",
+			"--static-listen", "127.0.0.1:0", "--static-path-prefix", "/ui", "--static-cache-max-age", "1m")
+	}()
+
+	select {
+	case err := <-irq:
+		if !errors.Is(err, errDone) {
+			t.Errorf("Expected %v, got %v", errDone, err)
+		}
+	case <-time.After(time.Second):
+		t.Errorf("timeout")
+	}
+}
@@ -32,6 +32,12 @@ import (
 type IRQService interface {
 	run.Service
 	io.Closer
+	// CloseWithError initiates shutdown of the run.Group with the provided
+	// error as if a Service failed with it.
+	CloseWithError(err error) error
+	// Done returns a channel which is closed once the run.Group has stopped
+	// all of its Services, i.e. when the IRQService's PostRun is called.
+	Done() <-chan struct{}
 }
 
 // NewIRQService returns a IRQService for usage in run.Group tests.
@@ -39,17 +45,25 @@ type IRQService interface {
 // The GracefulStop() method will be called automatically when run.Group is
 // shutting down. Both Serve() and GracefulStop() should not be called outside
 // the internal run.Group logic as run.Service is to be managed by run.Group.
+// An IRQService can be reused across multiple run.Group Run cycles.
 func NewIRQService(cleanup func()) IRQService {
-	return &irqSvc{
-		irq: make(chan error),
-		cfn: cleanup,
-	}
+	i := &irqSvc{cfn: cleanup, done: make(chan struct{})}
+	i.reset()
+	return i
 }
 
 type irqSvc struct {
-	irq chan error
-	cfn func()
-	mu  sync.Mutex
+	irq  chan error
+	stop chan struct{}
+	done chan struct{}
+	cfn  func()
+	mu   sync.Mutex
+}
+
+// reset prepares the IRQService for a new Run cycle.
+func (i *irqSvc) reset() {
+	i.irq = make(chan error)
+	i.stop = make(chan struct{})
 }
 
 func (i *irqSvc) Name() string {
@@ -57,7 +71,20 @@ func (i *irqSvc) Name() string {
 }
 
 func (i *irqSvc) Serve() error {
-	return <-i.irq
+	i.mu.Lock()
+	if isClosed(i.done) {
+		// start of a new Run cycle
+		i.done = make(chan struct{})
+	}
+	irq, stop := i.irq, i.stop
+	i.mu.Unlock()
+
+	select {
+	case err := <-irq:
+		return err
+	case <-stop:
+		return nil
+	}
 }
 
 // GracefulStop is managed by run.Group. Do not call directly.
@@ -67,24 +94,63 @@ func (i *irqSvc) GracefulStop() {
 	i.mu.Lock()
 	defer i.mu.Unlock()
 
-	if i.irq != nil {
-		close(i.irq)
-		i.irq = nil
+	if !isClosed(i.stop) {
+		close(i.stop)
+	}
+}
+
+// PostRun is managed by run.Group. Do not call directly.
+func (i *irqSvc) PostRun() error {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	if !isClosed(i.done) {
+		close(i.done)
 	}
+	i.reset()
+	return nil
 }
 
 // Close signals the IRQService to shut down and run.Group is responsible
 // for cleaning up and calling GracefulStop() on all registered units.
 func (i *irqSvc) Close() error {
+	return i.CloseWithError(run.ErrRequestedShutdown)
+}
+
+// CloseWithError signals the IRQService to exit with the provided error,
+// initiating shutdown of the run.Group. It blocks until the IRQService is
+// served or the run.Group is already shutting down.
+func (i *irqSvc) CloseWithError(err error) error {
 	i.mu.Lock()
-	defer i.mu.Unlock()
+	irq, stop := i.irq, i.stop
+	i.mu.Unlock()
 
-	if i.irq != nil {
-		i.irq <- run.ErrRequestedShutdown
+	select {
+	case irq <- err:
+	case <-stop:
 	}
 	return nil
 }
 
+// Done returns a channel which is closed once the run.Group has stopped all
+// of its Services. The channel belongs to the current or, if in between Run
+// cycles, the most recently completed Run cycle.
+func (i *irqSvc) Done() <-chan struct{} {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	return i.done
+}
+
+func isClosed(c chan struct{}) bool {
+	select {
+	case <-c:
+		return true
+	default:
+		return false
+	}
+}
+
 // Svc allows one to quickly bootstrap a run.GroupService from
 // simple functions. This is especially useful for unit tests.
 type Svc struct {
@@ -16,9 +16,11 @@
 package test_test
 
 import (
+	"errors"
 	"runtime"
 	"sync"
 	"testing"
+	"time"
 
 	"github.com/basvanbeek/telemetry"
 
@@ -67,3 +69,34 @@ func TestIRQService(t *testing.T) {
 		wg.Wait()
 	})
 }
+
+// TestIRQServiceCycles tests CloseWithError, Done and reuse across Run cycles.
+func TestIRQServiceCycles(t *testing.T) {
+	var (
+		irqs   = test.NewIRQService(func() {})
+		errIRQ = errors.New("irq")
+	)
+
+	for idx, want := range []error{errIRQ, nil, errIRQ} {
+		g := &run.Group{Name: "test", Logger: telemetry.NoopLogger()}
+		g.Register(irqs)
+
+		errs := make(chan error, 1)
+		go func() { errs <- g.Run("./myService") }()
+
+		if want != nil {
+			_ = irqs.CloseWithError(want)
+		} else {
+			_ = irqs.Close()
+		}
+
+		select {
+		case <-irqs.Done():
+		case <-time.After(100 * time.Millisecond):
+			t.Fatalf("[%d] timeout waiting for Done", idx)
+		}
+		if err := <-errs; !errors.Is(err, want) {
+			t.Errorf("[%d] expected %v, got %v", idx, want, err)
+		}
+	}
+}
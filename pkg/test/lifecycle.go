@@ -0,0 +1,259 @@
+// Copyright (c) Bas van Beek 2024.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package test
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/basvanbeek/run"
+)
+
+// Phase is a bitmask of run.Group lifecycle phases.
+type Phase uint
+
+// Lifecycle phases.
+const (
+	WantInitialize Phase = 1 << iota
+	WantConfig
+	WantPreRun
+	WantServe
+	WantPostRun
+)
+
+var phaseNames = []struct {
+	phase Phase
+	name  string
+}{
+	{WantInitialize, "Initialize"},
+	{WantConfig, "Config"},
+	{WantPreRun, "PreRun"},
+	{WantServe, "Serve"},
+	{WantPostRun, "PostRun"},
+}
+
+// String implements fmt.Stringer.
+func (p Phase) String() string {
+	var names []string
+	for _, n := range phaseNames {
+		if p&n.phase != 0 {
+			names = append(names, n.name)
+		}
+	}
+	if len(names) == 0 {
+		return "none"
+	}
+	return strings.Join(names, "|")
+}
+
+// Phases returns the lifecycle phases the provided Unit participates in,
+// based on the run package interfaces it implements.
+func Phases(u run.Unit) Phase {
+	var p Phase
+	if _, ok := u.(run.Initializer); ok {
+		p |= WantInitialize
+	}
+	if _, ok := u.(run.InitializerE); ok {
+		p |= WantInitialize
+	}
+	if _, ok := u.(run.Config); ok {
+		p |= WantConfig
+	}
+	if _, ok := u.(run.PreRunner); ok {
+		p |= WantPreRun
+	}
+	if _, ok := u.(run.PreRunnerContext); ok {
+		p |= WantPreRun
+	}
+	if _, ok := u.(run.Service); ok {
+		p |= WantServe
+	}
+	if _, ok := u.(run.ServiceContext); ok {
+		p |= WantServe
+	}
+	if _, ok := u.(run.PostRunner); ok {
+		p |= WantPostRun
+	}
+	return p
+}
+
+// AssertLifecycle verifies the provided Unit participates in exactly the
+// wanted lifecycle phases, e.g.:
+//
+//	test.AssertLifecycle(t, unit, test.WantConfig|test.WantPreRun|test.WantServe)
+//
+// This catches Units not being wired into a phase due to a mistyped method
+// signature.
+func AssertLifecycle(t testing.TB, u run.Unit, want Phase) {
+	t.Helper()
+	have := Phases(u)
+	if missing := want &^ have; missing != 0 {
+		t.Errorf("unit %s does not implement phases: %s", u.Name(), missing)
+	}
+	if unexpected := have &^ want; unexpected != 0 {
+		t.Errorf("unit %s unexpectedly implements phases: %s", u.Name(), unexpected)
+	}
+}
+
+// Recorder instruments a Unit, recording the lifecycle phases run.Group
+// invokes on it. Register the Unit returned by Recorder.Unit with run.Group
+// instead of the original Unit.
+type Recorder struct {
+	unit run.Unit
+
+	mu     sync.Mutex
+	phases Phase
+	calls  []Phase
+}
+
+// Record returns a Recorder instrumenting the provided Unit.
+func Record(u run.Unit) *Recorder {
+	return &Recorder{unit: u}
+}
+
+// Unit returns the instrumented Unit. It participates in the Initialize,
+// Config, PreRun and PostRun phases, forwarding calls to the original Unit if
+// it implements them, and in the Serve phase if the original Unit implements
+// run.Service or run.ServiceContext.
+func (r *Recorder) Unit() run.Unit {
+	switch u := r.unit.(type) {
+	case run.Service:
+		return &recordedService{recorded: recorded{r}, svc: u}
+	case run.ServiceContext:
+		return &recordedServiceContext{recorded: recorded{r}, svc: u}
+	default:
+		return &recorded{r}
+	}
+}
+
+// Invoked returns the lifecycle phases invoked so far.
+func (r *Recorder) Invoked() Phase {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.phases
+}
+
+// Calls returns the invoked lifecycle phases in order of invocation.
+func (r *Recorder) Calls() []Phase {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]Phase(nil), r.calls...)
+}
+
+// AssertInvoked verifies exactly the wanted lifecycle phases have been invoked.
+func (r *Recorder) AssertInvoked(t testing.TB, want Phase) {
+	t.Helper()
+	if have := r.Invoked(); have != want {
+		t.Errorf("unit %s: expected phases %s to be invoked, got %s",
+			r.unit.Name(), want, have)
+	}
+}
+
+func (r *Recorder) record(p Phase) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.phases |= p
+	r.calls = append(r.calls, p)
+}
+
+type recorded struct {
+	r *Recorder
+}
+
+func (u *recorded) Name() string {
+	return u.r.unit.Name()
+}
+
+// Initialize implements run.InitializerE, forwarding to the original Unit if
+// it implements run.Initializer or run.InitializerE.
+func (u *recorded) Initialize() error {
+	switch i := u.r.unit.(type) {
+	case run.InitializerE:
+		u.r.record(WantInitialize)
+		return i.Initialize()
+	case run.Initializer:
+		u.r.record(WantInitialize)
+		i.Initialize()
+	}
+	return nil
+}
+
+func (u *recorded) FlagSet() *run.FlagSet {
+	if c, ok := u.r.unit.(run.Config); ok {
+		return c.FlagSet()
+	}
+	return nil
+}
+
+func (u *recorded) Validate() error {
+	if c, ok := u.r.unit.(run.Config); ok {
+		u.r.record(WantConfig)
+		return c.Validate()
+	}
+	return nil
+}
+
+func (u *recorded) PreRun() error {
+	return u.PreRunContext(context.Background())
+}
+
+// PreRunContext is called by run.Group instead of PreRun and forwards to the
+// PreRunContext method of the original Unit if implemented and its PreRun
+// method otherwise.
+func (u *recorded) PreRunContext(ctx context.Context) error {
+	switch p := u.r.unit.(type) {
+	case run.PreRunnerContext:
+		u.r.record(WantPreRun)
+		return p.PreRunContext(ctx)
+	case run.PreRunner:
+		u.r.record(WantPreRun)
+		return p.PreRun()
+	}
+	return nil
+}
+
+func (u *recorded) PostRun() error {
+	if p, ok := u.r.unit.(run.PostRunner); ok {
+		u.r.record(WantPostRun)
+		return p.PostRun()
+	}
+	return nil
+}
+
+type recordedService struct {
+	recorded
+	svc run.Service
+}
+
+func (u *recordedService) Serve() error {
+	u.r.record(WantServe)
+	return u.svc.Serve()
+}
+
+func (u *recordedService) GracefulStop() {
+	u.svc.GracefulStop()
+}
+
+type recordedServiceContext struct {
+	recorded
+	svc run.ServiceContext
+}
+
+func (u *recordedServiceContext) ServeContext(ctx context.Context) error {
+	u.r.record(WantServe)
+	return u.svc.ServeContext(ctx)
+}
@@ -0,0 +1,132 @@
+// Copyright (c) Bas van Beek 2024.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package test_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/basvanbeek/telemetry"
+
+	"github.com/basvanbeek/run"
+	"github.com/basvanbeek/run/pkg/test"
+)
+
+type lifecycleUnit struct {
+	started chan struct{}
+	quit    chan struct{}
+}
+
+func (l *lifecycleUnit) Name() string          { return "lifecycle" }
+func (l *lifecycleUnit) FlagSet() *run.FlagSet { return run.NewFlagSet("lifecycle") }
+func (l *lifecycleUnit) Validate() error       { return nil }
+func (l *lifecycleUnit) PreRun() error         { return nil }
+func (l *lifecycleUnit) Serve() error {
+	close(l.started)
+	<-l.quit
+	return nil
+}
+func (l *lifecycleUnit) GracefulStop() { close(l.quit) }
+
+func TestAssertLifecycle(t *testing.T) {
+	u := &lifecycleUnit{quit: make(chan struct{})}
+	test.AssertLifecycle(t, u, test.WantConfig|test.WantPreRun|test.WantServe)
+
+	if want, have := "Config|PreRun|Serve", test.Phases(u).String(); want != have {
+		t.Errorf("expected %s, got %s", want, have)
+	}
+
+	ft := &testing.T{}
+	test.AssertLifecycle(ft, u, test.WantConfig|test.WantPostRun)
+	if !ft.Failed() {
+		t.Error("expected AssertLifecycle to fail on phase mismatch")
+	}
+}
+
+func TestRecorder(t *testing.T) {
+	var (
+		g    = &run.Group{Name: "recorder", Logger: telemetry.NoopLogger()}
+		u    = &lifecycleUnit{started: make(chan struct{}), quit: make(chan struct{})}
+		rec  = test.Record(u)
+		irqs = test.NewIRQService(func() {})
+	)
+	g.Register(rec.Unit(), irqs)
+
+	errs := make(chan error)
+	go func() { errs <- g.Run("./myService") }()
+	<-u.started
+	_ = irqs.Close()
+	if err := <-errs; err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	rec.AssertInvoked(t, test.WantConfig|test.WantPreRun|test.WantServe)
+	calls := rec.Calls()
+	if len(calls) != 3 || calls[0] != test.WantConfig || calls[2] != test.WantServe {
+		t.Errorf("unexpected call order: %v", calls)
+	}
+}
+
+type preRunContextUnit struct {
+	called bool
+}
+
+func (p *preRunContextUnit) Name() string { return "pre-run-context" }
+func (p *preRunContextUnit) PreRunContext(ctx context.Context) error {
+	p.called = ctx != nil
+	return nil
+}
+
+func TestRecorderPreRunContext(t *testing.T) {
+	var (
+		g   = &run.Group{Name: "recorder", Logger: telemetry.NoopLogger()}
+		u   = &preRunContextUnit{}
+		rec = test.Record(u)
+	)
+	test.AssertLifecycle(t, u, test.WantPreRun)
+	g.Register(rec.Unit())
+
+	if err := g.Run("./myService"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !u.called {
+		t.Error("expected PreRunContext to be forwarded")
+	}
+	rec.AssertInvoked(t, test.WantPreRun)
+}
+
+type initializerE struct {
+	err error
+}
+
+func (i initializerE) Name() string      { return "initializer-e" }
+func (i initializerE) Initialize() error { return i.err }
+
+func TestRecorderInitializerE(t *testing.T) {
+	var (
+		g   = &run.Group{Name: "recorder", Logger: telemetry.NoopLogger()}
+		e   = errors.New("initialize failed")
+		u   = initializerE{err: e}
+		rec = test.Record(u)
+	)
+	test.AssertLifecycle(t, u, test.WantInitialize)
+	g.Register(rec.Unit())
+
+	if err := g.Run("./myService"); !errors.Is(err, e) {
+		t.Fatalf("expected %v, got %v", e, err)
+	}
+	rec.AssertInvoked(t, test.WantInitialize)
+}
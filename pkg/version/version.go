@@ -19,6 +19,8 @@ package version
 
 import (
 	"fmt"
+	"io"
+	"os"
 	"regexp"
 	"strconv"
 	"strings"
@@ -53,7 +55,12 @@ var mainBranches = []string{"HEAD", "main", "master"}
 
 // Show the service's version information.
 func Show(serviceName string) {
-	fmt.Println(serviceName + " " + Parse())
+	Fprint(os.Stdout, serviceName)
+}
+
+// Fprint writes the service's version information to w.
+func Fprint(w io.Writer, serviceName string) {
+	fmt.Fprintln(w, serviceName+" "+Parse())
 }
 
 // Parse returns the parsed service's version information. (from raw git label).
@@ -0,0 +1,225 @@
+// Copyright (c) Bas van Beek 2024.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package watcher implements a run.ServiceContext watching files and
+// directories for changes, such as mounted Kubernetes ConfigMaps and Secrets.
+//
+// The Watcher polls instead of using file system notifications (fsnotify).
+// Polling keeps the package free of platform specific dependencies and
+// reliably detects the symlink swaps Kubernetes uses to update mounted
+// volumes, which notification based watchers tend to miss or report on the
+// wrong path. The trade-off is a detection delay of up to the poll interval
+// and reading the watched files on each poll, which is fine for the small
+// configuration files this package targets. The interval is set by the
+// --watch-interval flag and defaults to DefaultInterval.
+package watcher
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/basvanbeek/run"
+	"github.com/basvanbeek/run/pkg/flag"
+)
+
+// DefaultInterval is the default interval between change checks.
+const DefaultInterval = 5 * time.Second
+
+// Watcher implements run.Config and run.ServiceContext. It polls the contents
+// of the watched files and directories and invokes the registered callbacks
+// when content changes.
+// Content is compared instead of relying on file system events, so atomic
+// symlink swaps as performed by Kubernetes when updating mounted ConfigMaps
+// and Secrets are reliably detected.
+type Watcher struct {
+	// Paths holds the files and directories to watch. Paths provided by flag
+	// are added to these.
+	Paths []string
+	// Clock is optional and paces the change checks. Defaults to
+	// run.SystemClock.
+	Clock run.Clock
+
+	flagPaths []string
+	paths     []string
+	interval  time.Duration
+
+	mu        sync.Mutex
+	callbacks []func(changed []string) error
+}
+
+// OnChange registers a callback invoked with the changed paths when content
+// changes. Callbacks are invoked serially in order of registration. If a
+// callback returns an error, the Watcher stops, which in a run.Group
+// environment means the entire Group is requested to stop.
+// To trigger the Group's Reloader pipeline use:
+//
+//	w.OnChange(func([]string) error { return g.Reload() })
+func (w *Watcher) OnChange(fn func(changed []string) error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.callbacks = append(w.callbacks, fn)
+}
+
+// Name implements run.Unit.
+func (w *Watcher) Name() string {
+	return "file-watcher"
+}
+
+// FlagSet implements run.Config.
+func (w *Watcher) FlagSet() *run.FlagSet {
+	flags := run.NewFlagSet("File watcher options")
+	flags.StringSliceVar(&w.flagPaths, "watch-path", nil,
+		"file or directory to watch for changes (repeatable)")
+	flags.DurationVar(&w.interval, "watch-interval", DefaultInterval,
+		"interval between polls for changes of the watched paths")
+	return flags
+}
+
+// Validate implements run.Config.
+func (w *Watcher) Validate() error {
+	if w.interval <= 0 {
+		return flag.NewValidationError("watch-interval", flag.ErrInvalidVal)
+	}
+	// merge into a separate slice so Paths is left untouched if the Group is
+	// reset and validated again
+	seen := make(map[string]bool)
+	w.paths = nil
+	for _, path := range slices.Concat(w.Paths, w.flagPaths) {
+		if !seen[path] {
+			seen[path] = true
+			w.paths = append(w.paths, path)
+		}
+	}
+	return nil
+}
+
+// ServeContext implements run.ServiceContext.
+func (w *Watcher) ServeContext(ctx context.Context) error {
+	clock := w.Clock
+	if clock == nil {
+		clock = run.SystemClock
+	}
+	interval := w.interval
+	if interval <= 0 {
+		// not configured by flag
+		interval = DefaultInterval
+	}
+	state := w.snapshot()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-clock.After(interval):
+		}
+		current := w.snapshot()
+		changed := diff(state, current)
+		state = current
+		if len(changed) == 0 {
+			continue
+		}
+		w.mu.Lock()
+		callbacks := append([]func([]string) error(nil), w.callbacks...)
+		w.mu.Unlock()
+		for _, fn := range callbacks {
+			if err := fn(changed); err != nil {
+				return fmt.Errorf("change of %s: %w", strings.Join(changed, ", "), err)
+			}
+		}
+	}
+}
+
+// snapshot returns the content hashes of all watched files. Missing or
+// unreadable files are recorded with an empty hash so their (re)appearance is
+// detected.
+func (w *Watcher) snapshot() map[string]string {
+	paths := w.paths
+	if paths == nil {
+		// not validated, e.g. when used outside of a run.Group
+		paths = w.Paths
+	}
+	state := make(map[string]string)
+	for _, path := range paths {
+		fi, err := os.Stat(path) // follows symlinks
+		if err != nil {
+			state[path] = ""
+			continue
+		}
+		if !fi.IsDir() {
+			state[path] = hash(path)
+			continue
+		}
+		entries, err := os.ReadDir(path)
+		if err != nil {
+			state[path] = ""
+			continue
+		}
+		for _, e := range entries {
+			// skip the hidden Kubernetes "..data" and timestamped directories
+			if strings.HasPrefix(e.Name(), "..") {
+				continue
+			}
+			file := filepath.Join(path, e.Name())
+			if fi, err = os.Stat(file); err == nil && !fi.IsDir() {
+				state[file] = hash(file)
+			}
+		}
+	}
+	return state
+}
+
+func hash(file string) string {
+	f, err := os.Open(file) //nolint:gosec // watching operator provided paths
+	if err != nil {
+		return ""
+	}
+	defer func() { _ = f.Close() }()
+	h := sha256.New()
+	if _, err = io.Copy(h, f); err != nil {
+		return ""
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// diff returns the sorted paths which have been added, removed or changed.
+func diff(prev, cur map[string]string) []string {
+	var changed []string
+	for path, h := range cur {
+		if p, ok := prev[path]; !ok || p != h {
+			changed = append(changed, path)
+		}
+	}
+	for path := range prev {
+		if _, ok := cur[path]; !ok {
+			changed = append(changed, path)
+		}
+	}
+	sort.Strings(changed)
+	return changed
+}
+
+var (
+	_ run.Config         = (*Watcher)(nil)
+	_ run.ServiceContext = (*Watcher)(nil)
+)
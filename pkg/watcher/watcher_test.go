@@ -0,0 +1,80 @@
+// Copyright (c) Bas van Beek 2024.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package watcher
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// TestSymlinkSwap mimics the way Kubernetes atomically updates a mounted
+// ConfigMap by swapping the "..data" symlink.
+func TestSymlinkSwap(t *testing.T) {
+	dir := t.TempDir()
+	mustWrite := func(path, content string) {
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	mustWrite(filepath.Join(dir, "..v1", "config.yaml"), "v1")
+	mustWrite(filepath.Join(dir, "..v2", "config.yaml"), "v2")
+	if err := os.Symlink("..v1", filepath.Join(dir, "..data")); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink(filepath.Join("..data", "config.yaml"), filepath.Join(dir, "config.yaml")); err != nil {
+		t.Fatal(err)
+	}
+
+	w := Watcher{Paths: []string{dir}}
+	before := w.snapshot()
+
+	// atomic swap
+	tmp := filepath.Join(dir, "..data_tmp")
+	if err := os.Symlink("..v2", tmp); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Rename(tmp, filepath.Join(dir, "..data")); err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{filepath.Join(dir, "config.yaml")}
+	if have := diff(before, w.snapshot()); !reflect.DeepEqual(want, have) {
+		t.Errorf("changed want: %v, have: %v", want, have)
+	}
+}
+
+func TestValidatePaths(t *testing.T) {
+	w := Watcher{Paths: []string{"a", "b"}}
+	if err := w.FlagSet().Parse([]string{"--watch-path", "b", "--watch-path", "c"}); err != nil {
+		t.Fatal(err)
+	}
+	// validating again, e.g. after a Group reset, must not duplicate paths
+	for i := 0; i < 2; i++ {
+		if err := w.Validate(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if want := []string{"a", "b", "c"}; !reflect.DeepEqual(want, w.paths) {
+		t.Errorf("paths want: %v, have: %v", want, w.paths)
+	}
+	if want := []string{"a", "b"}; !reflect.DeepEqual(want, w.Paths) {
+		t.Errorf("Paths want: %v, have: %v", want, w.Paths)
+	}
+}
@@ -0,0 +1,73 @@
+// Copyright (c) Bas van Beek 2024.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package run
+
+import (
+	"fmt"
+
+	"github.com/basvanbeek/multierror"
+)
+
+// PostRunner interface should be implemented by Group Unit objects that need
+// to clean up resources after the Group Services have stopped, e.g. closing
+// database connection pools or flushing telemetry exporters.
+// PostRunners are executed in reverse order of registration. If a PostRunner
+// is also a PreRunner, its PostRun is only executed if its PreRun succeeded.
+// If the Group fails before reaching the PreRun phase, no PostRun is executed.
+// PostRun errors are aggregated and returned by Run.
+type PostRunner interface {
+	// Unit is embedded for Group registration and identification
+	Unit
+	PostRun() error
+}
+
+// postRun runs the PostRun method of all registered PostRunner Units in
+// reverse order of registration.
+func (g *Group) postRun() (err error) {
+	g.mu.Lock()
+	units := append([]PostRunner(nil), g.z...)
+	preRan := append([]Unit(nil), g.preRan...)
+	g.mu.Unlock()
+
+	for idx := len(units) - 1; idx >= 0; idx-- {
+		// a PostRunner might have been de-registered
+		pr := units[idx]
+		if pr == nil || !hasPreRan(pr, preRan) {
+			continue
+		}
+		l := g.phaseLogger("post-run", g.nameOf(pr),
+			g.itemNr(len(units)-idx, len(units)))
+		pErr := pr.PostRun()
+		l.exit(pErr)
+		if pErr != nil {
+			err = multierror.Append(err, fmt.Errorf("%s: %w", g.msg(MsgPostRun, g.nameOf(pr)), pErr))
+		}
+	}
+	return err
+}
+
+// hasPreRan returns true if the provided Unit is not a PreRunner or
+// PreRunnerContext or if its PreRun succeeded.
+func hasPreRan(u Unit, preRan []Unit) bool {
+	if !isPreRunner(u) {
+		return true
+	}
+	for _, p := range preRan {
+		if p == u {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,68 @@
+// Copyright (c) Bas van Beek 2024.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package run_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/basvanbeek/telemetry"
+
+	"github.com/basvanbeek/run"
+	"github.com/basvanbeek/run/pkg/test"
+)
+
+type postRunner struct {
+	ran bool
+}
+
+func (p *postRunner) Name() string   { return "post-runner" }
+func (p *postRunner) PostRun() error { p.ran = true; return nil }
+
+func TestPostRun(t *testing.T) {
+	errFactory := errors.New("factory failed")
+	for _, tt := range []struct {
+		name    string
+		factory bool
+		ran     bool
+	}{
+		{name: "served", ran: true},
+		// startup failed before the PreRun phase, so no Unit ran
+		{name: "factory-error", factory: true},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			var (
+				g  = run.Group{Name: "post-run", Logger: telemetry.NoopLogger()}
+				pr = &postRunner{}
+			)
+			g.Register(pr, &test.Svc{
+				SvcName: "irqsvc",
+				Execute: func() error { return run.ErrRequestedShutdown },
+			})
+			if tt.factory {
+				g.RegisterFactory(func(run.Resolver) (run.Unit, error) {
+					return nil, errFactory
+				})
+			}
+			err := g.Run("./myService")
+			if tt.factory != errors.Is(err, errFactory) {
+				t.Errorf("Unexpected error: %v", err)
+			}
+			if pr.ran != tt.ran {
+				t.Errorf("Expected PostRun to have run: %t, got %t", tt.ran, pr.ran)
+			}
+		})
+	}
+}
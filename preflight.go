@@ -0,0 +1,173 @@
+// Copyright (c) Bas van Beek 2024.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package run
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/basvanbeek/multierror"
+)
+
+// ErrPreflight is returned by Run if the resources required by Requirer Units
+// are not available.
+const ErrPreflight Error = "pre-flight checks failed"
+
+// Requirer is an extension interface that Units can implement to declare the
+// resources they need, such as TCP ports, unix sockets and writable
+// directories. Group verifies the availability of all required resources
+// after the Config phase and before the PreRun phase, failing fast with a
+// consolidated report instead of failing mid-startup.
+type Requirer interface {
+	// Unit is embedded for Group registration and identification
+	Unit
+	// Requirements returns the resources required by the Unit. It is called
+	// after the Config phase, so requirements can depend on flag values.
+	Requirements() []Requirement
+}
+
+// Requirement describes a resource required by a Requirer Unit.
+type Requirement struct {
+	// Name describes the required resource in the pre-flight report.
+	Name string
+	// Check returns an error if the resource is not available.
+	Check func() error
+}
+
+// RequireTCPPort requires the provided TCP address to be available to listen
+// on.
+func RequireTCPPort(addr string) Requirement {
+	return Requirement{
+		Name: "tcp port " + addr,
+		Check: func() error {
+			lis, err := net.Listen("tcp", addr)
+			if err != nil {
+				return err
+			}
+			return lis.Close()
+		},
+	}
+}
+
+// RequireUnixSocket requires the provided unix socket path to be available to
+// listen on. A stale socket file no process is listening on is available.
+func RequireUnixSocket(path string) Requirement {
+	return Requirement{
+		Name: "unix socket " + path,
+		Check: func() error {
+			if _, err := os.Stat(path); err == nil {
+				conn, err := net.DialTimeout("unix", path, time.Second)
+				if err == nil {
+					_ = conn.Close()
+					return errors.New("address already in use")
+				}
+				return nil
+			}
+			lis, err := net.Listen("unix", path)
+			if err != nil {
+				return err
+			}
+			return lis.Close()
+		},
+	}
+}
+
+// RequireWritableDir requires the provided directory to exist and be
+// writable.
+func RequireWritableDir(path string) Requirement {
+	return Requirement{
+		Name: "writable directory " + path,
+		Check: func() error {
+			f, err := os.CreateTemp(path, ".preflight-*")
+			if err != nil {
+				return err
+			}
+			_ = f.Close()
+			return os.Remove(f.Name())
+		},
+	}
+}
+
+// RequireOpenFiles requires the soft limit of open file descriptors to be at
+// least n. On platforms without resource limits the requirement is met.
+func RequireOpenFiles(n uint64) Requirement {
+	return Requirement{
+		Name: fmt.Sprintf("open files limit %d", n),
+		Check: func() error {
+			limit, ok := openFilesLimit()
+			if ok && limit < n {
+				return fmt.Errorf("limit is %d", limit)
+			}
+			return nil
+		},
+	}
+}
+
+// RequireFreeSpace requires the file system holding the provided path to have
+// at least n bytes available. If path does not exist yet, its closest existing
+// parent directory is checked. On platforms without file system statistics
+// the requirement is met.
+func RequireFreeSpace(path string, n uint64) Requirement {
+	return Requirement{
+		Name: fmt.Sprintf("free space %d bytes at %s", n, path),
+		Check: func() error {
+			for {
+				if _, err := os.Stat(path); err == nil || filepath.Dir(path) == path {
+					break
+				}
+				path = filepath.Dir(path)
+			}
+			free, ok := freeSpace(path)
+			if ok && free < n {
+				return fmt.Errorf("%d bytes available", free)
+			}
+			return nil
+		},
+	}
+}
+
+// preflight checks the requirements of all registered Requirer Units and
+// reports all unavailable resources at once.
+func (g *Group) preflight() error {
+	var err error
+	total := phaseLen(g, &g.q)
+	for idx := 0; idx < total; idx++ {
+		// a Requirer might have been de-registered
+		r := unitAt(g, &g.q, idx)
+		if r == nil {
+			continue
+		}
+		l := g.phaseLogger("preflight", g.nameOf(r), g.itemNr(idx+1, total))
+		var rErr error
+		for _, req := range r.Requirements() {
+			if cErr := req.Check(); cErr != nil {
+				rErr = multierror.Append(rErr, fmt.Errorf("%s: %s: %w", g.nameOf(r), req.Name, cErr))
+			}
+		}
+		l.exit(rErr)
+		if rErr != nil {
+			err = multierror.Append(err, rErr)
+		}
+	}
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrPreflight,
+			multierror.SetFormatter(err, multierror.ListFormatFunc))
+	}
+	return nil
+}
@@ -0,0 +1,96 @@
+// Copyright (c) Bas van Beek 2024.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package run_test
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"net"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/basvanbeek/telemetry"
+
+	"github.com/basvanbeek/run"
+)
+
+type requirer struct {
+	reqs   []run.Requirement
+	preRan bool
+}
+
+func (r *requirer) Name() string                    { return "requirer" }
+func (r *requirer) Requirements() []run.Requirement { return r.reqs }
+func (r *requirer) PreRun() error                   { r.preRan = true; return nil }
+
+func TestPreflight(t *testing.T) {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = lis.Close() }()
+
+	var (
+		dir  = t.TempDir()
+		file = filepath.Join(dir, "file")
+		r    = &requirer{reqs: []run.Requirement{
+			run.RequireTCPPort("127.0.0.1:0"),
+			run.RequireTCPPort(lis.Addr().String()),
+			run.RequireWritableDir(dir),
+			run.RequireWritableDir(file),
+			run.RequireOpenFiles(1),
+			run.RequireFreeSpace(filepath.Join(dir, "missing", "sub"), 1),
+			run.RequireFreeSpace(dir, math.MaxUint64),
+		}}
+		g = run.NewGroup("preflight", run.WithLogger(telemetry.NoopLogger()))
+	)
+	if err = os.WriteFile(file, nil, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	g.Register(r)
+
+	err = g.Run("./myService")
+	if !errors.Is(err, run.ErrPreflight) {
+		t.Fatalf("Expected %v, got %v", run.ErrPreflight, err)
+	}
+	// the report holds all unavailable resources
+	wants := []string{"tcp port " + lis.Addr().String(), "writable directory " + file}
+	if runtime.GOOS == "linux" || runtime.GOOS == "darwin" {
+		wants = append(wants, fmt.Sprintf("free space %d bytes", uint64(math.MaxUint64)))
+	}
+	for _, want := range wants {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("Expected %q in report, got %v", want, err)
+		}
+	}
+	if strings.Contains(err.Error(), "127.0.0.1:0") || strings.Contains(err.Error(), "open files") ||
+		strings.Contains(err.Error(), "free space 1 bytes") {
+		t.Errorf("Expected available resources not to be reported, got %v", err)
+	}
+	if r.preRan {
+		t.Error("Expected PreRun phase not to run")
+	}
+
+	r.reqs = r.reqs[:1]
+	g = run.NewGroup("preflight", run.WithLogger(telemetry.NoopLogger()))
+	g.Register(r)
+	if err = g.Run("./myService"); err != nil || !r.preRan {
+		t.Errorf("Expected PreRun phase to run, got %v", err)
+	}
+}
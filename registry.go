@@ -0,0 +1,91 @@
+// Copyright (c) Bas van Beek 2024.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package run
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// ErrAlreadyProvided is returned when providing a value for a key which
+// already holds a value.
+const ErrAlreadyProvided Error = "already provided"
+
+// ErrNotProvided is returned when resolving a key which holds no value.
+const ErrNotProvided Error = "not provided"
+
+// ErrNotAssignable is returned by Resolve when the provided value can't be
+// returned as the requested type, e.g. a nil value provided for an interface
+// type.
+const ErrNotAssignable Error = "provided value not assignable"
+
+// Provide publishes a shared resource (e.g. a database pool, TLS config or
+// listener) under the provided key, allowing other Units to resolve it. It is
+// typically called from PreRun so Units registered later can resolve it in
+// their own PreRun. Provide is safe for concurrent use.
+func (g *Group) Provide(key string, value any) error {
+	return g.provide(key, value)
+}
+
+// Resolve returns the shared resource published under the provided key.
+// Resolve is safe for concurrent use.
+func (g *Group) Resolve(key string) (any, error) {
+	return g.resolve(key)
+}
+
+// Provide publishes a shared resource keyed by its type T. See Group.Provide.
+func Provide[T any](g *Group, value T) error {
+	return g.provide(typeKey[T](), value)
+}
+
+// Resolve returns the shared resource keyed by type T. See Group.Resolve.
+func Resolve[T any](g *Group) (T, error) {
+	var t T
+	v, err := g.resolve(typeKey[T]())
+	if err != nil {
+		return t, err
+	}
+	if t, ok := v.(T); ok {
+		return t, nil
+	}
+	return t, fmt.Errorf("%v: %w", typeKey[T](), ErrNotAssignable)
+}
+
+func typeKey[T any]() reflect.Type {
+	return reflect.TypeOf((*T)(nil)).Elem()
+}
+
+func (g *Group) provide(key, value any) error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if _, ok := g.registry[key]; ok {
+		return fmt.Errorf("%v: %w", key, ErrAlreadyProvided)
+	}
+	if g.registry == nil {
+		g.registry = make(map[any]any)
+	}
+	g.registry[key] = value
+	return nil
+}
+
+func (g *Group) resolve(key any) (any, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	v, ok := g.registry[key]
+	if !ok {
+		return nil, fmt.Errorf("%v: %w", key, ErrNotProvided)
+	}
+	return v, nil
+}
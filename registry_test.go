@@ -0,0 +1,73 @@
+// Copyright (c) Bas van Beek 2024.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package run_test
+
+import (
+	"errors"
+	"io"
+	"testing"
+
+	"github.com/basvanbeek/telemetry"
+
+	"github.com/basvanbeek/run"
+)
+
+type pool struct{ size int }
+
+func TestRegistry(t *testing.T) {
+	var (
+		g        = run.Group{Name: "registry", Logger: telemetry.NoopLogger()}
+		resolved *pool
+		name     any
+	)
+
+	g.Register(
+		run.NewPreRunner("provider", func() error {
+			if err := run.Provide(&g, &pool{size: 5}); err != nil {
+				return err
+			}
+			return g.Provide("name", "db")
+		}),
+		run.NewPreRunner("consumer", func() (err error) {
+			if resolved, err = run.Resolve[*pool](&g); err != nil {
+				return err
+			}
+			name, err = g.Resolve("name")
+			return err
+		}),
+	)
+
+	if err := g.Run(); err != nil {
+		t.Fatalf("Expected proper close, got %v", err)
+	}
+	if resolved == nil || resolved.size != 5 {
+		t.Errorf("Expected resolved pool, got %+v", resolved)
+	}
+	if name != "db" {
+		t.Errorf("Expected resolved name, got %v", name)
+	}
+	if err := run.Provide(&g, &pool{}); !errors.Is(err, run.ErrAlreadyProvided) {
+		t.Errorf("Expected %v, got %v", run.ErrAlreadyProvided, err)
+	}
+	if _, err := run.Resolve[pool](&g); !errors.Is(err, run.ErrNotProvided) {
+		t.Errorf("Expected %v, got %v", run.ErrNotProvided, err)
+	}
+	if err := run.Provide[io.Writer](&g, nil); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := run.Resolve[io.Writer](&g); !errors.Is(err, run.ErrNotAssignable) {
+		t.Errorf("Expected %v, got %v", run.ErrNotAssignable, err)
+	}
+}
@@ -0,0 +1,88 @@
+// Copyright (c) Bas van Beek 2024.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package run
+
+import (
+	"fmt"
+
+	"github.com/basvanbeek/multierror"
+)
+
+// FlagSource is an extension interface that Units can implement if they
+// provide flag values from sources other than the command line, such as
+// configuration files or remote configuration backends.
+// LoadFlags is called after the command line has been parsed and before the
+// Config Units are validated. It receives the FlagSet holding the flags of all
+// registered Config Units and should only set flags that have not been
+// changed, so command line flags and earlier registered FlagSources take
+// precedence. See FlagSet.SetUnchanged.
+type FlagSource interface {
+	// Unit is embedded for Group registration and identification
+	Unit
+	LoadFlags(fs *FlagSet) error
+}
+
+// Reloader is an extension interface that Units can implement if they are
+// able to reload their configuration or state at runtime. Reloaders are
+// triggered by calling Group.Reload, e.g. from the RefreshCallback of the
+// signal handler or when a configuration source reports changes.
+type Reloader interface {
+	// Unit is embedded for Group registration and identification
+	Unit
+	Reload() error
+}
+
+// Reload runs the Reload method of all registered Reloader Units serially, in
+// order of registration. Concurrent calls to Reload are serialized. Errors of
+// individual Reloaders are aggregated and do not stop the remaining Reloaders
+// from running.
+func (g *Group) Reload() (err error) {
+	g.reloadMu.Lock()
+	defer g.reloadMu.Unlock()
+
+	total := phaseLen(g, &g.r)
+	for idx := 0; idx < total; idx++ {
+		// a Reloader might have been de-registered
+		r := unitAt(g, &g.r, idx)
+		if r == nil {
+			continue
+		}
+		if rErr := r.Reload(); rErr != nil {
+			err = multierror.Append(err, fmt.Errorf("reload %s: %w", g.nameOf(r), rErr))
+		}
+	}
+	if err != nil {
+		err = multierror.SetFormatter(err, multierror.ListFormatFunc)
+	}
+	return err
+}
+
+// loadFlags runs the LoadFlags method of all registered FlagSource Units.
+func (g *Group) loadFlags() error {
+	g.trackFlagSources(SourceCommandLine)
+	for idx := 0; idx < phaseLen(g, &g.v); idx++ {
+		// a FlagSource might have been de-registered
+		v := unitAt(g, &g.v, idx)
+		if v == nil {
+			continue
+		}
+		g.Logger.Debug("load-flags", "phase", "load-flags", "unit", g.nameOf(v))
+		if err := v.LoadFlags(g.f); err != nil {
+			return fmt.Errorf("load flags %s: %w", g.nameOf(v), err)
+		}
+		g.trackFlagSources(g.nameOf(v))
+	}
+	return nil
+}
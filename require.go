@@ -0,0 +1,58 @@
+// Copyright (c) Bas van Beek 2024.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package run
+
+import "fmt"
+
+// ErrTooFewServices is returned by Run if fewer Service and ServiceContext
+// Units are registered than required by RequireServices.
+const ErrTooFewServices Error = "too few services"
+
+// RequireServices makes Run fail fast with ErrTooFewServices if fewer than n
+// Service and ServiceContext Units remain registered once the Config phase
+// has completed, e.g. because all of them were disabled by flags. Without it,
+// Run returns immediately without error if no services are left to run.
+// RequireServices must be called before Run.
+func (g *Group) RequireServices(n int) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.minServices = n
+}
+
+// requiredServices returns ErrTooFewServices if fewer Service and
+// ServiceContext Units are registered than required.
+func (g *Group) requiredServices() error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.minServices <= 0 {
+		return nil
+	}
+	var n int
+	for _, s := range g.s {
+		if s != nil {
+			n++
+		}
+	}
+	for _, x := range g.x {
+		if x != nil {
+			n++
+		}
+	}
+	if n < g.minServices {
+		return fmt.Errorf("%w: %d registered, %d required", ErrTooFewServices,
+			n, g.minServices)
+	}
+	return nil
+}
@@ -0,0 +1,48 @@
+// Copyright (c) Bas van Beek 2024.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package run_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/basvanbeek/telemetry"
+
+	"github.com/basvanbeek/run"
+)
+
+func TestRequireServices(t *testing.T) {
+	for _, tt := range []struct {
+		args []string
+		want error
+	}{
+		{args: []string{"./myService"}, want: run.ErrTooFewServices},
+		{args: []string{"./myService", "--svc"}, want: nil},
+	} {
+		var (
+			g   = run.Group{Name: "require", Logger: telemetry.NoopLogger()}
+			svc = &optionalService{name: "svc"}
+		)
+		g.Register(svc)
+		g.RequireServices(1)
+
+		if err := g.Run(tt.args...); !errors.Is(err, tt.want) {
+			t.Errorf("%v: Expected %v, got %v", tt.args, tt.want, err)
+		}
+		if tt.want != nil && svc.preRan {
+			t.Errorf("%v: Expected to fail before PreRun", tt.args)
+		}
+	}
+}
@@ -0,0 +1,129 @@
+// Copyright (c) Bas van Beek 2024.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package run
+
+import (
+	"strings"
+	"time"
+
+	"github.com/spf13/pflag"
+)
+
+// ErrRunning is returned by Reset if the Group is still running.
+const ErrRunning Error = "group is running"
+
+// Reset prepares the Group to be run again with the same registered Units,
+// e.g. in tests, REPL-style tools or supervisors re-running the whole Group.
+// It restores the default values of all parsed flags, removes the slots of
+// de-registered Units and clears the state of the previous run, including
+// recorded timings and resources published with Provide.
+//
+// Reset returns ErrRunning if called before Run returned. Units registered
+// after the Config phase of the previous run did not register their Config
+// phases and need to be registered again to take part in the next one.
+func (g *Group) Reset() error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.started && !g.stopping || g.done != nil && !isClosed(g.done) ||
+		g.startDone != nil && !isClosed(g.startDone) {
+		return ErrRunning
+	}
+
+	for _, set := range g.sets {
+		if set != nil {
+			set.VisitAll(resetFlag)
+		}
+	}
+	g.f, g.sets, g.flagSources = nil, nil, nil
+
+	// factory built Units are constructed anew on the next run
+	for _, u := range g.built {
+		g.deregister(u, false, false)
+	}
+	g.built = nil
+	if g.drainer != nil {
+		g.drainer.draining.Store(false)
+	}
+
+	g.i = compact(g.i)
+	g.n = compact(g.n)
+	g.c = compact(g.c)
+	g.a = compact(g.a)
+	g.v = compact(g.v)
+	g.k = compact(g.k)
+	g.r = compact(g.r)
+	g.q = compact(g.q)
+	g.t = compact(g.t)
+	g.p = compact(g.p)
+	g.j = compact(g.j)
+	g.s = compact(g.s)
+	g.x = compact(g.x)
+	g.z = compact(g.z)
+
+	g.configured, g.initialized = false, 0
+	g.args, g.remaining, g.passThrough, g.unknown = nil, nil, nil, nil
+	g.launch, g.startDone, g.startErr = nil, nil, nil
+	g.preRan, g.registry, g.timings = nil, nil, nil
+	g.ctx, g.cancel, g.errs, g.done = nil, nil, nil, nil
+	g.groupCtx, g.groupCancel = nil, nil
+	g.anomalies = nil
+	g.spans, g.readyAt = nil, time.Time{}
+	g.running, g.started, g.stopping = nil, false, false
+	return nil
+}
+
+// resetFlag restores the default value of the provided flag and marks it as
+// not changed.
+func resetFlag(f *pflag.Flag) {
+	if r, ok := f.Value.(interface{ Reset() }); ok {
+		// values which can't be restored from their printed default
+		r.Reset()
+	} else if s, ok := f.Value.(pflag.SliceValue); ok {
+		// slice values append on Set once changed
+		var values []string
+		if def := strings.Trim(f.DefValue, "[]"); def != "" {
+			values = strings.Split(def, ",")
+		}
+		_ = s.Replace(values)
+	} else {
+		_ = f.Value.Set(f.DefValue)
+	}
+	f.Changed = false
+}
+
+// compact returns the provided phase slice without de-registered slots.
+func compact[T comparable](units []T) []T {
+	var (
+		zero T
+		out  = units[:0]
+	)
+	for _, u := range units {
+		if u != zero {
+			out = append(out, u)
+		}
+	}
+	clear(units[len(out):])
+	return out
+}
+
+// isClosed returns true if the provided channel is closed.
+func isClosed(c chan struct{}) bool {
+	select {
+	case <-c:
+		return true
+	default:
+		return false
+	}
+}
@@ -0,0 +1,100 @@
+// Copyright (c) Bas van Beek 2024.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package run_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/basvanbeek/telemetry"
+
+	"github.com/basvanbeek/run"
+	"github.com/basvanbeek/run/pkg/test"
+)
+
+func TestReset(t *testing.T) {
+	var (
+		g = run.NewGroup("reset", run.WithLogger(telemetry.NoopLogger()))
+
+		fs     = run.NewFlagSet("Reset options")
+		addr   string
+		token  string
+		tags   []string
+		served []string
+		block  = make(chan struct{})
+		irq    = make(chan error)
+	)
+	fs.StringVar(&addr, "addr", ":8080", "listen address")
+	fs.SensitiveStringVar(&token, "token", "none", "api token")
+	fs.StringSliceVar(&tags, "tag", []string{"a"}, "tags")
+
+	gone := &test.Svc{SvcName: "gone", Execute: func() error {
+		return run.ErrRequestedShutdown
+	}}
+	g.Register(configUnit{fs: fs}, &test.Svc{
+		SvcName: "svc",
+		Execute: func() error {
+			served = append(served, addr)
+			<-block
+			return run.ErrRequestedShutdown
+		},
+	}, gone)
+	g.Deregister(gone)
+
+	go func() {
+		irq <- g.Run("./myService", "--addr", ":9090", "--token", "secret", "--tag", "b")
+	}()
+	time.Sleep(20 * time.Millisecond)
+	if err := g.Reset(); !errors.Is(err, run.ErrRunning) {
+		t.Errorf("Expected %v, got %v", run.ErrRunning, err)
+	}
+	block <- struct{}{}
+	select {
+	case err := <-irq:
+		if err != nil {
+			t.Fatalf("Expected nil, got %v", err)
+		}
+	case <-time.After(100 * time.Millisecond):
+		t.Fatal("timeout")
+	}
+	if token != "secret" || len(tags) != 1 || tags[0] != "b" {
+		t.Fatalf("Expected parsed flags, got %q %v", token, tags)
+	}
+
+	if err := g.Reset(); err != nil {
+		t.Fatalf("Expected nil, got %v", err)
+	}
+	if addr != ":8080" || token != "none" || len(tags) != 1 || tags[0] != "a" {
+		t.Errorf("Expected default flag values, got %q %q %v", addr, token, tags)
+	}
+	if timings := g.UnitTimings(); len(timings) != 0 {
+		t.Errorf("Expected timings to be cleared, got %v", timings)
+	}
+
+	go func() { irq <- g.Run("./myService") }()
+	block <- struct{}{}
+	select {
+	case err := <-irq:
+		if err != nil {
+			t.Fatalf("Expected nil, got %v", err)
+		}
+	case <-time.After(100 * time.Millisecond):
+		t.Fatal("timeout")
+	}
+	if len(served) != 2 || served[0] != ":9090" || served[1] != ":8080" {
+		t.Errorf("Expected two runs with their own config, got %v", served)
+	}
+}
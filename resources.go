@@ -0,0 +1,147 @@
+// Copyright (c) Bas van Beek 2024.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package run
+
+import (
+	"bufio"
+	"bytes"
+	"runtime/metrics"
+	"runtime/pprof"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// UnitResources holds the resource usage attributed to a Unit.
+type UnitResources struct {
+	Name string `json:"name"`
+	// Goroutines holds the number of goroutines running on behalf of the
+	// Unit, i.e. its Serve or ServeContext goroutine and all goroutines
+	// started from it which are still alive.
+	Goroutines int `json:"goroutines"`
+}
+
+// ResourceUsage holds a sample of the resource usage of the process and the
+// Units of the Group. The Go runtime does not attribute heap allocations to
+// goroutines, so allocation statistics are only available process wide.
+type ResourceUsage struct {
+	Time  time.Time       `json:"time"`
+	Units []UnitResources `json:"units"`
+	// Goroutines holds the total number of goroutines of the process,
+	// including the ones not attributed to a Unit.
+	Goroutines int `json:"goroutines"`
+	// HeapAllocBytes and HeapAllocObjects hold the cumulative bytes and
+	// objects allocated on the heap since the start of the process.
+	HeapAllocBytes   uint64 `json:"heapAllocBytes"`
+	HeapAllocObjects uint64 `json:"heapAllocObjects"`
+	// HeapLiveBytes holds the bytes occupied by live and not yet swept heap
+	// objects.
+	HeapLiveBytes uint64 `json:"heapLiveBytes"`
+}
+
+// ResourceUsage samples the resource usage of the process and of the Service
+// and ServiceContext Units which have been served, in order of first serve.
+// Per Unit figures are only available if the Group was created with
+// WithResourceAccounting.
+func (g *Group) ResourceUsage() ResourceUsage {
+	usage := ResourceUsage{Time: g.Clock().Now()}
+	samples := []metrics.Sample{
+		{Name: "/sched/goroutines:goroutines"},
+		{Name: "/gc/heap/allocs:bytes"},
+		{Name: "/gc/heap/allocs:objects"},
+		{Name: "/memory/classes/heap/objects:bytes"},
+	}
+	metrics.Read(samples)
+	values := make([]uint64, len(samples))
+	for i, sample := range samples {
+		if sample.Value.Kind() == metrics.KindUint64 {
+			values[i] = sample.Value.Uint64()
+		}
+	}
+	usage.Goroutines = int(values[0])
+	usage.HeapAllocBytes, usage.HeapAllocObjects = values[1], values[2]
+	usage.HeapLiveBytes = values[3]
+
+	g.mu.Lock()
+	accounting := g.accounting
+	var names []string
+	for _, t := range g.timings {
+		if !t.ServeStart.IsZero() {
+			names = append(names, t.Name)
+		}
+	}
+	g.mu.Unlock()
+	if !accounting {
+		return usage
+	}
+	counts := goroutinesByUnit()
+	for _, name := range names {
+		usage.Units = append(usage.Units, UnitResources{
+			Name: name, Goroutines: counts[name],
+		})
+	}
+	return usage
+}
+
+// goroutinesByUnit returns the number of live goroutines per value of the
+// unit pprof label.
+func goroutinesByUnit() map[string]int {
+	var buf bytes.Buffer
+	if err := pprof.Lookup("goroutine").WriteTo(&buf, 1); err != nil {
+		return nil
+	}
+	var (
+		counts = make(map[string]int)
+		count  int
+		sc     = bufio.NewScanner(&buf)
+	)
+	sc.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for sc.Scan() {
+		line := sc.Text()
+		if n, _, ok := strings.Cut(line, " @ "); ok {
+			// start of a stack record shared by n goroutines
+			count, _ = strconv.Atoi(n)
+			continue
+		}
+		if labels, ok := strings.CutPrefix(line, "# labels: "); ok {
+			if name, ok := profileLabel(labels, labelUnit); ok {
+				counts[name] += count
+			}
+		}
+	}
+	return counts
+}
+
+// profileLabel returns the value of the label key from a goroutine profile
+// label set formatted as {"key":"value", ...}.
+func profileLabel(labels, key string) (string, bool) {
+	s := strings.TrimPrefix(labels, "{")
+	for {
+		k, err := strconv.QuotedPrefix(s)
+		if err != nil {
+			return "", false
+		}
+		s = strings.TrimPrefix(s[len(k):], ":")
+		v, err := strconv.QuotedPrefix(s)
+		if err != nil {
+			return "", false
+		}
+		s = strings.TrimPrefix(s[len(v):], ", ")
+		if k, _ = strconv.Unquote(k); k == key {
+			v, _ = strconv.Unquote(v)
+			return v, true
+		}
+	}
+}
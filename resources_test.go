@@ -0,0 +1,78 @@
+// Copyright (c) Bas van Beek 2024.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package run_test
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/basvanbeek/telemetry"
+
+	"github.com/basvanbeek/run"
+)
+
+func TestResourceUsage(t *testing.T) {
+	var (
+		g       = run.NewGroup("resources", run.WithResourceAccounting())
+		irq     = make(chan error)
+		started = make(chan struct{})
+	)
+	g.Logger = telemetry.NoopLogger()
+
+	g.Register(run.NewService("workers", make(chan struct{}),
+		func(quit chan struct{}) error {
+			var wg sync.WaitGroup
+			for i := 0; i < 2; i++ {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					<-quit
+				}()
+			}
+			close(started)
+			wg.Wait()
+			return nil
+		},
+		func(quit chan struct{}) { close(quit) },
+	))
+
+	go func() { irq <- g.Run("./myService") }()
+	<-started
+
+	usage := g.ResourceUsage()
+	if len(usage.Units) != 1 || usage.Units[0].Name != "workers" {
+		t.Fatalf("Expected usage of workers, got %+v", usage.Units)
+	}
+	if n := usage.Units[0].Goroutines; n != 3 {
+		t.Errorf("Expected 3 goroutines for workers, got %d", n)
+	}
+	if usage.Goroutines < 3 || usage.HeapAllocBytes == 0 {
+		t.Errorf("Expected process wide usage, got %+v", usage)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	if err := g.Shutdown(ctx); err != nil {
+		t.Fatalf("Expected successful shutdown, got %v", err)
+	}
+	if err := <-irq; err != nil {
+		t.Fatalf("Expected nil error, got %v", err)
+	}
+	if usage = g.ResourceUsage(); usage.Units[0].Goroutines != 0 {
+		t.Errorf("Expected no goroutines for stopped workers, got %+v", usage.Units)
+	}
+}
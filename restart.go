@@ -0,0 +1,206 @@
+// Copyright (c) Bas van Beek 2024.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package run
+
+import (
+	"fmt"
+	"time"
+)
+
+// RestartOption configures the behavior of Group.RestartUnit.
+type RestartOption func(*restartConfig)
+
+type restartConfig struct {
+	attempts    int
+	backoff     time.Duration
+	maxBackoff  time.Duration
+	stopTimeout time.Duration
+	fatal       bool
+}
+
+// RestartAttempts sets the maximum amount of PreRun attempts RestartUnit will
+// make before giving up. Defaults to 1.
+func RestartAttempts(n int) RestartOption {
+	return func(c *restartConfig) {
+		if n > 0 {
+			c.attempts = n
+		}
+	}
+}
+
+// RestartBackoff sets the delay between failed PreRun attempts. The delay
+// starts at initial and is doubled after each failure, capped at maxDelay.
+// A maxDelay of 0 means the delay is not capped.
+func RestartBackoff(initial, maxDelay time.Duration) RestartOption {
+	return func(c *restartConfig) {
+		c.backoff = initial
+		c.maxBackoff = maxDelay
+	}
+}
+
+// RestartStopTimeout sets the maximum amount of time RestartUnit waits for a
+// running Unit to return from Serve or ServeContext before giving up. By
+// default RestartUnit waits indefinitely.
+func RestartStopTimeout(d time.Duration) RestartOption {
+	return func(c *restartConfig) {
+		c.stopTimeout = d
+	}
+}
+
+// RestartFailureIsFatal propagates a failing restart to the Group, which
+// results in a Group shutdown as if the Unit's Serve method returned the
+// error.
+func RestartFailureIsFatal() RestartOption {
+	return func(c *restartConfig) {
+		c.fatal = true
+	}
+}
+
+// RestartUnit restarts the Service or ServiceContext Unit identified by name
+// while the Group is serving. If the Unit is still running it is gracefully
+// stopped first. The Unit's PreRun method (if implemented) is then executed,
+// retried according to the provided options, after which the Unit is served
+// again. RestartUnit returns once the Unit has been started again or the
+// restart failed.
+func (g *Group) RestartUnit(name string, opts ...RestartOption) (err error) {
+	cfg := restartConfig{attempts: 1}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+
+	g.mu.Lock()
+	if !g.started || g.stopping {
+		g.mu.Unlock()
+		return fmt.Errorf("restart %s: %w", name, ErrNotServing)
+	}
+	var u Unit
+	for _, s := range g.s {
+		if s != nil && g.nameOf(s) == name {
+			u = s
+			break
+		}
+	}
+	for _, x := range g.x {
+		if u == nil && x != nil && g.nameOf(x) == name {
+			u = x
+			break
+		}
+	}
+	if u == nil {
+		g.mu.Unlock()
+		return fmt.Errorf("restart %s: %w", name, ErrUnitNotFound)
+	}
+	// prev holds the record of the last instance of the Unit, which is replaced
+	// by the restarted instance
+	var prev, r *serving
+	for _, s := range g.running {
+		if s.unit == u {
+			prev = s
+		}
+	}
+	if prev != nil && !prev.done {
+		r = prev
+	}
+	if r != nil && !r.detached {
+		r.detached = true
+		go g.gracefulStop(r, ShutdownReason{Err: ErrRequestedShutdown})
+	}
+	ctx := g.ctx
+	g.mu.Unlock()
+
+	l := g.phaseLogger("restart", name, "(restart)")
+	defer func() {
+		l.exit(err)
+	}()
+
+	// wait for the running instance of the Unit to exit
+	if r != nil {
+		var timeout <-chan time.Time
+		if cfg.stopTimeout > 0 {
+			timer := g.Clock().NewTimer(cfg.stopTimeout)
+			defer timer.Stop()
+			timeout = timer.C()
+		}
+		select {
+		case <-r.exit:
+		case <-timeout:
+			return g.restartFailed(cfg, name, fmt.Errorf("restart %s: timeout waiting for unit to stop", name))
+		case <-ctx.Done():
+			return fmt.Errorf("restart %s: %w", name, ErrNotServing)
+		}
+	}
+
+	// run PreRun with backoff
+	backoff := cfg.backoff
+	for attempt := 1; attempt <= cfg.attempts; attempt++ {
+		if err = g.preRun(ctx, u); err == nil {
+			break
+		}
+		l.Debug("restart-pre-run-failed", "attempt", attempt, "error", err.Error())
+		if attempt == cfg.attempts {
+			break
+		}
+		select {
+		case <-g.Clock().After(backoff):
+		case <-ctx.Done():
+			return fmt.Errorf("restart %s: %w", name, ErrNotServing)
+		}
+		if backoff *= 2; cfg.maxBackoff > 0 && backoff > cfg.maxBackoff {
+			backoff = cfg.maxBackoff
+		}
+	}
+	if err != nil {
+		return g.restartFailed(cfg, name, fmt.Errorf("restart %s: %w", name, err))
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.stopping {
+		return fmt.Errorf("restart %s: %w", name, ErrNotServing)
+	}
+	g.serve(u, "(restart)", nil)
+	g.replaceServing(prev, u)
+	return nil
+}
+
+// replaceServing replaces the record of the previous instance of the provided
+// Unit by the record of its restarted instance, last in line, so the running
+// records don't grow with each restart.
+// It must be called while holding the Group lock.
+func (g *Group) replaceServing(prev *serving, u Unit) {
+	n := len(g.running)
+	if prev == nil || n == 0 || g.running[n-1].unit != u || g.running[n-1] == prev {
+		return
+	}
+	for idx, r := range g.running[:n-1] {
+		if r == prev {
+			g.running[idx] = g.running[n-1]
+			g.running[n-1] = nil
+			g.running = g.running[:n-1]
+			return
+		}
+	}
+}
+
+// restartFailed propagates the restart error to the Group if requested.
+func (g *Group) restartFailed(cfg restartConfig, name string, err error) error {
+	if cfg.fatal {
+		select {
+		case g.errs <- ShutdownReason{Unit: name, Err: err}:
+		default:
+		}
+	}
+	return err
+}
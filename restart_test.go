@@ -0,0 +1,135 @@
+// Copyright (c) Bas van Beek 2024.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package run_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/basvanbeek/telemetry"
+
+	"github.com/basvanbeek/run"
+	"github.com/basvanbeek/run/pkg/test"
+)
+
+func TestRestartUnit(t *testing.T) {
+	var (
+		logger = &debugLogger{Logger: telemetry.NoopLogger()}
+		g      = run.Group{Name: "RestartUnit", Logger: logger}
+		r      = &restartable{started: make(chan int, 5), failures: 2}
+		irq    = make(chan error)
+	)
+
+	g.Register(r)
+	g.Register(&test.Svc{
+		SvcName: "restarter",
+		Execute: func() error {
+			if n := <-r.started; n != 1 {
+				t.Errorf("Expected first start, got %d", n)
+			}
+			if err := g.RestartUnit("unknown"); !errors.Is(err, run.ErrUnitNotFound) {
+				t.Errorf("Expected %v, got %v", run.ErrUnitNotFound, err)
+			}
+			// PreRun fails twice, so we need three attempts
+			if err := g.RestartUnit("restartable",
+				run.RestartAttempts(2),
+				run.RestartBackoff(time.Millisecond, 0),
+			); err == nil {
+				t.Error("Expected restart to fail")
+			}
+			if err := g.RestartUnit("restartable",
+				run.RestartAttempts(3),
+				run.RestartBackoff(time.Millisecond, 0),
+			); err != nil {
+				t.Errorf("Expected restart to succeed, got %v", err)
+			}
+			if n := <-r.started; n != 2 {
+				t.Errorf("Expected second start, got %d", n)
+			}
+			return errIRQ
+		},
+	})
+
+	go func() { irq <- g.Run("./myService") }()
+
+	select {
+	case err := <-irq:
+		if !errors.Is(err, errIRQ) {
+			t.Errorf("Expected proper close, got %v", err)
+		}
+	case <-time.After(200 * time.Millisecond):
+		t.Errorf("timeout")
+	}
+	// both the failed and the successful restart log their exit
+	if n := logger.count("restart-exit"); n != 2 {
+		t.Errorf("Expected 2 restart exits, got %d", n)
+	}
+}
+
+// debugLogger counts the Debug messages logged by Group.
+type debugLogger struct {
+	telemetry.Logger
+	mu   sync.Mutex
+	msgs []string
+}
+
+func (l *debugLogger) DebugEnabled() bool { return true }
+
+func (l *debugLogger) With(...interface{}) telemetry.Logger { return l }
+
+func (l *debugLogger) Debug(msg string, _ ...interface{}) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.msgs = append(l.msgs, msg)
+}
+
+func (l *debugLogger) count(msg string) (n int) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for _, m := range l.msgs {
+		if m == msg {
+			n++
+		}
+	}
+	return n
+}
+
+type restartable struct {
+	preRuns  int
+	serves   int
+	failures int
+	started  chan int
+}
+
+func (r *restartable) Name() string { return "restartable" }
+
+func (r *restartable) PreRun() error {
+	r.preRuns++
+	if r.preRuns > 1 && r.failures > 0 {
+		r.failures--
+		return errors.New("pre-run failure")
+	}
+	return nil
+}
+
+func (r *restartable) ServeContext(ctx context.Context) error {
+	r.serves++
+	r.started <- r.serves
+	<-ctx.Done()
+	return nil
+}
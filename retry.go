@@ -0,0 +1,79 @@
+// Copyright (c) Bas van Beek 2024.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package run
+
+import (
+	"context"
+	"time"
+
+	"github.com/basvanbeek/telemetry"
+
+	"github.com/basvanbeek/run/pkg/backoff"
+)
+
+// loggerAware is implemented by Units provided by this package which want to
+// log using the Group's Logger.
+type loggerAware interface {
+	useLogger(l telemetry.Logger)
+}
+
+// NewRetryingPreRunner takes a name, a standalone pre runner compatible
+// function and a backoff configuration and turns them into a Group compatible
+// PreRunner, ready for registration. The function is retried with exponential
+// backoff until it succeeds or the limits of the backoff configuration are
+// reached, logging each failed attempt. This is useful for "wait for
+// dependency to be reachable" logic at startup.
+func NewRetryingPreRunner(name string, fn func() error, cfg backoff.Config) PreRunner {
+	return &retryingPreRunner{name: name, fn: fn, cfg: cfg}
+}
+
+type retryingPreRunner struct {
+	name   string
+	fn     func() error
+	cfg    backoff.Config
+	logger telemetry.Logger
+}
+
+func (r *retryingPreRunner) Name() string {
+	return r.name
+}
+
+func (r *retryingPreRunner) PreRun() error {
+	return r.PreRunContext(context.Background())
+}
+
+// PreRunContext stops retrying once the provided context is done, e.g. when
+// the Group is requested to shut down during startup.
+func (r *retryingPreRunner) PreRunContext(ctx context.Context) error {
+	return r.cfg.Retry(ctx, r.fn,
+		func(attempt int, err error, next time.Duration) {
+			if r.logger != nil {
+				r.logger.Info("pre-run-retry", "attempt", attempt,
+					"error", err.Error(), "next", next.String())
+			}
+		},
+	)
+}
+
+func (r *retryingPreRunner) useLogger(l telemetry.Logger) {
+	r.logger = l
+}
+
+// useClock implements clockAware, leaving an explicit backoff Clock in place.
+func (r *retryingPreRunner) useClock(c Clock) {
+	if r.cfg.Clock == nil {
+		r.cfg.Clock = c
+	}
+}
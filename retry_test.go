@@ -0,0 +1,56 @@
+// Copyright (c) Bas van Beek 2024.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package run_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/basvanbeek/telemetry"
+
+	"github.com/basvanbeek/run"
+	"github.com/basvanbeek/run/pkg/backoff"
+)
+
+func TestRetryingPreRunner(t *testing.T) {
+	var (
+		g     = run.Group{Name: "retry", Logger: telemetry.NoopLogger()}
+		calls int
+	)
+	g.Register(run.NewRetryingPreRunner("dependency", func() error {
+		if calls++; calls < 3 {
+			return errIRQ
+		}
+		return nil
+	}, backoff.Config{InitialInterval: time.Millisecond}))
+
+	if err := g.Run("./myService"); err != nil {
+		t.Fatalf("Expected proper close, got %v", err)
+	}
+	if calls != 3 {
+		t.Errorf("Expected 3 attempts, got %d", calls)
+	}
+
+	g = run.Group{Name: "retry", Logger: telemetry.NoopLogger()}
+	g.Register(run.NewRetryingPreRunner("dependency", func() error {
+		return errIRQ
+	}, backoff.Config{InitialInterval: time.Millisecond, MaxAttempts: 2}))
+
+	err := g.Run("./myService")
+	if !errors.Is(err, errIRQ) || !errors.Is(err, backoff.ErrMaxAttempts) {
+		t.Errorf("Expected %v and %v, got %v", errIRQ, backoff.ErrMaxAttempts, err)
+	}
+}
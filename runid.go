@@ -0,0 +1,41 @@
+// Copyright (c) Bas van Beek 2024.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package run
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"strconv"
+	"time"
+)
+
+// RunID returns the id generated at the start of the Config phase, unique to
+// each run of the Group. It is included in the lifecycle logs, the version
+// output and the Status of the Group, and can be used by Units for e.g. lease
+// names and log correlation. It is empty before the Config phase.
+func (g *Group) RunID() string {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.runID
+}
+
+// newRunID returns a random 16 character hexadecimal id.
+func newRunID() string {
+	var b [8]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return strconv.FormatInt(time.Now().UnixNano(), 16)
+	}
+	return hex.EncodeToString(b[:])
+}
@@ -0,0 +1,57 @@
+// Copyright (c) Bas van Beek 2024.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package run_test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/basvanbeek/telemetry"
+
+	"github.com/basvanbeek/run"
+)
+
+func TestRunID(t *testing.T) {
+	var stdout bytes.Buffer
+	g := run.NewGroup("runid", run.WithLogger(telemetry.NoopLogger()),
+		run.WithOutput(&stdout, &stdout))
+	g.Register(configUnit{fs: run.NewFlagSet("runid")})
+	if id := g.RunID(); id != "" {
+		t.Errorf("Expected no run id before Config, got %q", id)
+	}
+
+	if err := g.RunConfig("./myService"); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	first := g.RunID()
+	if len(first) != 16 {
+		t.Errorf("Expected 16 character run id, got %q", first)
+	}
+	if s := g.Status(); s.RunID != first {
+		t.Errorf("Expected status run id %q, got %q", first, s.RunID)
+	}
+
+	if err := g.RunConfig("./myService", "--version"); err != run.ErrBailEarlyRequest {
+		t.Fatalf("Expected bail early request, got %v", err)
+	}
+	second := g.RunID()
+	if second == first {
+		t.Errorf("Expected a new run id for each run, got %q twice", first)
+	}
+	if !strings.Contains(stdout.String(), "run id "+second) {
+		t.Errorf("Expected version output to hold the run id, got %q", stdout.String())
+	}
+}
@@ -0,0 +1,194 @@
+// Copyright (c) Bas van Beek 2024.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package run
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	stdlog "log"
+	"net"
+	"net/http"
+	"strings"
+)
+
+// GRPCServerer holds the methods of *grpc.Server needed by GRPCServer. It
+// allows for registering gRPC servers without this package depending on the
+// gRPC module.
+type GRPCServerer interface {
+	Serve(lis net.Listener) error
+	GracefulStop()
+}
+
+// HTTPServer returns a Service managing the lifecycle of the provided
+// http.Server. If the server holds a TLS configuration with certificates, it
+// serves TLS. GracefulStop shuts down the server, waiting for in-flight
+// requests to finish. A server closed outside the Group's control results in
+// an ErrRequestedShutdown.
+func HTTPServer(name string, srv *http.Server) Service {
+	return &httpService{name: name, srv: srv}
+}
+
+// ListenerService returns a Service serving the provided http.Handler on an
+// already established net.Listener, e.g. one obtained through socket
+// activation or bound during the PreRun phase.
+func ListenerService(name string, lis net.Listener, handler http.Handler) Service {
+	return MultiListenerService(name, []net.Listener{lis}, handler)
+}
+
+// MultiListenerService returns a Service serving the provided http.Handler on
+// all provided net.Listeners with a single http.Server, e.g. to serve both
+// IPv4 and IPv6 or a unix socket next to a TCP address. The listeners are
+// served and stopped together, see ServeListeners.
+func MultiListenerService(name string, listeners []net.Listener, handler http.Handler) Service {
+	return &httpService{
+		name: name,
+		srv:  &http.Server{Handler: handler}, //nolint:gosec // timeouts are up to the handler
+		lis:  listeners,
+	}
+}
+
+// Listen binds all provided addresses, typically taken from a repeatable flag.
+// Addresses prefixed with "unix:" bind a unix socket, all others a TCP
+// address. If one of the addresses can't be bound, the listeners bound so far
+// are closed.
+func Listen(addrs ...string) ([]net.Listener, error) {
+	listeners := make([]net.Listener, 0, len(addrs))
+	for _, addr := range addrs {
+		network := "tcp"
+		if path, ok := strings.CutPrefix(addr, "unix:"); ok {
+			network, addr = "unix", path
+		}
+		lis, err := net.Listen(network, addr)
+		if err != nil {
+			for _, l := range listeners {
+				_ = l.Close()
+			}
+			return nil, err
+		}
+		listeners = append(listeners, lis)
+	}
+	return listeners, nil
+}
+
+// ServeListeners serves the provided http.Server on all provided listeners,
+// using TLS if the server holds a TLS configuration with certificates, and
+// blocks until all of them have stopped. If serving one of the listeners
+// fails, the server is closed to stop the others and the error is returned.
+// Otherwise http.ErrServerClosed is returned once the server is shut down.
+func ServeListeners(srv *http.Server, listeners ...net.Listener) error {
+	// decide on TLS up front as serving writes to the TLS configuration
+	useTLS := hasTLS(srv)
+	errs := make(chan error, len(listeners))
+	for _, lis := range listeners {
+		go func(lis net.Listener) {
+			if useTLS {
+				errs <- srv.ServeTLS(lis, "", "")
+				return
+			}
+			errs <- srv.Serve(lis)
+		}(lis)
+	}
+	var err error
+	for range listeners {
+		sErr := <-errs
+		if errors.Is(sErr, http.ErrServerClosed) {
+			if err == nil {
+				err = sErr
+			}
+			continue
+		}
+		if err == nil || errors.Is(err, http.ErrServerClosed) {
+			// stop serving the other listeners
+			err = sErr
+			_ = srv.Close()
+		}
+	}
+	return err
+}
+
+// hasTLS returns true if the provided server holds a TLS configuration with
+// certificates.
+func hasTLS(srv *http.Server) bool {
+	return srv.TLSConfig != nil && (len(srv.TLSConfig.Certificates) > 0 ||
+		srv.TLSConfig.GetCertificate != nil)
+}
+
+// GRPCServer returns a Service serving the provided gRPC server on the
+// provided net.Listener. GracefulStop gracefully stops the gRPC server.
+func GRPCServer(name string, srv GRPCServerer, lis net.Listener) Service {
+	return &grpcService{name: name, srv: srv, lis: lis}
+}
+
+type httpService struct {
+	name string
+	srv  *http.Server
+	lis  []net.Listener
+}
+
+func (h *httpService) Name() string {
+	return h.name
+}
+
+func (h *httpService) Serve() error {
+	var err error
+	switch {
+	case len(h.lis) > 0:
+		err = ServeListeners(h.srv, h.lis...)
+	case hasTLS(h.srv):
+		err = h.srv.ListenAndServeTLS("", "")
+	default:
+		err = h.srv.ListenAndServe()
+	}
+	if errors.Is(err, http.ErrServerClosed) {
+		return fmt.Errorf("%s: %w", h.name, ErrRequestedShutdown)
+	}
+	return err
+}
+
+// useStdLogger implements stdLogAware, leaving an explicit ErrorLog in place.
+func (h *httpService) useStdLogger(l *stdlog.Logger) {
+	if h.srv.ErrorLog == nil {
+		h.srv.ErrorLog = l
+	}
+}
+
+func (h *httpService) GracefulStop() {
+	if err := h.srv.Shutdown(context.Background()); err != nil {
+		_ = h.srv.Close()
+	}
+}
+
+type grpcService struct {
+	name string
+	srv  GRPCServerer
+	lis  net.Listener
+}
+
+func (g *grpcService) Name() string {
+	return g.name
+}
+
+func (g *grpcService) Serve() error {
+	if err := g.srv.Serve(g.lis); err != nil {
+		return err
+	}
+	// Serve only returns without error after (Graceful)Stop has been called
+	return fmt.Errorf("%s: %w", g.name, ErrRequestedShutdown)
+}
+
+func (g *grpcService) GracefulStop() {
+	g.srv.GracefulStop()
+}
@@ -0,0 +1,135 @@
+// Copyright (c) Bas van Beek 2024.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package run_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/basvanbeek/telemetry"
+
+	"github.com/basvanbeek/run"
+	"github.com/basvanbeek/run/pkg/test"
+)
+
+func TestListenerService(t *testing.T) {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("unable to listen: %v", err)
+	}
+
+	var (
+		g   = run.Group{Name: "ListenerService", Logger: telemetry.NoopLogger()}
+		irq = make(chan error)
+	)
+
+	g.Register(run.ListenerService("http", lis,
+		http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			_, _ = io.WriteString(w, "ok")
+		}),
+	))
+	g.Register(&test.Svc{
+		SvcName: "client",
+		Execute: func() error {
+			res, err := http.Get("http://" + lis.Addr().String()) //nolint:noctx // test
+			if err != nil {
+				return err
+			}
+			defer func() { _ = res.Body.Close() }()
+			if b, _ := io.ReadAll(res.Body); string(b) != "ok" {
+				t.Errorf("Expected ok, got %q", b)
+			}
+			return errIRQ
+		},
+	})
+
+	go func() { irq <- g.Run("./myService") }()
+
+	select {
+	case err := <-irq:
+		if !errors.Is(err, errIRQ) {
+			t.Errorf("Expected proper close, got %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Errorf("timeout")
+	}
+}
+
+func TestMultiListenerService(t *testing.T) {
+	sock := filepath.Join(t.TempDir(), "http.sock")
+	listeners, err := run.Listen("127.0.0.1:0", "unix:"+sock)
+	if err != nil {
+		t.Fatalf("unable to listen: %v", err)
+	}
+
+	var (
+		g    = run.Group{Name: "MultiListenerService", Logger: telemetry.NoopLogger()}
+		unix = &http.Client{Transport: &http.Transport{
+			DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+				return (&net.Dialer{}).DialContext(ctx, "unix", sock)
+			},
+		}}
+	)
+	g.Register(run.MultiListenerService("http", listeners,
+		http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			_, _ = io.WriteString(w, "ok")
+		}),
+	))
+	g.Register(&test.Svc{
+		SvcName: "client",
+		Execute: func() error {
+			for _, c := range []struct {
+				client *http.Client
+				url    string
+			}{
+				{http.DefaultClient, "http://" + listeners[0].Addr().String()},
+				{unix, "http://unix"},
+			} {
+				res, err := c.client.Get(c.url) //nolint:noctx // test
+				if err != nil {
+					return err
+				}
+				b, _ := io.ReadAll(res.Body)
+				_ = res.Body.Close()
+				if string(b) != "ok" {
+					t.Errorf("%s: Expected ok, got %q", c.url, b)
+				}
+			}
+			return errIRQ
+		},
+	})
+
+	if err := g.Run("./myService"); !errors.Is(err, errIRQ) {
+		t.Errorf("Expected proper close, got %v", err)
+	}
+}
+
+func TestListenError(t *testing.T) {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("unable to listen: %v", err)
+	}
+	defer func() { _ = lis.Close() }()
+
+	if _, err = run.Listen("127.0.0.1:0", lis.Addr().String()); err == nil {
+		t.Error("Expected address in use error, got nil")
+	}
+}
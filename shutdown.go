@@ -0,0 +1,202 @@
+// Copyright (c) Bas van Beek 2024.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package run
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+)
+
+// ShutdownReason describes why a Unit is requested to stop.
+type ShutdownReason struct {
+	// Unit holds the name of the Unit whose exit initiated the shutdown. It is
+	// empty if the shutdown was initiated from outside the Group's Units, e.g.
+	// by Deregister or StopUnit.
+	Unit string
+	// Err holds the error that initiated the shutdown. An orderly shutdown is
+	// signaled by an error wrapping ErrRequestedShutdown.
+	Err error
+}
+
+// Requested returns true if the shutdown is orderly instead of being caused
+// by a failure.
+func (r ShutdownReason) Requested() bool {
+	return errors.Is(r.Err, ErrRequestedShutdown)
+}
+
+// String implements fmt.Stringer.
+func (r ShutdownReason) String() string {
+	switch {
+	case r.Unit == "":
+		return fmt.Sprintf("%v", r.Err)
+	case r.Err == nil:
+		return "unit " + r.Unit + " exited"
+	default:
+		return fmt.Sprintf("unit %s: %v", r.Unit, r.Err)
+	}
+}
+
+// cause returns the reason as error, used as the cause of the context
+// cancellation signaling the shutdown.
+func (r ShutdownReason) cause() error {
+	switch {
+	case r.Unit == "" && r.Err == nil:
+		return context.Canceled
+	case r.Unit == "":
+		return r.Err
+	case r.Err == nil:
+		return errors.New(r.String())
+	default:
+		return fmt.Errorf("unit %s: %w", r.Unit, r.Err)
+	}
+}
+
+// ReasonStopper is an extension interface that Service Units can implement to
+// learn why they are requested to stop. If implemented, StopWithReason is
+// called instead of GracefulStop and must gracefully stop the Service and make
+// the Serve call return.
+type ReasonStopper interface {
+	Service
+	StopWithReason(reason ShutdownReason)
+}
+
+// ShutdownAborter is an extension interface that Service and ServiceContext
+// Units can implement to abort a graceful shutdown in progress, e.g. when an
+// operator repeats an interrupt signal. AbortShutdown is called once the Group
+// starts its teardown and the returned channel must be closed to stop waiting
+// for the remaining Units to return, in which case Run returns an error
+// wrapping ErrForcedShutdown.
+type ShutdownAborter interface {
+	Unit
+	AbortShutdown() <-chan struct{}
+}
+
+const (
+	errTimeout Error = "timeout"
+	errAborted Error = "aborted"
+)
+
+// abortShutdown returns a channel closed by the first of the provided
+// ShutdownAborter Units requesting to abort the graceful shutdown.
+func (g *Group) abortShutdown(aborters []ShutdownAborter) <-chan struct{} {
+	if len(aborters) == 0 {
+		return nil
+	}
+	g.mu.Lock()
+	done := g.done
+	g.mu.Unlock()
+
+	var (
+		abort = make(chan struct{})
+		once  sync.Once
+	)
+	for _, a := range aborters {
+		go func(ch <-chan struct{}) {
+			select {
+			case <-ch:
+				once.Do(func() { close(abort) })
+			case <-done:
+			}
+		}(a.AbortShutdown())
+	}
+	return abort
+}
+
+// draining returns the names of the running Units which have not returned.
+func (g *Group) draining() []string {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	var names []string
+	for _, r := range g.running {
+		if !r.done {
+			names = append(names, g.nameOf(r.unit))
+		}
+	}
+	return names
+}
+
+type shutdownReasonKey struct{}
+
+// ShutdownReasonFromContext returns the reason a ServiceContext Unit is
+// requested to stop. It returns false if the context provided to ServeContext
+// has not been canceled by the Group.
+func ShutdownReasonFromContext(ctx context.Context) (ShutdownReason, bool) {
+	p, ok := ctx.Value(shutdownReasonKey{}).(*atomic.Pointer[ShutdownReason])
+	if !ok {
+		return ShutdownReason{}, false
+	}
+	if r := p.Load(); r != nil {
+		return *r, true
+	}
+	return ShutdownReason{}, false
+}
+
+// Context returns a context which is canceled once the Group starts stopping
+// its Service and ServiceContext Units, or Run returns. As with ServeContext,
+// context.Cause returns why the shutdown started. It allows request
+// handlers and background helpers outside of the registered Units to observe
+// the shutdown without plumbing the ServeContext context around. Context can
+// be called before Run. After Reset, a new context is returned.
+func (g *Group) Context() context.Context {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.groupContext()
+}
+
+// groupContext returns the context returned by Context, creating it if
+// needed. It must be called while holding the Group lock.
+func (g *Group) groupContext() context.Context {
+	if g.groupCtx == nil {
+		g.groupCtx, g.groupCancel = context.WithCancelCause(context.Background())
+	}
+	return g.groupCtx
+}
+
+// cancelGroupContext cancels the context returned by Context with the
+// provided cause. It must be called while holding the Group lock.
+func (g *Group) cancelGroupContext(cause error) {
+	g.groupContext()
+	g.groupCancel(cause)
+}
+
+// Shutdown initiates a graceful shutdown of a running Group, following the same
+// teardown path as a Service returning ErrRequestedShutdown, and waits for Run
+// to return or the provided context to expire. If called before the Serve
+// phase, the Group shuts down as soon as its Services have been started.
+// Shutdown is safe for concurrent use and may be called multiple times.
+func (g *Group) Shutdown(ctx context.Context) error {
+	g.mu.Lock()
+	done, errs := g.done, g.errs
+	g.mu.Unlock()
+	if done == nil {
+		return fmt.Errorf("shutdown: %w", ErrNotServing)
+	}
+
+	select {
+	case errs <- ShutdownReason{Err: fmt.Errorf("shutdown: %w", ErrRequestedShutdown)}:
+	default:
+		// shutdown has already been initiated
+	}
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("shutdown: %w", ctx.Err())
+	}
+}
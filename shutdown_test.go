@@ -0,0 +1,247 @@
+// Copyright (c) Bas van Beek 2024.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package run_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/basvanbeek/telemetry"
+
+	"github.com/basvanbeek/run"
+)
+
+type reasonService struct {
+	quit    chan struct{}
+	reasons chan run.ShutdownReason
+}
+
+func (r *reasonService) Name() string { return "reason-service" }
+func (r *reasonService) Serve() error {
+	<-r.quit
+	return nil
+}
+func (r *reasonService) GracefulStop() {
+	panic("GracefulStop must not be called for a ReasonStopper")
+}
+func (r *reasonService) StopWithReason(reason run.ShutdownReason) {
+	r.reasons <- reason
+	close(r.quit)
+}
+
+type reasonServiceContext struct {
+	reasons chan run.ShutdownReason
+}
+
+func (r *reasonServiceContext) Name() string { return "reason-service-context" }
+func (r *reasonServiceContext) ServeContext(ctx context.Context) error {
+	<-ctx.Done()
+	reason, _ := run.ShutdownReasonFromContext(ctx)
+	r.reasons <- reason
+	return nil
+}
+
+func TestShutdownReason(t *testing.T) {
+	var (
+		g       = run.Group{Name: "reason", Logger: telemetry.NoopLogger()}
+		irq     = make(chan error)
+		reasons = make(chan run.ShutdownReason, 2)
+	)
+
+	g.Register(
+		&reasonService{quit: make(chan struct{}), reasons: reasons},
+		&reasonServiceContext{reasons: reasons},
+		run.NewActor("failing",
+			func() error {
+				time.Sleep(10 * time.Millisecond)
+				return errIRQ
+			},
+			func(error) {},
+		),
+	)
+
+	if _, ok := run.ShutdownReasonFromContext(context.Background()); ok {
+		t.Error("Expected no shutdown reason for unrelated context")
+	}
+
+	go func() { irq <- g.Run("./myService") }()
+
+	select {
+	case err := <-irq:
+		if !errors.Is(err, errIRQ) {
+			t.Errorf("Expected %v, got %v", errIRQ, err)
+		}
+	case <-time.After(100 * time.Millisecond):
+		t.Fatal("timeout")
+	}
+	for i := 0; i < 2; i++ {
+		reason := <-reasons
+		if reason.Unit != "failing" || !errors.Is(reason.Err, errIRQ) || reason.Requested() {
+			t.Errorf("Expected failure of unit failing, got %s", reason)
+		}
+	}
+}
+
+func TestShutdown(t *testing.T) {
+	var (
+		g       = run.Group{Name: "shutdown", Logger: telemetry.NoopLogger()}
+		irq     = make(chan error)
+		started = make(chan struct{})
+	)
+
+	if err := g.Shutdown(context.Background()); !errors.Is(err, run.ErrNotServing) {
+		t.Errorf("Expected %v, got %v", run.ErrNotServing, err)
+	}
+
+	g.Register(run.NewService("blocking", make(chan struct{}),
+		func(quit chan struct{}) error {
+			close(started)
+			<-quit
+			return nil
+		},
+		func(quit chan struct{}) { close(quit) },
+	))
+
+	go func() { irq <- g.Run("./myService") }()
+	<-started
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	errs := make(chan error, 2)
+	for i := 0; i < 2; i++ {
+		go func() { errs <- g.Shutdown(ctx) }()
+	}
+	for i := 0; i < 2; i++ {
+		if err := <-errs; err != nil {
+			t.Errorf("Expected successful shutdown, got %v", err)
+		}
+	}
+
+	select {
+	case err := <-irq:
+		if err != nil {
+			t.Errorf("Expected requested shutdown, got %v", err)
+		}
+	case <-time.After(100 * time.Millisecond):
+		t.Errorf("timeout")
+	}
+}
+
+type ctxKey struct{}
+
+func TestRunContext(t *testing.T) {
+	var (
+		g       = run.Group{Name: "run-context", Logger: telemetry.NoopLogger()}
+		irq     = make(chan error)
+		values  = make(chan any, 1)
+		started = make(chan struct{})
+	)
+
+	g.Register(&contextValueService{values: values, started: started})
+
+	ctx, cancel := context.WithCancel(context.WithValue(context.Background(), ctxKey{}, "value"))
+	go func() { irq <- g.RunContext(ctx, "./myService") }()
+	<-started
+	cancel()
+
+	select {
+	case err := <-irq:
+		if err != nil {
+			t.Errorf("Expected requested shutdown, got %v", err)
+		}
+		if v := <-values; v != "value" {
+			t.Errorf("Expected context value, got %v", v)
+		}
+	case <-time.After(100 * time.Millisecond):
+		t.Errorf("timeout")
+	}
+}
+
+type contextValueService struct {
+	values  chan any
+	started chan struct{}
+}
+
+func (c *contextValueService) Name() string { return "context-value" }
+func (c *contextValueService) ServeContext(ctx context.Context) error {
+	close(c.started)
+	<-ctx.Done()
+	c.values <- ctx.Value(ctxKey{})
+	if reason, ok := run.ShutdownReasonFromContext(ctx); !ok || !reason.Requested() {
+		return errors.New("missing requested shutdown reason")
+	}
+	return nil
+}
+
+func TestGroupContext(t *testing.T) {
+	var (
+		g       = run.Group{Name: "context", Logger: telemetry.NoopLogger()}
+		ctx     = g.Context()
+		serving bool
+	)
+	g.Register(run.NewActor("trigger", func() error {
+		serving = ctx.Err() == nil
+		return run.ErrRequestedShutdown
+	}, func(error) {}))
+
+	if err := g.Run("./myService"); err != nil {
+		t.Fatalf("Expected proper close, got %v", err)
+	}
+	if !serving {
+		t.Error("Expected context to be alive while serving")
+	}
+	if ctx.Err() == nil {
+		t.Error("Expected context to be canceled at shutdown")
+	}
+
+	if err := g.Reset(); err != nil {
+		t.Fatalf("Expected nil error, got %v", err)
+	}
+	if g.Context().Err() != nil {
+		t.Error("Expected a new context after Reset")
+	}
+}
+
+type causeService struct {
+	cause error
+}
+
+func (c *causeService) Name() string { return "cause-service" }
+func (c *causeService) ServeContext(ctx context.Context) error {
+	<-ctx.Done()
+	c.cause = context.Cause(ctx)
+	return nil
+}
+
+func TestServeContextCause(t *testing.T) {
+	var (
+		g   = run.Group{Name: "cause", Logger: telemetry.NoopLogger()}
+		e   = errors.New("boom")
+		svc = &causeService{}
+	)
+	g.Register(run.NewActor("failing", func() error { return e }, func(error) {}), svc)
+
+	if err := g.Run("./myService"); !errors.Is(err, e) {
+		t.Fatalf("Expected %v, got %v", e, err)
+	}
+	if !errors.Is(svc.cause, e) || svc.cause.Error() != "unit failing: boom" {
+		t.Errorf("Expected cause attributed to unit failing, got %v", svc.cause)
+	}
+	if gc := context.Cause(g.Context()); !errors.Is(gc, e) {
+		t.Errorf("Expected group context cause %v, got %v", e, gc)
+	}
+}
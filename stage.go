@@ -0,0 +1,232 @@
+// Copyright (c) Bas van Beek 2024.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package run
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/basvanbeek/multierror"
+)
+
+// DefaultPreRunStage is the PreRun stage of PreRunner Units not implementing
+// PreRunStager.
+const DefaultPreRunStage = 0
+
+// PreRunStager is an extension interface that PreRunner Units can implement
+// to have their PreRun method executed in a specific stage. PreRunnerContext
+// Units can opt in as well by implementing the PreRunStage method. Stages are executed
+// in ascending order, each stage only starting once all PreRunners of the
+// previous stage have successfully completed. This allows for clearly defined
+// waves of PreRunners across packages (e.g. stage 1: secrets, stage 2:
+// database, stage 3: migrations).
+//
+// PreRunners of DefaultPreRunStage are executed serially in order of
+// registration. PreRunners of all other stages are executed concurrently
+// within their stage, so they must not depend on each other.
+type PreRunStager interface {
+	PreRunner
+	PreRunStage() int
+}
+
+// preRunStage holds the PreRunner indexes belonging to a single stage.
+type preRunStage struct {
+	stage int
+	items []int
+}
+
+// preRunStages returns the PreRun stages in order of execution.
+func (g *Group) preRunStages(total int) []preRunStage {
+	var (
+		stages  []preRunStage
+		stageOf = make(map[int]int)
+	)
+	for idx := 0; idx < total; idx++ {
+		stage := DefaultPreRunStage
+		if s, ok := unitAt(g, &g.p, idx).(interface{ PreRunStage() int }); ok {
+			stage = s.PreRunStage()
+		}
+		pos, ok := stageOf[stage]
+		if !ok {
+			pos = len(stages)
+			stageOf[stage] = pos
+			stages = append(stages, preRunStage{stage: stage})
+		}
+		stages[pos].items = append(stages[pos].items, idx)
+	}
+	sort.Slice(stages, func(i, j int) bool {
+		return stages[i].stage < stages[j].stage
+	})
+	return stages
+}
+
+// runPreRunStage executes the PreRunners of the provided stage. The context
+// provided to PreRunnerContext Units is cancelled when ctx is done or the
+// stage timeout set by WithPreRunStageTimeout expires.
+func (g *Group) runPreRunStage(ctx context.Context, s preRunStage, total int) (err error) {
+	stageCtx := ctx
+	if d, ok := g.preRunTimeouts[s.stage]; ok && d > 0 {
+		var cancel context.CancelFunc
+		stageCtx, cancel = context.WithTimeoutCause(ctx, d,
+			categorize(fmt.Errorf("pre-run stage %d: %w", s.stage,
+				context.DeadlineExceeded), ErrStartupTimeout))
+		defer cancel()
+		defer func() {
+			// attribute failures to the expired stage timeout, also if the
+			// PreRunner did not return the context cause
+			if err != nil && ctx.Err() == nil && stageCtx.Err() != nil &&
+				!errors.Is(err, ErrStartupTimeout) {
+				err = categorize(err, ErrStartupTimeout)
+			}
+		}()
+	}
+	if s.stage == DefaultPreRunStage {
+		for _, idx := range s.items {
+			if ctx.Err() != nil {
+				// do not start the next PreRunner if startup is interrupted
+				return context.Cause(ctx)
+			}
+			if err = g.runPreRunner(stageCtx, idx+1, total, unitAt(g, &g.p, idx)); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	g.Logger.Debug("pre-run-stage", "stage", s.stage, "units", len(s.items))
+	var (
+		mu sync.Mutex
+		wg sync.WaitGroup
+	)
+	for _, idx := range s.items {
+		wg.Add(1)
+		go func(idx int) {
+			defer wg.Done()
+			if pErr := g.runPreRunner(stageCtx, idx+1, total, unitAt(g, &g.p, idx)); pErr != nil {
+				mu.Lock()
+				err = multierror.Append(err, pErr)
+				mu.Unlock()
+			}
+		}(idx)
+	}
+	wg.Wait()
+	if err != nil {
+		return fmt.Errorf("pre-run stage %d: %w", s.stage, err)
+	}
+	return nil
+}
+
+// DefaultStartStage is the start stage of Service and ServiceContext Units not
+// implementing StartStager.
+const DefaultStartStage = 0
+
+// StartStager is an extension interface that Service and ServiceContext Units
+// can implement to be started in a specific wave. Waves are started in
+// ascending order of stage, each wave only starting once all Units of the
+// previous wave implementing ReadyNotifier have signaled to be ready (e.g.
+// stage 1: cache loader, stage 2: HTTP front-end). Units within a wave are
+// started concurrently. If a Unit exits while waiting for its wave to become
+// ready, the next waves are not started.
+type StartStager interface {
+	Unit
+	StartStage() int
+}
+
+// ReadyNotifier is an extension interface that Service and ServiceContext
+// Units can implement to signal readiness to the Group by closing the
+// returned channel. Units started in a wave before others, see StartStager,
+// are waited upon before the next wave starts. Units not implementing
+// ReadyNotifier are considered ready once started.
+type ReadyNotifier interface {
+	Unit
+	Ready() <-chan struct{}
+}
+
+// startUnit holds a Service or ServiceContext Unit to start together with its
+// log item and stagger order.
+type startUnit struct {
+	unit  Unit
+	item  string
+	order int
+}
+
+// startWave holds the Units belonging to a single start stage.
+type startWave struct {
+	stage int
+	units []startUnit
+}
+
+// startWaves returns the provided Units grouped by start stage in order of
+// execution.
+func startWaves(units []startUnit) []startWave {
+	var (
+		waves   []startWave
+		stageOf = make(map[int]int)
+	)
+	for _, su := range units {
+		stage := DefaultStartStage
+		if s, ok := su.unit.(StartStager); ok {
+			stage = s.StartStage()
+		}
+		pos, ok := stageOf[stage]
+		if !ok {
+			pos = len(waves)
+			stageOf[stage] = pos
+			waves = append(waves, startWave{stage: stage})
+		}
+		waves[pos].units = append(waves[pos].units, su)
+	}
+	sort.Slice(waves, func(i, j int) bool {
+		return waves[i].stage < waves[j].stage
+	})
+	return waves
+}
+
+// waitReady waits for the provided started Units implementing ReadyNotifier to
+// signal readiness. It returns false if ctx is done, one of the Units exited
+// without being stopped individually, or a shutdown reason arrived on errs
+// before all Units became ready. As each Unit launched so far reports an exit
+// not allowed to leave the Group running on errs, as do Shutdown and the
+// signal handlers, this covers the failure of sibling Units and shutdown
+// requests during startup. A received shutdown reason is returned for the
+// caller to handle.
+func (g *Group) waitReady(ctx context.Context, started []*serving,
+	errs <-chan ShutdownReason,
+) (*ShutdownReason, bool) {
+	for _, r := range started {
+		rn, ok := r.unit.(ReadyNotifier)
+		if !ok {
+			continue
+		}
+		select {
+		case <-rn.Ready():
+		case <-r.exit:
+			g.mu.Lock()
+			detached := r.detached
+			g.mu.Unlock()
+			if !detached {
+				return nil, false
+			}
+		case reason := <-errs:
+			return &reason, false
+		case <-ctx.Done():
+			return nil, false
+		}
+	}
+	return nil, true
+}
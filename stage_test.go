@@ -0,0 +1,282 @@
+// Copyright (c) Bas van Beek 2024.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package run_test
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/basvanbeek/telemetry"
+
+	"github.com/basvanbeek/run"
+)
+
+type stagedPreRunner struct {
+	name  string
+	stage int
+	err   error
+	log   func(string)
+}
+
+func (s stagedPreRunner) Name() string     { return s.name }
+func (s stagedPreRunner) PreRunStage() int { return s.stage }
+func (s stagedPreRunner) PreRun() error {
+	s.log(s.name)
+	return s.err
+}
+
+func TestPreRunStages(t *testing.T) {
+	var (
+		g     = run.Group{Name: "stages", Logger: telemetry.NoopLogger()}
+		mu    sync.Mutex
+		order []string
+		log   = func(name string) {
+			mu.Lock()
+			order = append(order, name)
+			mu.Unlock()
+		}
+	)
+
+	g.Register(
+		stagedPreRunner{name: "migrations", stage: 3, log: log},
+		run.NewPreRunner("default-1", func() error { log("default-1"); return nil }),
+		stagedPreRunner{name: "db", stage: 2, log: log},
+		stagedPreRunner{name: "secrets", stage: 1, log: log},
+		run.NewPreRunner("default-2", func() error { log("default-2"); return nil }),
+	)
+
+	if err := g.Run("./myService"); err != nil {
+		t.Fatalf("Expected proper close, got %v", err)
+	}
+	expected := []string{"default-1", "default-2", "secrets", "db", "migrations"}
+	if !reflect.DeepEqual(order, expected) {
+		t.Errorf("Expected order %v, got %v", expected, order)
+	}
+
+	// all PreRunners of a failing stage are run, next stages are not
+	order = nil
+	g = run.Group{Name: "stages", Logger: telemetry.NoopLogger()}
+	g.Register(
+		stagedPreRunner{name: "secrets-1", stage: 1, log: log, err: errIRQ},
+		stagedPreRunner{name: "secrets-2", stage: 1, log: log},
+		stagedPreRunner{name: "db", stage: 2, log: log},
+	)
+
+	if err := g.Run("./myService"); !errors.Is(err, errIRQ) {
+		t.Errorf("Expected %v, got %v", errIRQ, err)
+	}
+	if len(order) != 2 {
+		t.Errorf("Expected only stage 1 to run, got %v", order)
+	}
+}
+
+type ctxPreRunner struct {
+	stage   int
+	started chan struct{}
+}
+
+func (c *ctxPreRunner) Name() string     { return "ctx-pre-run" }
+func (c *ctxPreRunner) PreRunStage() int { return c.stage }
+func (c *ctxPreRunner) PreRun() error {
+	return errors.New("PreRun must not be called for a PreRunnerContext")
+}
+func (c *ctxPreRunner) PreRunContext(ctx context.Context) error {
+	close(c.started)
+	<-ctx.Done()
+	return ctx.Err()
+}
+
+func TestPreRunnerContextInterrupted(t *testing.T) {
+	var (
+		g       = run.Group{Name: "interrupted", Logger: telemetry.NoopLogger()}
+		pr      = &ctxPreRunner{started: make(chan struct{})}
+		next    bool
+		irq     = make(chan error)
+		ctx, cn = context.WithCancel(context.Background())
+	)
+	defer cn()
+
+	g.Register(pr, run.NewPreRunner("next", func() error {
+		next = true
+		return nil
+	}))
+
+	go func() { irq <- g.RunContext(ctx, "./myService") }()
+	<-pr.started
+	cn()
+
+	select {
+	case err := <-irq:
+		if err != nil {
+			t.Errorf("Expected requested shutdown, got %v", err)
+		}
+	case <-time.After(100 * time.Millisecond):
+		t.Fatal("timeout")
+	}
+	if next {
+		t.Error("Expected next PreRunner not to run after interruption")
+	}
+}
+
+func TestPreRunStageTimeout(t *testing.T) {
+	var (
+		g  = run.NewGroup("timeout", run.WithPreRunStageTimeout(2, 10*time.Millisecond))
+		pr = &ctxPreRunner{stage: 2, started: make(chan struct{})}
+	)
+	g.Logger = telemetry.NoopLogger()
+	g.Register(pr)
+
+	err := g.Run("./myService")
+	for _, want := range []error{context.DeadlineExceeded, run.ErrStartupTimeout, run.ErrPreRun} {
+		if !errors.Is(err, want) {
+			t.Errorf("Expected %v, got %v", want, err)
+		}
+	}
+}
+
+type waveService struct {
+	name   string
+	stage  int
+	loaded bool
+	ready  chan struct{}
+	serve  func(ctx context.Context) error
+}
+
+func (w *waveService) Name() string           { return w.name }
+func (w *waveService) StartStage() int        { return w.stage }
+func (w *waveService) Ready() <-chan struct{} { return w.ready }
+func (w *waveService) ServeContext(ctx context.Context) error {
+	if w.serve != nil {
+		return w.serve(ctx)
+	}
+	time.Sleep(10 * time.Millisecond)
+	w.loaded = true
+	close(w.ready)
+	<-ctx.Done()
+	return nil
+}
+
+func TestStartWaves(t *testing.T) {
+	var (
+		g        = run.Group{Name: "waves", Logger: telemetry.NoopLogger()}
+		loader   = &waveService{name: "loader", stage: 1, ready: make(chan struct{})}
+		frontend = &waveService{name: "frontend", stage: 2}
+		loaded   bool
+	)
+	frontend.serve = func(context.Context) error {
+		loaded = loader.loaded
+		return run.ErrRequestedShutdown
+	}
+	// register in reverse order of start stages
+	g.Register(frontend, loader)
+
+	if err := g.Run("./myService"); err != nil {
+		t.Fatalf("Expected proper close, got %v", err)
+	}
+	if !loaded {
+		t.Error("Expected frontend to start once the loader was ready")
+	}
+}
+
+func TestStartWavesAborted(t *testing.T) {
+	var (
+		g        = run.Group{Name: "waves", Logger: telemetry.NoopLogger()}
+		e        = errors.New("load failed")
+		loader   = &waveService{name: "loader", stage: 1, ready: make(chan struct{})}
+		frontend = &waveService{name: "frontend", stage: 2}
+		started  bool
+	)
+	loader.serve = func(context.Context) error { return e }
+	frontend.serve = func(context.Context) error {
+		started = true
+		return nil
+	}
+	g.Register(loader, frontend)
+
+	if err := g.Run("./myService"); !errors.Is(err, e) {
+		t.Errorf("Expected %v, got %v", e, err)
+	}
+	if started {
+		t.Error("Expected frontend not to start once the loader failed")
+	}
+}
+
+func TestStartWavesSiblingFailed(t *testing.T) {
+	var (
+		g        = run.Group{Name: "waves", Logger: telemetry.NoopLogger()}
+		e        = errors.New("sibling failed")
+		loader   = &waveService{name: "loader", stage: 1, ready: make(chan struct{})}
+		sibling  = &waveService{name: "sibling", stage: 1}
+		frontend = &waveService{name: "frontend", stage: 2}
+		res      = make(chan error)
+	)
+	// the loader never becomes ready
+	loader.serve = func(ctx context.Context) error {
+		<-ctx.Done()
+		return nil
+	}
+	sibling.serve = func(context.Context) error { return e }
+	frontend.serve = func(context.Context) error {
+		t.Error("Expected frontend not to start once a sibling failed")
+		return nil
+	}
+	g.Register(loader, sibling, frontend)
+
+	go func() { res <- g.Run("./myService") }()
+	select {
+	case err := <-res:
+		if !errors.Is(err, e) {
+			t.Errorf("Expected %v, got %v", e, err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Expected Run to return once a sibling failed")
+	}
+}
+
+func TestStartWavesShutdown(t *testing.T) {
+	var (
+		g        = run.Group{Name: "waves", Logger: telemetry.NoopLogger()}
+		started  = make(chan struct{})
+		loader   = &waveService{name: "loader", stage: 1, ready: make(chan struct{})}
+		frontend = &waveService{name: "frontend", stage: 2}
+		res      = make(chan error)
+	)
+	// the loader never becomes ready
+	loader.serve = func(ctx context.Context) error {
+		close(started)
+		<-ctx.Done()
+		return nil
+	}
+	frontend.serve = func(context.Context) error {
+		t.Error("Expected frontend not to start once shutdown was requested")
+		return nil
+	}
+	g.Register(loader, frontend)
+
+	go func() { res <- g.Run("./myService") }()
+	<-started
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err := g.Shutdown(ctx); err != nil {
+		t.Fatalf("Expected shutdown during start waves, got %v", err)
+	}
+	if err := <-res; err != nil {
+		t.Errorf("Expected proper close, got %v", err)
+	}
+}
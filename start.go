@@ -0,0 +1,92 @@
+// Copyright (c) Bas van Beek 2024.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package run
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// Start runs the Group like Run, but returns once the Config and PreRun
+// phases have completed and the Service and ServiceContext Units have been
+// launched, allowing embedding programs such as test harnesses or GUI
+// applications to control the lifecycle without blocking. If Run returns
+// before launching the Units, e.g. due to a configuration error or a --help
+// request, Start returns its error. Use Wait to wait for the Group to stop and
+// Stop to initiate its shutdown.
+func (g *Group) Start(args ...string) error {
+	g.mu.Lock()
+	if g.startDone != nil && !isClosed(g.startDone) {
+		g.mu.Unlock()
+		return fmt.Errorf("start: %w", ErrRunning)
+	}
+	launch, done := make(chan struct{}), make(chan struct{})
+	g.launch, g.startDone, g.startErr = launch, done, nil
+	g.mu.Unlock()
+
+	go func() {
+		err := g.RunContext(context.Background(), args...)
+		g.mu.Lock()
+		g.startErr = err
+		g.launch = nil
+		g.mu.Unlock()
+		close(done)
+	}()
+
+	select {
+	case <-launch:
+		return nil
+	case <-done:
+		return g.Wait()
+	}
+}
+
+// Wait blocks until the Group launched by Start has stopped and returns the
+// error Run would have returned. It returns an error wrapping ErrNotServing if
+// Start was not called.
+func (g *Group) Wait() error {
+	g.mu.Lock()
+	done := g.startDone
+	g.mu.Unlock()
+	if done == nil {
+		return fmt.Errorf("wait: %w", ErrNotServing)
+	}
+	<-done
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.startErr
+}
+
+// Stop initiates a graceful shutdown of the Group launched by Start, see
+// Shutdown, and waits for it to stop or the provided context to expire. It
+// returns the error Run would have returned.
+func (g *Group) Stop(ctx context.Context) error {
+	g.mu.Lock()
+	done := g.startDone
+	g.mu.Unlock()
+	if done == nil {
+		return fmt.Errorf("stop: %w", ErrNotServing)
+	}
+	if err := g.Shutdown(ctx); err != nil && !errors.Is(err, ErrNotServing) {
+		return fmt.Errorf("stop: %w", errors.Unwrap(err))
+	}
+	select {
+	case <-done:
+		return g.Wait()
+	case <-ctx.Done():
+		return fmt.Errorf("stop: %w", ctx.Err())
+	}
+}
@@ -0,0 +1,105 @@
+// Copyright (c) Bas van Beek 2024.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package run_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/basvanbeek/telemetry"
+
+	"github.com/basvanbeek/run"
+	"github.com/basvanbeek/run/pkg/test"
+)
+
+func TestStartStop(t *testing.T) {
+	var (
+		g       = run.NewGroup("start", run.WithLogger(telemetry.NoopLogger()))
+		started = make(chan struct{})
+		stopped = make(chan struct{})
+	)
+	g.Register(run.NewActor("svc",
+		func() error {
+			close(started)
+			<-stopped
+			return nil
+		},
+		func(error) { close(stopped) },
+	))
+
+	if err := g.Wait(); !errors.Is(err, run.ErrNotServing) {
+		t.Errorf("Expected %v before Start, got %v", run.ErrNotServing, err)
+	}
+	if err := g.Start("./myService"); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	select {
+	case <-started:
+	case <-time.After(time.Second):
+		t.Fatal("timeout waiting for service to start")
+	}
+	if err := g.Start("./myService"); !errors.Is(err, run.ErrRunning) {
+		t.Errorf("Expected %v, got %v", run.ErrRunning, err)
+	}
+	if err := g.Reset(); !errors.Is(err, run.ErrRunning) {
+		t.Errorf("Expected %v, got %v", run.ErrRunning, err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err := g.Stop(ctx); err != nil {
+		t.Fatalf("Expected graceful stop, got %v", err)
+	}
+	if err := g.Wait(); err != nil {
+		t.Errorf("Expected Wait to return the Run result, got %v", err)
+	}
+}
+
+func TestStartWait(t *testing.T) {
+	var (
+		g   = run.NewGroup("start", run.WithLogger(telemetry.NoopLogger()))
+		irq = test.NewIRQService(func() {})
+	)
+	g.Register(irq)
+
+	if err := g.Start("./myService"); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	res := make(chan error)
+	go func() { res <- g.Wait() }()
+	_ = irq.Close()
+	select {
+	case err := <-res:
+		if err != nil {
+			t.Errorf("Expected nil, got %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("timeout waiting for Wait to return")
+	}
+}
+
+func TestStartConfigError(t *testing.T) {
+	g := run.NewGroup("start", run.WithLogger(telemetry.NoopLogger()))
+	g.Register(configUnit{fs: run.NewFlagSet("start")})
+
+	if err := g.Start("./myService", "--unknown"); !errors.Is(err, run.ErrUsage) {
+		t.Errorf("Expected %v, got %v", run.ErrUsage, err)
+	}
+	if err := g.Wait(); !errors.Is(err, run.ErrUsage) {
+		t.Errorf("Expected Wait to return %v, got %v", run.ErrUsage, err)
+	}
+}
@@ -0,0 +1,74 @@
+// Copyright (c) Bas van Beek 2024.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package run
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+)
+
+// DefaultStartupSignals are the signals aborting the startup of a Group
+// during its Config and PreRun phases unless overridden by
+// WithStartupSignals.
+var DefaultStartupSignals = []os.Signal{os.Interrupt, syscall.SIGTERM}
+
+// startupContext returns a context derived from parent which is cancelled
+// when one of the startup signals is received, as no signal handling Service
+// is running yet during the Config and PreRun phases. After the first signal
+// the signals are no longer caught, so a repeated signal terminates the
+// process if startup does not abort in time. The returned function stops
+// listening for the signals and is safe to call multiple times.
+func (g *Group) startupContext(parent context.Context) (context.Context, func()) {
+	sigs := g.startupSignals
+	if sigs == nil {
+		sigs = DefaultStartupSignals
+	}
+	ctx, cancel := context.WithCancelCause(parent)
+	if len(sigs) == 0 {
+		return ctx, func() { cancel(nil) }
+	}
+	var (
+		ch   = make(chan os.Signal, 1)
+		done = make(chan struct{})
+	)
+	signal.Notify(ch, sigs...)
+	go func() {
+		select {
+		case sig := <-ch:
+			// restore the default behavior for a repeated signal
+			signal.Stop(ch)
+			cancel(fmt.Errorf("received signal %s", sig))
+		case <-done:
+		}
+	}()
+	return ctx, sync.OnceFunc(func() {
+		signal.Stop(ch)
+		close(done)
+		cancel(nil)
+	})
+}
+
+// startupInterrupted returns an error wrapping ErrRequestedShutdown if the
+// provided startup context is done.
+func startupInterrupted(ctx context.Context) error {
+	if ctx.Err() != nil {
+		return requestedShutdown(ctx)
+	}
+	return nil
+}
@@ -0,0 +1,95 @@
+// Copyright (c) Bas van Beek 2024.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build unix
+
+package run_test
+
+import (
+	"context"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/basvanbeek/telemetry"
+
+	"github.com/basvanbeek/run"
+)
+
+func TestStartupSignal(t *testing.T) {
+	var (
+		g      = run.NewGroup("startup", run.WithStartupSignals(syscall.SIGUSR2))
+		pr     = &ctxPreRunner{started: make(chan struct{})}
+		served bool
+		irq    = make(chan error)
+	)
+	g.Logger = telemetry.NoopLogger()
+	g.Register(pr, run.NewService("never", nil,
+		func(chan struct{}) error {
+			served = true
+			return nil
+		},
+		func(chan struct{}) {},
+	))
+
+	go func() { irq <- g.RunContext(context.Background(), "./myService") }()
+	<-pr.started
+	if err := syscall.Kill(syscall.Getpid(), syscall.SIGUSR2); err != nil {
+		t.Fatal(err)
+	}
+
+	select {
+	case err := <-irq:
+		if err != nil {
+			t.Errorf("Expected requested shutdown, got %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("timeout")
+	}
+	if served {
+		t.Error("Expected startup to be aborted before serving")
+	}
+}
+
+func TestStartupSignalPlainPreRunner(t *testing.T) {
+	var (
+		g       = run.NewGroup("startup", run.WithStartupSignals(syscall.SIGUSR2))
+		started = make(chan struct{})
+		release = make(chan struct{})
+		irq     = make(chan error)
+	)
+	defer close(release)
+	g.Logger = telemetry.NoopLogger()
+	g.Register(run.NewPreRunner("blocking", func() error {
+		close(started)
+		<-release
+		return nil
+	}))
+
+	go func() { irq <- g.RunContext(context.Background(), "./myService") }()
+	<-started
+	if err := syscall.Kill(syscall.Getpid(), syscall.SIGUSR2); err != nil {
+		t.Fatal(err)
+	}
+
+	// the blocking PreRun can't be interrupted so it is abandoned
+	select {
+	case err := <-irq:
+		if err != nil {
+			t.Errorf("Expected requested shutdown, got %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("timeout")
+	}
+}
@@ -0,0 +1,70 @@
+// Copyright (c) Bas van Beek 2024.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package run
+
+import (
+	"expvar"
+	"time"
+
+	"github.com/basvanbeek/run/pkg/version"
+)
+
+// Status holds a snapshot of the lifecycle state of a Group.
+type Status struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+	// Instance holds the id of this instance of the Group, see WithInstanceID.
+	Instance string `json:"instance"`
+	// RunID holds the id of the current run of the Group, see Group.RunID.
+	RunID string `json:"runID"`
+	// Started holds the start of the Config phase of the Group.
+	Started time.Time `json:"started"`
+	// UptimeSeconds holds the time passed since Started.
+	UptimeSeconds float64 `json:"uptimeSeconds"`
+	// Units holds the state of all Units that reached at least one of the
+	// tracked lifecycle phases, see UnitTimings.
+	Units []UnitState `json:"units"`
+	// LastShutdown describes the reason of the last shutdown of the Serve
+	// phase, if any.
+	LastShutdown string `json:"lastShutdown,omitempty"`
+}
+
+// WithExpvar publishes the Status of the Group as expvar variable with the
+// provided name, evaluated on each read. This allows generic fleet tooling
+// to scrape the process state through the /debug/vars endpoint. As with
+// expvar.Publish, the name must be unique within the process.
+func WithExpvar(name string) Option {
+	return func(g *Group) {
+		expvar.Publish(name, expvar.Func(func() any { return g.Status() }))
+	}
+}
+
+// Status returns a snapshot of the lifecycle state of the Group.
+func (g *Group) Status() Status {
+	g.mu.Lock()
+	s := Status{
+		Name: g.Name, Version: version.Parse(), Instance: g.InstanceID(),
+		RunID: g.runID, Started: g.startTime,
+	}
+	if g.lastStop != nil {
+		s.LastShutdown = g.lastStop.String()
+	}
+	g.mu.Unlock()
+	if !s.Started.IsZero() {
+		s.UptimeSeconds = g.since(s.Started).Seconds()
+	}
+	s.Units = g.unitStates()
+	return s
+}
@@ -0,0 +1,60 @@
+// Copyright (c) Bas van Beek 2024.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package run_test
+
+import (
+	"encoding/json"
+	"expvar"
+	"strings"
+	"testing"
+
+	"github.com/basvanbeek/telemetry"
+
+	"github.com/basvanbeek/run"
+	"github.com/basvanbeek/run/pkg/test"
+)
+
+func TestStatusExpvar(t *testing.T) {
+	g := run.NewGroup("status", run.WithExpvar("rungroup-status-test"))
+	g.Logger = telemetry.NoopLogger()
+	g.Register(&test.Svc{
+		SvcName: "stopper",
+		Execute: func() error { return run.ErrRequestedShutdown },
+	})
+	if status := g.Status(); !status.Started.IsZero() || status.LastShutdown != "" {
+		t.Errorf("Expected empty status before Run, got %+v", status)
+	}
+	if err := g.Run("./myService"); err != nil {
+		t.Fatalf("Expected nil error, got %v", err)
+	}
+
+	v := expvar.Get("rungroup-status-test")
+	if v == nil {
+		t.Fatal("Expected published expvar")
+	}
+	var status run.Status
+	if err := json.Unmarshal([]byte(v.String()), &status); err != nil {
+		t.Fatalf("Expected JSON status, got %q: %v", v.String(), err)
+	}
+	if status.Name != "status" || status.Started.IsZero() || status.UptimeSeconds <= 0 {
+		t.Errorf("Expected started status group, got %+v", status)
+	}
+	if !strings.HasPrefix(status.LastShutdown, "unit stopper:") {
+		t.Errorf("Expected shutdown reason of stopper, got %q", status.LastShutdown)
+	}
+	if len(status.Units) != 1 || status.Units[0].State != run.StateStopped {
+		t.Errorf("Expected stopped unit, got %+v", status.Units)
+	}
+}
@@ -0,0 +1,65 @@
+// Copyright (c) Bas van Beek 2024.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package run
+
+import (
+	stdlog "log"
+
+	"github.com/basvanbeek/run/pkg/log"
+)
+
+// WithStdLogBridge redirects the output of the standard library log package
+// to the Group's Logger while Run is active, so no output of dependencies
+// escapes structured logging. HTTP servers registered with HTTPServer,
+// ListenerService and MultiListenerService without an explicit ErrorLog log
+// through the Group's Logger attributed to their Unit.
+// The bridge is not installed if the Group uses the bare bones Logger of
+// pkg/log, as that Logger writes through the standard library log package.
+func WithStdLogBridge() Option {
+	return func(g *Group) {
+		g.stdLogBridge = true
+	}
+}
+
+// stdLogAware is implemented by Units provided by this package which log
+// through a standard library log.Logger.
+type stdLogAware interface {
+	useStdLogger(l *stdlog.Logger)
+}
+
+// bridgingStdLog returns true if the standard library log output is bridged to
+// the Group's Logger.
+func (g *Group) bridgingStdLog() bool {
+	_, bare := g.Logger.(*log.Logger)
+	return g.stdLogBridge && !bare
+}
+
+// bridgeStdLog redirects the output of the standard library log package to
+// the Group's Logger if requested and returns the function restoring the
+// original output.
+func (g *Group) bridgeStdLog() (restore func()) {
+	if !g.bridgingStdLog() {
+		return func() {}
+	}
+	w, flags, prefix := stdlog.Writer(), stdlog.Flags(), stdlog.Prefix()
+	stdlog.SetOutput(log.Writer{Logger: g.Logger.With("unit", "stdlib")})
+	stdlog.SetFlags(0)
+	stdlog.SetPrefix("")
+	return func() {
+		stdlog.SetOutput(w)
+		stdlog.SetFlags(flags)
+		stdlog.SetPrefix(prefix)
+	}
+}
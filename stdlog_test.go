@@ -0,0 +1,63 @@
+// Copyright (c) Bas van Beek 2024.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package run_test
+
+import (
+	"bytes"
+	stdlog "log"
+	"net/http"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/basvanbeek/run"
+	"github.com/basvanbeek/run/pkg/log"
+)
+
+func TestStdLogBridge(t *testing.T) {
+	var (
+		buf bytes.Buffer
+		srv = &http.Server{Addr: "127.0.0.1:0"} //nolint:gosec // test server
+		g   = run.NewGroup("bridge",
+			run.WithLogger(log.NewJSON(&buf)),
+			run.WithStdLogBridge(),
+		)
+	)
+	g.Register(
+		run.HTTPServer("web", srv),
+		run.NewActor("logger", func() error {
+			stdlog.Print("hello from stdlib")
+			return run.ErrRequestedShutdown
+		}, func(error) {}),
+	)
+
+	if err := g.Run("./myService"); err != nil {
+		t.Fatalf("Expected clean exit, got %v", err)
+	}
+	if out := buf.String(); !strings.Contains(out, `"msg":"hello from stdlib","unit":"stdlib"`) {
+		t.Errorf("Expected bridged stdlib log line, got %s", out)
+	}
+	if stdlog.Writer() != os.Stderr {
+		t.Error("Expected stdlib log output to be restored")
+	}
+
+	if srv.ErrorLog == nil {
+		t.Fatal("Expected ErrorLog of HTTP server to be set")
+	}
+	srv.ErrorLog.Print("http: handshake error")
+	if out := buf.String(); !strings.Contains(out, `"msg":"http: handshake error","unit":"web"`) {
+		t.Errorf("Expected HTTP server log line attributed to its unit, got %s", out)
+	}
+}
@@ -0,0 +1,166 @@
+// Copyright (c) Bas van Beek 2024.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package run
+
+import (
+	"context"
+	"fmt"
+	"sync"
+)
+
+// Task is an extension interface that Units can implement to run finite work
+// once the PreRun phase has completed, such as migrations, batch jobs or
+// workers processing a bounded amount of input. By default Tasks run one
+// after the other in order of registration before the Service and
+// ServiceContext Units are started. See WithTaskMode for alternatives.
+// Tasks registered after the Group started serving are not run.
+type Task interface {
+	// Unit is embedded for Group registration and identification
+	Unit
+	// RunTask runs the Task to completion or until the provided context is
+	// canceled.
+	RunTask(ctx context.Context) error
+}
+
+// ErrTask is matched by the error returned by Run if a Task Unit failed.
+const ErrTask Error = "task failed"
+
+// TaskMode configures the execution of Task Units. The zero value runs Tasks
+// sequentially before starting the Services, after which the Group continues
+// as usual.
+type TaskMode uint
+
+// Task modes to combine with WithTaskMode.
+const (
+	// TasksParallel runs the Tasks concurrently. The first failing Task
+	// cancels the context of the others.
+	TasksParallel TaskMode = 1 << iota
+	// TasksAlongsideServices runs the Tasks together with the Service and
+	// ServiceContext Units instead of completing them first. A failing Task
+	// shuts down the Group like a failing Service.
+	TasksAlongsideServices
+	// TasksShutdown shuts down the Group cleanly once all Tasks completed.
+	// When running the Tasks before the Services, the Services are not
+	// started at all.
+	TasksShutdown
+)
+
+// WithTaskMode sets how the Group runs its Task Units.
+func WithTaskMode(mode TaskMode) Option {
+	return func(g *Group) {
+		g.taskMode = mode
+	}
+}
+
+// errTasksDone is the shutdown reason of a Group configured with
+// TasksShutdown once all Tasks running alongside the Services completed.
+var errTasksDone = fmt.Errorf("%w: all tasks completed", ErrRequestedShutdown)
+
+// tasks returns the registered Task Units.
+func (g *Group) tasks() []Task {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	var tasks []Task
+	for _, t := range g.j {
+		// a Task might have been de-registered during Run
+		if t != nil {
+			tasks = append(tasks, t)
+		}
+	}
+	return tasks
+}
+
+// runTasks runs the provided Tasks sequentially or, if TasksParallel is set,
+// concurrently. It returns the error of the first failing Task.
+func (g *Group) runTasks(ctx context.Context, tasks []Task) error {
+	if g.taskMode&TasksParallel == 0 {
+		for idx, t := range tasks {
+			if err := g.runTask(ctx, idx+1, len(tasks), t); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	ctx, cancel := context.WithCancelCause(ctx)
+	defer cancel(nil)
+	var (
+		wg  sync.WaitGroup
+		mu  sync.Mutex
+		err error
+	)
+	for idx, t := range tasks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if tErr := g.runTask(ctx, idx+1, len(tasks), t); tErr != nil {
+				mu.Lock()
+				if err == nil {
+					err = tErr
+				}
+				mu.Unlock()
+				cancel(tErr)
+			}
+		}()
+	}
+	wg.Wait()
+	return err
+}
+
+// runTask runs the provided Task as part of the Group's task phase.
+func (g *Group) runTask(ctx context.Context, itemNr, total int, t Task) (err error) {
+	l := g.phaseLogger("task", g.nameOf(t), g.itemNr(itemNr, total))
+	defer func() {
+		l.exit(err)
+	}()
+	g.withLabels(ctx, t, "task", func(ctx context.Context) {
+		err = t.RunTask(ctx)
+	})
+	if err != nil {
+		return categorize(fmt.Errorf("task %s: %w", g.nameOf(t), err), ErrTask)
+	}
+	return nil
+}
+
+// taskRunner runs the Tasks as a ServiceContext alongside the Service and
+// ServiceContext Units.
+type taskRunner struct {
+	g     *Group
+	tasks []Task
+}
+
+func (t taskRunner) Name() string {
+	return "tasks"
+}
+
+func (t taskRunner) ServeContext(ctx context.Context) error {
+	if err := t.g.runTasks(ctx, t.tasks); err != nil {
+		if ctx.Err() != nil {
+			// canceled by the Group shutting down
+			return nil
+		}
+		return err
+	}
+	if t.g.taskMode&TasksShutdown != 0 {
+		return errTasksDone
+	}
+	return nil
+}
+
+// MayExitClean implements MayExitClean as completed Tasks should not stop the
+// Group unless requested by TasksShutdown.
+func (t taskRunner) MayExitClean() bool {
+	return true
+}
@@ -0,0 +1,160 @@
+// Copyright (c) Bas van Beek 2024.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package run_test
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/basvanbeek/telemetry"
+
+	"github.com/basvanbeek/run"
+)
+
+type task struct {
+	name string
+	run  func(ctx context.Context) error
+}
+
+func (t task) Name() string                      { return t.name }
+func (t task) RunTask(ctx context.Context) error { return t.run(ctx) }
+
+// recordTask returns a Task appending its name to order when run.
+func recordTask(name string, mu *sync.Mutex, order *[]string) task {
+	return task{name: name, run: func(context.Context) error {
+		mu.Lock()
+		defer mu.Unlock()
+		*order = append(*order, name)
+		return nil
+	}}
+}
+
+func TestTasksBeforeServices(t *testing.T) {
+	var (
+		mu    sync.Mutex
+		order []string
+		g     = run.NewGroup("tasks", run.WithLogger(telemetry.NoopLogger()))
+	)
+	g.Register(
+		recordTask("task-1", &mu, &order),
+		recordTask("task-2", &mu, &order),
+		run.NewActor("service", func() error {
+			mu.Lock()
+			order = append(order, "service")
+			mu.Unlock()
+			return run.ErrRequestedShutdown
+		}, func(error) {}),
+	)
+
+	if err := g.Run("./myService"); err != nil {
+		t.Fatalf("Expected clean exit, got %v", err)
+	}
+	if want, have := []string{"task-1", "task-2", "service"}, order; !slices.Equal(want, have) {
+		t.Errorf("order want: %v, have: %v", want, have)
+	}
+}
+
+func TestTasksShutdown(t *testing.T) {
+	var (
+		mu      sync.Mutex
+		order   []string
+		started bool
+		g       = run.NewGroup("tasks",
+			run.WithLogger(telemetry.NoopLogger()),
+			run.WithTaskMode(run.TasksParallel|run.TasksShutdown),
+		)
+	)
+	g.Register(
+		recordTask("task-1", &mu, &order),
+		recordTask("task-2", &mu, &order),
+		run.NewActor("service", func() error {
+			started = true
+			return run.ErrRequestedShutdown
+		}, func(error) {}),
+	)
+
+	if err := g.Run("./myService"); err != nil {
+		t.Fatalf("Expected clean exit, got %v", err)
+	}
+	if len(order) != 2 || started {
+		t.Errorf("Expected both tasks to run and the service not to start, got %v (started: %t)", order, started)
+	}
+}
+
+func TestTasksParallelError(t *testing.T) {
+	var (
+		errTask = errors.New("task failed")
+		g       = run.NewGroup("tasks",
+			run.WithLogger(telemetry.NoopLogger()),
+			run.WithTaskMode(run.TasksParallel),
+		)
+	)
+	g.Register(
+		task{name: "failing", run: func(context.Context) error { return errTask }},
+		task{name: "blocking", run: func(ctx context.Context) error {
+			<-ctx.Done()
+			return ctx.Err()
+		}},
+	)
+
+	err := g.Run("./myService")
+	if !errors.Is(err, run.ErrTask) || !errors.Is(err, errTask) {
+		t.Errorf("Expected %v, got %v", errTask, err)
+	}
+}
+
+func TestTasksAlongsideServices(t *testing.T) {
+	var (
+		block = make(chan struct{})
+		done  = make(chan struct{})
+		irq   = make(chan error)
+		g     = run.NewGroup("tasks",
+			run.WithLogger(telemetry.NoopLogger()),
+			run.WithTaskMode(run.TasksAlongsideServices|run.TasksShutdown),
+		)
+	)
+	g.Register(
+		task{name: "job", run: func(context.Context) error {
+			<-block
+			return nil
+		}},
+		run.NewActor("service", func() error {
+			<-done
+			return nil
+		}, func(error) { close(done) }),
+	)
+
+	go func() { irq <- g.Run("./myService") }()
+
+	select {
+	case err := <-irq:
+		t.Fatalf("Expected Group to run until the task completes, got %v", err)
+	case <-time.After(50 * time.Millisecond):
+	}
+	close(block)
+
+	select {
+	case err := <-irq:
+		if err != nil {
+			t.Errorf("Expected clean exit, got %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("timeout")
+	}
+}
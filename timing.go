@@ -0,0 +1,134 @@
+// Copyright (c) Bas van Beek 2024.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package run
+
+import (
+	"reflect"
+	"time"
+)
+
+// UnitTiming holds the timestamps of the lifecycle phase transitions of a Unit
+// as observed by Group. Timestamps of phase transitions that did not (yet)
+// occur hold the zero value. If a Unit is restarted, its Serve related
+// timestamps reflect the last run.
+type UnitTiming struct {
+	Name string
+	// Configured is set once the Config phase of the Unit has completed.
+	Configured time.Time
+	// PreRunStart and PreRunEnd are set when the PreRun method of the Unit is
+	// called and has returned.
+	PreRunStart time.Time
+	PreRunEnd   time.Time
+	// ServeStart is set when the Serve or ServeContext method of the Unit is
+	// called.
+	ServeStart time.Time
+	// StopStart is set when the Unit is requested to stop and StopEnd when its
+	// Serve or ServeContext method has returned.
+	StopStart time.Time
+	StopEnd   time.Time
+
+	// now holds the time of the Group's Clock the UnitTiming was taken at.
+	now time.Time
+}
+
+// PreRunDuration returns the time spent in the PreRun method of the Unit.
+func (t UnitTiming) PreRunDuration() time.Duration {
+	return between(t.PreRunStart, t.PreRunEnd)
+}
+
+// ServeDuration returns the lifetime of the Unit's Serve or ServeContext
+// method. If the Unit is still serving, the lifetime up until the moment
+// UnitTimings was called is returned, as measured by the Group's Clock.
+func (t UnitTiming) ServeDuration() time.Duration {
+	if !t.ServeStart.IsZero() && t.StopEnd.IsZero() {
+		return between(t.ServeStart, t.now)
+	}
+	return between(t.ServeStart, t.StopEnd)
+}
+
+// StopDuration returns the time the Unit took to return after being requested
+// to stop. If the Unit is still stopping, the duration up until the moment
+// UnitTimings was called is returned, as measured by the Group's Clock.
+func (t UnitTiming) StopDuration() time.Duration {
+	if !t.StopStart.IsZero() && t.StopEnd.IsZero() {
+		return between(t.StopStart, t.now)
+	}
+	return between(t.StopStart, t.StopEnd)
+}
+
+// between returns the duration between start and end if both are set.
+func between(start, end time.Time) time.Duration {
+	if start.IsZero() || end.IsZero() || end.Before(start) {
+		return 0
+	}
+	return end.Sub(start)
+}
+
+// unitTiming links a UnitTiming to the Unit it is recorded for.
+type unitTiming struct {
+	unit Unit
+	UnitTiming
+}
+
+// UnitTimings returns the phase transition timestamps of all Units that have
+// reached at least one of the tracked lifecycle phases, in order of first
+// occurrence. This allows slow starting and slow stopping Units to be
+// identified, e.g. by exposing the durations as metrics.
+func (g *Group) UnitTimings() []UnitTiming {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	var (
+		now     = g.Clock().Now()
+		timings = make([]UnitTiming, 0, len(g.timings))
+	)
+	for _, t := range g.timings {
+		t.UnitTiming.now = now
+		timings = append(timings, t.UnitTiming)
+	}
+	return timings
+}
+
+// recordTiming records a phase transition of the provided Unit.
+func (g *Group) recordTiming(u Unit, record func(t *UnitTiming)) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	record(g.timing(u))
+}
+
+// timing returns the UnitTiming of the provided Unit, creating it if needed.
+// It must be called while holding the Group lock.
+func (g *Group) timing(u Unit) *UnitTiming {
+	for _, t := range g.timings {
+		if sameUnit(t.unit, u) {
+			return &t.UnitTiming
+		}
+	}
+	t := &unitTiming{unit: u, UnitTiming: UnitTiming{Name: g.nameOf(u)}}
+	g.timings = append(g.timings, t)
+	return &t.UnitTiming
+}
+
+// sameUnit returns true if a and b refer to the same Unit. Units of a type
+// which is not comparable, e.g. function types, are matched by type and name.
+func sameUnit(a, b Unit) bool {
+	ta, tb := reflect.TypeOf(a), reflect.TypeOf(b)
+	if ta != tb {
+		return false
+	}
+	if !ta.Comparable() {
+		return a.Name() == b.Name()
+	}
+	return a == b
+}
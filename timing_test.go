@@ -0,0 +1,145 @@
+// Copyright (c) Bas van Beek 2024.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package run_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/basvanbeek/telemetry"
+
+	"github.com/basvanbeek/run"
+	"github.com/basvanbeek/run/pkg/test"
+)
+
+func TestUnitTimings(t *testing.T) {
+	var (
+		g       = run.Group{Name: "timings", Logger: telemetry.NoopLogger()}
+		started = make(chan struct{})
+		irq     = make(chan error)
+	)
+
+	g.Register(
+		configUnit{fs: run.NewFlagSet("config")},
+		run.NewPreRunner("slow-pre-run", func() error {
+			time.Sleep(10 * time.Millisecond)
+			return nil
+		}),
+		run.NewService("slow-stop", make(chan struct{}),
+			func(quit chan struct{}) error {
+				close(started)
+				<-quit
+				time.Sleep(10 * time.Millisecond)
+				return nil
+			},
+			func(quit chan struct{}) { close(quit) },
+		),
+		&test.Svc{
+			SvcName: "irqsvc",
+			Execute: func() error {
+				<-started
+				return run.ErrRequestedShutdown
+			},
+		},
+	)
+
+	go func() { irq <- g.Run("./myService") }()
+
+	select {
+	case err := <-irq:
+		if err != nil {
+			t.Fatalf("Expected requested shutdown, got %v", err)
+		}
+	case <-time.After(100 * time.Millisecond):
+		t.Fatal("timeout")
+	}
+
+	timings := make(map[string]run.UnitTiming)
+	for _, ut := range g.UnitTimings() {
+		timings[ut.Name] = ut
+	}
+	if timings["config-unit"].Configured.IsZero() {
+		t.Error("Expected config-unit to be configured")
+	}
+	if d := timings["slow-pre-run"].PreRunDuration(); d < 10*time.Millisecond {
+		t.Errorf("Expected pre-run duration of at least 10ms, got %s", d)
+	}
+	slow := timings["slow-stop"]
+	if slow.ServeStart.IsZero() || slow.StopStart.IsZero() || slow.StopEnd.IsZero() {
+		t.Errorf("Expected serve and stop timestamps, got %+v", slow)
+	}
+	if d := slow.StopDuration(); d < 10*time.Millisecond {
+		t.Errorf("Expected stop duration of at least 10ms, got %s", d)
+	}
+	if slow.ServeDuration() < slow.StopDuration() {
+		t.Errorf("Expected serve duration to include stop duration, got %+v", slow)
+	}
+	if irqsvc := timings["irqsvc"]; !irqsvc.StopStart.IsZero() || irqsvc.StopEnd.IsZero() {
+		t.Errorf("Expected irqsvc to have exited by itself, got %+v", irqsvc)
+	}
+}
+
+func TestUnitTimingsClock(t *testing.T) {
+	var (
+		clock = test.NewClock(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+		g     = run.NewGroup("timings", run.WithClock(clock),
+			run.WithLogger(telemetry.NoopLogger()))
+		started = make(chan struct{})
+		stop    = make(chan struct{})
+		irq     = make(chan error)
+	)
+
+	g.Register(
+		run.NewService("serving", make(chan struct{}),
+			func(quit chan struct{}) error {
+				close(started)
+				<-quit
+				return nil
+			},
+			func(quit chan struct{}) { close(quit) },
+		),
+		&test.Svc{
+			SvcName: "irqsvc",
+			Execute: func() error {
+				<-stop
+				return run.ErrRequestedShutdown
+			},
+		},
+	)
+
+	go func() { irq <- g.Run("./myService") }()
+
+	<-started
+	clock.Advance(time.Minute)
+	for _, ut := range g.UnitTimings() {
+		if ut.Name != "serving" {
+			continue
+		}
+		// the duration of a running Unit follows the Group's Clock
+		if d := ut.ServeDuration(); d != time.Minute {
+			t.Errorf("Expected serve duration of %s, got %s", time.Minute, d)
+		}
+	}
+	close(stop)
+
+	select {
+	case err := <-irq:
+		if err != nil {
+			t.Fatalf("Expected requested shutdown, got %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("timeout")
+	}
+}
@@ -0,0 +1,29 @@
+// Copyright (c) Bas van Beek 2024.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package run
+
+import "os"
+
+// maxProcessTitle holds the maximum length of a Linux task comm value.
+const maxProcessTitle = 15
+
+// setProcessTitle sets the comm value of the main thread, as shown by ps and
+// top.
+func setProcessTitle(title string) error {
+	if len(title) > maxProcessTitle {
+		title = title[:maxProcessTitle]
+	}
+	return os.WriteFile("/proc/self/comm", []byte(title), 0)
+}
@@ -0,0 +1,176 @@
+// Copyright (c) Bas van Beek 2024.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package run
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// DeregisteredSlot marks the phase slot of a Unit which was de-registered
+// after the Config phase, see DeregisterPhases.
+const DeregisteredSlot = "--deregistered--"
+
+// Group kinds as reported by Topology.
+const (
+	KindCLI     = "cli"
+	KindService = "svc"
+)
+
+// UnitInfo describes a registered Unit and the Group phases it is registered
+// for.
+type UnitInfo struct {
+	Name   string   `json:"name"`
+	Type   string   `json:"type"`
+	Phases []string `json:"phases"`
+	// Stopped is set if the Unit has been stopped individually while the Group
+	// is serving.
+	Stopped bool `json:"stopped,omitempty"`
+}
+
+// PhaseInfo lists the Units registered for a Group phase in order of
+// execution. Slots of Units de-registered after the Config phase hold
+// DeregisteredSlot.
+type PhaseInfo struct {
+	Phase string   `json:"phase"`
+	Units []string `json:"units"`
+}
+
+// Topology describes the registered Units of a Group and their phase
+// membership, e.g. for tooling inspecting a built binary through the
+// --show-rungroup-units flag.
+type Topology struct {
+	Group string `json:"group"`
+	// Kind is KindService if Service or ServiceContext Units are registered
+	// and KindCLI otherwise.
+	Kind   string      `json:"kind"`
+	Units  []UnitInfo  `json:"units"`
+	Phases []PhaseInfo `json:"phases"`
+}
+
+// Topology returns the registered Units of the Group and their phase
+// membership.
+func (g *Group) Topology() Topology {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	var (
+		t     = Topology{Group: g.Name, Kind: KindCLI}
+		units []Unit
+	)
+	for _, p := range []struct {
+		name  string
+		slots []Unit
+	}{
+		{"initialize", unitSlots(g.i)},
+		{"name", unitSlots(g.n)},
+		{"config", unitSlots(g.c)},
+		{"args", unitSlots(g.a)},
+		{"flag-source", unitSlots(g.v)},
+		{"post-parse", unitSlots(g.t)},
+		{"config-command", unitSlots(g.k)},
+		{"preflight", unitSlots(g.q)},
+		{"pre-run", unitSlots(g.p)},
+		{"task", unitSlots(g.j)},
+		{"reload", unitSlots(g.r)},
+		{"post-run", unitSlots(g.z)},
+		{"serve", unitSlots(g.s)},
+		{"serve-context", unitSlots(g.x)},
+	} {
+		if len(p.slots) == 0 {
+			continue
+		}
+		phase := PhaseInfo{Phase: p.name}
+		for _, u := range p.slots {
+			if u == nil {
+				phase.Units = append(phase.Units, DeregisteredSlot)
+				continue
+			}
+			phase.Units = append(phase.Units, g.nameOf(u))
+			idx := len(units)
+			for i := range units {
+				if sameUnit(units[i], u) {
+					idx = i
+					break
+				}
+			}
+			if idx == len(units) {
+				units = append(units, u)
+				t.Units = append(t.Units, UnitInfo{
+					Name: g.nameOf(u), Type: fmt.Sprintf("%T", u),
+				})
+			}
+			t.Units[idx].Phases = append(t.Units[idx].Phases, p.name)
+			if p.name == "serve" || p.name == "serve-context" {
+				t.Kind = KindService
+				t.Units[idx].Stopped = g.stopped(u)
+			}
+		}
+		t.Phases = append(t.Phases, phase)
+	}
+	return t
+}
+
+// ListUnits returns a list of all Group phases and the Units registered to each
+// of them.
+func (g *Group) ListUnits() string {
+	t := g.Topology()
+	stopped := make(map[string]bool)
+	for _, u := range t.Units {
+		stopped[u.Name] = u.Stopped
+	}
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "Group: %s [%s]", t.Group, t.Kind)
+	for _, p := range t.Phases {
+		fmt.Fprintf(&sb, "\n- %s: ", p.Phase)
+		for _, name := range p.Units {
+			sb.WriteString(name)
+			if stopped[name] && (p.Phase == "serve" || p.Phase == "serve-context") {
+				sb.WriteString("(stopped)")
+			}
+			sb.WriteString(" ")
+		}
+	}
+	return sb.String()
+}
+
+// listUnits writes the Group topology in the provided output format.
+func (g *Group) listUnits(format string) error {
+	switch format {
+	case "", "text":
+		fmt.Fprintln(g.stdout(), g.ListUnits())
+		return nil
+	case "json":
+		b, err := json.MarshalIndent(g.Topology(), "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Fprintln(g.stdout(), string(b))
+		return nil
+	default:
+		return fmt.Errorf("unsupported output format %q: use text or json", format)
+	}
+}
+
+// unitSlots returns the slots of the provided phase slice as Units, holding
+// nil for de-registered slots.
+func unitSlots[T any](phase []T) []Unit {
+	slots := make([]Unit, len(phase))
+	for idx, p := range phase {
+		slots[idx], _ = any(p).(Unit)
+	}
+	return slots
+}
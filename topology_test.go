@@ -0,0 +1,95 @@
+// Copyright (c) Bas van Beek 2024.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package run_test
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/basvanbeek/telemetry"
+
+	"github.com/basvanbeek/run"
+)
+
+func TestTopology(t *testing.T) {
+	var (
+		g   = run.Group{Name: "topology", Logger: telemetry.NoopLogger()}
+		s   service
+		pre = &ctxPreRunner{}
+	)
+	g.Register(&s, pre)
+	if err := g.RunConfig("./myService", "-f", "1"); err != nil {
+		t.Fatalf("Expected nil error, got %v", err)
+	}
+	g.Deregister(pre)
+
+	topology := g.Topology()
+	if topology.Group != "topology" || topology.Kind != run.KindService {
+		t.Errorf("Expected topology svc group, got %+v", topology)
+	}
+	if len(topology.Units) != 1 {
+		t.Fatalf("Expected a single Unit, got %+v", topology.Units)
+	}
+	want := run.UnitInfo{
+		Name:   "testsvc",
+		Type:   "*run_test.service",
+		Phases: []string{"initialize", "name", "config", "pre-run", "serve"},
+	}
+	if !reflect.DeepEqual(topology.Units[0], want) {
+		t.Errorf("Expected %+v, got %+v", want, topology.Units[0])
+	}
+	for _, p := range topology.Phases {
+		if p.Phase != "pre-run" {
+			continue
+		}
+		if want := []string{"testsvc", run.DeregisteredSlot}; !reflect.DeepEqual(p.Units, want) {
+			t.Errorf("Expected pre-run slots %v, got %v", want, p.Units)
+		}
+	}
+	if units := g.ListUnits(); !strings.Contains(units, "- pre-run: testsvc "+run.DeregisteredSlot) {
+		t.Errorf("Expected de-registered slot in list, got %s", units)
+	}
+}
+
+func TestShowRunGroupUnitsJSON(t *testing.T) {
+	var (
+		g   = run.Group{Name: "topology", Logger: telemetry.NoopLogger()}
+		err error
+	)
+	g.Register(run.NewPreRunner("pre-run", func() error { return nil }))
+
+	out := captureStdout(t, func() {
+		err = g.RunConfig("./myService", "--show-rungroup-units", "--output", "json")
+	})
+	if !errors.Is(err, run.ErrBailEarlyRequest) {
+		t.Fatalf("Expected %v, got %v", run.ErrBailEarlyRequest, err)
+	}
+	var topology run.Topology
+	if err = json.Unmarshal([]byte(out), &topology); err != nil {
+		t.Fatalf("Expected JSON output, got %q: %v", out, err)
+	}
+	if topology.Kind != run.KindCLI || len(topology.Units) != 1 ||
+		topology.Units[0].Name != "pre-run" {
+		t.Errorf("Expected pre-run cli topology, got %+v", topology)
+	}
+
+	g = run.Group{Name: "topology", Logger: telemetry.NoopLogger()}
+	if err = g.RunConfig("./myService", "--show-rungroup-units", "--output", "xml"); err == nil {
+		t.Error("Expected unsupported output format error")
+	}
+}
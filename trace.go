@@ -0,0 +1,121 @@
+// Copyright (c) Bas van Beek 2024.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package run
+
+import (
+	"fmt"
+	"sort"
+	"time"
+)
+
+// Span holds the time a Unit spent in one of its lifecycle phases.
+type Span struct {
+	Unit     string        `json:"unit"`
+	Phase    string        `json:"phase"`
+	Start    time.Time     `json:"start"`
+	Duration time.Duration `json:"duration"`
+}
+
+// Trace holds the bootstrap performance of the last run of a Group created
+// with WithTrace. Long-lived Serve and ServeContext phases are not traced.
+type Trace struct {
+	// Start holds the start of the Config phase.
+	Start time.Time `json:"start"`
+	// TimeToReady holds the time from Start until all Service and
+	// ServiceContext Units have been started. It is zero if the Group did not
+	// get ready (yet).
+	TimeToReady time.Duration `json:"timeToReady"`
+	// Spans holds the traced phases in order of completion.
+	Spans []Span `json:"spans"`
+}
+
+// PhaseDurations returns the total time spent in each phase across Units.
+func (t Trace) PhaseDurations() map[string]time.Duration {
+	d := make(map[string]time.Duration)
+	for _, s := range t.Spans {
+		d[s.Phase] += s.Duration
+	}
+	return d
+}
+
+// UnitDurations returns the total time spent by each Unit across phases.
+func (t Trace) UnitDurations() map[string]time.Duration {
+	d := make(map[string]time.Duration)
+	for _, s := range t.Spans {
+		d[s.Unit] += s.Duration
+	}
+	return d
+}
+
+// Slowest returns up to n Spans in order of descending duration.
+func (t Trace) Slowest(n int) []Span {
+	spans := append([]Span(nil), t.Spans...)
+	sort.SliceStable(spans, func(i, j int) bool {
+		return spans[i].Duration > spans[j].Duration
+	})
+	return spans[:min(n, len(spans))]
+}
+
+// WithTrace enables tracing the time each Unit spends in each bootstrap phase
+// and the total time to ready. Once ready, the Trace is logged at Info level
+// and it is available through Group.Trace, allowing startup performance
+// regressions to be tracked.
+func WithTrace() Option {
+	return func(g *Group) {
+		g.tracing = true
+	}
+}
+
+// Trace returns the Trace of the current or last run of the Group. It holds no
+// Spans unless the Group was created with WithTrace.
+func (g *Group) Trace() Trace {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	t := Trace{Start: g.startTime, Spans: append([]Span(nil), g.spans...)}
+	if !g.readyAt.IsZero() {
+		t.TimeToReady = between(g.startTime, g.readyAt)
+	}
+	return t
+}
+
+// traceSpan records the phase of the Unit started at start as completed.
+func (g *Group) traceSpan(unit, phase string, start time.Time) {
+	if !g.tracing || phase == "serve" || phase == "serve-context" {
+		return
+	}
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.spans = append(g.spans, Span{
+		Unit: unit, Phase: phase, Start: start, Duration: g.since(start),
+	})
+}
+
+// traceReady records the Group as ready and logs its Trace.
+func (g *Group) traceReady() {
+	if !g.tracing {
+		return
+	}
+	g.mu.Lock()
+	g.readyAt = g.Clock().Now()
+	g.mu.Unlock()
+
+	t := g.Trace()
+	slowest := make([]string, 0, 3)
+	for _, s := range t.Slowest(3) {
+		slowest = append(slowest, fmt.Sprintf("%s/%s (%s)", s.Unit, s.Phase, s.Duration))
+	}
+	g.Logger.Info("startup-trace", "timeToReady", t.TimeToReady.String(),
+		"spans", len(t.Spans), "slowest", slowest)
+}
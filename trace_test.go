@@ -0,0 +1,86 @@
+// Copyright (c) Bas van Beek 2024.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package run_test
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/basvanbeek/telemetry"
+
+	"github.com/basvanbeek/run"
+)
+
+func TestTrace(t *testing.T) {
+	g := run.NewGroup("trace", run.WithTrace())
+	g.Logger = telemetry.NoopLogger()
+	g.Register(
+		run.NewPreRunner("slow", func() error {
+			time.Sleep(5 * time.Millisecond)
+			return nil
+		}),
+		run.NewActor("svc", func() error {
+			return run.ErrRequestedShutdown
+		}, func(error) {}),
+	)
+
+	if err := g.Run("./myService"); err != nil {
+		t.Fatalf("Expected proper close, got %v", err)
+	}
+	tr := g.Trace()
+	if tr.TimeToReady < 5*time.Millisecond {
+		t.Errorf("Expected time to ready to include PreRun, got %s", tr.TimeToReady)
+	}
+	if s := tr.Slowest(1); len(s) != 1 || s[0].Unit != "slow" || s[0].Phase != "pre-run" {
+		t.Errorf("Expected slow pre-run as slowest span, got %v", s)
+	}
+	if d := tr.PhaseDurations()["pre-run"]; d < 5*time.Millisecond {
+		t.Errorf("Expected pre-run phase duration, got %s", d)
+	}
+	for _, s := range tr.Spans {
+		if s.Phase == "serve" {
+			t.Errorf("Expected serve phase not to be traced, got %v", s)
+		}
+	}
+}
+
+func benchmarkBootstrap(b *testing.B, units int, opts ...run.Option) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		g := run.NewGroup("bench", opts...)
+		g.Logger = telemetry.NoopLogger()
+		for idx := 0; idx < units; idx++ {
+			fs := run.NewFlagSet(fmt.Sprintf("unit %d", idx))
+			fs.Int(fmt.Sprintf("unit-%d", idx), idx, "bench flag")
+			g.Register(configUnit{fs: fs}, run.NewPreRunner(fmt.Sprintf("pre-run-%d", idx),
+				func() error { return nil }))
+		}
+		if err := g.Run("./myService"); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkBootstrap(b *testing.B) {
+	for _, units := range []int{1, 10, 60} {
+		b.Run(fmt.Sprintf("units=%d", units), func(b *testing.B) {
+			benchmarkBootstrap(b, units)
+		})
+		b.Run(fmt.Sprintf("units=%d/trace", units), func(b *testing.B) {
+			benchmarkBootstrap(b, units, run.WithTrace())
+		})
+	}
+}
@@ -0,0 +1,85 @@
+// Copyright (c) Bas van Beek 2024.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package run
+
+import "sync"
+
+// NewService takes a name, a state value and a pair of serve and stop functions
+// operating on that state and turns them into a Group compatible Service, ready
+// for registration. The serve function is called in the Serve phase and must
+// block until the stop function is called. This removes the need for a
+// dedicated struct for small Services.
+func NewService[T any](name string, state T, serve func(T) error, stop func(T)) Service {
+	return &typedService[T]{name: name, state: state, serve: serve, stop: stop}
+}
+
+type typedService[T any] struct {
+	name  string
+	state T
+	serve func(T) error
+	stop  func(T)
+}
+
+func (s *typedService[T]) Name() string {
+	return s.name
+}
+
+func (s *typedService[T]) Serve() error {
+	return s.serve(s.state)
+}
+
+func (s *typedService[T]) GracefulStop() {
+	if s.stop != nil {
+		s.stop(s.state)
+	}
+}
+
+// NewConfig takes a name, a state value, a function registering flags bound to
+// that state and an optional validate function and turns them into a Group
+// compatible Config, ready for registration. The flags function is called once
+// when the Config's FlagSet is first requested.
+func NewConfig[T any](name string, state T, flags func(*FlagSet, T), validate func(T) error) Config {
+	return &typedConfig[T]{name: name, state: state, flags: flags, validate: validate}
+}
+
+type typedConfig[T any] struct {
+	name     string
+	state    T
+	flags    func(*FlagSet, T)
+	validate func(T) error
+	once     sync.Once
+	fs       *FlagSet
+}
+
+func (c *typedConfig[T]) Name() string {
+	return c.name
+}
+
+func (c *typedConfig[T]) FlagSet() *FlagSet {
+	c.once.Do(func() {
+		c.fs = NewFlagSet(c.name + " options")
+		if c.flags != nil {
+			c.flags(c.fs, c.state)
+		}
+	})
+	return c.fs
+}
+
+func (c *typedConfig[T]) Validate() error {
+	if c.validate == nil {
+		return nil
+	}
+	return c.validate(c.state)
+}
@@ -0,0 +1,98 @@
+// Copyright (c) Bas van Beek 2024.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package run_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/basvanbeek/telemetry"
+
+	"github.com/basvanbeek/run"
+)
+
+type listenerState struct {
+	addr string
+	quit chan struct{}
+}
+
+func TestTypedUnits(t *testing.T) {
+	var (
+		g     = run.Group{Name: "typed", Logger: telemetry.NoopLogger()}
+		state = &listenerState{quit: make(chan struct{})}
+		irq   = make(chan error)
+		seen  = make(chan string, 1)
+	)
+
+	g.Register(
+		run.NewConfig("listener-config", state,
+			func(fs *run.FlagSet, s *listenerState) {
+				fs.StringVar(&s.addr, "listen-addr", ":8080", "listen address")
+			},
+			func(s *listenerState) error {
+				if s.addr == "" {
+					return errors.New("missing listen address")
+				}
+				return nil
+			},
+		),
+		run.NewService("listener", state,
+			func(s *listenerState) error {
+				seen <- s.addr
+				<-s.quit
+				return nil
+			},
+			func(s *listenerState) { close(s.quit) },
+		),
+		run.NewService("stopper", state,
+			func(*listenerState) error {
+				<-time.After(10 * time.Millisecond)
+				return errIRQ
+			},
+			nil,
+		),
+	)
+
+	go func() { irq <- g.Run("./myService", "--listen-addr", ":9090") }()
+
+	select {
+	case err := <-irq:
+		if !errors.Is(err, errIRQ) {
+			t.Errorf("Expected %v, got %v", errIRQ, err)
+		}
+		if addr := <-seen; addr != ":9090" {
+			t.Errorf("Expected listen address :9090, got %s", addr)
+		}
+	case <-time.After(100 * time.Millisecond):
+		t.Errorf("timeout")
+	}
+
+	g = run.Group{Name: "typed", Logger: telemetry.NoopLogger()}
+	g.Register(run.NewConfig("listener-config", state,
+		func(fs *run.FlagSet, s *listenerState) {
+			fs.StringVar(&s.addr, "listen-addr", ":8080", "listen address")
+		},
+		func(s *listenerState) error {
+			if s.addr == "" {
+				return errors.New("missing listen address")
+			}
+			return nil
+		},
+	))
+	if err := g.RunConfig("./myService", "--listen-addr", ""); err == nil {
+		t.Error("Expected validation error, got nil")
+	}
+}
@@ -0,0 +1,152 @@
+// Copyright (c) Bas van Beek 2024.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package run
+
+import (
+	"errors"
+	"sort"
+	"strings"
+
+	"github.com/spf13/pflag"
+)
+
+// ErrUsage is matched by the error returned by Run if the command line could
+// not be parsed, e.g. due to an unknown flag. Use errors.As with a UsageError
+// for the suggested flags.
+const ErrUsage Error = "usage error"
+
+// ExitCodeUsage is the default exit code of a UsageError, see ExitCode and
+// WithUsageExitCode.
+const ExitCodeUsage = 2
+
+// maxSuggestions holds the maximum amount of flags suggested for an unknown
+// flag.
+const maxSuggestions = 3
+
+// UsageError is returned by Run if the command line could not be parsed.
+type UsageError struct {
+	// Err holds the parse error, including the suggestions if any.
+	Err error
+	// Suggestions holds the registered flags closest to a mistyped flag, most
+	// likely first.
+	Suggestions []string
+	// Code holds the exit code to use, see ExitCode.
+	Code int
+}
+
+// Error implements error.
+func (u *UsageError) Error() string {
+	return u.Err.Error()
+}
+
+// Unwrap returns the parse error.
+func (u *UsageError) Unwrap() error {
+	return u.Err
+}
+
+// Is allows errors.Is to match the ErrUsage category.
+func (u *UsageError) Is(target error) bool {
+	return target == ErrUsage //nolint:errorlint // sentinel match
+}
+
+// ExitCode returns the exit code of the usage error.
+func (u *UsageError) ExitCode() int {
+	return u.Code
+}
+
+// WithUsageExitCode sets the exit code of the UsageError returned by Run if
+// the command line could not be parsed. Defaults to ExitCodeUsage.
+func WithUsageExitCode(code int) Option {
+	return func(g *Group) {
+		g.usageExitCode = code
+	}
+}
+
+// ExitCode returns the process exit code for the error returned by Run: 0 if
+// nil, the code of an error implementing ExitCode() int such as UsageError,
+// and 1 otherwise.
+func ExitCode(err error) int {
+	if err == nil {
+		return 0
+	}
+	var c interface{ ExitCode() int }
+	if errors.As(err, &c) {
+		return c.ExitCode()
+	}
+	return 1
+}
+
+// usageError turns the provided flag parse error into a UsageError, adding
+// suggestions for a mistyped flag based on the edit distance to all
+// registered flags.
+func (g *Group) usageError(err error) error {
+	u := &UsageError{Err: err, Code: g.usageExitCode}
+	if u.Code == 0 {
+		u.Code = ExitCodeUsage
+	}
+	name, ok := strings.CutPrefix(err.Error(), "unknown flag: --")
+	if !ok {
+		return u
+	}
+	type candidate struct {
+		name     string
+		distance int
+	}
+	var (
+		candidates []candidate
+		limit      = max(2, len(name)/3)
+	)
+	g.f.VisitAll(func(f *pflag.Flag) {
+		if f.Hidden {
+			return
+		}
+		d := editDistance(name, f.Name)
+		if d <= limit || strings.HasPrefix(f.Name, name) {
+			candidates = append(candidates, candidate{f.Name, d})
+		}
+	})
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].distance < candidates[j].distance
+	})
+	for _, c := range candidates[:min(len(candidates), maxSuggestions)] {
+		u.Suggestions = append(u.Suggestions, "--"+c.name)
+	}
+	if len(u.Suggestions) > 0 {
+		u.Err = errors.New(err.Error() + "; " +
+			g.msg(MsgFlagSuggestion, strings.Join(u.Suggestions, ", ")))
+	}
+	return u
+}
+
+// editDistance returns the Levenshtein distance between a and b.
+func editDistance(a, b string) int {
+	prev := make([]int, len(b)+1)
+	curr := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		curr[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+	return prev[len(b)]
+}
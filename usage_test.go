@@ -0,0 +1,79 @@
+// Copyright (c) Bas van Beek 2024.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package run_test
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/basvanbeek/telemetry"
+
+	"github.com/basvanbeek/run"
+)
+
+func TestUsageError(t *testing.T) {
+	tests := []struct {
+		name        string
+		opts        []run.Option
+		args        []string
+		suggestions []string
+		code        int
+	}{
+		{"typo", nil, []string{"--config-flg"}, []string{"--config-flag"}, run.ExitCodeUsage},
+		{"prefix", nil, []string{"--config"}, []string{"--config-flag"}, run.ExitCodeUsage},
+		{"no match", nil, []string{"--something-else"}, nil, run.ExitCodeUsage},
+		{"shorthand", nil, []string{"-Z"}, nil, run.ExitCodeUsage},
+		{"exit code", []run.Option{run.WithUsageExitCode(64)}, []string{"--config-flg"},
+			[]string{"--config-flag"}, 64},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := run.NewGroup("usage", append(tt.opts,
+				run.WithLogger(telemetry.NoopLogger()))...)
+			fs := run.NewFlagSet("usage")
+			fs.String("config-flag", "", "a flag")
+			g.Register(configUnit{fs: fs})
+
+			err := g.Run(append([]string{"./myService"}, tt.args...)...)
+			if !errors.Is(err, run.ErrUsage) {
+				t.Fatalf("Expected %v, got %v", run.ErrUsage, err)
+			}
+			var u *run.UsageError
+			if !errors.As(err, &u) {
+				t.Fatalf("Expected UsageError, got %T", err)
+			}
+			if !reflect.DeepEqual(u.Suggestions, tt.suggestions) {
+				t.Errorf("Expected suggestions %v, got %v", tt.suggestions, u.Suggestions)
+			}
+			if hint := strings.Contains(err.Error(), "did you mean --config-flag?"); hint != (tt.suggestions != nil) {
+				t.Errorf("Unexpected suggestion in error: %v", err)
+			}
+			if code := run.ExitCode(err); code != tt.code {
+				t.Errorf("Expected exit code %d, got %d", tt.code, code)
+			}
+		})
+	}
+}
+
+func TestExitCode(t *testing.T) {
+	if code := run.ExitCode(nil); code != 0 {
+		t.Errorf("Expected exit code 0, got %d", code)
+	}
+	if code := run.ExitCode(errors.New("failure")); code != 1 {
+		t.Errorf("Expected exit code 1, got %d", code)
+	}
+}
@@ -0,0 +1,144 @@
+// Copyright (c) Bas van Beek 2024.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package run
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/basvanbeek/multierror"
+
+	"github.com/basvanbeek/run/pkg/flag"
+)
+
+// ErrMissingRequiredFlags is returned by RunConfig if flags marked as required
+// have not been provided.
+const ErrMissingRequiredFlags Error = "missing required flags"
+
+// ErrValidation is matched by the error returned by RunConfig or Run if the
+// configuration was rejected by a Validate method, a CrossValidate function or
+// missing required flags.
+const ErrValidation Error = "validation failed"
+
+// ValidateError attributes an error returned by a Config Unit's Validate method
+// to the Unit and its FlagSet.
+type ValidateError struct {
+	// Unit holds the name of the Config Unit.
+	Unit string
+	// FlagSet holds the name of the Config Unit's FlagSet.
+	FlagSet string
+	// Err holds the error returned by Validate.
+	Err error
+}
+
+// Error implements error.
+func (v *ValidateError) Error() string {
+	if v.FlagSet == "" {
+		return fmt.Sprintf("validate %s: %v", v.Unit, v.Err)
+	}
+	return fmt.Sprintf("validate %s (%s): %v", v.Unit, v.FlagSet, v.Err)
+}
+
+// Unwrap returns the error returned by Validate.
+func (v *ValidateError) Unwrap() error {
+	return v.Err
+}
+
+// Is allows errors.Is to match the ErrValidation category.
+func (v *ValidateError) Is(target error) bool {
+	return target == ErrValidation //nolint:errorlint // sentinel match
+}
+
+// ValidateErrors returns the list of Validate errors found in the error
+// returned by RunConfig or Run, allowing for programmatic inspection of which
+// Unit rejected its configuration.
+func ValidateErrors(err error) []*ValidateError {
+	var (
+		me   *multierror.Error
+		errs = []error{err}
+		out  []*ValidateError
+	)
+	if errors.As(err, &me) {
+		errs = me.WrappedErrors()
+	}
+	for _, e := range errs {
+		var v *ValidateError
+		if errors.As(e, &v) {
+			out = append(out, v)
+		}
+	}
+	return out
+}
+
+// missingRequired returns a single error listing the missing required flags of
+// all Config Units.
+func (g *Group) missingRequired(fs []*flag.Set) error {
+	var missing []string
+	for idx := range fs {
+		cfg := unitAt(g, &g.c, idx)
+		if cfg == nil || fs[idx] == nil {
+			continue
+		}
+		names := fs[idx].MissingRequired()
+		if len(names) == 0 {
+			continue
+		}
+		for i := range names {
+			names[i] = "--" + names[i]
+		}
+		missing = append(missing, g.nameOf(cfg)+" ("+strings.Join(names, ", ")+")")
+	}
+	if len(missing) == 0 {
+		return nil
+	}
+	return categorize(fmt.Errorf("%w: %s", ErrMissingRequiredFlags,
+		strings.Join(missing, "; ")), ErrValidation)
+}
+
+// CrossValidate registers a function validating configuration spanning
+// multiple Units, e.g. TLS being enabled on an HTTP Unit requiring a
+// certificate Unit to be configured. The functions are called in order of
+// registration once all Config Units have been validated successfully and
+// receive the registered Units in order of phase and registration. All errors
+// are reported at once and make RunConfig fail.
+// CrossValidate is safe for concurrent use.
+func (g *Group) CrossValidate(fn func(units []Unit) error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.crossChecks = append(g.crossChecks, fn)
+}
+
+// crossValidate calls the functions registered with CrossValidate.
+func (g *Group) crossValidate() error {
+	g.mu.Lock()
+	fns := append([]func([]Unit) error{}, g.crossChecks...)
+	units := g.units()
+	g.mu.Unlock()
+
+	var err error
+	for idx, fn := range fns {
+		l := g.phaseLogger("cross-validate", g.Name, g.itemNr(idx+1, len(fns)))
+		vErr := fn(units)
+		l.exit(vErr)
+		if vErr != nil {
+			err = multierror.Append(err, vErr)
+		}
+	}
+	if err != nil {
+		return categorize(fmt.Errorf("cross-validate: %w", err), ErrValidation)
+	}
+	return nil
+}